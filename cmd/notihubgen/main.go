@@ -0,0 +1,231 @@
+/*
+Command notihubgen generates typed notification constants and builders
+from a YAML description of notification types, keeping producers
+in sync with the templates registered in the notification hub.
+
+Usage:
+
+	//go:generate go run github.com/vippsas/gozure/cmd/notihubgen -in notifications.yaml -out notifications_gen.go
+
+Input format:
+
+	package: notifications
+	types:
+	  - name: NewMessage
+	    template: new_message
+	    tags: [feature:chat]
+	    priority: high
+	    params: [sender, text]
+*/
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+var identifierRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+type (
+	spec struct {
+		Package string     `yaml:"package"`
+		Types   []typeSpec `yaml:"types"`
+	}
+
+	typeSpec struct {
+		Name     string   `yaml:"name"`
+		Template string   `yaml:"template"`
+		Tags     []string `yaml:"tags"`
+		Priority string   `yaml:"priority"`
+		Params   []string `yaml:"params"`
+	}
+)
+
+func main() {
+	in := flag.String("in", "notifications.yaml", "notification types YAML file")
+	out := flag.String("out", "notifications_gen.go", "generated Go file")
+	flag.Parse()
+
+	src, err := ioutil.ReadFile(*in)
+	if err != nil {
+		log.Fatalf("notihubgen: %s", err)
+	}
+
+	code, err := generate(src)
+	if err != nil {
+		log.Fatalf("notihubgen: %s: %s", *in, err)
+	}
+
+	if err := ioutil.WriteFile(*out, code, 0644); err != nil {
+		log.Fatalf("notihubgen: %s", err)
+	}
+}
+
+// generate returns formatted Go source for notification types YAML
+func generate(src []byte) ([]byte, error) {
+	var s spec
+	if err := yaml.UnmarshalStrict(src, &s); err != nil {
+		return nil, err
+	}
+
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := codeTemplate.Execute(&buf, s); err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+func (s spec) validate() error {
+	if !identifierRe.MatchString(s.Package) {
+		return fmt.Errorf("invalid package name '%s'", s.Package)
+	}
+
+	names := map[string]bool{}
+	for i, t := range s.Types {
+		if !identifierRe.MatchString(t.Name) {
+			return fmt.Errorf("type %d: invalid name '%s'", i, t.Name)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("type %d: duplicate name '%s'", i, t.Name)
+		}
+		names[t.Name] = true
+
+		if t.Template == "" {
+			return fmt.Errorf("type '%s': missing template", t.Name)
+		}
+
+		switch t.Priority {
+		case "", "high", "normal":
+		default:
+			return fmt.Errorf("type '%s': unknown priority '%s', expected high or normal", t.Name, t.Priority)
+		}
+
+		fields := map[string]bool{}
+		for _, param := range t.Params {
+			field := exportedName(param)
+			if !identifierRe.MatchString(field) || fields[field] {
+				return fmt.Errorf("type '%s': invalid or duplicate param '%s'", t.Name, param)
+			}
+			fields[field] = true
+		}
+	}
+
+	return nil
+}
+
+// exportedName converts snake_case or camelCase param into exported Go identifier
+func exportedName(param string) string {
+	parts := strings.FieldsFunc(param, func(r rune) bool {
+		return r == '_' || r == '-' || r == '.'
+	})
+
+	for i, part := range parts {
+		parts[i] = strings.ToUpper(part[:1]) + part[1:]
+	}
+
+	return strings.Join(parts, "")
+}
+
+func (t typeSpec) PriorityOrDefault() string {
+	if t.Priority == "" {
+		return "normal"
+	}
+
+	return t.Priority
+}
+
+var codeTemplate = template.Must(template.New("code").Funcs(template.FuncMap{
+	"exported": exportedName,
+}).Parse(`// Code generated by notihubgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import "github.com/vippsas/gozure/notihub"
+
+// Type identifies a notification type
+type Type string
+
+const (
+{{- range .Types}}
+	{{.Name}} Type = "{{.Name}}"
+{{- end}}
+)
+
+// Types lists all notification types
+var Types = []Type{
+{{- range .Types}}
+	{{.Name}},
+{{- end}}
+}
+
+// TemplateName returns the hub template name of the type
+func (t Type) TemplateName() string {
+	switch t {
+{{- range .Types}}
+	case {{.Name}}:
+		return {{printf "%q" .Template}}
+{{- end}}
+	}
+
+	return ""
+}
+
+// Tags returns default tags of the type
+func (t Type) Tags() []string {
+	switch t {
+{{- range .Types}}
+	case {{.Name}}:
+		return []string{ {{- range $i, $tag := .Tags}}{{if $i}}, {{end}}{{printf "%q" $tag}}{{end -}} }
+{{- end}}
+	}
+
+	return nil
+}
+
+// Priority returns priority of the type, high or normal
+func (t Type) Priority() string {
+	switch t {
+{{- range .Types}}
+	case {{.Name}}:
+		return {{printf "%q" .PriorityOrDefault}}
+{{- end}}
+	}
+
+	return ""
+}
+{{range .Types}}
+// {{.Name}}Params are template properties of {{.Name}} notifications
+type {{.Name}}Params struct {
+{{- range .Params}}
+	{{exported .}} string
+{{- end}}
+}
+
+// Build{{.Name}} builds {{.Name}} template notification
+func Build{{.Name}}(p {{.Name}}Params) (*notihub.Notification, error) {
+	payload, err := notihub.DefaultEncoder.Marshal(map[string]string{
+{{- range .Params}}
+		{{printf "%q" .}}: p.{{exported .}},
+{{- end}}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return notihub.NewNotification(notihub.Template, payload)
+}
+{{end}}`))
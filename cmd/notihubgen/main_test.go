@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func Test_Generate(t *testing.T) {
+	src, err := ioutil.ReadFile("testdata/notifications.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := generate(src)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if *update {
+		if err := ioutil.WriteFile("testdata/notifications_gen.golden", code, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	golden, err := ioutil.ReadFile("testdata/notifications_gen.golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(code) != string(golden) {
+		t.Errorf("Generated code differs from golden file. Expected:\n%s\ngot:\n%s", golden, code)
+	}
+}
+
+func Test_GenerateInvalid(t *testing.T) {
+	testCases := []string{
+		`package: 1invalid`,
+		"package: p\ntypes:\n  - name: A\n    template: a\n  - name: A\n    template: b",
+		"package: p\ntypes:\n  - name: A",
+		"package: p\ntypes:\n  - name: A\n    template: a\n    priority: urgent",
+		"package: p\ntypes:\n  - name: A\n    template: a\n    params: [text, Text]",
+		"package: p\nunknown: field",
+	}
+
+	for i, src := range testCases {
+		if _, err := generate([]byte(src)); err == nil {
+			t.Errorf("test case %d. Expected error, got nil", i)
+		}
+	}
+}
+
+func Test_ExportedName(t *testing.T) {
+	testCases := map[string]string{
+		"text":        "Text",
+		"sender_name": "SenderName",
+		"senderName":  "SenderName",
+		"deep-link":   "DeepLink",
+	}
+
+	for param, expected := range testCases {
+		if obtained := exportedName(param); obtained != expected {
+			t.Errorf("exportedName(%s). Expected '%s', got '%s'", param, expected, obtained)
+		}
+	}
+}
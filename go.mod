@@ -1,8 +1,11 @@
 module github.com/vippsas/gozure
 
 require (
-	golang.org/x/net v0.0.0-20190119204137-ed066c81e75e // indirect
+	golang.org/x/crypto v0.57.0
 	gopkg.in/xmlpath.v2 v2.0.0-20150820204837-860cbeca3ebc
+	gopkg.in/yaml.v2 v2.4.0
 )
 
-go 1.13
+require golang.org/x/net v0.58.0 // indirect
+
+go 1.26.0
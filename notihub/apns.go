@@ -0,0 +1,148 @@
+package notihub
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+const apnsCredentialName = "ApnsCredential"
+
+type (
+	// PnsCredentialExpiry describes the configured APNS credential.
+	// Token keys never expire, so NotAfter is zero for token credentials
+	PnsCredentialExpiry struct {
+		Platform Platform
+		// Kind is "certificate" or "token"
+		Kind string
+		// Subject is the certificate subject, or the key id of token credentials
+		Subject  string
+		NotAfter time.Time
+	}
+
+	// CredentialMetrics is Metrics also receiving PNS credential expiry warnings
+	CredentialMetrics interface {
+		Metrics
+		ObserveCredentialExpiry(expiry PnsCredentialExpiry, remaining time.Duration)
+	}
+)
+
+// Expires reports whether the credential has an expiry date
+func (e PnsCredentialExpiry) Expires() bool {
+	return !e.NotAfter.IsZero()
+}
+
+// GetPnsCredentialExpiry inspects the hub APNS credential, nil when not configured
+func (m *ManagementClient) GetPnsCredentialExpiry(ctx context.Context) (*PnsCredentialExpiry, error) {
+	expiry, err := m.pnsCredentialExpiry(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ManagementClient.GetPnsCredentialExpiry: %w", err)
+	}
+
+	return expiry, nil
+}
+
+// CheckPnsCredentialExpiry reports the hub APNS credential to the hub metrics
+// when it is a CredentialMetrics and the credential expires within days.
+// Returns the credential expiry, nil when not configured
+func (m *ManagementClient) CheckPnsCredentialExpiry(ctx context.Context, days int) (*PnsCredentialExpiry, error) {
+	expiry, err := m.pnsCredentialExpiry(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ManagementClient.CheckPnsCredentialExpiry: %w", err)
+	}
+
+	if expiry == nil || !expiry.Expires() {
+		return expiry, nil
+	}
+
+	remaining := expiry.NotAfter.Sub(m.hub.now())
+	if metrics, ok := m.hub.metrics.(CredentialMetrics); ok && remaining < time.Duration(days)*24*time.Hour {
+		metrics.ObserveCredentialExpiry(*expiry, remaining)
+	}
+
+	return expiry, nil
+}
+
+// pnsCredentialExpiry reads the APNS credential of the hub description
+func (m *ManagementClient) pnsCredentialExpiry(ctx context.Context) (*PnsCredentialExpiry, error) {
+	description, err := m.getDescription(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	properties, ok, err := description.credential(apnsCredentialName)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	if keyID := properties["KeyId"]; keyID != "" {
+		return &PnsCredentialExpiry{Platform: PlatformApns, Kind: "token", Subject: keyID}, nil
+	}
+	if properties["ApnsCertificate"] == "" {
+		return nil, nil
+	}
+
+	cert, err := parseApnsCertificate(properties["ApnsCertificate"], properties["CertificateKey"])
+	if err != nil {
+		return nil, err
+	}
+
+	return &PnsCredentialExpiry{
+		Platform: PlatformApns,
+		Kind:     "certificate",
+		Subject:  cert.Subject.CommonName,
+		NotAfter: cert.NotAfter,
+	}, nil
+}
+
+// parseApnsCertificate decodes base64 PKCS#12 archive protected by password,
+// or PEM or DER certificate, and returns its certificate expiring first
+func parseApnsCertificate(encoded, password string) (*x509.Certificate, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: apns certificate is not base64 encoded", ErrInvalidCredential)
+	}
+
+	var (
+		der            [][]byte
+		notImplemented pkcs12.NotImplementedError
+	)
+	if blocks, err := pkcs12.ToPEM(data, password); err == nil {
+		for _, block := range blocks {
+			if block.Type == "CERTIFICATE" {
+				der = append(der, block.Bytes)
+			}
+		}
+	} else if errors.As(err, &notImplemented) {
+		// archives exported by OpenSSL 3 and recent keychains are encrypted by AES, which pkcs12 can not decode
+		return nil, fmt.Errorf("%w: apns certificate archive uses unsupported encryption, like AES, "+
+			"re-export it with legacy encryption, e.g. openssl pkcs12 -export -legacy: %v", ErrInvalidCredential, err)
+	} else if block, _ := pem.Decode(data); block != nil {
+		der = append(der, block.Bytes)
+	} else {
+		der = append(der, data)
+	}
+
+	var first *x509.Certificate
+	for _, b := range der {
+		cert, err := x509.ParseCertificate(b)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to parse apns certificate: %v", ErrInvalidCredential, err)
+		}
+		if first == nil || cert.NotAfter.Before(first.NotAfter) {
+			first = cert
+		}
+	}
+
+	if first == nil {
+		return nil, fmt.Errorf("%w: apns certificate archive has no certificate", ErrInvalidCredential)
+	}
+
+	return first, nil
+}
@@ -0,0 +1,130 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testApnsCertificate is base64 PKCS#12 archive protected by "secret",
+// of certificate "Apple Push Services: com.example.app" valid until 2036-10-12
+const testApnsCertificate = "MIIDugIBAzCCA4AGCSqGSIb3DQEHAaCCA3EEggNtMIIDaTCCAl8GCSqGSIb3DQEHBqCCAlAwggJMAgEAMIICRQYJKoZIhvcNAQcB" +
+	"MBwGCiqGSIb3DQEMAQYwDgQIleE4s8vU9o0CAggAgIICGC379xQJg7qiwvZJ/CnOG542kTJxMP8B1C6Up83yasEOSM2zl/K/kPQ2" +
+	"9t2W3cV2IIVnDY8G1FVX6Xck87ruuKiFtFH2uNSEfz1ZpY0FwX/TiC0xy7pGA1w99RVD7op6687yXkhACCgIaxTaaPpjQnJTpSZ+" +
+	"Er7q+hT2758fFaRxB1M8zDM4DAWEVuCvvpbYCyEeTNDFAo6Knq9svFcUjT3K3Ms9F8Kz/R/+jZErZJW53m9Vz2FZoYPItJLh8lDr" +
+	"mCndanQyy6AZIwUbs9NL9qeM3T2MPY88YuKJyRv0wtpcl3KGsk1ko/bUCMHwt5FKNWmxNBfCgZCJ7rdDuVcFoITuIRiGUlOvgGrQ" +
+	"dXWjrLQshjhG2kZ0WlXr6sRwMXSkAtsHxm39Qix5LnHUznW8akbi7G3CgvLItzhZ7Z7sqPzWyL5Ra7ayfwGwfelTR/degFR2Rdvm" +
+	"39fMIZJ20Y+0Tp//o5u4XgxwDaanOfnIvblymr2OtvLONxymf2cfJUpxhkmmxlKrDrI8LVPoc9RzRXPi5fFR08AUMObnfNznGOc2" +
+	"Iw7JFLEZMaXiiH3wLl1gIuUJZTJhqmVpoVymG3oeRc11YuwOW2pZyBe8b8Jiudr5zMDVBPdlq0QLisVZwzg7Nn/1C41a12gqepOf" +
+	"4NBgYC6jArpcInNn9bG05hFgXp9INdxbFR6eWAEIVF4DBUZi3IMSR7o6NNhoMIIBAgYJKoZIhvcNAQcBoIH0BIHxMIHuMIHrBgsq" +
+	"hkiG9w0BDAoBAqCBtDCBsTAcBgoqhkiG9w0BDAEDMA4ECHr3YoXsNFpsAgIIAASBkFAz9saqOXUGwfhwIQlJYjG1gaiX9a+RdT9+" +
+	"kQw29d4fq4e1UKPPBQlPh4CEcUNL6EoBrBfdHcoQ7YbOXOlsVAcvIad8jl3imU2or7OaXNnZATsCOkYex4vdHd5rl0R/FF09UpsY" +
+	"XMsmd2rPZkph+KNjCPklYtHxJZXmOH3wivmqGbaqubfLm5f0LMya/0rMkzElMCMGCSqGSIb3DQEJFTEWBBRwlJOp0/S3qu5Vyieq" +
+	"VwfladFAXzAxMCEwCQYFKw4DAhoFAAQU94Lt8CMkgYSnB8w2s/RDdWexMDQECM2w6ZWQiCIyAgIIAA=="
+
+// testApnsAESCertificate is base64 PKCS#12 archive protected by "secret" like testApnsCertificate,
+// encrypted by AES as exported by OpenSSL 3 without -legacy
+const testApnsAESCertificate = "MIIEPAIBAzCCA/IGCSqGSIb3DQEHAaCCA+MEggPfMIID2zCCApIGCSqGSIb3DQEHBqCCAoMwggJ/AgEAMIICeAYJKoZIhvcNAQcB" +
+	"MFcGCSqGSIb3DQEFDTBKMCkGCSqGSIb3DQEFDDAcBAhq/tXjdqvPpwICCAAwDAYIKoZIhvcNAgkFADAdBglghkgBZQMEASoEEKVt" +
+	"5Ho6Xlh6wL9dOz1pW0GAggIQVyFZnJKR7euizGzsm34PnHwG1LL+GqJUxBd+226rEbP49IZjaH6czclpM2EWCzzdNoIZhc7BPAsL" +
+	"I95j7Mi42go+gph2q2ZEPdPKq4h+9l6m9JShO8ZYZReeGO+RYMSCIWMKUIkJKWqkFCJMQLwLr8JYGaiiSFCNY5xW4Bmf6GDAHzjZ" +
+	"hSlhwcwSfnqxv0KQ0THPcQidJbnvNfBXbs79yAneIxVK5RSoMissyszVO1my5tOmiQSXtuXS1YiyEkM1QWpzvp7UkJ3kV3HcFCDq" +
+	"rZeBem9VqZLZ84Dmi252Z1Jt4Za9XHeLv/IGEeiME9v7XUS0WfmJrveEak/dezD5p1BPpaP+2Ev95VZd9ER3d//dY/QN4obzDN7T" +
+	"vvBNLhwdzc0ZYM9KYjEdACKyOb8yRYvElQIzTfx2l0N2PtejwGv8r65o0O0ScRMDtMbAYQ8KjCicf7HiAaoecnb6I0FPq/Q6N6b7" +
+	"mOTc8hyqfcxYC3NA/N2HZ4taldi+QogAIQ55xVv1QG41hMwI7JW7vN4qEkjCEgQjJaAJ3obNSzVoTmGLgI5e4rky3BOYJVZsPkHX" +
+	"mKVp9Eekby53z84JYk3oc2RiilRGR6S/aoc/7v+J28B4nXIa2hxY7C9NJCaA6FZZpZPtouHKJfFrrlz/iENM2//BppWwINt5SDXJ" +
+	"eiAKEJrCJuXLWk/RFELLK3opc3zXMIIBQQYJKoZIhvcNAQcBoIIBMgSCAS4wggEqMIIBJgYLKoZIhvcNAQwKAQKgge8wgewwVwYJ" +
+	"KoZIhvcNAQUNMEowKQYJKoZIhvcNAQUMMBwECBAoDr5n9KvtAgIIADAMBggqhkiG9w0CCQUAMB0GCWCGSAFlAwQBKgQQwOnCGUIS" +
+	"/o2PiTcAqW+cQASBkGeSzqM+NBIWiVMNWx6T+3KKGOGPtC0ChBzpsfM3fTWdZcBIzNdJ6Tspgl9mEMc2v39SX8yIg7LHIr8ZdVyw" +
+	"N9CjBB98R48tHiDxGMHbmDOVC8finl2bB2tmKHR0H9slCSV8f3lDPE7X3hk1rpQnxWxUKjJjNjCKXUl5DcevS0oV7YKHD0ejcGav" +
+	"Na+VB85rnTElMCMGCSqGSIb3DQEJFTEWBBQcZzO4G97boCaCi1+m+6+qJ948UjBBMDEwDQYJYIZIAWUDBAIBBQAEIOR9hgWCoaIj" +
+	"NPaCAg/dMs6bricIiCVAaEmVhdkvw1E3BAhyqkmsuvgfFgICCAA="
+
+type credentialMetrics struct {
+	Metrics
+	warnings []time.Duration
+}
+
+func (m *credentialMetrics) ObserveCredentialExpiry(expiry PnsCredentialExpiry, remaining time.Duration) {
+	m.warnings = append(m.warnings, remaining)
+}
+
+func Test_ManagementClientPnsCredentialExpiry(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newHubDescriptionServer(testHubDescription)
+	defer server.Close()
+
+	metrics := &credentialMetrics{Metrics: NewCampaignMetrics()}
+	clock := &mockClock{now: time.Date(2036, 9, 12, 3, 23, 45, 0, time.UTC)}
+	client := server.client()
+	WithMetrics(metrics)(client.hub)
+	WithClock(clock)(client.hub)
+	ctx := context.Background()
+
+	if expiry, err := client.GetPnsCredentialExpiry(ctx); err != nil || expiry != nil {
+		t.Errorf(errfmt, "unconfigured credential", nil, expiry)
+	}
+
+	if err := client.updateCredential(ctx, apnsCredentialName, []credentialProperty{{"ApnsCertificate", testApnsCertificate}, {"CertificateKey", "secret"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	expiry, err := client.GetPnsCredentialExpiry(ctx)
+	if err != nil {
+		t.Fatalf(errfmt, "certificate expiry error", nil, err)
+	}
+	if expected := time.Date(2036, 10, 12, 3, 23, 45, 0, time.UTC); expiry.Kind != "certificate" || !expiry.NotAfter.Equal(expected) {
+		t.Errorf(errfmt, "certificate expiry", expected, expiry.NotAfter)
+	}
+	if expiry.Subject != "Apple Push Services: com.example.app" {
+		t.Errorf(errfmt, "certificate subject", "Apple Push Services: com.example.app", expiry.Subject)
+	}
+
+	tests := []struct {
+		days     int
+		warnings int
+	}{
+		{7, 0},
+		{30, 0},
+		{31, 1},
+	}
+
+	for _, testData := range tests {
+		metrics.warnings = nil
+		if _, err := client.CheckPnsCredentialExpiry(ctx, testData.days); err != nil {
+			t.Fatalf(errfmt, "check error", nil, err)
+		}
+		if len(metrics.warnings) != testData.warnings {
+			t.Errorf(errfmt, "warnings within days", testData.warnings, len(metrics.warnings))
+		}
+	}
+	if metrics.warnings[0] != 30*24*time.Hour {
+		t.Errorf(errfmt, "remaining validity", 30*24*time.Hour, metrics.warnings[0])
+	}
+
+	if err := client.updateCredential(ctx, apnsCredentialName, []credentialProperty{{"ApnsCertificate", testApnsCertificate}, {"CertificateKey", "wrong"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetPnsCredentialExpiry(ctx); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf(errfmt, "wrong password error", ErrInvalidCredential, err)
+	}
+
+	if err := client.updateCredential(ctx, apnsCredentialName, []credentialProperty{{"ApnsCertificate", testApnsAESCertificate}, {"CertificateKey", "secret"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetPnsCredentialExpiry(ctx); !errors.Is(err, ErrInvalidCredential) || !strings.Contains(err.Error(), "legacy encryption") {
+		t.Errorf(errfmt, "aes archive error", "re-export with legacy encryption", err)
+	}
+
+	if err := client.updateCredential(ctx, apnsCredentialName, []credentialProperty{{"KeyId", "key-1"}, {"Token", "p8"}}); err != nil {
+		t.Fatal(err)
+	}
+	metrics.warnings = nil
+	expiry, err = client.CheckPnsCredentialExpiry(ctx, 365)
+	if err != nil || expiry.Kind != "token" || expiry.Subject != "key-1" || expiry.Expires() || len(metrics.warnings) != 0 {
+		t.Errorf(errfmt, "token credential", "non-expiring key-1", expiry)
+	}
+}
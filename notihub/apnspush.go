@@ -0,0 +1,62 @@
+package notihub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const (
+	// apnsPriorityImmediate delivers the push right away, allowed for user visible pushes only
+	apnsPriorityImmediate = "10"
+	// apnsPriorityThrottled lets the device delay the push to save power
+	apnsPriorityThrottled = "5"
+	// apnsPriorityLow delivers the push when the device is awake anyway
+	apnsPriorityLow = "1"
+)
+
+// ErrInvalidApnsPush is returned when apple notification headers contradict its payload.
+// APNS does not reject such pushes, but silently throttles or drops them
+var ErrInvalidApnsPush = errors.New("invalid apns push")
+
+// checkApnsPush validates apns-push-type and apns-priority headers of apple notification with payload.
+// Headers inferred from the payload are valid, mistakes come with headers set by WithHeaders
+func checkApnsPush(payload []byte, header http.Header) error {
+	pushType, priority := header.Get("X-Apns-Push-Type"), header.Get("X-Apns-Priority")
+
+	switch priority {
+	case "", apnsPriorityImmediate, apnsPriorityThrottled, apnsPriorityLow:
+	default:
+		return fmt.Errorf("%w: apns-priority must be %s, %s or %s, got '%s'",
+			ErrInvalidApnsPush, apnsPriorityImmediate, apnsPriorityThrottled, apnsPriorityLow, priority)
+	}
+
+	var notification iosBackgroundNotification
+	if err := json.Unmarshal(payload, &notification); err != nil {
+		return nil
+	}
+	visible := notification.Aps.isVisible()
+	silent := notification.Aps.ContentAvailable == 1 && !visible
+
+	switch {
+	case pushType == "background" && visible:
+		return fmt.Errorf("%w: payload with alert, badge or sound must have apns-push-type alert, got background", ErrInvalidApnsPush)
+	case pushType == "background" && priority == apnsPriorityImmediate:
+		return fmt.Errorf("%w: background push must have apns-priority %s, got %s", ErrInvalidApnsPush, apnsPriorityThrottled, priority)
+	case isUrgentInterruption(notification.Aps.InterruptionLevel) && (pushType == "background" || priority != "" && priority != apnsPriorityImmediate):
+		return fmt.Errorf("%w: %s interruption level must be sent as apns-push-type alert with apns-priority %s, got %s with priority %s",
+			ErrInvalidApnsPush, notification.Aps.InterruptionLevel, apnsPriorityImmediate, pushType, priority)
+	case silent && priority == apnsPriorityImmediate:
+		return fmt.Errorf("%w: content-available push without alert, badge or sound must have apns-priority %s, got %s",
+			ErrInvalidApnsPush, apnsPriorityThrottled, priority)
+	}
+
+	return nil
+}
+
+// isUrgentInterruption identifies interruption levels breaking through Focus,
+// which the device shows late when the push is throttled
+func isUrgentInterruption(level AppleInterruptionLevel) bool {
+	return level == InterruptionTimeSensitive || level == InterruptionCritical
+}
@@ -0,0 +1,67 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func Test_CheckApnsPush(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	silent := `{"aps":{"content-available":1}}`
+	alert := `{"aps":{"alert":"hi"}}`
+	mixed := `{"aps":{"alert":"hi","content-available":1}}`
+
+	testPatterns := []struct {
+		name     string
+		payload  string
+		pushType string
+		priority string
+		err      error
+	}{
+		{"background", silent, "background", "5", nil},
+		{"background with low priority", silent, "background", "1", nil},
+		{"alert", alert, "alert", "10", nil},
+		{"mixed alert", mixed, "alert", "10", nil},
+		{"background with priority 10", silent, "background", "10", ErrInvalidApnsPush},
+		{"content-available with priority 10", silent, "alert", "10", ErrInvalidApnsPush},
+		{"alert with push type background", alert, "background", "5", ErrInvalidApnsPush},
+		{"badge with push type background", `{"aps":{"badge":0,"content-available":1}}`, "background", "5", ErrInvalidApnsPush},
+		{"unknown priority", alert, "alert", "7", ErrInvalidApnsPush},
+		{"time sensitive", `{"aps":{"alert":"hi","interruption-level":"time-sensitive"}}`, "alert", "10", nil},
+		{"passive with priority 5", `{"aps":{"alert":"hi","interruption-level":"passive"}}`, "alert", "5", nil},
+		{"time sensitive with priority 5", `{"aps":{"alert":"hi","interruption-level":"time-sensitive"}}`, "alert", "5", ErrInvalidApnsPush},
+		{"critical background", `{"aps":{"content-available":1,"interruption-level":"critical"}}`, "background", "5", ErrInvalidApnsPush},
+		{"not json", "hi", "background", "5", nil},
+	}
+
+	for _, testData := range testPatterns {
+		header := http.Header{"X-Apns-Push-Type": {testData.pushType}, "X-Apns-Priority": {testData.priority}}
+		if err := checkApnsPush([]byte(testData.payload), header); !errors.Is(err, testData.err) {
+			t.Errorf(errfmt, testData.name+" error", testData.err, err)
+		}
+	}
+}
+
+func Test_NotificationHubApnsPushValidation(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	requests := 0
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		requests++
+		return nil, nil
+	})
+
+	mixed := &Notification{AppleFormat, []byte(`{"aps":{"alert":"hi","content-available":1}}`)}
+	if headers := nhub.notificationHeaders(mixed); headers["X-Apns-Push-Type"] != "alert" || headers["X-Apns-Priority"] != "10" {
+		t.Errorf(errfmt, "inferred mixed push headers", "alert with priority 10", headers)
+	}
+
+	silent := &Notification{AppleFormat, []byte(`{"aps":{"content-available":1}}`)}
+	_, err := nhub.Send(context.Background(), silent, []string{"news"}, WithHeaders(map[string]string{"X-Apns-Priority": "10"}))
+	if !errors.Is(err, ErrInvalidApnsPush) || requests != 0 {
+		t.Errorf(errfmt, "overridden priority error", ErrInvalidApnsPush, err)
+	}
+}
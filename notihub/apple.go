@@ -0,0 +1,172 @@
+package notihub
+
+import "fmt"
+
+// Interruption levels of apple notifications, iOS 15 and later
+const (
+	// InterruptionPassive adds notification to the list without lighting up the screen or playing sound
+	InterruptionPassive AppleInterruptionLevel = "passive"
+	// InterruptionActive presents notification immediately, the default
+	InterruptionActive AppleInterruptionLevel = "active"
+	// InterruptionTimeSensitive breaks through Focus, requires the Time Sensitive Notifications capability
+	InterruptionTimeSensitive AppleInterruptionLevel = "time-sensitive"
+	// InterruptionCritical breaks through Focus and the mute switch, requires Apple's entitlement
+	InterruptionCritical AppleInterruptionLevel = "critical"
+)
+
+type (
+	// AppleInterruptionLevel is the importance and delivery timing of apple notification
+	AppleInterruptionLevel string
+
+	// AppleAlert is the alert of APNS payload. Alerts with only Body set are sent
+	// as plain string alert, others as alert dictionary without the empty fields
+	AppleAlert struct {
+		Title           string   `json:"title,omitempty"`
+		Subtitle        string   `json:"subtitle,omitempty"`
+		Body            string   `json:"body,omitempty"`
+		LaunchImage     string   `json:"launch-image,omitempty"`
+		TitleLocKey     string   `json:"title-loc-key,omitempty"`
+		TitleLocArgs    []string `json:"title-loc-args,omitempty"`
+		SubtitleLocKey  string   `json:"subtitle-loc-key,omitempty"`
+		SubtitleLocArgs []string `json:"subtitle-loc-args,omitempty"`
+		LocKey          string   `json:"loc-key,omitempty"`
+		LocArgs         []string `json:"loc-args,omitempty"`
+	}
+
+	// ApplePayload builds APNS notification payload, empty fields are omitted
+	ApplePayload struct {
+		Alert AppleAlert
+		// Badge is a pointer, so that badge 0 clearing the app badge can be told from no badge
+		Badge            *int
+		Sound            string
+		ThreadId         string
+		Category         string
+		ContentAvailable bool
+		MutableContent   bool
+		// InterruptionLevel is omitted when empty
+		InterruptionLevel AppleInterruptionLevel
+		// RelevanceScore between 0 and 1 picks the summary highlight, omitted when nil
+		RelevanceScore *float64
+		// Data holds custom keys placed next to aps
+		Data map[string]interface{}
+	}
+
+	appleAlertDictionary AppleAlert
+
+	appleAps struct {
+		Alert             *AppleAlert            `json:"alert,omitempty"`
+		Badge             *int                   `json:"badge,omitempty"`
+		Sound             string                 `json:"sound,omitempty"`
+		ThreadId          string                 `json:"thread-id,omitempty"`
+		Category          string                 `json:"category,omitempty"`
+		ContentAvailable  int                    `json:"content-available,omitempty"`
+		MutableContent    int                    `json:"mutable-content,omitempty"`
+		InterruptionLevel AppleInterruptionLevel `json:"interruption-level,omitempty"`
+		RelevanceScore    *float64               `json:"relevance-score,omitempty"`
+	}
+)
+
+// NewAppleAlert returns plain string alert of text
+func NewAppleAlert(text string) AppleAlert {
+	return AppleAlert{Body: text}
+}
+
+// IsZero identifies whether alert has no fields set
+func (a AppleAlert) IsZero() bool {
+	return a.isPlain() && a.Body == ""
+}
+
+// isPlain identifies whether alert has no fields but Body set
+func (a AppleAlert) isPlain() bool {
+	return a.Title == "" && a.Subtitle == "" && a.LaunchImage == "" &&
+		a.TitleLocKey == "" && len(a.TitleLocArgs) == 0 &&
+		a.SubtitleLocKey == "" && len(a.SubtitleLocArgs) == 0 &&
+		a.LocKey == "" && len(a.LocArgs) == 0
+}
+
+// MarshalJSON encodes alert as string when only Body is set, as dictionary otherwise
+func (a AppleAlert) MarshalJSON() ([]byte, error) {
+	if a.isPlain() {
+		return DefaultEncoder.Marshal(a.Body)
+	}
+
+	return DefaultEncoder.Marshal(appleAlertDictionary(a))
+}
+
+// UnmarshalJSON decodes both string and dictionary alerts
+func (a *AppleAlert) UnmarshalJSON(b []byte) error {
+	var text string
+	if err := DefaultEncoder.Unmarshal(b, &text); err == nil {
+		*a = AppleAlert{Body: text}
+		return nil
+	}
+
+	return DefaultEncoder.Unmarshal(b, (*appleAlertDictionary)(a))
+}
+
+// Validate checks interruption level and relevance score
+func (p ApplePayload) Validate() error {
+	switch p.InterruptionLevel {
+	case "", InterruptionPassive, InterruptionActive, InterruptionTimeSensitive, InterruptionCritical:
+	default:
+		return fmt.Errorf("unknown interruption level '%s', must be %s, %s, %s or %s", p.InterruptionLevel,
+			InterruptionPassive, InterruptionActive, InterruptionTimeSensitive, InterruptionCritical)
+	}
+
+	if p.RelevanceScore != nil && (*p.RelevanceScore < 0 || *p.RelevanceScore > 1) {
+		return fmt.Errorf("relevance score must be between 0 and 1, got %v", *p.RelevanceScore)
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes payload as aps dictionary along with the custom data keys
+func (p ApplePayload) MarshalJSON() ([]byte, error) {
+	return p.marshalJSON(DefaultEncoder)
+}
+
+// marshalJSON encodes payload with encoder, see MarshalJSON
+func (p ApplePayload) marshalJSON(encoder Encoder) ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	aps := appleAps{
+		Badge:             p.Badge,
+		Sound:             p.Sound,
+		ThreadId:          p.ThreadId,
+		Category:          p.Category,
+		InterruptionLevel: p.InterruptionLevel,
+		RelevanceScore:    p.RelevanceScore,
+	}
+	if !p.Alert.IsZero() {
+		aps.Alert = &p.Alert
+	}
+	if p.ContentAvailable {
+		aps.ContentAvailable = 1
+	}
+	if p.MutableContent {
+		aps.MutableContent = 1
+	}
+
+	payload := make(map[string]interface{}, len(p.Data)+1)
+	for key, value := range p.Data {
+		payload[key] = value
+	}
+	if _, ok := payload["aps"]; ok {
+		return nil, fmt.Errorf("custom data key 'aps' is reserved")
+	}
+	payload["aps"] = aps
+
+	return encoder.Marshal(payload)
+}
+
+// Notification returns AppleFormat notification of the payload
+func (p ApplePayload) Notification() (*Notification, error) {
+	payload, err := p.marshalJSON(DefaultEncoder)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Notification{AppleFormat, payload}, nil
+}
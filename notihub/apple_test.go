@@ -0,0 +1,106 @@
+package notihub
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func Test_ApplePayload(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	zero, relevance := 0, 0.8
+	testPatterns := []struct {
+		name     string
+		payload  ApplePayload
+		expected string
+	}{
+		{"plain alert", ApplePayload{Alert: NewAppleAlert("hi")}, `{"aps":{"alert":"hi"}}`},
+		{"alert dictionary", ApplePayload{Alert: AppleAlert{Title: "Hello", Body: "hi"}}, `{"aps":{"alert":{"title":"Hello","body":"hi"}}}`},
+		{
+			"localized alert",
+			ApplePayload{Alert: AppleAlert{LocKey: "GREETING", LocArgs: []string{"Jane"}}, Sound: "default"},
+			`{"aps":{"alert":{"loc-key":"GREETING","loc-args":["Jane"]},"sound":"default"}}`,
+		},
+		{"badge only", ApplePayload{Badge: &zero}, `{"aps":{"badge":0}}`},
+		{
+			"silent with data",
+			ApplePayload{ContentAvailable: true, Data: map[string]interface{}{"sync": "1"}},
+			`{"aps":{"content-available":1},"sync":"1"}`,
+		},
+		{
+			"every aps field",
+			ApplePayload{Alert: AppleAlert{Subtitle: "s"}, ThreadId: "chat", Category: "MESSAGE", MutableContent: true},
+			`{"aps":{"alert":{"subtitle":"s"},"thread-id":"chat","category":"MESSAGE","mutable-content":1}}`,
+		},
+		{
+			"interruption level and relevance score",
+			ApplePayload{Alert: NewAppleAlert("hi"), InterruptionLevel: InterruptionTimeSensitive, RelevanceScore: &relevance},
+			`{"aps":{"alert":"hi","interruption-level":"time-sensitive","relevance-score":0.8}}`,
+		},
+	}
+
+	for _, testData := range testPatterns {
+		n, err := testData.payload.Notification()
+		if err != nil {
+			t.Errorf(errfmt, testData.name+" error", nil, err)
+			continue
+		}
+		if n.Format != AppleFormat || string(n.Payload) != testData.expected {
+			t.Errorf(errfmt, testData.name+" payload", testData.expected, string(n.Payload))
+		}
+	}
+
+	if _, err := (ApplePayload{Data: map[string]interface{}{"aps": 1}}).Notification(); err == nil {
+		t.Errorf(errfmt, "reserved key error", "error", err)
+	}
+	if _, err := (ApplePayload{Alert: NewAppleAlert("hi"), InterruptionLevel: "urgent"}).Notification(); err == nil {
+		t.Errorf(errfmt, "unknown interruption level error", "error", err)
+	}
+	for _, score := range []float64{-0.1, 1.5} {
+		if err := (ApplePayload{RelevanceScore: &score}).Validate(); err == nil {
+			t.Errorf(errfmt, "relevance score out of range error", "error", err)
+		}
+	}
+}
+
+func Test_AppleAlertUnmarshal(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var alerts []AppleAlert
+	if err := json.Unmarshal([]byte(`["hi",{"title":"Hello","body":"hi"}]`), &alerts); err != nil {
+		t.Fatalf(errfmt, "unmarshal error", nil, err)
+	}
+
+	if len(alerts) != 2 || alerts[0].Body != "hi" || !alerts[0].isPlain() || alerts[1].Title != "Hello" || alerts[1].Body != "hi" {
+		t.Errorf(errfmt, "alerts", "plain and dictionary", alerts)
+	}
+	if !(AppleAlert{}).IsZero() || NewAppleAlert("hi").IsZero() {
+		t.Errorf(errfmt, "zero alert", true, false)
+	}
+}
+
+func Test_ApplePayloadEncoder(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	payload := ApplePayload{Alert: NewAppleAlert("hi"), Data: map[string]interface{}{"sync": "1"}}
+	expected := `{"aps":{"alert":"hi"},"sync":"1"}`
+
+	encoder := &countingEncoder{}
+	n, err := MarshalNotification(encoder, AppleFormat, payload)
+	if err != nil || string(n.Payload) != expected {
+		t.Errorf(errfmt, "payload", expected, n)
+	}
+	if encoder.marshals != 1 {
+		t.Errorf(errfmt, "hub encoder marshals", 1, encoder.marshals)
+	}
+
+	DefaultEncoder = encoder
+	defer func() { DefaultEncoder = StdEncoder{} }()
+
+	if n, err = payload.Notification(); err != nil || string(n.Payload) != expected {
+		t.Errorf(errfmt, "payload", expected, n)
+	}
+	if encoder.marshals < 2 {
+		t.Errorf(errfmt, "default encoder marshals", "at least 1", encoder.marshals-1)
+	}
+}
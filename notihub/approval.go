@@ -0,0 +1,77 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNotApproved is returned when a send requiring approval is rejected by the hub Approver
+var ErrNotApproved = errors.New("notification not approved")
+
+type (
+	// Approver grants sends reaching large audiences
+	Approver interface {
+		// Approve blocks until the send is approved or rejected,
+		// it returns nil when the send may proceed
+		Approve(ctx context.Context, req ApprovalRequest) error
+	}
+
+	// ApproverFunc is a function implementing Approver
+	ApproverFunc func(ctx context.Context, req ApprovalRequest) error
+
+	// ApprovalRequest describes a send awaiting approval
+	ApprovalRequest struct {
+		Notification *Notification
+		OrTags       []string
+		// TagExpression is the complete expression of targeted registrations,
+		// including hub default tags
+		TagExpression string
+		// Audience is the estimated number of targeted registrations
+		Audience int
+	}
+
+	approval struct {
+		approver  Approver
+		threshold int
+	}
+)
+
+// Approve calls f
+func (f ApproverFunc) Approve(ctx context.Context, req ApprovalRequest) error {
+	return f(ctx, req)
+}
+
+// WithApprover requires approval of tag sends whose estimated audience reaches threshold.
+// The audience is estimated like EstimateAudience before each send, counting registrations of every tag
+// only until threshold is reached, so that large audiences are not paged through. Sends fail when
+// the estimate can not be made
+func WithApprover(approver Approver, threshold int) HubOption {
+	return func(h *NotificationHub) {
+		h.approval = &approval{approver: approver, threshold: threshold}
+	}
+}
+
+// checkApproval asks the hub Approver to grant a send reaching a large audience
+func (h *NotificationHub) checkApproval(ctx context.Context, n *Notification, orTags []string, o *sendOptions) error {
+	if h.approval == nil {
+		return nil
+	}
+
+	expr := h.sendTagExpression(ctx, orTags, o)
+	audience, err := h.estimateAudience(ctx, expr, h.approval.threshold)
+	if err != nil {
+		return fmt.Errorf("estimating audience: %w", err)
+	}
+
+	if audience < h.approval.threshold {
+		return nil
+	}
+
+	req := ApprovalRequest{Notification: n, OrTags: orTags, TagExpression: expr, Audience: audience}
+	if err := h.approval.approver.Approve(ctx, req); err != nil {
+		return fmt.Errorf("%w: audience of %d: %v", ErrNotApproved, audience, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_NotificationHubApproval(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newRegistrationFeedServer(map[string][]string{"news": registrationIDs(30), "sport": registrationIDs(15)})
+	defer server.Close()
+
+	var requests []ApprovalRequest
+	approved := true
+	approver := ApproverFunc(func(ctx context.Context, req ApprovalRequest) error {
+		requests = append(requests, req)
+		if !approved {
+			return errors.New("change not scheduled")
+		}
+		return nil
+	})
+
+	nhub := server.hub(WithApprover(approver, 40))
+	n := &Notification{AndroidFormat, []byte(`{"data":{}}`)}
+
+	if _, err := nhub.Send(context.Background(), n, []string{"news"}); err != nil || len(requests) != 0 {
+		t.Errorf(errfmt, "send below threshold without approval", 0, len(requests))
+	}
+
+	if _, err := nhub.Send(context.Background(), n, []string{"news", "sport"}); err != nil {
+		t.Errorf(errfmt, "approved send error", nil, err)
+	}
+	if len(requests) != 1 || requests[0].Audience != 45 {
+		t.Errorf(errfmt, "approval request audience", 45, requests)
+	}
+
+	approved = false
+	_, err := nhub.Send(context.Background(), n, []string{"news", "sport"})
+	if !errors.Is(err, ErrNotApproved) {
+		t.Errorf(errfmt, "rejected send error", ErrNotApproved, err)
+	}
+
+	if server.sends != 2 {
+		t.Errorf(errfmt, "sends", 2, server.sends)
+	}
+
+	if _, err := nhub.SendDirect(context.Background(), n, "handle"); err != nil || len(requests) != 2 {
+		t.Errorf(errfmt, "direct send without approval", 2, len(requests))
+	}
+}
+
+func Test_NotificationHubApprovalSampledAudience(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newRegistrationFeedServer(map[string][]string{"news": registrationIDs(350)})
+	defer server.Close()
+
+	var audience int
+	nhub := server.hub(WithApprover(ApproverFunc(func(ctx context.Context, req ApprovalRequest) error {
+		audience = req.Audience
+		return nil
+	}), 40))
+
+	if _, err := nhub.Send(context.Background(), &Notification{AndroidFormat, []byte(`{"data":{}}`)}, []string{"news"}); err != nil {
+		t.Fatalf(errfmt, "send error", nil, err)
+	}
+	if server.pages != 1 || audience < 40 {
+		t.Errorf(errfmt, "pages counted for approval", 1, server.pages)
+	}
+}
+
+func Test_NotificationHubApprovalSyncPush(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newRegistrationFeedServer(map[string][]string{"$InstallationId:{device}": registrationIDs(1)})
+	defer server.Close()
+
+	approvals := 0
+	nhub := server.hub(WithApprover(ApproverFunc(func(ctx context.Context, req ApprovalRequest) error {
+		approvals++
+		return nil
+	}), 40))
+
+	pushed := make(chan error, 1)
+	coordinator := NewSyncPushCoordinator(context.Background(), nhub, SyncPushOptions{
+		Notifications: SilentSyncNotifications()[:1],
+		OnPush: func(deviceID string, results []PlatformResult, err error) {
+			pushed <- err
+		},
+	})
+	defer coordinator.Close()
+
+	coordinator.Notify("device")
+	select {
+	case err := <-pushed:
+		if err != nil {
+			t.Errorf(errfmt, "sync push error", nil, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf(errfmt, "sync push", "sent", "none")
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.sends != 1 || approvals != 0 {
+		t.Errorf(errfmt, "sync push sends without approval", 1, server.sends)
+	}
+}
@@ -0,0 +1,37 @@
+package notihub
+
+import (
+	"context"
+	"fmt"
+)
+
+// SendAsync sends notification in background, retrying according to the hub retry policy.
+// The result is delivered on the returned channel once the send completes, then the channel is closed.
+// ctx governs the send, it must outlive the caller request for the send to complete.
+// See WithAsyncLanes for limiting concurrency with priority lanes
+func (h *NotificationHub) SendAsync(ctx context.Context, n *Notification, orTags []string, opts ...SendOption) <-chan SendResult {
+	results := make(chan SendResult, 1)
+	o := newSendOptions(opts)
+
+	err := h.goAsync(ctx, o.priority, "send", func(ctx context.Context) {
+		defer close(results)
+
+		_, err := h.send(ctx, n, orTags, o)
+
+		result := SendResult{CorrelationID: o.correlationID}
+		if o.result != nil {
+			result = *o.result
+		}
+		if err != nil {
+			result.Err = fmt.Errorf("NotificationHub.SendAsync: %w", err)
+		}
+
+		results <- result
+	})
+	if err != nil {
+		results <- SendResult{CorrelationID: o.correlationID, Err: fmt.Errorf("NotificationHub.SendAsync: %w", err)}
+		close(results)
+	}
+
+	return results
+}
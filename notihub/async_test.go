@@ -0,0 +1,73 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_NotificationHubSendAsync(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	release := make(chan struct{})
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(),
+		WithRetry(RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}))
+
+	results := hub.SendAsync(context.Background(), &Notification{Template, []byte("{}")}, []string{"tag"}, WithCorrelationID("correlation"))
+
+	select {
+	case result := <-results:
+		t.Fatalf(errfmt, "pending send", "no result", result)
+	default:
+	}
+	close(release)
+
+	result, ok := <-results
+	if !ok || result.Err != nil {
+		t.Fatalf(errfmt, "result error", nil, result.Err)
+	}
+	if result.CorrelationID != "correlation" || result.Attempts != 2 {
+		t.Errorf(errfmt, "result", "2 attempts with correlation id", result)
+	}
+
+	if _, ok := <-results; ok {
+		t.Errorf(errfmt, "results channel", "closed", "open")
+	}
+}
+
+func Test_NotificationHubSendAsyncError(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client())
+
+	var tracked SendResult
+	result := <-hub.SendAsync(context.Background(), &Notification{Template, []byte("{}")}, nil, WithResult(&tracked), BroadcastAll())
+
+	var resErr *ResponseError
+	if !errors.As(result.Err, &resErr) || resErr.StatusCode != http.StatusBadRequest {
+		t.Errorf(errfmt, "error", "ResponseError with status 400", result.Err)
+	}
+	if result.Attempts != 1 || tracked.Attempts != 1 {
+		t.Errorf(errfmt, "attempts", 1, result.Attempts)
+	}
+}
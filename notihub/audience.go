@@ -0,0 +1,237 @@
+package notihub
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type (
+	// audienceEstimator approximates the number of registrations
+	// targeted by a tag expression from per tag registration counts
+	audienceEstimator struct {
+		hub *NotificationHub
+		// limit stops counting registrations of a tag once reached, counting all when not positive
+		limit  int
+		counts map[string]int
+	}
+
+	// tagExpressionParser is a recursive descent parser of tag expressions
+	tagExpressionParser struct {
+		tokens []string
+		pos    int
+	}
+
+	// tagExpressionNode is a parsed tag expression,
+	// op is one of "||", "&&", "!" or empty for tags
+	tagExpressionNode struct {
+		op       string
+		tag      string
+		operands []*tagExpressionNode
+	}
+)
+
+// EstimateAudience approximates the number of registrations targeted by tagExpression,
+// e.g. "(news || sport) && lang:en", all registrations when it is empty.
+// Registrations of every tag are counted by paging through them, alternatives
+// are summed and conjunctions take the smallest operand, so the estimate tends to be an upper bound
+func (h *NotificationHub) EstimateAudience(ctx context.Context, tagExpression string) (int, error) {
+	count, err := h.estimateAudience(ctx, tagExpression, 0)
+	if err != nil {
+		return 0, fmt.Errorf("NotificationHub.EstimateAudience: %w", err)
+	}
+
+	return count, nil
+}
+
+// estimateAudience approximates the audience of tagExpression, counting registrations of every tag
+// until limit is reached when it is positive. Estimates reaching limit are not accurate but stay at or above it
+func (h *NotificationHub) estimateAudience(ctx context.Context, tagExpression string, limit int) (int, error) {
+	node, err := parseTagExpression(tagExpression)
+	if err != nil {
+		return 0, err
+	}
+
+	e := &audienceEstimator{hub: h, limit: limit, counts: map[string]int{}}
+	if node == nil {
+		return e.count(ctx, "")
+	}
+
+	return e.estimate(ctx, node)
+}
+
+// estimate approximates the audience of node
+func (e *audienceEstimator) estimate(ctx context.Context, node *tagExpressionNode) (int, error) {
+	switch node.op {
+	case "":
+		return e.count(ctx, node.tag)
+	case "!":
+		all, err := e.count(ctx, "")
+		if err != nil {
+			return 0, err
+		}
+		if e.limit > 0 && all >= e.limit {
+			return all, nil
+		}
+		count, err := e.estimate(ctx, node.operands[0])
+		if err != nil {
+			return 0, err
+		}
+		return all - count, nil
+	}
+
+	result := 0
+	for i, operand := range node.operands {
+		count, err := e.estimate(ctx, operand)
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case node.op == "||":
+			result += count
+		case i == 0 || count < result:
+			result = count
+		}
+	}
+
+	return result, nil
+}
+
+// count returns the number of registrations with tag, all registrations when tag is empty
+func (e *audienceEstimator) count(ctx context.Context, tag string) (int, error) {
+	if count, ok := e.counts[tag]; ok {
+		return count, nil
+	}
+
+	count, err := e.hub.countRegistrations(ctx, tag, e.limit)
+	if err != nil {
+		return 0, err
+	}
+	e.counts[tag] = count
+
+	return count, nil
+}
+
+// parseTagExpression parses tag expression, nil is returned for empty expression
+func parseTagExpression(expr string) (*tagExpressionNode, error) {
+	tokens, err := tokenizeTagExpression(expr)
+	if err != nil || len(tokens) == 0 {
+		return nil, err
+	}
+
+	p := &tagExpressionParser{tokens: tokens}
+	node, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("tag expression '%s': unexpected '%s'", expr, p.tokens[p.pos])
+	}
+
+	return node, nil
+}
+
+// tokenizeTagExpression splits tag expression into operators, parentheses and tags
+func tokenizeTagExpression(expr string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(expr); {
+		switch c := expr[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')' || c == '!':
+			tokens = append(tokens, string(c))
+			i++
+		case strings.HasPrefix(expr[i:], "||") || strings.HasPrefix(expr[i:], "&&"):
+			tokens = append(tokens, expr[i:i+2])
+			i += 2
+		case isTagChar(rune(c)):
+			start := i
+			for i < len(expr) && isTagChar(rune(expr[i])) {
+				i++
+			}
+			if i < len(expr) && expr[start:i+1] == installationTagPrefix {
+				end := strings.IndexByte(expr[i:], '}')
+				if end < 0 {
+					return nil, fmt.Errorf("tag expression '%s': missing '}'", expr)
+				}
+				i += end + 1
+			}
+			tokens = append(tokens, expr[start:i])
+		default:
+			return nil, fmt.Errorf("tag expression '%s': unexpected character '%c'", expr, c)
+		}
+	}
+
+	return tokens, nil
+}
+
+// isTagChar reports whether c may be part of a tag. Braces are only accepted
+// around ids of installation tags, see installationTagPrefix
+func isTagChar(c rune) bool {
+	return c < unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c) || strings.ContainsRune("_@#.:-$", c))
+}
+
+// or parses alternatives: and ("||" and)*
+func (p *tagExpressionParser) or() (*tagExpressionNode, error) {
+	return p.binary("||", p.and)
+}
+
+// and parses conjunctions: unary ("&&" unary)*
+func (p *tagExpressionParser) and() (*tagExpressionNode, error) {
+	return p.binary("&&", p.unary)
+}
+
+func (p *tagExpressionParser) binary(op string, operand func() (*tagExpressionNode, error)) (*tagExpressionNode, error) {
+	node, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.pos < len(p.tokens) && p.tokens[p.pos] == op {
+		p.pos++
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if node.op != op {
+			node = &tagExpressionNode{op: op, operands: []*tagExpressionNode{node}}
+		}
+		node.operands = append(node.operands, next)
+	}
+
+	return node, nil
+}
+
+// unary parses negations, parenthesized expressions and tags
+func (p *tagExpressionParser) unary() (*tagExpressionNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("tag expression: unexpected end")
+	}
+
+	token := p.tokens[p.pos]
+	p.pos++
+
+	switch token {
+	case "!":
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &tagExpressionNode{op: "!", operands: []*tagExpressionNode{operand}}, nil
+	case "(":
+		node, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos] != ")" {
+			return nil, fmt.Errorf("tag expression: missing ')'")
+		}
+		p.pos++
+		return node, nil
+	case ")", "||", "&&":
+		return nil, fmt.Errorf("tag expression: unexpected '%s'", token)
+	}
+
+	return &tagExpressionNode{tag: token}, nil
+}
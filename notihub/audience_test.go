@@ -0,0 +1,76 @@
+package notihub
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_NotificationHubEstimateAudience(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newRegistrationFeedServer(map[string][]string{
+		"":        registrationIDs(500),
+		"news":    registrationIDs(120),
+		"sport":   registrationIDs(80),
+		"lang:en": registrationIDs(300),
+	})
+	defer server.Close()
+	nhub := server.hub()
+
+	testPatterns := []struct {
+		expr     string
+		expected int
+	}{
+		{"", 500},
+		{"news", 120},
+		{"news || sport", 200},
+		{"(news || sport) && lang:en", 200},
+		{"news && lang:en && sport", 80},
+		{"!news", 380},
+		{"lang:en && !(news || sport)", 300},
+		{"unknown", 0},
+	}
+
+	for _, testData := range testPatterns {
+		count, err := nhub.EstimateAudience(context.Background(), testData.expr)
+		if err != nil {
+			t.Errorf(errfmt, testData.expr+" error", nil, err)
+			continue
+		}
+		if count != testData.expected {
+			t.Errorf(errfmt, testData.expr, testData.expected, count)
+		}
+	}
+}
+
+func Test_ParseTagExpression(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	testPatterns := []struct {
+		expr  string
+		valid bool
+	}{
+		{"a || b && !c", true},
+		{"(a || (b && c)) && user:1@x.com", true},
+		{"a ||", false},
+		{"(a || b", false},
+		{"a b", false},
+		{"a | b", false},
+		{"&& a", false},
+		{"$InstallationId:{a-1} || news", true},
+		{"$InstallationId:{a-1", false},
+		{"news{a}", false},
+	}
+
+	for _, testData := range testPatterns {
+		_, err := parseTagExpression(testData.expr)
+		if (err == nil) != testData.valid {
+			t.Errorf(errfmt, testData.expr+" valid", testData.valid, err)
+		}
+	}
+
+	node, _ := parseTagExpression("a || b && !c || d")
+	if node.op != "||" || len(node.operands) != 3 || node.operands[1].op != "&&" || node.operands[1].operands[1].op != "!" {
+		t.Errorf(errfmt, "operator precedence", "a || (b && !c) || d", node)
+	}
+}
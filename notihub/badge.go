@@ -0,0 +1,82 @@
+package notihub
+
+import (
+	"context"
+	"fmt"
+)
+
+// maxWindowsBadgeCount is the highest number displayed by a WNS badge
+const maxWindowsBadgeCount = 99
+
+const (
+	BadgeNone        BadgeGlyph = "none"
+	BadgeActivity    BadgeGlyph = "activity"
+	BadgeAlarm       BadgeGlyph = "alarm"
+	BadgeAlert       BadgeGlyph = "alert"
+	BadgeAttention   BadgeGlyph = "attention"
+	BadgeAvailable   BadgeGlyph = "available"
+	BadgeAway        BadgeGlyph = "away"
+	BadgeBusy        BadgeGlyph = "busy"
+	BadgeError       BadgeGlyph = "error"
+	BadgeNewMessage  BadgeGlyph = "newMessage"
+	BadgePaused      BadgeGlyph = "paused"
+	BadgePlaying     BadgeGlyph = "playing"
+	BadgeUnavailable BadgeGlyph = "unavailable"
+)
+
+// BadgeGlyph is a WNS badge glyph
+type BadgeGlyph string
+
+// SetBadge returns notification setting app badge to count.
+// Only AppleFormat and WindowsFormat support badges
+func SetBadge(format NotificationFormat, count int) (*Notification, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("negative badge count %d", count)
+	}
+
+	switch format {
+	case AppleFormat:
+		payload, err := DefaultEncoder.Marshal(map[string]interface{}{
+			"aps": map[string]int{"badge": count},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &Notification{AppleFormat, payload}, nil
+	case WindowsFormat:
+		if count == 0 {
+			return SetBadgeGlyph(BadgeNone), nil
+		}
+		if count > maxWindowsBadgeCount {
+			count = maxWindowsBadgeCount
+		}
+		return &Notification{WindowsFormat, []byte(fmt.Sprintf(`<badge value="%d"/>`, count))}, nil
+	}
+
+	return nil, fmt.Errorf("format '%s' does not support badges", format)
+}
+
+// ClearBadge returns notification removing app badge
+func ClearBadge(format NotificationFormat) (*Notification, error) {
+	return SetBadge(format, 0)
+}
+
+// SetBadgeGlyph returns WNS notification setting app badge to glyph
+func SetBadgeGlyph(glyph BadgeGlyph) *Notification {
+	return &Notification{WindowsFormat, []byte(fmt.Sprintf(`<badge value="%s"/>`, glyph))}
+}
+
+// SendBadgeUpdate sets app badge to count on apple and windows
+// devices matching orTags, count 0 clears the badge
+func (h *NotificationHub) SendBadgeUpdate(ctx context.Context, orTags []string, count int, opts ...SendOption) ([]PlatformResult, error) {
+	notifications := make([]*Notification, 0, 2)
+	for _, format := range []NotificationFormat{AppleFormat, WindowsFormat} {
+		n, err := SetBadge(format, count)
+		if err != nil {
+			return nil, fmt.Errorf("NotificationHub.SendBadgeUpdate: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+
+	return h.Broadcast(ctx, notifications, orTags, opts...)
+}
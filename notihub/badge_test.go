@@ -0,0 +1,125 @@
+package notihub
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func Test_SetBadge(t *testing.T) {
+	testCases := []struct {
+		format          NotificationFormat
+		count           int
+		expectedPayload string
+		hasErr          bool
+	}{
+		{
+			format:          AppleFormat,
+			count:           5,
+			expectedPayload: `{"aps":{"badge":5}}`,
+		},
+		{
+			format:          AppleFormat,
+			count:           0,
+			expectedPayload: `{"aps":{"badge":0}}`,
+		},
+		{
+			format:          WindowsFormat,
+			count:           5,
+			expectedPayload: `<badge value="5"/>`,
+		},
+		{
+			format:          WindowsFormat,
+			count:           150,
+			expectedPayload: `<badge value="99"/>`,
+		},
+		{
+			format:          WindowsFormat,
+			count:           0,
+			expectedPayload: `<badge value="none"/>`,
+		},
+		{
+			format: AppleFormat,
+			count:  -1,
+			hasErr: true,
+		},
+		{
+			format: AndroidFormat,
+			count:  1,
+			hasErr: true,
+		},
+	}
+
+	for i, testCase := range testCases {
+		n, err := SetBadge(testCase.format, testCase.count)
+		if (err != nil) != testCase.hasErr {
+			t.Errorf("SetBadge test case %d. Expected error: %t, got: %v", i, testCase.hasErr, err)
+			continue
+		}
+
+		if err == nil && string(n.Payload) != testCase.expectedPayload {
+			t.Errorf("SetBadge test case %d. Expected payload: %s, got: %s", i, testCase.expectedPayload, n.Payload)
+		}
+	}
+
+	if n := SetBadgeGlyph(BadgeNewMessage); string(n.Payload) != `<badge value="newMessage"/>` {
+		t.Errorf("Expected glyph payload: %s, got: %s", `<badge value="newMessage"/>`, n.Payload)
+	}
+}
+
+func Test_WindowsNotificationType(t *testing.T) {
+	testCases := map[string]string{
+		`<badge value="1"/>`:                            "wns/badge",
+		`<?xml version="1.0"?><toast><visual/></toast>`: "wns/toast",
+		`<tile><visual/></tile>`:                        "wns/tile",
+		`<raw/>`:                                        "",
+		`not xml`:                                       "",
+	}
+
+	for payload, expected := range testCases {
+		if obtained := windowsNotificationType([]byte(payload)); obtained != expected {
+			t.Errorf("windowsNotificationType(%s). Expected '%s', got '%s'", payload, expected, obtained)
+		}
+	}
+}
+
+func Test_NotificationHubSendBadgeUpdate(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		payloads = map[string]string{}
+		wnsTypes = map[string]string{}
+	)
+
+	mockClient := &mockHubHttpClient{execFunc: func(req *http.Request) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		format := req.Header.Get("ServiceBusNotification-Format")
+		b, _ := ioutil.ReadAll(req.Body)
+		payloads[format] = string(b)
+		wnsTypes[format] = req.Header.Get("X-WNS-Type")
+
+		return nil, nil
+	}}
+
+	nhub := &NotificationHub{
+		hubURL:         &url.URL{Host: "testHost", Scheme: schemeDefault, Path: "testPath"},
+		client:         mockClient,
+		expiryTimeFunc: TimeFunc(mockExpiryTime),
+	}
+
+	if _, err := nhub.SendBadgeUpdate(context.Background(), []string{"user:1"}, 3); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if payloads["apple"] != `{"aps":{"badge":3}}` || payloads["windows"] != `<badge value="3"/>` {
+		t.Errorf("Expected apple and windows badge payloads, got: %v", payloads)
+	}
+
+	if wnsTypes["windows"] != "wns/badge" || wnsTypes["apple"] != "" {
+		t.Errorf("Expected X-WNS-Type wns/badge for windows only, got: %v", wnsTypes)
+	}
+}
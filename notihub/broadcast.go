@@ -0,0 +1,154 @@
+package notihub
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type (
+	// PlatformResult is the outcome of a single format send within Broadcast
+	PlatformResult struct {
+		Format   NotificationFormat
+		Response []byte
+		Result   SendResult
+		Err      error
+	}
+
+	// MultiError is returned by Broadcast when some of the platform sends fail.
+	// Results contain outcomes of all platforms, successful ones included.
+	// MultiError does not wrap the platform errors, errors.Is and errors.As
+	// do not reach them, check Err of Failed results instead
+	MultiError struct {
+		Results []PlatformResult
+
+		hub           *NotificationHub
+		notifications map[NotificationFormat]*Notification
+		orTags        []string
+		opts          []SendOption
+	}
+)
+
+// Broadcast concurrently sends notifications, one per format, to recipients matching orTags.
+// Results are returned in the order of notifications. When only some of the sends fail,
+// *MultiError is returned and can be used to retry the failed platforms.
+// With WithRing the notifications are verified on the test ring before anyone else receives them
+func (h *NotificationHub) Broadcast(ctx context.Context, notifications []*Notification, orTags []string, opts ...SendOption) ([]PlatformResult, error) {
+	byFormat := make(map[NotificationFormat]*Notification, len(notifications))
+	for _, n := range notifications {
+		if _, ok := byFormat[n.Format]; ok {
+			return nil, fmt.Errorf("NotificationHub.Broadcast: duplicate notification format '%s'", n.Format)
+		}
+		byFormat[n.Format] = n
+	}
+
+	if o := newSendOptions(opts); o.ring != "" {
+		// the ring exclusion tags every send, so untargeted broadcasts are confirmed before it is added
+		if err := h.checkBroadcast(ctx, orTags, o); err != nil {
+			return nil, fmt.Errorf("NotificationHub.Broadcast: %w", err)
+		}
+		if err := h.broadcastRing(ctx, notifications, o.ring, opts); err != nil {
+			return nil, fmt.Errorf("NotificationHub.Broadcast: %w", err)
+		}
+		exclusion := "!" + o.ring
+		opts = append(opts[:len(opts):len(opts)], func(o *sendOptions) {
+			o.andTags = append(o.andTags, exclusion)
+		})
+	}
+
+	results := h.broadcast(ctx, notifications, orTags, opts)
+
+	return results, newMultiError(h, results, byFormat, orTags, opts)
+}
+
+// broadcast sends notifications concurrently and collects per format results
+func (h *NotificationHub) broadcast(ctx context.Context, notifications []*Notification, orTags []string, opts []SendOption) []PlatformResult {
+	results := make([]PlatformResult, len(notifications))
+
+	var wg sync.WaitGroup
+	for i, n := range notifications {
+		wg.Add(1)
+		i, n := i, n
+		h.goLabeled(ctx, "broadcast", func(ctx context.Context) {
+			defer wg.Done()
+
+			o := newSendOptions(opts)
+			o.result = &results[i].Result
+
+			results[i].Format = n.Format
+			results[i].Response, results[i].Err = h.send(ctx, n, orTags, o)
+		})
+	}
+	wg.Wait()
+
+	return results
+}
+
+// newMultiError returns *MultiError if any of results failed
+func newMultiError(h *NotificationHub, results []PlatformResult, notifications map[NotificationFormat]*Notification, orTags []string, opts []SendOption) error {
+	for _, result := range results {
+		if result.Err != nil {
+			return &MultiError{
+				Results:       results,
+				hub:           h,
+				notifications: notifications,
+				orTags:        orTags,
+				opts:          opts,
+			}
+		}
+	}
+
+	return nil
+}
+
+// Error returns the number of failed platform sends followed by
+// the format and error message of each of them
+func (e *MultiError) Error() string {
+	failed := e.Failed()
+
+	msgs := make([]string, 0, len(failed))
+	for _, result := range failed {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", result.Format, result.Err))
+	}
+
+	return fmt.Sprintf("NotificationHub.Broadcast: %d of %d platform sends failed: %s", len(failed), len(e.Results), strings.Join(msgs, "; "))
+}
+
+// Failed returns results of the failed platform sends
+func (e *MultiError) Failed() []PlatformResult {
+	var failed []PlatformResult
+	for _, result := range e.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+
+	return failed
+}
+
+// Retry re-sends only the failed platforms and returns results of all platforms,
+// the successful ones of the original broadcast included.
+// *MultiError is returned again if any of the platforms still fails
+func (e *MultiError) Retry(ctx context.Context) ([]PlatformResult, error) {
+	var (
+		retried []*Notification
+		indices []int
+	)
+
+	for i, result := range e.Results {
+		if result.Err != nil {
+			retried = append(retried, e.notifications[result.Format])
+			indices = append(indices, i)
+		}
+	}
+
+	results := make([]PlatformResult, len(e.Results))
+	copy(results, e.Results)
+
+	for i, result := range e.hub.broadcast(ctx, retried, e.orTags, e.opts) {
+		results[indices[i]] = result
+	}
+
+	return results, newMultiError(e.hub, results, e.notifications, e.orTags, e.opts)
+}
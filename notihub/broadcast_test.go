@@ -0,0 +1,88 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func Test_NotificationHubBroadcastPartialFailure(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var (
+		mu       sync.Mutex
+		requests = map[string]int{}
+		failGcm  = true
+	)
+
+	mockClient := &mockHubHttpClient{execFunc: func(req *http.Request) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		format := req.Header.Get("ServiceBusNotification-Format")
+		requests[format]++
+		if format == string(AndroidFormat) && failGcm {
+			return nil, errors.New("gcm failure")
+		}
+
+		return []byte(format), nil
+	}}
+
+	nhub := &NotificationHub{
+		sasKeyValue:    "testKeyValue",
+		sasKeyName:     "testKeyName",
+		hubURL:         &url.URL{Host: "testHost", Scheme: schemeDefault, Path: "testPath"},
+		client:         mockClient,
+		expiryTimeFunc: TimeFunc(mockExpiryTime),
+	}
+
+	notifications := []*Notification{
+		{AppleFormat, []byte(`{"aps":{"alert":"hi"}}`)},
+		{AndroidFormat, []byte(`{"data":{"msg":"hi"}}`)},
+	}
+
+	results, err := nhub.Broadcast(context.Background(), notifications, []string{"tag"})
+
+	var multiErr *MultiError
+	if !errors.As(err, &multiErr) {
+		t.Fatalf(errfmt, "error", "*MultiError", err)
+	}
+
+	if len(results) != 2 || results[0].Err != nil || results[1].Err == nil {
+		t.Fatalf(errfmt, "results", "apple success and gcm failure", results)
+	}
+
+	if failed := multiErr.Failed(); len(failed) != 1 || failed[0].Format != AndroidFormat {
+		t.Errorf(errfmt, "failed", AndroidFormat, failed)
+	}
+
+	failGcm = false
+	results, err = multiErr.Retry(context.Background())
+	if err != nil {
+		t.Fatalf(errfmt, "retry error", nil, err)
+	}
+
+	if string(results[0].Response) != string(AppleFormat) || string(results[1].Response) != string(AndroidFormat) {
+		t.Errorf(errfmt, "retry results", "apple and gcm responses", results)
+	}
+
+	if requests[string(AppleFormat)] != 1 || requests[string(AndroidFormat)] != 2 {
+		t.Errorf(errfmt, "requests", "apple 1, gcm 2", requests)
+	}
+}
+
+func Test_NotificationHubBroadcastDuplicateFormat(t *testing.T) {
+	nhub := &NotificationHub{hubURL: &url.URL{}}
+
+	notifications := []*Notification{
+		{AppleFormat, []byte("{}")},
+		{AppleFormat, []byte("{}")},
+	}
+
+	if _, err := nhub.Broadcast(context.Background(), notifications, nil); err == nil {
+		t.Error("Expected duplicate format error, got nil")
+	}
+}
@@ -0,0 +1,29 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrBroadcastNotConfirmed is returned when notification would be sent to every device
+// of the hub, because it targets no tags, without the BroadcastAll option
+var ErrBroadcastNotConfirmed = errors.New("broadcast to all devices not confirmed")
+
+// BroadcastAll confirms the notification is meant for every device of the hub
+// when it targets no tags. Without it such sends fail with ErrBroadcastNotConfirmed,
+// protecting against tag slices left empty by mistake
+func BroadcastAll() SendOption {
+	return func(o *sendOptions) {
+		o.broadcastAll = true
+	}
+}
+
+// checkBroadcast returns ErrBroadcastNotConfirmed
+// if the send targets every device without confirmation
+func (h *NotificationHub) checkBroadcast(ctx context.Context, orTags []string, o *sendOptions) error {
+	if h.sendTagExpression(ctx, orTags, o) != "" || o != nil && o.broadcastAll {
+		return nil
+	}
+
+	return ErrBroadcastNotConfirmed
+}
@@ -0,0 +1,52 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func Test_NotificationHubBroadcastAll(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	requests := 0
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		requests++
+		return nil, nil
+	})
+	n := &Notification{Template, []byte("{}")}
+	ctx := context.Background()
+
+	unconfirmed := []func() error{
+		func() error { _, err := nhub.Send(ctx, n, nil); return err },
+		func() error { _, err := nhub.Send(ctx, n, []string{}); return err },
+		func() error { _, err := nhub.Schedule(ctx, n, nil, time.Now().Add(time.Hour)); return err },
+		func() error { _, err := nhub.SendTest(ctx, n, nil); return err },
+	}
+	for i, send := range unconfirmed {
+		if err := send(); !errors.Is(err, ErrBroadcastNotConfirmed) {
+			t.Errorf(errfmt, "unconfirmed broadcast error", ErrBroadcastNotConfirmed, err)
+		}
+		if requests != 0 {
+			t.Fatalf(errfmt, "requests of unconfirmed broadcast", 0, i)
+		}
+	}
+
+	if _, err := nhub.Send(ctx, n, nil, BroadcastAll()); err != nil {
+		t.Errorf(errfmt, "confirmed broadcast error", nil, err)
+	}
+	if _, err := nhub.Send(ctx, n, []string{"tag"}); err != nil {
+		t.Errorf(errfmt, "tagged send error", nil, err)
+	}
+
+	WithDefaultTags("tenant")(nhub)
+	if _, err := nhub.Send(ctx, n, nil); err != nil {
+		t.Errorf(errfmt, "send with default tags error", nil, err)
+	}
+
+	if requests != 3 {
+		t.Errorf(errfmt, "requests", 3, requests)
+	}
+}
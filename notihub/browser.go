@@ -0,0 +1,98 @@
+package notihub
+
+import (
+	"context"
+	"crypto/elliptic"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+)
+
+const browserCredentialName = "BrowserCredential"
+
+// BrowserCredential is the VAPID credential of browser push.
+// Keys are base64url encoded: the public key is an uncompressed P-256 point,
+// the private key its 32 byte scalar
+type BrowserCredential struct {
+	// Subject is the contact of the application server, mailto: or https: URL
+	Subject         string
+	VapidPublicKey  string
+	VapidPrivateKey string
+}
+
+// Validate checks the subject and that the keys are a matching P-256 key pair
+func (c BrowserCredential) Validate() error {
+	subject, err := url.Parse(c.Subject)
+	if err != nil || !(subject.Scheme == "mailto" && subject.Opaque != "" || subject.Scheme == "https" && subject.Host != "") {
+		return fmt.Errorf("%w: subject '%s' must be mailto: or https: URL", ErrInvalidCredential, c.Subject)
+	}
+
+	public, err := decodeVapidKey(c.VapidPublicKey)
+	if err != nil || len(public) != 65 || public[0] != 4 {
+		return fmt.Errorf("%w: public key must be base64url encoded uncompressed P-256 point", ErrInvalidCredential)
+	}
+
+	curve := elliptic.P256()
+	x, y := new(big.Int).SetBytes(public[1:33]), new(big.Int).SetBytes(public[33:])
+	if !curve.IsOnCurve(x, y) {
+		return fmt.Errorf("%w: public key is not a P-256 point", ErrInvalidCredential)
+	}
+
+	private, err := decodeVapidKey(c.VapidPrivateKey)
+	if err != nil || len(private) != 32 {
+		return fmt.Errorf("%w: private key must be base64url encoded 32 byte P-256 scalar", ErrInvalidCredential)
+	}
+
+	if px, py := curve.ScalarBaseMult(private); px.Cmp(x) != 0 || py.Cmp(y) != 0 {
+		return fmt.Errorf("%w: private key does not match public key", ErrInvalidCredential)
+	}
+
+	return nil
+}
+
+// SetBrowserCredential validates credential and sets it as the hub browser credential
+func (m *ManagementClient) SetBrowserCredential(ctx context.Context, credential BrowserCredential) error {
+	if err := credential.Validate(); err != nil {
+		return fmt.Errorf("ManagementClient.SetBrowserCredential: %w", err)
+	}
+
+	err := m.updateCredential(ctx, browserCredentialName, []credentialProperty{
+		{Name: "Subject", Value: credential.Subject},
+		{Name: "VapidPublicKey", Value: credential.VapidPublicKey},
+		{Name: "VapidPrivateKey", Value: credential.VapidPrivateKey},
+	})
+	if err != nil {
+		return fmt.Errorf("ManagementClient.SetBrowserCredential: %w", err)
+	}
+
+	return nil
+}
+
+// GetBrowserCredential returns the hub browser credential, nil when not configured
+func (m *ManagementClient) GetBrowserCredential(ctx context.Context) (*BrowserCredential, error) {
+	description, err := m.getDescription(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ManagementClient.GetBrowserCredential: %w", err)
+	}
+
+	properties, ok, err := description.credential(browserCredentialName)
+	if err != nil {
+		return nil, fmt.Errorf("ManagementClient.GetBrowserCredential: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &BrowserCredential{
+		Subject:         properties["Subject"],
+		VapidPublicKey:  properties["VapidPublicKey"],
+		VapidPrivateKey: properties["VapidPrivateKey"],
+	}, nil
+}
+
+// decodeVapidKey decodes base64url key, with or without padding
+func decodeVapidKey(key string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(key, "="))
+}
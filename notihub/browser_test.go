@@ -0,0 +1,93 @@
+package notihub
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func newTestVapidKeys(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	private := make([]byte, 32)
+	d := key.D.Bytes()
+	copy(private[32-len(d):], d)
+
+	return base64.RawURLEncoding.EncodeToString(elliptic.Marshal(elliptic.P256(), key.X, key.Y)),
+		base64.RawURLEncoding.EncodeToString(private)
+}
+
+func Test_BrowserCredentialValidate(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	public, private := newTestVapidKeys(t)
+	otherPublic, _ := newTestVapidKeys(t)
+
+	// altering a character of y coordinate moves the point off the curve
+	offCurve := []byte(public)
+	if offCurve[60] == 'A' {
+		offCurve[60] = 'B'
+	} else {
+		offCurve[60] = 'A'
+	}
+
+	tests := []struct {
+		name       string
+		credential BrowserCredential
+		valid      bool
+	}{
+		{"valid", BrowserCredential{"mailto:push@example.com", public, private}, true},
+		{"https subject, padded keys", BrowserCredential{"https://example.com/contact", public + "=", private + "="}, true},
+		{"bare subject", BrowserCredential{"push@example.com", public, private}, false},
+		{"standard base64", BrowserCredential{"mailto:push@example.com", "+/" + public[2:], private}, false},
+		{"short public key", BrowserCredential{"mailto:push@example.com", public[:40], private}, false},
+		{"point not on curve", BrowserCredential{"mailto:push@example.com", string(offCurve), private}, false},
+		{"mismatched keys", BrowserCredential{"mailto:push@example.com", otherPublic, private}, false},
+		{"short private key", BrowserCredential{"mailto:push@example.com", public, private[:20]}, false},
+	}
+
+	for _, test := range tests {
+		err := test.credential.Validate()
+		if test.valid && err != nil || !test.valid && !errors.Is(err, ErrInvalidCredential) {
+			t.Errorf(errfmt, test.name+" validation", test.valid, err)
+		}
+	}
+}
+
+func Test_ManagementClientBrowserCredential(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newHubDescriptionServer(testHubDescription)
+	defer server.Close()
+
+	client := server.client()
+	ctx := context.Background()
+
+	if credential, err := client.GetBrowserCredential(ctx); err != nil || credential != nil {
+		t.Errorf(errfmt, "unset credential", nil, credential)
+	}
+
+	if err := client.SetBrowserCredential(ctx, BrowserCredential{Subject: "push@example.com"}); !errors.Is(err, ErrInvalidCredential) || len(server.updates) != 0 {
+		t.Errorf(errfmt, "invalid credential error", ErrInvalidCredential, err)
+	}
+
+	public, private := newTestVapidKeys(t)
+	expected := BrowserCredential{"mailto:push@example.com", public, private}
+	if err := client.SetBrowserCredential(ctx, expected); err != nil {
+		t.Fatalf(errfmt, "set error", nil, err)
+	}
+
+	credential, err := client.GetBrowserCredential(ctx)
+	if err != nil || credential == nil || *credential != expected {
+		t.Errorf(errfmt, "credential", expected, credential)
+	}
+}
@@ -0,0 +1,299 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// ErrBulkAborted is returned by BulkSender.Run once the send is aborted
+var ErrBulkAborted = errors.New("bulk send aborted")
+
+type (
+	// BulkMessage is a single send of a bulk campaign, it targets
+	// DeviceHandle directly when set and OrTags recipients otherwise
+	BulkMessage struct {
+		Notification *Notification
+		OrTags       []string
+		DeviceHandle string
+	}
+
+	// CheckpointStore persists the progress of bulk campaigns
+	CheckpointStore interface {
+		// LoadCheckpoint returns the index of the next message to send, 0 for new campaigns
+		LoadCheckpoint(ctx context.Context, campaignID string) (int, error)
+		// SaveCheckpoint stores the index of the next message to send
+		SaveCheckpoint(ctx context.Context, campaignID string, next int) error
+	}
+
+	// MemoryCheckpointStore is CheckpointStore keeping checkpoints in process memory
+	MemoryCheckpointStore struct {
+		mu          sync.Mutex
+		checkpoints map[string]int
+	}
+
+	// BulkOptions configures BulkSender
+	BulkOptions struct {
+		// Store persists progress, so that Run continues where a previous run stopped.
+		// Progress is kept only by the sender when nil
+		Store CheckpointStore
+		// Interval is the minimum time between consecutive sends
+		Interval time.Duration
+		// SendOptions are applied to every send
+		SendOptions []SendOption
+	}
+
+	// BulkFailure is a message which could not be sent
+	BulkFailure struct {
+		Index int
+		Err   error
+	}
+
+	// BulkProgress describes the state of a bulk campaign
+	BulkProgress struct {
+		Total    int
+		Next     int
+		Sent     int
+		Failures []BulkFailure
+		Paused   bool
+		Aborted  bool
+	}
+
+	// BulkSender sends campaign messages one by one
+	// and can be paused, resumed or aborted while running
+	BulkSender struct {
+		hub        *NotificationHub
+		campaignID string
+		messages   []BulkMessage
+		opts       BulkOptions
+
+		mu       sync.Mutex
+		progress BulkProgress
+		pausing  chan struct{}
+		resumed  chan struct{}
+		aborted  chan struct{}
+	}
+)
+
+// NewMemoryCheckpointStore initializes and returns MemoryCheckpointStore pointer
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{checkpoints: map[string]int{}}
+}
+
+// LoadCheckpoint returns the index of the next campaign message to send
+func (s *MemoryCheckpointStore) LoadCheckpoint(ctx context.Context, campaignID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.checkpoints[campaignID], nil
+}
+
+// SaveCheckpoint stores the index of the next campaign message to send
+func (s *MemoryCheckpointStore) SaveCheckpoint(ctx context.Context, campaignID string, next int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkpoints[campaignID] = next
+	return nil
+}
+
+// NewBulkSender initializes and returns BulkSender pointer
+// sending messages of campaign identified by campaignID
+func (h *NotificationHub) NewBulkSender(campaignID string, messages []BulkMessage, opts BulkOptions) *BulkSender {
+	return &BulkSender{
+		hub:        h,
+		campaignID: campaignID,
+		messages:   messages,
+		opts:       opts,
+		progress:   BulkProgress{Total: len(messages)},
+		pausing:    make(chan struct{}),
+		aborted:    make(chan struct{}),
+	}
+}
+
+// Run sends messages starting from the stored checkpoint until all are sent,
+// ctx is done or the send is aborted. Failed messages are recorded in progress
+// and do not stop the campaign. Checkpoint is saved after every message
+func (s *BulkSender) Run(ctx context.Context) (progress BulkProgress, err error) {
+	pprof.Do(ctx, s.hub.profileLabels("bulk"), func(ctx context.Context) {
+		progress, err = s.run(ctx)
+	})
+
+	return progress, err
+}
+
+// run sends messages until all are sent, ctx is done or the send is aborted
+func (s *BulkSender) run(ctx context.Context) (BulkProgress, error) {
+	if s.opts.Store != nil {
+		next, err := s.opts.Store.LoadCheckpoint(ctx, s.campaignID)
+		if err != nil {
+			return s.Progress(), fmt.Errorf("BulkSender.Run: %w", err)
+		}
+		s.mu.Lock()
+		if next > s.progress.Next {
+			s.progress.Next = next
+		}
+		s.mu.Unlock()
+	}
+
+	var lastSend time.Time
+	for {
+		if err := s.waitResumed(ctx); err != nil {
+			return s.Progress(), fmt.Errorf("BulkSender.Run: %w", err)
+		}
+
+		s.mu.Lock()
+		i := s.progress.Next
+		s.mu.Unlock()
+		if i >= len(s.messages) {
+			return s.Progress(), nil
+		}
+
+		if !lastSend.IsZero() {
+			if err := s.waitInterval(ctx, lastSend); err != nil {
+				return s.Progress(), fmt.Errorf("BulkSender.Run: %w", err)
+			}
+		}
+		if err := s.waitResumed(ctx); err != nil {
+			return s.Progress(), fmt.Errorf("BulkSender.Run: %w", err)
+		}
+		lastSend = time.Now()
+
+		err := s.send(ctx, &s.messages[i])
+
+		s.mu.Lock()
+		if err != nil {
+			s.progress.Failures = append(s.progress.Failures, BulkFailure{Index: i, Err: err})
+		} else {
+			s.progress.Sent++
+		}
+		s.progress.Next = i + 1
+		s.mu.Unlock()
+
+		if s.opts.Store != nil {
+			if err := s.opts.Store.SaveCheckpoint(ctx, s.campaignID, i+1); err != nil {
+				return s.Progress(), fmt.Errorf("BulkSender.Run: %w", err)
+			}
+		}
+	}
+}
+
+// Pause stops sending after the message in flight until Resume is called
+func (s *BulkSender) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.progress.Paused {
+		s.progress.Paused = true
+		s.resumed = make(chan struct{})
+		close(s.pausing)
+	}
+}
+
+// Resume continues a paused send
+func (s *BulkSender) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.progress.Paused {
+		s.progress.Paused = false
+		s.pausing = make(chan struct{})
+		close(s.resumed)
+	}
+}
+
+// Abort stops the send after the message in flight, Run returns ErrBulkAborted.
+// The checkpoint is kept, so that a new sender can continue the campaign
+func (s *BulkSender) Abort() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.progress.Aborted {
+		s.progress.Aborted = true
+		close(s.aborted)
+	}
+}
+
+// Progress returns the current state of the campaign
+func (s *BulkSender) Progress() BulkProgress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	progress := s.progress
+	progress.Failures = append([]BulkFailure(nil), s.progress.Failures...)
+
+	return progress
+}
+
+// waitResumed blocks while the sender is paused
+func (s *BulkSender) waitResumed(ctx context.Context) error {
+	s.mu.Lock()
+	paused, resumed := s.progress.Paused, s.resumed
+	s.mu.Unlock()
+
+	if !paused {
+		select {
+		case <-s.aborted:
+			return ErrBulkAborted
+		default:
+			return nil
+		}
+	}
+
+	select {
+	case <-resumed:
+		return s.waitResumed(ctx)
+	case <-s.aborted:
+		return ErrBulkAborted
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// waitInterval blocks until Interval since lastSend passes. Pausing the sender stops the wait
+// until it is resumed, aborting it or ctx being done ends the wait with an error
+func (s *BulkSender) waitInterval(ctx context.Context, lastSend time.Time) error {
+	for {
+		if err := s.waitResumed(ctx); err != nil {
+			return err
+		}
+
+		d := s.opts.Interval - time.Since(lastSend)
+		if d <= 0 {
+			return nil
+		}
+
+		s.mu.Lock()
+		pausing := s.pausing
+		s.mu.Unlock()
+
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+			return nil
+		case <-pausing:
+			timer.Stop()
+		case <-s.aborted:
+			timer.Stop()
+			return ErrBulkAborted
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// send sends a single campaign message
+func (s *BulkSender) send(ctx context.Context, m *BulkMessage) error {
+	o := newSendOptions(s.opts.SendOptions)
+	if m.DeviceHandle != "" {
+		_, err := s.hub.sendDirect(ctx, m.Notification, m.DeviceHandle, o)
+		return err
+	}
+
+	_, err := s.hub.send(ctx, m.Notification, m.OrTags, o)
+	return err
+}
@@ -0,0 +1,190 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newBulkTestHub(execFunc func(req *http.Request) ([]byte, error)) *NotificationHub {
+	return &NotificationHub{
+		sasKeyValue:    "testKeyValue",
+		sasKeyName:     "testKeyName",
+		hubURL:         &url.URL{Host: "testHost", Scheme: schemeDefault, Path: "testPath"},
+		client:         &mockHubHttpClient{execFunc: execFunc},
+		expiryTimeFunc: TimeFunc(mockExpiryTime),
+	}
+}
+
+func newBulkTestMessages(count int) []BulkMessage {
+	messages := make([]BulkMessage, count)
+	for i := range messages {
+		messages[i] = BulkMessage{Notification: &Notification{AndroidFormat, []byte(`{"data":{}}`)}, OrTags: []string{"tag"}}
+	}
+	messages[count-1] = BulkMessage{Notification: &Notification{AndroidFormat, []byte(`{"data":{}}`)}, DeviceHandle: "handle"}
+
+	return messages
+}
+
+func Test_BulkSenderPauseResume(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var (
+		mu       sync.Mutex
+		sent     int
+		direct   int
+		sender   *BulkSender
+		pausedAt = make(chan struct{})
+	)
+
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		sent++
+		if req.URL.Query().Get(directParam) != "" || req.Header.Get("ServiceBusNotification-DeviceHandle") != "" {
+			direct++
+		}
+		if sent == 2 {
+			sender.Pause()
+			close(pausedAt)
+		}
+		if sent == 3 {
+			return nil, errors.New("send failure")
+		}
+		return nil, nil
+	})
+
+	store := NewMemoryCheckpointStore()
+	sender = nhub.NewBulkSender("campaign", newBulkTestMessages(5), BulkOptions{Store: store})
+
+	done := make(chan error)
+	go func() {
+		_, err := sender.Run(context.Background())
+		done <- err
+	}()
+
+	<-pausedAt
+	time.Sleep(10 * time.Millisecond)
+	if progress := sender.Progress(); !progress.Paused || progress.Next != 2 {
+		t.Errorf(errfmt, "paused progress", 2, progress.Next)
+	}
+	if next, _ := store.LoadCheckpoint(context.Background(), "campaign"); next != 2 {
+		t.Errorf(errfmt, "checkpoint while paused", 2, next)
+	}
+
+	sender.Resume()
+	if err := <-done; err != nil {
+		t.Fatalf(errfmt, "run error", nil, err)
+	}
+
+	progress := sender.Progress()
+	if progress.Next != 5 || progress.Sent != 4 || len(progress.Failures) != 1 || progress.Failures[0].Index != 2 {
+		t.Errorf(errfmt, "final progress", "4 sent and message 2 failed", progress)
+	}
+	if direct != 1 {
+		t.Errorf(errfmt, "direct sends", 1, direct)
+	}
+}
+
+func Test_BulkSenderAbortAndContinue(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var (
+		sent   int
+		sender *BulkSender
+	)
+
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		sent++
+		if sent == 3 {
+			sender.Abort()
+		}
+		return nil, nil
+	})
+
+	store := NewMemoryCheckpointStore()
+	messages := newBulkTestMessages(5)
+
+	sender = nhub.NewBulkSender("campaign", messages, BulkOptions{Store: store})
+	progress, err := sender.Run(context.Background())
+	if !errors.Is(err, ErrBulkAborted) {
+		t.Fatalf(errfmt, "aborted error", ErrBulkAborted, err)
+	}
+	if !progress.Aborted || progress.Next != 3 {
+		t.Errorf(errfmt, "aborted progress", 3, progress.Next)
+	}
+
+	progress, err = nhub.NewBulkSender("campaign", messages, BulkOptions{Store: store}).Run(context.Background())
+	if err != nil {
+		t.Fatalf(errfmt, "continued run error", nil, err)
+	}
+	if sent != 5 || progress.Sent != 2 || progress.Next != 5 {
+		t.Errorf(errfmt, "messages sent in total", 5, sent)
+	}
+}
+
+func Test_BulkSenderPausedContextCanceled(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		return nil, nil
+	})
+
+	sender := nhub.NewBulkSender("campaign", newBulkTestMessages(2), BulkOptions{})
+	sender.Pause()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := sender.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf(errfmt, "paused run error", context.DeadlineExceeded, err)
+	}
+}
+
+func Test_BulkSenderPauseDuringInterval(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var (
+		mu   sync.Mutex
+		sent int
+	)
+	first := make(chan struct{})
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		sent++
+		if sent == 1 {
+			close(first)
+		}
+		return nil, nil
+	})
+
+	sender := nhub.NewBulkSender("campaign", newBulkTestMessages(3), BulkOptions{Interval: 30 * time.Millisecond})
+	done := make(chan error)
+	go func() {
+		_, err := sender.Run(context.Background())
+		done <- err
+	}()
+
+	<-first
+	sender.Pause()
+	time.Sleep(60 * time.Millisecond)
+	mu.Lock()
+	if sent != 1 {
+		t.Errorf(errfmt, "sends while paused during interval", 1, sent)
+	}
+	mu.Unlock()
+
+	sender.Abort()
+	if err := <-done; !errors.Is(err, ErrBulkAborted) {
+		t.Errorf(errfmt, "aborted error", ErrBulkAborted, err)
+	}
+	if progress := sender.Progress(); progress.Sent != 1 || progress.Next != 1 {
+		t.Errorf(errfmt, "sent messages", 1, progress.Sent)
+	}
+}
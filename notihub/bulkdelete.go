@@ -0,0 +1,149 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const defaultBulkDeleteConcurrency = 10
+
+type (
+	// BulkDeleteOptions configures DeleteInstallationsByTag
+	BulkDeleteOptions struct {
+		// Concurrency limits the number of installations deleted concurrently, 10 by default
+		Concurrency int
+		// DryRun lists installations matching the tag without deleting them
+		DryRun bool
+		// OnProgress, when not nil, is called after every page of registrations
+		OnProgress func(BulkDeleteProgress)
+	}
+
+	// BulkDeleteProgress counts installations processed by DeleteInstallationsByTag so far.
+	// Skipped counts registrations not belonging to installations, which are left in place
+	BulkDeleteProgress struct {
+		Matched int
+		Deleted int
+		Failed  int
+		Skipped int
+	}
+
+	// BulkDeleteResult is the outcome of deleting a single installation
+	BulkDeleteResult struct {
+		InstallationId string
+		Err            error
+	}
+
+	// BulkDeleteReport lists results in the order installations were found
+	BulkDeleteReport struct {
+		DryRun   bool
+		Progress BulkDeleteProgress
+		Results  []BulkDeleteResult
+	}
+)
+
+// DeleteInstallationsByTag pages through registrations with tag and deletes the installations
+// they belong to, e.g. on feature sunsets or tenant offboarding.
+// Report is returned even when some of the deletions fail
+func (h *NotificationHub) DeleteInstallationsByTag(ctx context.Context, tag string, opts BulkDeleteOptions) (*BulkDeleteReport, error) {
+	if tag == "" {
+		return nil, errors.New("NotificationHub.DeleteInstallationsByTag: tag is required")
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBulkDeleteConcurrency
+	}
+
+	report := &BulkDeleteReport{DryRun: opts.DryRun}
+	seen := map[string]bool{}
+
+	pager := h.registrationPager(tag)
+	for pager.More() {
+		registrations, err := pager.NextPage(ctx)
+		if err != nil {
+			return report, fmt.Errorf("NotificationHub.DeleteInstallationsByTag: %w", err)
+		}
+
+		var ids []string
+		for _, r := range registrations {
+			installationID, ok := registrationInstallation(r)
+			if !ok {
+				report.Progress.Skipped++
+				continue
+			}
+			if seen[installationID] {
+				continue
+			}
+			seen[installationID] = true
+			ids = append(ids, installationID)
+		}
+
+		for _, result := range h.deleteInstallationBatch(ctx, ids, concurrency, opts.DryRun) {
+			report.Results = append(report.Results, result)
+			report.Progress.Matched++
+			switch {
+			case result.Err != nil:
+				report.Progress.Failed++
+			case !opts.DryRun:
+				report.Progress.Deleted++
+			}
+		}
+
+		if opts.OnProgress != nil {
+			opts.OnProgress(report.Progress)
+		}
+	}
+
+	if failed := report.Failed(); len(failed) > 0 {
+		return report, fmt.Errorf("NotificationHub.DeleteInstallationsByTag: %d of %d installations failed, first: %s: %w", len(failed), len(report.Results), failed[0].InstallationId, failed[0].Err)
+	}
+
+	return report, nil
+}
+
+// Failed returns results of installations which could not be deleted
+func (r *BulkDeleteReport) Failed() []BulkDeleteResult {
+	var failed []BulkDeleteResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+
+	return failed
+}
+
+// deleteInstallationBatch deletes installations with at most concurrency requests in flight,
+// results are returned in the order of ids
+func (h *NotificationHub) deleteInstallationBatch(ctx context.Context, ids []string, concurrency int, dryRun bool) []BulkDeleteResult {
+	results := make([]BulkDeleteResult, len(ids))
+	if dryRun {
+		for i, id := range ids {
+			results[i].InstallationId = id
+		}
+		return results
+	}
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		i, id := i, id
+		h.goLabeled(ctx, "delete-installations", func(ctx context.Context) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			results[i] = BulkDeleteResult{InstallationId: id, Err: h.deleteInstallation(ctx, id)}
+		})
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,91 @@
+package notihub
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func Test_NotificationHubDeleteInstallationsByTag(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	feed := `<feed xmlns="http://www.w3.org/2005/Atom">` +
+		`<entry><content type="application/xml"><GcmRegistrationDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><Tags>tenant:a,$InstallationId:{inst-1}</Tags><RegistrationId>1</RegistrationId><GcmRegistrationId>a</GcmRegistrationId></GcmRegistrationDescription></content></entry>` +
+		`<entry><content type="application/xml"><GcmTemplateRegistrationDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><Tags>tenant:a,$InstallationId:{inst-1}</Tags><RegistrationId>2</RegistrationId><GcmRegistrationId>a</GcmRegistrationId></GcmTemplateRegistrationDescription></content></entry>` +
+		`<entry><content type="application/xml"><AppleRegistrationDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><Tags>tenant:a,$InstallationId:{inst-2}</Tags><RegistrationId>3</RegistrationId><DeviceToken>token</DeviceToken></AppleRegistrationDescription></content></entry>` +
+		`<entry><content type="application/xml"><AppleRegistrationDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><Tags>tenant:a,$InstallationId:{inst-3}</Tags><RegistrationId>4</RegistrationId><DeviceToken>locked</DeviceToken></AppleRegistrationDescription></content></entry>` +
+		`<entry><content type="application/xml"><AppleRegistrationDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><Tags>tenant:a</Tags><RegistrationId>5</RegistrationId><DeviceToken>legacy</DeviceToken></AppleRegistrationDescription></content></entry>` +
+		`</feed>`
+
+	var (
+		mu      sync.Mutex
+		listed  string
+		deleted []string
+	)
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		if req.Method == "GET" {
+			listed = req.URL.Path
+			return []byte(feed), nil
+		}
+		if strings.HasSuffix(req.URL.Path, "/inst-3") {
+			return nil, &ResponseError{StatusCode: http.StatusForbidden, Header: http.Header{}}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		deleted = append(deleted, req.Method+" "+req.URL.Path)
+		return nil, nil
+	})
+
+	tests := []struct {
+		name             string
+		opts             BulkDeleteOptions
+		expectedDeleted  []string
+		expectedProgress BulkDeleteProgress
+	}{
+		{
+			name:             "dry run",
+			opts:             BulkDeleteOptions{DryRun: true},
+			expectedProgress: BulkDeleteProgress{Matched: 3, Skipped: 1},
+		},
+		{
+			name:             "delete",
+			opts:             BulkDeleteOptions{Concurrency: 2},
+			expectedDeleted:  []string{"DELETE /testPath/installations/inst-1", "DELETE /testPath/installations/inst-2"},
+			expectedProgress: BulkDeleteProgress{Matched: 3, Deleted: 2, Failed: 1, Skipped: 1},
+		},
+	}
+
+	for _, test := range tests {
+		deleted = nil
+		var progress []BulkDeleteProgress
+		test.opts.OnProgress = func(p BulkDeleteProgress) { progress = append(progress, p) }
+
+		report, err := nhub.DeleteInstallationsByTag(context.Background(), "tenant:a", test.opts)
+		if test.opts.DryRun != (err == nil) {
+			t.Errorf(errfmt, test.name+" error", "inst-3 failure unless dry run", err)
+		}
+		if listed != "/testPath/tags/tenant:a/registrations" {
+			t.Errorf(errfmt, test.name+" listed registrations", "/testPath/tags/tenant:a/registrations", listed)
+		}
+
+		sort.Strings(deleted)
+		if !reflect.DeepEqual(deleted, test.expectedDeleted) {
+			t.Errorf(errfmt, test.name+" deletions", test.expectedDeleted, deleted)
+		}
+		if report.DryRun != test.opts.DryRun || report.Progress != test.expectedProgress || !reflect.DeepEqual(progress, []BulkDeleteProgress{test.expectedProgress}) {
+			t.Errorf(errfmt, test.name+" progress", test.expectedProgress, progress)
+		}
+		if len(report.Results) != 3 || report.Results[0].InstallationId != "inst-1" || report.Results[2].InstallationId != "inst-3" {
+			t.Errorf(errfmt, test.name+" results", "inst-1, inst-2, inst-3", report.Results)
+		}
+	}
+
+	if _, err := nhub.DeleteInstallationsByTag(context.Background(), "", BulkDeleteOptions{}); err == nil {
+		t.Errorf(errfmt, "empty tag error", "error", err)
+	}
+}
@@ -0,0 +1,144 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	// CanaryBuckets is the number of canary buckets recipients are split into
+	CanaryBuckets = 10
+	// DefaultCanaryTagPrefix prefixes canary bucket tags
+	DefaultCanaryTagPrefix = "canary:"
+)
+
+// ErrCanaryAborted is returned when the canary health check fails
+// and the notification is not sent to the rest of the recipients
+var ErrCanaryAborted = errors.New("canary aborted")
+
+type (
+	// Canary configures SendCanary
+	Canary struct {
+		// Percent of recipients receiving the notification first,
+		// rounded to the nearest whole bucket of 100/CanaryBuckets percent.
+		// Both stages must stay within the hub tag expression limits, the remainder
+		// excludes the canary buckets, so each bucket counts against the 6 tags of
+		// expressions with && or !, along with orTags and the send tags
+		Percent int
+		// TagPrefix prefixes bucket tags, DefaultCanaryTagPrefix by default
+		TagPrefix string
+		// Wait is the time given to the canary delivery before the health check
+		Wait time.Duration
+		// Health decides whether the notification can be sent to the rest
+		// of the recipients, the send is aborted when it returns an error
+		Health func(ctx context.Context, canary SendResult) error
+	}
+
+	// CanaryResult describes both stages of a canary send.
+	// Remainder is zero when the send is aborted
+	CanaryResult struct {
+		Canary    SendResult
+		Remainder SendResult
+	}
+)
+
+// CanaryBucketTag returns the canary bucket tag of installation or registration id
+// which should be added to its tags for SendCanary to reach it in the canary stage
+func CanaryBucketTag(prefix, id string) string {
+	if prefix == "" {
+		prefix = DefaultCanaryTagPrefix
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(id))
+
+	return fmt.Sprintf("%s%d", prefix, h.Sum32()%CanaryBuckets)
+}
+
+// SendCanary sends notification to the canary buckets of orTags recipients first,
+// waits c.Wait and checks c.Health before sending it to the remaining recipients.
+// ErrCanaryAborted is returned when the health check fails
+func (h *NotificationHub) SendCanary(ctx context.Context, n *Notification, orTags []string, c Canary, opts ...SendOption) (*CanaryResult, error) {
+	result, err := h.sendCanary(ctx, n, orTags, &c, opts)
+	if err != nil {
+		return result, fmt.Errorf("NotificationHub.SendCanary: %w", err)
+	}
+
+	return result, nil
+}
+
+func (h *NotificationHub) sendCanary(ctx context.Context, n *Notification, orTags []string, c *Canary, opts []SendOption) (*CanaryResult, error) {
+	canaryTags, err := c.bucketExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CanaryResult{}
+
+	o := newSendOptions(opts)
+	o.result = &result.Canary
+	o.andTags = append(o.andTags, canaryTags)
+
+	remainder := newSendOptions(opts)
+	remainder.result = &result.Remainder
+	remainder.andTags = append(remainder.andTags, "!"+canaryTags)
+
+	// the canary stage is not sent when the remainder could not follow
+	for _, stage := range []*sendOptions{o, remainder} {
+		if err := checkTagLimits(h.sendTagExpression(ctx, orTags, stage)); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := h.send(ctx, n, orTags, o); err != nil {
+		return result, fmt.Errorf("canary: %w", err)
+	}
+
+	if err := sleepContext(ctx, c.Wait); err != nil {
+		return result, err
+	}
+
+	if c.Health != nil {
+		if err := c.Health(ctx, result.Canary); err != nil {
+			return result, fmt.Errorf("%w: %v", ErrCanaryAborted, err)
+		}
+	}
+
+	if _, err := h.send(ctx, n, orTags, remainder); err != nil {
+		return result, fmt.Errorf("remainder: %w", err)
+	}
+
+	return result, nil
+}
+
+// bucketExpression returns tag expression matching canary buckets
+func (c *Canary) bucketExpression() (string, error) {
+	if c.Percent <= 0 || c.Percent >= 100 {
+		return "", fmt.Errorf("canary percent must be between 1 and 99, got %d", c.Percent)
+	}
+
+	prefix := c.TagPrefix
+	if prefix == "" {
+		prefix = DefaultCanaryTagPrefix
+	}
+
+	buckets := int(math.Round(float64(c.Percent*CanaryBuckets) / 100))
+	if buckets < 1 {
+		buckets = 1
+	}
+	if buckets >= CanaryBuckets {
+		buckets = CanaryBuckets - 1
+	}
+
+	tags := make([]string, buckets)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("%s%d", prefix, i)
+	}
+
+	return "(" + strings.Join(tags, " || ") + ")", nil
+}
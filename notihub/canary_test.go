@@ -0,0 +1,103 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func Test_NotificationHubSendCanary(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var tags []string
+	mockClient := &mockHubHttpClient{execFunc: func(req *http.Request) ([]byte, error) {
+		tags = append(tags, req.Header.Get("ServiceBusNotification-Tags"))
+		return nil, nil
+	}}
+
+	nhub := &NotificationHub{
+		sasKeyValue:    "testKeyValue",
+		sasKeyName:     "testKeyName",
+		hubURL:         &url.URL{Host: "testHost", Scheme: schemeDefault, Path: "testPath"},
+		client:         mockClient,
+		expiryTimeFunc: TimeFunc(mockExpiryTime),
+		defaultTags:    []string{"env:prod"},
+	}
+
+	n := &Notification{AndroidFormat, []byte(`{"data":{"msg":"hi"}}`)}
+
+	var healthChecked bool
+	canary := Canary{Percent: 25, Health: func(ctx context.Context, canary SendResult) error {
+		healthChecked = true
+		if len(tags) != 1 || canary.Attempts != 1 {
+			t.Errorf(errfmt, "canary sent before health check", 1, len(tags))
+		}
+		return nil
+	}}
+
+	result, err := nhub.SendCanary(context.Background(), n, []string{"news", "sport"}, canary)
+	if err != nil {
+		t.Fatalf(errfmt, "canary send error", nil, err)
+	}
+
+	expectedTags := []string{
+		"(news || sport) && env:prod && (canary:0 || canary:1 || canary:2)",
+		"(news || sport) && env:prod && !(canary:0 || canary:1 || canary:2)",
+	}
+	if len(tags) != 2 || tags[0] != expectedTags[0] || tags[1] != expectedTags[1] {
+		t.Errorf(errfmt, "tag expressions", expectedTags, tags)
+	}
+	if !healthChecked || result.Canary.Attempts != 1 || result.Remainder.Attempts != 1 {
+		t.Errorf(errfmt, "canary result", "health checked and both stages sent", result)
+	}
+
+	tags = nil
+	canary.Health = func(ctx context.Context, canary SendResult) error {
+		return errors.New("error rate too high")
+	}
+	result, err = nhub.SendCanary(context.Background(), n, nil, canary)
+	if !errors.Is(err, ErrCanaryAborted) {
+		t.Errorf(errfmt, "canary aborted error", ErrCanaryAborted, err)
+	}
+	if len(tags) != 1 || result.Remainder.Attempts != 0 {
+		t.Errorf(errfmt, "sends of aborted canary", 1, len(tags))
+	}
+
+	tags = nil
+	if _, err := nhub.SendCanary(context.Background(), n, []string{"news", "sport"}, Canary{Percent: 50}); err == nil || len(tags) != 0 {
+		t.Errorf(errfmt, "sends over tag limits", 0, len(tags))
+	}
+}
+
+func Test_CanaryBucketExpression(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	testPatterns := []struct {
+		canary   Canary
+		expected string
+		err      bool
+	}{
+		{Canary{Percent: 1}, "(canary:0)", false},
+		{Canary{Percent: 30, TagPrefix: "cohort-"}, "(cohort-0 || cohort-1 || cohort-2)", false},
+		{Canary{Percent: 14}, "(canary:0)", false},
+		{Canary{Percent: 25}, "(canary:0 || canary:1 || canary:2)", false},
+		{Canary{Percent: 99}, "(canary:0 || canary:1 || canary:2 || canary:3 || canary:4 || canary:5 || canary:6 || canary:7 || canary:8)", false},
+		{Canary{Percent: 0}, "", true},
+		{Canary{Percent: 100}, "", true},
+	}
+
+	for _, testData := range testPatterns {
+		expr, err := testData.canary.bucketExpression()
+		if expr != testData.expected || (err != nil) != testData.err {
+			t.Errorf(errfmt, "bucket expression", testData.expected, expr)
+		}
+	}
+
+	tag := CanaryBucketTag("", "installation-id")
+	if tag != CanaryBucketTag(DefaultCanaryTagPrefix, "installation-id") || !strings.HasPrefix(tag, DefaultCanaryTagPrefix) {
+		t.Errorf(errfmt, "stable bucket tag", tag, CanaryBucketTag(DefaultCanaryTagPrefix, "installation-id"))
+	}
+}
@@ -0,0 +1,44 @@
+package notihub
+
+import "fmt"
+
+// FormatCapabilities describes features of notifications of a format supported by the client
+type FormatCapabilities struct {
+	// SilentPush is background notification waking the app without alerting the user
+	SilentPush bool
+	// Actions are buttons shown with the notification
+	Actions bool
+	// Images are shown in the notification
+	Images bool
+	// Collapse replaces undelivered notifications of the same collapse key
+	Collapse bool
+	// TTL discards notifications not delivered in time
+	TTL bool
+	// MaxPayloadSize is the payload size limit in bytes
+	MaxPayloadSize int
+}
+
+// formatCapabilities lists features of native formats.
+// Template notifications have the features of the templates registered by devices
+var formatCapabilities = map[NotificationFormat]FormatCapabilities{
+	Template:           {},
+	AppleFormat:        {SilentPush: true, Actions: true, Images: true, Collapse: true, TTL: true},
+	AndroidFormat:      {SilentPush: true, Images: true, Collapse: true, TTL: true},
+	FcmV1Format:        {SilentPush: true, Images: true, Collapse: true, TTL: true},
+	BaiduFormat:        {SilentPush: true},
+	KindleFormat:       {SilentPush: true, Collapse: true, TTL: true},
+	WindowsFormat:      {SilentPush: true, Actions: true, Images: true, Collapse: true, TTL: true},
+	WindowsPhoneFormat: {SilentPush: true, Images: true},
+}
+
+// Capabilities returns features of notifications of format, so that composers offer only
+// what reaches devices. Template notifications report no features besides the payload limit
+func Capabilities(format NotificationFormat) (FormatCapabilities, error) {
+	capabilities, ok := formatCapabilities[format]
+	if !ok {
+		return FormatCapabilities{}, fmt.Errorf("unknown format '%s'", format)
+	}
+
+	capabilities.MaxPayloadSize = format.MaxPayloadSize()
+	return capabilities, nil
+}
@@ -0,0 +1,34 @@
+package notihub
+
+import "testing"
+
+func Test_Capabilities(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	testPatterns := []struct {
+		format   NotificationFormat
+		expected FormatCapabilities
+	}{
+		{AppleFormat, FormatCapabilities{SilentPush: true, Actions: true, Images: true, Collapse: true, TTL: true, MaxPayloadSize: 4096}},
+		{FcmV1Format, FormatCapabilities{SilentPush: true, Images: true, Collapse: true, TTL: true, MaxPayloadSize: 4096}},
+		{WindowsPhoneFormat, FormatCapabilities{SilentPush: true, Images: true, MaxPayloadSize: 3072}},
+		{Template, FormatCapabilities{MaxPayloadSize: 4096}},
+	}
+
+	for _, testData := range testPatterns {
+		capabilities, err := Capabilities(testData.format)
+		if err != nil || capabilities != testData.expected {
+			t.Errorf(errfmt, string(testData.format)+" capabilities", testData.expected, capabilities)
+		}
+	}
+
+	for format := range maxPayloadSizes {
+		if _, err := Capabilities(format); err != nil {
+			t.Errorf(errfmt, string(format)+" error", nil, err)
+		}
+	}
+
+	if _, err := Capabilities("sms"); err == nil {
+		t.Errorf(errfmt, "unknown format error", "error", err)
+	}
+}
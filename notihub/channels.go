@@ -0,0 +1,140 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrChannelUnavailable is returned by ChannelProvider when the app
+// cannot supply a fresh channel yet, for example because the device is offline
+var ErrChannelUnavailable = errors.New("fresh channel unavailable")
+
+// wnsExpiredChannelOutcomes are WNS outcomes reported for expired channel URIs
+var wnsExpiredChannelOutcomes = []string{
+	"ChannelExpired",
+	"ExpiredChannel",
+	"Expired",
+}
+
+type (
+	// ChannelProvider asks the consuming app for a fresh push channel of the installation
+	ChannelProvider interface {
+		FreshChannel(ctx context.Context, installation *Installation) (string, error)
+	}
+
+	// ChannelProviderFunc is ChannelProvider function
+	ChannelProviderFunc func(ctx context.Context, installation *Installation) (string, error)
+
+	// ChannelRefresher replaces expired WNS channel URIs of installations
+	// with fresh ones supplied by ChannelProvider
+	ChannelRefresher struct {
+		hub      *NotificationHub
+		provider ChannelProvider
+	}
+
+	// ChannelRefresh is the outcome of refreshing a single installation channel.
+	// Refreshed is false when the channel was not expired or no fresh channel was available
+	ChannelRefresh struct {
+		InstallationId string
+		Refreshed      bool
+		Err            error
+	}
+)
+
+// FreshChannel calls f
+func (f ChannelProviderFunc) FreshChannel(ctx context.Context, installation *Installation) (string, error) {
+	return f(ctx, installation)
+}
+
+// NewChannelRefresher initializes and returns ChannelRefresher pointer
+func NewChannelRefresher(hub *NotificationHub, provider ChannelProvider) *ChannelRefresher {
+	return &ChannelRefresher{hub: hub, provider: provider}
+}
+
+// IsWnsChannelExpired identifies whether WNS outcome reports expired channel URI
+func IsWnsChannelExpired(outcome string) bool {
+	for _, expired := range wnsExpiredChannelOutcomes {
+		if strings.EqualFold(outcome, expired) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Refresh replaces the channel of installation marked expired by the hub
+func (r *ChannelRefresher) Refresh(ctx context.Context, installationID string) (bool, error) {
+	refreshed, err := r.refresh(ctx, installationID, false)
+	if err != nil {
+		return false, fmt.Errorf("ChannelRefresher.Refresh: %w", err)
+	}
+
+	return refreshed, nil
+}
+
+// RefreshFeedback replaces channels of WNS installations reported
+// expired in feedback records, each installation is refreshed once
+func (r *ChannelRefresher) RefreshFeedback(ctx context.Context, records []FeedbackRecord) ([]ChannelRefresh, error) {
+	var refreshes []ChannelRefresh
+	seen := map[string]bool{}
+
+	for _, record := range records {
+		if record.Platform != PlatformWns || record.InstallationId == "" || seen[record.InstallationId] || !IsWnsChannelExpired(record.Error) {
+			continue
+		}
+		seen[record.InstallationId] = true
+
+		if err := ctx.Err(); err != nil {
+			return refreshes, fmt.Errorf("ChannelRefresher.RefreshFeedback: %w", err)
+		}
+
+		refresh := ChannelRefresh{InstallationId: record.InstallationId}
+		refresh.Refreshed, refresh.Err = r.refresh(ctx, record.InstallationId, true)
+		refreshes = append(refreshes, refresh)
+	}
+
+	for _, refresh := range refreshes {
+		if refresh.Err != nil {
+			return refreshes, fmt.Errorf("ChannelRefresher.RefreshFeedback: installation '%s': %w", refresh.InstallationId, refresh.Err)
+		}
+	}
+
+	return refreshes, nil
+}
+
+// refresh asks the provider for a fresh channel and updates the installation.
+// Unless reported is set, only installations marked expired by the hub are refreshed
+func (r *ChannelRefresher) refresh(ctx context.Context, installationID string, reported bool) (bool, error) {
+	installation, err := r.hub.getInstallation(ctx, installationID)
+	if err != nil {
+		return false, err
+	}
+
+	if installation.Platform != PlatformWns {
+		return false, nil
+	}
+	if !reported && !installation.ExpiredPushChannel {
+		return false, nil
+	}
+
+	channel, err := r.provider.FreshChannel(ctx, installation)
+	if errors.Is(err, ErrChannelUnavailable) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if channel == "" || channel == installation.PushChannel {
+		return false, nil
+	}
+
+	installation.PushChannel = channel
+	installation.ExpiredPushChannel = false
+	if err := r.hub.putInstallation(ctx, installation); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
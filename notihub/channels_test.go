@@ -0,0 +1,104 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_ChannelRefresher(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newInstallationServer(
+		Installation{InstallationId: "expired", Platform: PlatformWns, PushChannel: "https://old", ExpiredPushChannel: true},
+		Installation{InstallationId: "valid", Platform: PlatformWns, PushChannel: "https://valid"},
+		Installation{InstallationId: "offline", Platform: PlatformWns, PushChannel: "https://offline", ExpiredPushChannel: true},
+		Installation{InstallationId: "apple", Platform: PlatformApns, PushChannel: "token", ExpiredPushChannel: true},
+	)
+	defer server.Close()
+
+	var asked []string
+	refresher := NewChannelRefresher(server.hub(), ChannelProviderFunc(func(ctx context.Context, installation *Installation) (string, error) {
+		asked = append(asked, installation.InstallationId)
+		if installation.InstallationId == "offline" {
+			return "", ErrChannelUnavailable
+		}
+		return "https://fresh-" + installation.InstallationId, nil
+	}))
+
+	tests := []struct {
+		id        string
+		refreshed bool
+	}{
+		{"expired", true},
+		{"valid", false},
+		{"offline", false},
+		{"apple", false},
+	}
+
+	for _, test := range tests {
+		refreshed, err := refresher.Refresh(context.Background(), test.id)
+		if err != nil {
+			t.Fatalf(errfmt, test.id+" error", nil, err)
+		}
+		if refreshed != test.refreshed {
+			t.Errorf(errfmt, test.id+" refreshed", test.refreshed, refreshed)
+		}
+	}
+
+	if expected := []string{"expired", "offline"}; !reflect.DeepEqual(asked, expected) {
+		t.Errorf(errfmt, "provider calls", expected, asked)
+	}
+
+	if got := server.installations["expired"]; got.PushChannel != "https://fresh-expired" || got.ExpiredPushChannel {
+		t.Errorf(errfmt, "refreshed installation", "fresh channel", got)
+	}
+	if server.writes != 1 {
+		t.Errorf(errfmt, "writes", 1, server.writes)
+	}
+
+	if _, err := refresher.Refresh(context.Background(), "missing"); !isNotFoundError(errors.Unwrap(err)) {
+		t.Errorf(errfmt, "missing installation error", "not found", err)
+	}
+}
+
+func Test_ChannelRefresherFeedback(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newInstallationServer(
+		Installation{InstallationId: "reported", Platform: PlatformWns, PushChannel: "https://old"},
+	)
+	defer server.Close()
+
+	refresher := NewChannelRefresher(server.hub(), ChannelProviderFunc(func(ctx context.Context, installation *Installation) (string, error) {
+		return "https://fresh", nil
+	}))
+
+	records := []FeedbackRecord{
+		{Platform: PlatformWns, InstallationId: "reported", Error: "ChannelExpired"},
+		{Platform: PlatformWns, InstallationId: "reported", Error: "ChannelExpired"},
+		{Platform: PlatformWns, InstallationId: "throttled", Error: "Throttled"},
+		{Platform: PlatformApns, InstallationId: "apple", Error: "Expired"},
+		{Platform: PlatformWns, RegistrationId: "registration", Error: "ChannelExpired"},
+	}
+
+	refreshes, err := refresher.RefreshFeedback(context.Background(), records)
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	expected := []ChannelRefresh{{InstallationId: "reported", Refreshed: true}}
+	if !reflect.DeepEqual(refreshes, expected) {
+		t.Errorf(errfmt, "refreshes", expected, refreshes)
+	}
+	if got := server.installations["reported"].PushChannel; got != "https://fresh" {
+		t.Errorf(errfmt, "push channel", "https://fresh", got)
+	}
+
+	records = append(records, FeedbackRecord{Platform: PlatformWns, InstallationId: "missing", Error: "Expired"})
+	refreshes, err = refresher.RefreshFeedback(context.Background(), records)
+	if err == nil || len(refreshes) != 2 || refreshes[1].Err == nil {
+		t.Errorf(errfmt, "missing installation failure", "error", refreshes)
+	}
+}
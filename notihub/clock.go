@@ -0,0 +1,25 @@
+package notihub
+
+import "time"
+
+// Clock provides current time to the notification hub.
+// It is used for token expiry and scheduling decisions
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+// Now returns current local time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// now returns current time according to the hub clock
+func (h *NotificationHub) now() time.Time {
+	if h.clock == nil {
+		return time.Now()
+	}
+
+	return h.clock.Now()
+}
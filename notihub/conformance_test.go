@@ -0,0 +1,253 @@
+package notihub
+
+import (
+	"context"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+var updateFixtures = flag.Bool("update", false, "update conformance fixtures")
+
+const testRegistrationEntry = `<entry xmlns="http://www.w3.org/2005/Atom">
+    <content type="application/xml">
+        <GcmRegistrationDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect">
+            <ExpirationTime>2030-01-01T00:00:00</ExpirationTime>
+            <RegistrationId>registration-1</RegistrationId>
+            <ETag>1</ETag>
+            <GcmRegistrationId>gcm-handle</GcmRegistrationId>
+        </GcmRegistrationDescription>
+    </content>
+</entry>`
+
+const testNotificationOutcome = `<NotificationOutcome xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><Success>1</Success><Failure>0</Failure><Results/></NotificationOutcome>`
+
+// conformanceCase is a hub operation whose request is compared to the fixture of name
+type conformanceCase struct {
+	name     string
+	response string
+	call     func(ctx context.Context, h *NotificationHub) error
+}
+
+var conformanceCases = []conformanceCase{
+	{
+		name: "send",
+		call: func(ctx context.Context, h *NotificationHub) error {
+			_, err := h.Send(ctx, &Notification{AppleFormat, []byte(`{"aps":{"alert":"hi"}}`)}, []string{"news", "sport"}, WithCorrelationID("correlation"))
+			return err
+		},
+	},
+	{
+		name: "send_template",
+		call: func(ctx context.Context, h *NotificationHub) error {
+			_, err := h.Send(ctx, &Notification{Template, []byte(`{"message":"hi"}`)}, nil, WithCorrelationID("correlation"), BroadcastAll())
+			return err
+		},
+	},
+	{
+		name: "send_direct",
+		call: func(ctx context.Context, h *NotificationHub) error {
+			_, err := h.SendDirect(ctx, &Notification{AndroidFormat, []byte(`{"data":{"msg":"hi"}}`)}, "gcm-handle", WithCorrelationID("correlation"))
+			return err
+		},
+	},
+	{
+		name:     "send_test",
+		response: testNotificationOutcome,
+		call: func(ctx context.Context, h *NotificationHub) error {
+			_, err := h.SendTest(ctx, &Notification{WindowsFormat, []byte(`<toast><visual><binding template="ToastText01"><text id="1">hi</text></binding></visual></toast>`)}, []string{"user:1"}, WithCorrelationID("correlation"))
+			return err
+		},
+	},
+	{
+		name: "schedule",
+		call: func(ctx context.Context, h *NotificationHub) error {
+			deliverTime := time.Date(2100, 1, 2, 3, 4, 5, 0, time.UTC)
+			_, err := h.Schedule(ctx, &Notification{AndroidFormat, []byte(`{"data":{"msg":"hi"}}`)}, []string{"news"}, deliverTime, WithCorrelationID("correlation"))
+			return err
+		},
+	},
+	{
+		name:     "register",
+		response: testRegistrationEntry,
+		call: func(ctx context.Context, h *NotificationHub) error {
+			_, _, err := h.Register(Registration{DeviceId: "gcm-handle", Service: AndroidFormat, Tags: "news,sport"})
+			return err
+		},
+	},
+	{
+		name:     "register_update",
+		response: testRegistrationEntry,
+		call: func(ctx context.Context, h *NotificationHub) error {
+			_, _, err := h.Register(Registration{DeviceId: "apns-token", Service: AppleFormat, Tags: "news", RegistrationId: "registration-1"})
+			return err
+		},
+	},
+	{
+		name:     "list_registrations",
+		response: `<feed xmlns="http://www.w3.org/2005/Atom"></feed>`,
+		call: func(ctx context.Context, h *NotificationHub) error {
+			_, _, err := h.listRegistrations(ctx, "news", ListRegistrationsOptions{Top: 100, ContinuationToken: "token"})
+			return err
+		},
+	},
+	{
+		name: "put_installation",
+		call: func(ctx context.Context, h *NotificationHub) error {
+			return h.putInstallation(ctx, &Installation{InstallationId: "installation-1", Platform: "gcm", PushChannel: "gcm-handle", Tags: []string{"news"}})
+		},
+	},
+	{
+		name:     "get_installation",
+		response: `{"installationId":"installation-1","platform":"gcm","pushChannel":"gcm-handle"}`,
+		call: func(ctx context.Context, h *NotificationHub) error {
+			_, err := h.getInstallation(ctx, "installation-1")
+			return err
+		},
+	},
+	{
+		name: "patch_installation",
+		call: func(ctx context.Context, h *NotificationHub) error {
+			return h.patchInstallation(ctx, "installation-1", []InstallationPatch{{Op: PatchAdd, Path: "/tags", Value: "news"}, {Op: PatchRemove, Path: "/tags/sports"}})
+		},
+	},
+	{
+		name: "delete_installation",
+		call: func(ctx context.Context, h *NotificationHub) error {
+			return h.deleteInstallation(ctx, "installation-1")
+		},
+	},
+	{
+		name: "delete_registration",
+		call: func(ctx context.Context, h *NotificationHub) error {
+			return h.deleteRegistration(ctx, "registration-1")
+		},
+	},
+	{
+		name: "do",
+		call: func(ctx context.Context, h *NotificationHub) error {
+			_, _, err := h.Do(ctx, "POST", "registrationIds?$top=1", []byte("{}"), map[string]string{"Content-Type": "application/json"})
+			return err
+		},
+	},
+	{
+		name: "put_fcmv1_registration",
+		call: func(ctx context.Context, h *NotificationHub) error {
+			return h.putFcmV1Registration(ctx, RegistrationDescription{Type: "GcmRegistrationDescription", RegistrationId: "registration-1", Tags: "news", GcmRegistrationId: "gcm-handle"})
+		},
+	},
+	{
+		name:     "submit_job",
+		response: fmt.Sprintf(testJobEntryTemplate, "job-1", "0", ExportRegistrations, "Started", ""),
+		call: func(ctx context.Context, h *NotificationHub) error {
+			_, err := h.SubmitJob(ctx, &Job{Type: ExportRegistrations, OutputContainerUri: "https://storage/output?sig=signature"})
+			return err
+		},
+	},
+	{
+		name:     "get_job",
+		response: fmt.Sprintf(testJobEntryTemplate, "job-1", "100", ExportRegistrations, "Completed", ""),
+		call: func(ctx context.Context, h *NotificationHub) error {
+			_, err := h.GetJob(ctx, "job-1")
+			return err
+		},
+	},
+	{
+		name:     "get_hub_description",
+		response: testHubDescription,
+		call: func(ctx context.Context, h *NotificationHub) error {
+			_, err := NewManagementClient(h).getDescription(ctx)
+			return err
+		},
+	},
+	{
+		name: "put_hub_description",
+		call: func(ctx context.Context, h *NotificationHub) error {
+			var entry hubDescriptionEntry
+			if err := xml.Unmarshal([]byte(testHubDescription), &entry); err != nil {
+				return err
+			}
+			return NewManagementClient(h).putDescription(ctx, &entry.Content.Description)
+		},
+	},
+}
+
+// dumpConformanceRequest writes request line, sorted headers and body.
+// Random correlation ids are replaced, so that dumps are stable
+func dumpConformanceRequest(req *http.Request) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", req.Method, req.URL)
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := req.Header.Get(name)
+		if name == http.CanonicalHeaderKey(correlationIdHeader) && len(value) == 36 {
+			value = "{random}"
+		}
+		if name == userAgentHeader {
+			value = strings.Replace(value, "v"+Version, "v{version}", 1)
+		}
+		fmt.Fprintf(&b, "%s: %s\n", name, value)
+	}
+
+	if req.Body != nil {
+		body, _ := ioutil.ReadAll(req.Body)
+		fmt.Fprintf(&b, "\n%s\n", body)
+	}
+
+	return b.String()
+}
+
+func Test_NotificationHubConformance(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	for _, testCase := range conformanceCases {
+		var dumps []string
+		mockClient := &mockHubHttpClient{execFunc: func(req *http.Request) ([]byte, error) {
+			dumps = append(dumps, dumpConformanceRequest(req))
+			return []byte(testCase.response), nil
+		}}
+
+		nhub := NewNotificationHub("Endpoint=sb://testhub-ns.servicebus.windows.net/;SharedAccessKeyName=DefaultFullSharedAccessSignature;SharedAccessKey=testKey", "testhub", nil)
+		nhub.client = mockClient
+		nhub.expiryTimeFunc = TimeFunc(mockExpiryTime)
+
+		if err := testCase.call(context.Background(), nhub); err != nil {
+			t.Errorf(errfmt, testCase.name+" error", nil, err)
+			continue
+		}
+		if len(dumps) != 1 {
+			t.Errorf(errfmt, testCase.name+" requests", 1, len(dumps))
+			continue
+		}
+
+		fixture := filepath.Join("testdata", "conformance", testCase.name+".txt")
+		if *updateFixtures {
+			if err := ioutil.WriteFile(fixture, []byte(dumps[0]), 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		expected, err := ioutil.ReadFile(fixture)
+		if err != nil {
+			t.Errorf(errfmt, testCase.name+" fixture", fixture, err)
+			continue
+		}
+		if dumps[0] != string(expected) {
+			t.Errorf(errfmt, testCase.name+" request", string(expected), dumps[0])
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package notihub
+
+import "context"
+
+type (
+	// hubContextKey is the context key of the hub client
+	hubContextKey struct{}
+
+	// tagsContextKey is the context key of context tags
+	tagsContextKey struct{}
+)
+
+// NewContext returns copy of ctx carrying hub,
+// used by middleware to inject tenant specific hub clients into requests
+func NewContext(ctx context.Context, hub *NotificationHub) context.Context {
+	return context.WithValue(ctx, hubContextKey{}, hub)
+}
+
+// FromContext returns hub carried by ctx, if any
+func FromContext(ctx context.Context) (*NotificationHub, bool) {
+	hub, ok := ctx.Value(hubContextKey{}).(*NotificationHub)
+	return hub, ok && hub != nil
+}
+
+// WithContextTags returns copy of ctx carrying tags every recipient of sends made with it
+// must have, e.g. tenant or environment tags attached by middleware.
+// Tags accumulate over nested contexts and are combined with hub default tags
+// and tags passed to Send using logical AND
+func WithContextTags(ctx context.Context, tags ...string) context.Context {
+	if len(tags) == 0 {
+		return ctx
+	}
+
+	parent := ContextTags(ctx)
+	return context.WithValue(ctx, tagsContextKey{}, append(parent[:len(parent):len(parent)], tags...))
+}
+
+// ContextTags returns tags carried by ctx
+func ContextTags(ctx context.Context) []string {
+	tags, _ := ctx.Value(tagsContextKey{}).([]string)
+	return tags
+}
@@ -0,0 +1,71 @@
+package notihub
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func Test_HubContext(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	if hub, ok := FromContext(context.Background()); ok || hub != nil {
+		t.Errorf(errfmt, "hub of empty context", nil, hub)
+	}
+
+	tenantA, tenantB := &NotificationHub{}, &NotificationHub{}
+	ctx := NewContext(context.Background(), tenantA)
+	if hub, ok := FromContext(ctx); !ok || hub != tenantA {
+		t.Errorf(errfmt, "tenant hub", tenantA, hub)
+	}
+
+	if hub, _ := FromContext(NewContext(ctx, tenantB)); hub != tenantB {
+		t.Errorf(errfmt, "overriding tenant hub", tenantB, hub)
+	}
+
+	if _, ok := FromContext(NewContext(ctx, nil)); ok {
+		t.Errorf(errfmt, "nil hub found", false, ok)
+	}
+}
+
+func Test_ContextTags(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var tagExpr string
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		tagExpr = req.Header.Get("ServiceBusNotification-Tags")
+		return nil, nil
+	})
+	WithDefaultTags("env:prod")(nhub)
+
+	tenant := WithContextTags(context.Background(), "tenant:a")
+	nested := WithContextTags(tenant, "region:eu")
+	sibling := WithContextTags(tenant, "region:us")
+
+	testPatterns := []struct {
+		name     string
+		ctx      context.Context
+		orTags   []string
+		opts     []SendOption
+		expected string
+	}{
+		{"tenant", tenant, []string{"news", "sport"}, nil, "(news || sport) && env:prod && tenant:a"},
+		{"nested", nested, []string{"news"}, []SendOption{withAndTags("vip")}, "news && env:prod && tenant:a && region:eu && vip"},
+		{"sibling", sibling, nil, nil, "env:prod && tenant:a && region:us"},
+		{"without context tags", context.Background(), []string{"news"}, nil, "news && env:prod"},
+	}
+
+	for _, testData := range testPatterns {
+		if _, err := nhub.Send(testData.ctx, &Notification{AndroidFormat, []byte(`{"data":{}}`)}, testData.orTags, testData.opts...); err != nil {
+			t.Errorf(errfmt, testData.name+" error", nil, err)
+			continue
+		}
+		if tagExpr != testData.expected {
+			t.Errorf(errfmt, testData.name+" tag expression", testData.expected, tagExpr)
+		}
+	}
+
+	if tags := ContextTags(WithContextTags(tenant)); len(tags) != 1 || tags[0] != "tenant:a" {
+		t.Errorf(errfmt, "context tags", []string{"tenant:a"}, tags)
+	}
+}
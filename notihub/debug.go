@@ -0,0 +1,176 @@
+package notihub
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// debugBodyLimit is the number of body bytes dumped
+	debugBodyLimit = 512
+	// redactedDebugBody replaces bodies of requests marked by withSensitiveBodies
+	redactedDebugBody = "[redacted]"
+)
+
+var (
+	// debugRequestHeaders are request headers dumped by the debug transport
+	debugRequestHeaders = []string{
+		"Authorization",
+		"Content-Type",
+		"ServiceBusNotification-Format",
+		"ServiceBusNotification-Tags",
+		"ServiceBusNotification-DeviceHandle",
+		"ServiceBusNotification-ScheduleTime",
+		"X-Apns-Push-Type",
+		"X-WNS-Type",
+		correlationIdHeader,
+	}
+
+	// debugResponseHeaders are response headers dumped by the debug transport
+	debugResponseHeaders = []string{
+		"Content-Type",
+		"Location",
+		"Retry-After",
+		trackingIdHeader,
+		continuationTokenHeader,
+	}
+)
+
+// sensitiveBodiesKey marks contexts of requests carrying credentials or device handles in their bodies
+type sensitiveBodiesKey struct{}
+
+// debugTransport dumps sanitized requests and responses
+type debugTransport struct {
+	next http.RoundTripper
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// WithDebugTransport makes the hub dump every request and response to w.
+// Credentials are redacted and device handles shortened, bodies are truncated.
+// Meant for troubleshooting outside of production
+func WithDebugTransport(w io.Writer) HubOption {
+	return func(h *NotificationHub) {
+		h.debugWriter = w
+	}
+}
+
+// RoundTrip dumps request, executes it with the next transport and dumps response
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = b
+		req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	}
+
+	var dump strings.Builder
+	fmt.Fprintf(&dump, "> %s %s\n", req.Method, req.URL)
+	writeDebugHeaders(&dump, ">", req.Header, debugRequestHeaders)
+	writeDebugBody(&dump, ">", debugBody(req, reqBody))
+
+	started := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(started).Round(time.Millisecond)
+
+	if err != nil {
+		fmt.Fprintf(&dump, "< error after %s: %s\n", elapsed, err)
+		t.write(dump.String())
+		return nil, err
+	}
+
+	respBody, rerr := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	fmt.Fprintf(&dump, "< %s in %s\n", resp.Status, elapsed)
+	writeDebugHeaders(&dump, "<", resp.Header, debugResponseHeaders)
+	writeDebugBody(&dump, "<", debugBody(req, respBody))
+	if rerr != nil {
+		fmt.Fprintf(&dump, "< body read error: %s\n", rerr)
+	}
+	t.write(dump.String())
+
+	return resp, nil
+}
+
+func (t *debugTransport) write(s string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	io.WriteString(t.w, s)
+}
+
+// writeDebugHeaders writes sanitized values of selected headers
+func writeDebugHeaders(w io.Writer, prefix string, header http.Header, selected []string) {
+	for _, name := range selected {
+		value := header.Get(name)
+		if value == "" {
+			continue
+		}
+
+		fmt.Fprintf(w, "%s %s: %s\n", prefix, name, sanitizeDebugHeader(name, value))
+	}
+}
+
+// sanitizeDebugHeader redacts credentials and shortens device handles
+func sanitizeDebugHeader(name, value string) string {
+	switch http.CanonicalHeaderKey(name) {
+	case "Authorization":
+		return "[redacted]"
+	case "Servicebusnotification-Devicehandle":
+		return shortenDebugValue(value)
+	}
+
+	return value
+}
+
+// withSensitiveBodies marks requests made with ctx as carrying credentials or device handles in request
+// or response bodies, which are redacted from debug dumps and payload samples
+func withSensitiveBodies(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sensitiveBodiesKey{}, true)
+}
+
+// debugBody returns request or response body of req to dump, redacted when marked by withSensitiveBodies
+func debugBody(req *http.Request, body []byte) []byte {
+	if len(body) > 0 && req.Context().Value(sensitiveBodiesKey{}) != nil {
+		return []byte(redactedDebugBody)
+	}
+
+	return body
+}
+
+// writeDebugBody writes body truncated to debugBodyLimit bytes
+func writeDebugBody(w io.Writer, prefix string, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+
+	if len(body) > debugBodyLimit {
+		fmt.Fprintf(w, "%s %s... (%d bytes)\n", prefix, body[:debugBodyLimit], len(body))
+		return
+	}
+
+	fmt.Fprintf(w, "%s %s\n", prefix, body)
+}
+
+// shortenDebugValue keeps only the beginning of a sensitive value
+func shortenDebugValue(value string) string {
+	if len(value) <= 8 {
+		return "[redacted]"
+	}
+
+	return value[:6] + "...[redacted]"
+}
@@ -0,0 +1,52 @@
+package notihub
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_NotificationHubDebugTransport(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(trackingIdHeader, "tracking-1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(strings.Repeat("r", 600)))
+	}))
+	defer server.Close()
+
+	var dump bytes.Buffer
+	nhub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(), WithDebugTransport(&dump))
+
+	n := &Notification{AndroidFormat, []byte(`{"data":{"msg":"hi"}}`)}
+	b, err := nhub.SendDirect(context.Background(), n, "device-handle-secret")
+	if err != nil {
+		t.Fatalf(errfmt, "send error", nil, err)
+	}
+	if len(b) != 600 {
+		t.Errorf(errfmt, "response body passed through", 600, len(b))
+	}
+
+	out := dump.String()
+	for _, expected := range []string{
+		"> POST " + server.URL + "/hub/messages?",
+		"> Authorization: [redacted]\n",
+		"> ServiceBusNotification-DeviceHandle: device...[redacted]\n",
+		"> " + `{"data":{"msg":"hi"}}` + "\n",
+		"< 201 Created in ",
+		"< TrackingId: tracking-1\n",
+		"... (600 bytes)\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf(errfmt, "dump line", expected, out)
+		}
+	}
+
+	if strings.Contains(out, "SharedAccessSignature") || strings.Contains(out, "handle-secret") {
+		t.Errorf(errfmt, "sanitized dump", "no credentials", out)
+	}
+}
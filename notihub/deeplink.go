@@ -0,0 +1,189 @@
+package notihub
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DeepLinkKey is the custom data key carrying deep links of JSON payloads, see InjectDeepLink
+const DeepLinkKey = "link"
+
+// ErrInvalidDeepLink is returned for links apps can not open safely
+var ErrInvalidDeepLink = errors.New("invalid deep link")
+
+// unsafeDeepLinkSchemes are rejected by ValidateDeepLink
+var unsafeDeepLinkSchemes = map[string]bool{
+	"http":       true,
+	"javascript": true,
+	"data":       true,
+	"file":       true,
+	"vbscript":   true,
+}
+
+// BuildDeepLink returns link to path of app link base, like custom scheme "myapp://" or universal link
+// prefix "https://example.com/app", with query added to the query of base. Path is used as is,
+// escape path segments with url.PathEscape. The link is validated by ValidateDeepLink
+func BuildDeepLink(base, path string, query url.Values) (string, error) {
+	link := base
+	if path != "" {
+		if !strings.HasSuffix(base, "://") {
+			link = strings.TrimSuffix(link, "/") + "/"
+		}
+		link += strings.TrimPrefix(path, "/")
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidDeepLink, err)
+	}
+
+	if len(query) > 0 {
+		merged := u.Query()
+		for param, values := range query {
+			merged[param] = append(merged[param], values...)
+		}
+		u.RawQuery = merged.Encode()
+	}
+
+	link = u.String()
+	if err := ValidateDeepLink(link); err != nil {
+		return "", err
+	}
+
+	return link, nil
+}
+
+// ValidateDeepLink checks that link is an absolute link of custom app scheme, e.g. myapp://orders/42,
+// or https universal or app link. Plain http and scripting schemes are rejected
+func ValidateDeepLink(link string) error {
+	u, err := url.Parse(link)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidDeepLink, err)
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	switch {
+	case scheme == "":
+		return fmt.Errorf("%w: '%s' has no scheme", ErrInvalidDeepLink, link)
+	case unsafeDeepLinkSchemes[scheme]:
+		return fmt.Errorf("%w: scheme '%s' is not allowed", ErrInvalidDeepLink, u.Scheme)
+	case scheme == "https" && u.Host == "":
+		return fmt.Errorf("%w: '%s' has no host", ErrInvalidDeepLink, link)
+	case u.Host == "" && u.Path == "" && u.Opaque == "":
+		return fmt.Errorf("%w: '%s' has no target", ErrInvalidDeepLink, link)
+	}
+
+	return nil
+}
+
+// InjectDeepLink returns transformer placing validated link where apps of each platform read it:
+// custom key DeepLinkKey of apple and template payloads, data of GCM, FCM v1 and ADM payloads,
+// custom_content of Baidu payloads and launch attribute of WNS toasts. Other WNS payloads are sent as is.
+// GCM and FCM v1 payloads displaying a notification also get the link as android click action,
+// and FCM v1 web push notifications as fcm_options link when it is https. Data messages get no
+// notification fields, so that they are still delivered to the app
+func InjectDeepLink(link string) PayloadTransformer {
+	injectJSON := TransformJSON(func(format NotificationFormat, payload map[string]interface{}) error {
+		if err := setCustomData(format, payload, DeepLinkKey, link); err != nil {
+			return err
+		}
+
+		return setNotificationLink(format, payload, link)
+	})
+
+	return func(ctx context.Context, n *Notification) (*Notification, error) {
+		if err := ValidateDeepLink(link); err != nil {
+			return nil, err
+		}
+
+		if n.Format != WindowsFormat {
+			return injectJSON(ctx, n)
+		}
+
+		payload, err := setToastLaunch(n.Payload, link)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Notification{n.Format, payload}, nil
+	}
+}
+
+// setNotificationLink sets link as the target opened by tapping notifications of GCM and FCM v1 payloads
+func setNotificationLink(format NotificationFormat, payload map[string]interface{}, link string) error {
+	switch format {
+	case AndroidFormat:
+		if hasJSONPath(payload, "notification") {
+			return setJSONPath(payload, "notification.click_action", link)
+		}
+	case FcmV1Format:
+		common := hasJSONPath(payload, "message.notification")
+		if common || hasJSONPath(payload, "message.android.notification") {
+			if err := setJSONPath(payload, "message.android.notification.click_action", link); err != nil {
+				return err
+			}
+		}
+		if strings.HasPrefix(strings.ToLower(link), "https://") && (common || hasJSONPath(payload, "message.webpush.notification")) {
+			return setJSONPath(payload, "message.webpush.fcm_options.link", link)
+		}
+	}
+
+	return nil
+}
+
+// setToastLaunch sets launch attribute of toast root element of payload, other payloads are returned as is
+func setToastLaunch(payload []byte, launch string) ([]byte, error) {
+	dec := xml.NewDecoder(bytes.NewReader(payload))
+	for {
+		offset := dec.InputOffset()
+		token, err := dec.Token()
+		if err != nil {
+			return payload, nil
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != "toast" {
+			return payload, nil
+		}
+
+		end := dec.InputOffset()
+		selfClosing := bytes.HasSuffix(bytes.TrimRight(payload[offset:end], " \t\r\n"), []byte("/>"))
+
+		var tag bytes.Buffer
+		tag.WriteString("<toast")
+		for _, attr := range start.Attr {
+			if attr.Name.Space == "" && attr.Name.Local == "launch" {
+				continue
+			}
+			name := attr.Name.Local
+			if attr.Name.Space == "xmlns" {
+				name = "xmlns:" + name
+			}
+			tag.WriteString(" " + name + `="`)
+			if err := xml.EscapeText(&tag, []byte(attr.Value)); err != nil {
+				return nil, err
+			}
+			tag.WriteString(`"`)
+		}
+		tag.WriteString(` launch="`)
+		if err := xml.EscapeText(&tag, []byte(launch)); err != nil {
+			return nil, err
+		}
+		tag.WriteString(`"`)
+		if selfClosing {
+			tag.WriteString("/>")
+		} else {
+			tag.WriteString(">")
+		}
+
+		return append(append(append([]byte(nil), payload[:offset]...), tag.Bytes()...), payload[end:]...), nil
+	}
+}
@@ -0,0 +1,108 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func Test_BuildDeepLink(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	tests := []struct {
+		base     string
+		path     string
+		query    url.Values
+		expected string
+	}{
+		{"myapp://", "orders/42", nil, "myapp://orders/42"},
+		{"https://example.com/app/", "/orders/42", url.Values{"utm_source": {"push"}}, "https://example.com/app/orders/42?utm_source=push"},
+		{"https://example.com?tenant=a", "", url.Values{"ref": {"push"}}, "https://example.com?ref=push&tenant=a"},
+		{"http://example.com", "orders", nil, ""},
+		{"orders/42", "", nil, ""},
+	}
+
+	for _, test := range tests {
+		link, err := BuildDeepLink(test.base, test.path, test.query)
+		if link != test.expected || (err == nil) != (test.expected != "") {
+			t.Errorf(errfmt, "link of "+test.base+" "+test.path, test.expected, link)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidDeepLink) {
+			t.Errorf(errfmt, "error", ErrInvalidDeepLink, err)
+		}
+	}
+}
+
+func Test_ValidateDeepLink(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	tests := []struct {
+		link  string
+		valid bool
+	}{
+		{"myapp://orders/42", true},
+		{"myapp:orders", true},
+		{"https://example.com/orders/42", true},
+		{"https:///orders", false},
+		{"http://example.com", false},
+		{"JavaScript:alert(1)", false},
+		{"myapp://", false},
+		{"/orders/42", false},
+		{"%zz", false},
+	}
+
+	for _, test := range tests {
+		if err := ValidateDeepLink(test.link); (err == nil) != test.valid {
+			t.Errorf(errfmt, "validation of "+test.link, test.valid, err)
+		}
+	}
+}
+
+func Test_InjectDeepLink(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	link := "myapp://orders/42?a=1&b=2"
+	tests := []struct {
+		n        *Notification
+		expected string
+	}{
+		{&Notification{AppleFormat, []byte(`{"aps":{"alert":"hi"}}`)}, `{"aps":{"alert":"hi"},"link":"myapp://orders/42?a=1\u0026b=2"}`},
+		{&Notification{AndroidFormat, []byte(`{"notification":{"title":"hi"}}`)}, `{"data":{"link":"myapp://orders/42?a=1\u0026b=2"},"notification":{"click_action":"myapp://orders/42?a=1\u0026b=2","title":"hi"}}`},
+		{&Notification{AndroidFormat, []byte(`{"data":{"id":"1"}}`)}, `{"data":{"id":"1","link":"myapp://orders/42?a=1\u0026b=2"}}`},
+		{&Notification{FcmV1Format, []byte(`{"message":{"data":{"id":"1"}}}`)}, `{"message":{"data":{"id":"1","link":"myapp://orders/42?a=1\u0026b=2"}}}`},
+		{
+			&Notification{FcmV1Format, []byte(`{"message":{"notification":{"title":"hi"}}}`)},
+			`{"message":{"android":{"notification":{"click_action":"myapp://orders/42?a=1\u0026b=2"}},"data":{"link":"myapp://orders/42?a=1\u0026b=2"},"notification":{"title":"hi"}}}`,
+		},
+		{&Notification{Template, []byte(`{"message":"hi"}`)}, `{"link":"myapp://orders/42?a=1\u0026b=2","message":"hi"}`},
+		{
+			&Notification{WindowsFormat, []byte(`<?xml version="1.0"?><toast duration="long" launch="old"><visual/></toast>`)},
+			`<?xml version="1.0"?><toast duration="long" launch="myapp://orders/42?a=1&amp;b=2"><visual/></toast>`,
+		},
+		{&Notification{WindowsFormat, []byte(`<toast />`)}, `<toast launch="myapp://orders/42?a=1&amp;b=2"/>`},
+		{&Notification{WindowsFormat, []byte(`<tile><visual/></tile>`)}, `<tile><visual/></tile>`},
+	}
+
+	for _, test := range tests {
+		n, err := InjectDeepLink(link)(context.Background(), test.n)
+		if err != nil {
+			t.Errorf(errfmt, string(test.n.Format)+" error", nil, err)
+			continue
+		}
+		if string(n.Payload) != test.expected {
+			t.Errorf(errfmt, string(test.n.Format)+" payload", test.expected, string(n.Payload))
+		}
+	}
+
+	universal := "https://example.com/orders/42"
+	fcmV1 := &Notification{FcmV1Format, []byte(`{"message":{"webpush":{"notification":{"title":"hi"}}}}`)}
+	expected := `{"message":{"data":{"link":"https://example.com/orders/42"},"webpush":{"fcm_options":{"link":"https://example.com/orders/42"},"notification":{"title":"hi"}}}}`
+	if n, err := InjectDeepLink(universal)(context.Background(), fcmV1); err != nil || string(n.Payload) != expected {
+		t.Errorf(errfmt, "web push payload", expected, n)
+	}
+
+	if _, err := InjectDeepLink("http://example.com")(context.Background(), tests[0].n); !errors.Is(err, ErrInvalidDeepLink) {
+		t.Errorf(errfmt, "invalid link error", ErrInvalidDeepLink, err)
+	}
+}
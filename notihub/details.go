@@ -0,0 +1,148 @@
+package notihub
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// States of notifications reported by NotificationDetails
+const (
+	NotificationEnqueued      NotificationState = "Enqueued"
+	NotificationProcessing    NotificationState = "Processing"
+	NotificationCompleted     NotificationState = "Completed"
+	NotificationAbandoned     NotificationState = "Abandoned"
+	NotificationNoTargetFound NotificationState = "NoTargetFound"
+	NotificationCancelled     NotificationState = "Cancelled"
+	NotificationUnknown       NotificationState = "Unknown"
+)
+
+const (
+	outcomeCountsSuffix = "OutcomeCounts"
+	// outcomeSuccess is the outcome of notifications delivered to the PNS
+	outcomeSuccess = "Success"
+)
+
+type (
+	// NotificationState is the processing state of a sent notification
+	NotificationState string
+
+	// NotificationDetails is the telemetry of a sent notification,
+	// available on Standard tier hubs and in exported telemetry blobs
+	NotificationDetails struct {
+		NotificationId     string
+		Location           string
+		State              NotificationState
+		EnqueueTime        time.Time
+		StartTime          time.Time
+		EndTime            time.Time
+		NotificationBody   string
+		TargetPlatforms    string
+		PnsErrorDetailsUri string
+		// OutcomeCounts counts outcomes, e.g. Success or InvalidCredentials, per platform
+		OutcomeCounts map[Platform]map[string]int
+	}
+
+	notificationDetailsXML struct {
+		XMLName            xml.Name           `xml:"NotificationDetails"`
+		NotificationId     string             `xml:"NotificationId"`
+		Location           string             `xml:"Location"`
+		State              string             `xml:"State"`
+		EnqueueTime        string             `xml:"EnqueueTime"`
+		StartTime          string             `xml:"StartTime"`
+		EndTime            string             `xml:"EndTime"`
+		NotificationBody   string             `xml:"NotificationBody"`
+		TargetPlatforms    string             `xml:"TargetPlatforms"`
+		PnsErrorDetailsUri string             `xml:"PnsErrorDetailsUri"`
+		Other              []outcomeCountsXML `xml:",any"`
+	}
+
+	outcomeCountsXML struct {
+		XMLName  xml.Name
+		Outcomes []struct {
+			Name  string `xml:"Name"`
+			Count int    `xml:"Count"`
+		} `xml:"Outcome"`
+	}
+)
+
+// IsFinal identifies whether the notification is no longer processed
+func (s NotificationState) IsFinal() bool {
+	switch s {
+	case NotificationCompleted, NotificationAbandoned, NotificationNoTargetFound, NotificationCancelled:
+		return true
+	}
+
+	return false
+}
+
+// ParseNotificationDetails parses NotificationDetails XML
+func ParseNotificationDetails(b []byte) (*NotificationDetails, error) {
+	raw := &notificationDetailsXML{}
+	if err := xml.Unmarshal(b, raw); err != nil {
+		return nil, fmt.Errorf("failed to parse notification details: %w", err)
+	}
+
+	d := &NotificationDetails{
+		NotificationId:     raw.NotificationId,
+		Location:           raw.Location,
+		State:              NotificationState(raw.State),
+		NotificationBody:   raw.NotificationBody,
+		TargetPlatforms:    raw.TargetPlatforms,
+		PnsErrorDetailsUri: raw.PnsErrorDetailsUri,
+		OutcomeCounts:      map[Platform]map[string]int{},
+	}
+
+	for _, t := range []struct {
+		value string
+		dst   *time.Time
+	}{
+		{raw.EnqueueTime, &d.EnqueueTime},
+		{raw.StartTime, &d.StartTime},
+		{raw.EndTime, &d.EndTime},
+	} {
+		if t.value == "" {
+			continue
+		}
+		parsed, err := parseHubTime(t.value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse notification details: %w", err)
+		}
+		*t.dst = parsed
+	}
+
+	for _, counts := range raw.Other {
+		name := counts.XMLName.Local
+		if !strings.HasSuffix(name, outcomeCountsSuffix) {
+			continue
+		}
+
+		platform := Platform(strings.ToLower(strings.TrimSuffix(name, outcomeCountsSuffix)))
+		outcomes := d.OutcomeCounts[platform]
+		if outcomes == nil {
+			outcomes = map[string]int{}
+			d.OutcomeCounts[platform] = outcomes
+		}
+		for _, outcome := range counts.Outcomes {
+			outcomes[outcome.Name] += outcome.Count
+		}
+	}
+
+	return d, nil
+}
+
+// Count returns the number of outcome occurrences reported for platform
+func (d *NotificationDetails) Count(platform Platform, outcome string) int {
+	return d.OutcomeCounts[platform][outcome]
+}
+
+// Total returns the number of outcome occurrences reported for all platforms
+func (d *NotificationDetails) Total(outcome string) int {
+	total := 0
+	for _, outcomes := range d.OutcomeCounts {
+		total += outcomes[outcome]
+	}
+
+	return total
+}
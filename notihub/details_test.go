@@ -0,0 +1,81 @@
+package notihub
+
+import (
+	"testing"
+	"time"
+)
+
+const testNotificationDetails = `<NotificationDetails xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect" xmlns:i="http://www.w3.org/2001/XMLSchema-instance">
+    <NotificationId>notification-1</NotificationId>
+    <Location>https://testhub-ns.servicebus.windows.net/hub/messages/notification-1?api-version=2015-01</Location>
+    <State>Completed</State>
+    <EnqueueTime>2020-01-01T12:00:00Z</EnqueueTime>
+    <StartTime>2020-01-01T12:00:01Z</StartTime>
+    <EndTime>2020-01-01T12:00:02.5Z</EndTime>
+    <NotificationBody>{"aps":{"alert":"hi"}}</NotificationBody>
+    <TargetPlatforms>apple,gcm</TargetPlatforms>
+    <ApnsOutcomeCounts>
+        <Outcome><Name>Success</Name><Count>3</Count></Outcome>
+        <Outcome><Name>InvalidToken</Name><Count>1</Count></Outcome>
+    </ApnsOutcomeCounts>
+    <FcmV1OutcomeCounts>
+        <Outcome><Name>Success</Name><Count>2</Count></Outcome>
+    </FcmV1OutcomeCounts>
+    <PnsErrorDetailsUri>https://storage/errors</PnsErrorDetailsUri>
+</NotificationDetails>`
+
+func Test_ParseNotificationDetails(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	d, err := ParseNotificationDetails([]byte(testNotificationDetails))
+	if err != nil {
+		t.Fatalf(errfmt, "parse error", nil, err)
+	}
+
+	if d.NotificationId != "notification-1" || d.State != NotificationCompleted || !d.State.IsFinal() {
+		t.Errorf(errfmt, "notification state", NotificationCompleted, d.State)
+	}
+	if expected := time.Date(2020, 1, 1, 12, 0, 2, 500000000, time.UTC); !d.EndTime.Equal(expected) {
+		t.Errorf(errfmt, "end time", expected, d.EndTime)
+	}
+	if d.TargetPlatforms != "apple,gcm" || d.PnsErrorDetailsUri != "https://storage/errors" {
+		t.Errorf(errfmt, "details", "target platforms and error details", d)
+	}
+
+	if d.Count(PlatformApns, "Success") != 3 || d.Count(PlatformApns, "InvalidToken") != 1 || d.Count(PlatformFcmV1, "Success") != 2 {
+		t.Errorf(errfmt, "outcome counts", "apns 3+1, fcmv1 2", d.OutcomeCounts)
+	}
+	if total := d.Total("Success"); total != 5 {
+		t.Errorf(errfmt, "total successes", 5, total)
+	}
+
+	if NotificationProcessing.IsFinal() {
+		t.Errorf(errfmt, "processing final", false, true)
+	}
+	if _, err := ParseNotificationDetails([]byte("<NotificationDetails><EndTime>yesterday</EndTime></NotificationDetails>")); err == nil {
+		t.Errorf(errfmt, "invalid time error", "error", err)
+	}
+}
+
+func Test_ParseNotificationOutcome(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	outcome, err := ParseNotificationOutcome([]byte(`<NotificationOutcome xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect">
+    <Success>1</Success>
+    <Failure>1</Failure>
+    <Results>
+        <RegistrationResult><ApplicationPlatform>gcm</ApplicationPlatform><PnsHandle>handle-1</PnsHandle><RegistrationId>1</RegistrationId><Outcome>The Notification was successfully sent to the Push Notification System</Outcome></RegistrationResult>
+        <RegistrationResult><ApplicationPlatform>apple</ApplicationPlatform><PnsHandle>handle-2</PnsHandle><RegistrationId>2</RegistrationId><Outcome>BadDeviceToken</Outcome></RegistrationResult>
+    </Results>
+</NotificationOutcome>`))
+	if err != nil {
+		t.Fatalf(errfmt, "parse error", nil, err)
+	}
+
+	if outcome.Success != 1 || outcome.Failure != 1 || len(outcome.Results) != 2 || outcome.Results[1].PnsHandle != "handle-2" {
+		t.Errorf(errfmt, "outcome", "1 success, 1 failure", outcome)
+	}
+	if outcome.Results[0].ApplicationPlatform != PlatformGcm || outcome.Results[1].ApplicationPlatform != PlatformApns {
+		t.Errorf(errfmt, "platforms", []Platform{PlatformGcm, PlatformApns}, outcome.Results)
+	}
+}
@@ -0,0 +1,83 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ErrInvalidRelPath is returned by Do for paths outside of the hub
+var ErrInvalidRelPath = errors.New("invalid relative path")
+
+// Do makes an authenticated request to relPath of the hub, e.g. "messages/123"
+// or "registrationIds?$top=1", so that endpoints without first-class support can be called
+// with the hub signing, retry policy, kill switches and error handling.
+// The api-version query parameter is added unless relPath sets it.
+// relPath must stay within the hub, paths resolving outside of it, e.g. "../other-hub/messages",
+// are refused with ErrInvalidRelPath.
+// Returns the response body and header, failed responses are returned as *ResponseError
+func (h *NotificationHub) Do(ctx context.Context, method, relPath string, body []byte, headers map[string]string) ([]byte, http.Header, error) {
+	b, header, err := h.do(ctx, method, relPath, body, headers)
+	if err != nil {
+		return nil, nil, fmt.Errorf("NotificationHub.Do: %w", err)
+	}
+
+	return b, header, nil
+}
+
+// do makes authenticated request to relPath
+func (h *NotificationHub) do(ctx context.Context, method, relPath string, body []byte, headers map[string]string) ([]byte, http.Header, error) {
+	rel, err := url.Parse(relPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := h.checkRelPath(rel); err != nil {
+		return nil, nil, err
+	}
+
+	query := h.hubURL.Query()
+	for key, values := range rel.Query() {
+		query[key] = values
+	}
+
+	req, err := h.newRequest(ctx, method, rel.EscapedPath(), query, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	for header, val := range headers {
+		req.Header.Set(header, val)
+	}
+
+	o := &sendOptions{}
+	b, err := h.exec(req, o)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return b, o.header, nil
+}
+
+// checkRelPath returns error if rel is not a path within the hub, also once unescaped,
+// as the hub resolves escaped dots and slashes
+func (h *NotificationHub) checkRelPath(rel *url.URL) error {
+	if rel.Scheme != "" || rel.Host != "" {
+		return fmt.Errorf("%w: '%s'", ErrInvalidRelPath, rel)
+	}
+
+	unescaped, err := url.PathUnescape(rel.EscapedPath())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRelPath, err)
+	}
+
+	hubPath := path.Clean("/" + h.hubURL.Path)
+	joined := path.Join(hubPath, unescaped)
+	if joined != hubPath && !strings.HasPrefix(joined, hubPath+"/") {
+		return fmt.Errorf("%w: '%s'", ErrInvalidRelPath, rel)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_NotificationHubDo(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI()+" "+r.Header.Get("X-Custom"))
+		if r.URL.Path == "/hub/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if len(requests) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Location", "https://ns/hub/registrations/1")
+		w.Write([]byte("created"))
+	}))
+	defer server.Close()
+
+	nhub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(), WithRetry(RetryPolicy{MaxAttempts: 2}))
+
+	b, header, err := nhub.Do(context.Background(), "POST", "registrationIds?api-version=2020-06", nil, map[string]string{"X-Custom": "1"})
+	if err != nil || string(b) != "created" || header.Get("Location") != "https://ns/hub/registrations/1" {
+		t.Fatalf(errfmt, "response", "created with location", err)
+	}
+
+	expected := "POST /hub/registrationIds?api-version=2020-06 1"
+	if len(requests) != 2 || requests[1] != expected {
+		t.Errorf(errfmt, "requests", "retried "+expected, requests)
+	}
+
+	var resErr *ResponseError
+	if _, _, err := nhub.Do(context.Background(), "GET", "missing", nil, nil); !errors.As(err, &resErr) || resErr.StatusCode != http.StatusNotFound {
+		t.Errorf(errfmt, "response error", http.StatusNotFound, err)
+	}
+}
+
+func Test_NotificationHubDoRelPath(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.EscapedPath())
+	}))
+	defer server.Close()
+
+	nhub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client())
+
+	for _, relPath := range []string{"..", "../other/messages", "messages/../../other", "%2e%2e/other", "..%2Fother", "https://example.com/hub/messages"} {
+		if _, _, err := nhub.Do(context.Background(), "GET", relPath, nil, nil); !errors.Is(err, ErrInvalidRelPath) {
+			t.Errorf(errfmt, "error of "+relPath, ErrInvalidRelPath, err)
+		}
+	}
+	if len(requests) != 0 {
+		t.Errorf(errfmt, "requests", "none", requests)
+	}
+
+	for _, relPath := range []string{"messages/1", "registrations/../messages/1", "/messages/1"} {
+		if _, _, err := nhub.Do(context.Background(), "GET", relPath, nil, nil); err != nil {
+			t.Errorf(errfmt, "error of "+relPath, nil, err)
+		}
+	}
+	if len(requests) != 3 || requests[0] != "/hub/messages/1" || requests[2] != "/hub/messages/1" {
+		t.Errorf(errfmt, "requests", "within hub", requests)
+	}
+}
@@ -0,0 +1,70 @@
+package notihub
+
+import (
+	"fmt"
+	"time"
+)
+
+type (
+	// DripOptions configures DripSender pacing, at least one of PerMinute and Window must be set.
+	// When both are set, the slower of the two paces the campaign
+	DripOptions struct {
+		// PerMinute is the number of messages sent per minute
+		PerMinute int
+		// Window is the time the whole campaign is spread over
+		Window time.Duration
+		// Store persists progress, so that a restarted campaign resumes where it left off
+		Store CheckpointStore
+		// SendOptions are applied to every send
+		SendOptions []SendOption
+	}
+
+	// DripSender delivers campaign messages to an audience gradually.
+	// It is a paced BulkSender and can be paused, resumed or aborted the same way
+	DripSender struct {
+		*BulkSender
+	}
+)
+
+// NewDripSender initializes and returns DripSender pointer
+// sending messages of campaign identified by campaignID
+func (h *NotificationHub) NewDripSender(campaignID string, messages []BulkMessage, opts DripOptions) (*DripSender, error) {
+	interval, err := opts.interval(len(messages))
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.NewDripSender: %w", err)
+	}
+
+	bulkOpts := BulkOptions{Store: opts.Store, Interval: interval, SendOptions: opts.SendOptions}
+
+	return &DripSender{BulkSender: h.NewBulkSender(campaignID, messages, bulkOpts)}, nil
+}
+
+// Interval returns the time between consecutive sends
+func (s *DripSender) Interval() time.Duration {
+	return s.opts.Interval
+}
+
+// Remaining estimates the time needed to send the rest of the messages
+func (s *DripSender) Remaining() time.Duration {
+	progress := s.Progress()
+	return time.Duration(progress.Total-progress.Next) * s.opts.Interval
+}
+
+// interval returns the time between consecutive sends of count messages
+func (o *DripOptions) interval(count int) (time.Duration, error) {
+	if o.PerMinute < 0 || o.Window < 0 || o.PerMinute == 0 && o.Window == 0 {
+		return 0, fmt.Errorf("drip pace requires positive PerMinute or Window, got %d per minute over %s", o.PerMinute, o.Window)
+	}
+
+	var interval time.Duration
+	if o.PerMinute > 0 {
+		interval = time.Minute / time.Duration(o.PerMinute)
+	}
+	if o.Window > 0 && count > 0 {
+		if windowInterval := o.Window / time.Duration(count); windowInterval > interval {
+			interval = windowInterval
+		}
+	}
+
+	return interval, nil
+}
@@ -0,0 +1,109 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func Test_DripOptionsInterval(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	testPatterns := []struct {
+		opts     DripOptions
+		count    int
+		expected time.Duration
+		err      bool
+	}{
+		{DripOptions{PerMinute: 120}, 1000, 500 * time.Millisecond, false},
+		{DripOptions{Window: 2 * time.Hour}, 3600, 2 * time.Second, false},
+		{DripOptions{PerMinute: 120, Window: 2 * time.Hour}, 3600, 2 * time.Second, false},
+		{DripOptions{PerMinute: 10, Window: 2 * time.Hour}, 3600, 6 * time.Second, false},
+		{DripOptions{}, 10, 0, true},
+		{DripOptions{PerMinute: -1}, 10, 0, true},
+	}
+
+	for _, testData := range testPatterns {
+		interval, err := testData.opts.interval(testData.count)
+		if interval != testData.expected || (err != nil) != testData.err {
+			t.Errorf(errfmt, "interval", testData.expected, interval)
+		}
+	}
+}
+
+func Test_DripSenderResume(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var (
+		sent   []time.Time
+		sender *DripSender
+	)
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		sent = append(sent, time.Now())
+		if len(sent) == 2 {
+			sender.Abort()
+		}
+		return nil, nil
+	})
+
+	store := NewMemoryCheckpointStore()
+	messages := newBulkTestMessages(4)
+	opts := DripOptions{Window: 80 * time.Millisecond, Store: store}
+
+	sender, err := nhub.NewDripSender("drip", messages, opts)
+	if err != nil {
+		t.Fatalf(errfmt, "drip sender error", nil, err)
+	}
+	if sender.Interval() != 20*time.Millisecond || sender.Remaining() != 80*time.Millisecond {
+		t.Errorf(errfmt, "pace", 20*time.Millisecond, sender.Interval())
+	}
+
+	sender.Run(context.Background())
+	if sent[1].Sub(sent[0]) < 20*time.Millisecond {
+		t.Errorf(errfmt, "paced sends", 20*time.Millisecond, sent[1].Sub(sent[0]))
+	}
+
+	restarted, _ := nhub.NewDripSender("drip", messages, opts)
+	progress, err := restarted.Run(context.Background())
+	if err != nil || len(sent) != 4 || progress.Sent != 2 || restarted.Remaining() != 0 {
+		t.Errorf(errfmt, "messages sent after restart", 4, len(sent))
+	}
+}
+
+func Test_DripSenderAbortDuringInterval(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	sent := make(chan struct{}, 4)
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		sent <- struct{}{}
+		return nil, nil
+	})
+
+	sender, err := nhub.NewDripSender("drip", newBulkTestMessages(4), DripOptions{PerMinute: 1})
+	if err != nil {
+		t.Fatalf(errfmt, "drip sender error", nil, err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := sender.Run(context.Background())
+		done <- err
+	}()
+
+	<-sent
+	sender.Abort()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrBulkAborted) {
+			t.Errorf(errfmt, "aborted error", ErrBulkAborted, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf(errfmt, "run", "aborted during the interval", "still waiting")
+	}
+	if len(sent) != 0 || sender.Progress().Sent != 1 {
+		t.Errorf(errfmt, "sent messages", 1, sender.Progress().Sent)
+	}
+}
@@ -0,0 +1,65 @@
+package notihub
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type (
+	// Encoder marshals JSON payloads and installation documents.
+	// jsoniter.ConfigCompatibleWithStandardLibrary and similar drop-in encoders implement it
+	Encoder interface {
+		Marshal(v interface{}) ([]byte, error)
+		Unmarshal(data []byte, v interface{}) error
+	}
+
+	// StdEncoder is Encoder backed by encoding/json
+	StdEncoder struct{}
+
+	// encoderKey carries the hub encoder in contexts passed to payload transformers
+	encoderKey struct{}
+)
+
+// DefaultEncoder is used by payload builders and by hubs configured without WithEncoder.
+// It is meant to be replaced once at program start, before any use
+var DefaultEncoder Encoder = StdEncoder{}
+
+// Marshal calls json.Marshal
+func (StdEncoder) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal calls json.Unmarshal
+func (StdEncoder) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// WithEncoder sets the encoder of installation documents
+func WithEncoder(encoder Encoder) HubOption {
+	return func(h *NotificationHub) {
+		h.jsonEncoder = encoder
+	}
+}
+
+// encoder returns the hub encoder, DefaultEncoder when not configured
+func (h *NotificationHub) encoder() Encoder {
+	if h.jsonEncoder == nil {
+		return DefaultEncoder
+	}
+
+	return h.jsonEncoder
+}
+
+// contextWithEncoder returns ctx carrying encoder
+func contextWithEncoder(ctx context.Context, encoder Encoder) context.Context {
+	return context.WithValue(ctx, encoderKey{}, encoder)
+}
+
+// contextEncoder returns the encoder carried by ctx, DefaultEncoder when there is none
+func contextEncoder(ctx context.Context) Encoder {
+	if encoder, ok := ctx.Value(encoderKey{}).(Encoder); ok {
+		return encoder
+	}
+
+	return DefaultEncoder
+}
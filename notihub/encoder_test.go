@@ -0,0 +1,65 @@
+package notihub
+
+import (
+	"context"
+	"testing"
+)
+
+// countingEncoder is Encoder counting calls of the standard encoder
+type countingEncoder struct {
+	StdEncoder
+	marshals, unmarshals int
+}
+
+func (e *countingEncoder) Marshal(v interface{}) ([]byte, error) {
+	e.marshals++
+	return e.StdEncoder.Marshal(v)
+}
+
+func (e *countingEncoder) Unmarshal(data []byte, v interface{}) error {
+	e.unmarshals++
+	return e.StdEncoder.Unmarshal(data, v)
+}
+
+func Test_NotificationHubEncoder(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newInstallationServer()
+	defer server.Close()
+
+	encoder := &countingEncoder{}
+	hub := server.hub(WithEncoder(encoder))
+
+	installation := &Installation{InstallationId: "a", Platform: PlatformApns, PushChannel: "token"}
+	if err := hub.putInstallation(context.Background(), installation); err != nil {
+		t.Fatalf(errfmt, "put error", nil, err)
+	}
+	got, err := hub.getInstallation(context.Background(), "a")
+	if err != nil || !installationsEqual(got, installation) {
+		t.Fatalf(errfmt, "installation", installation, got)
+	}
+
+	if encoder.marshals != 1 || encoder.unmarshals != 1 {
+		t.Errorf(errfmt, "encoder calls", "1 marshal, 1 unmarshal", encoder)
+	}
+
+	if (&NotificationHub{}).encoder() != DefaultEncoder {
+		t.Errorf(errfmt, "default encoder", DefaultEncoder, (&NotificationHub{}).encoder())
+	}
+}
+
+func Test_DefaultEncoder(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	encoder := &countingEncoder{}
+	DefaultEncoder = encoder
+	defer func() { DefaultEncoder = StdEncoder{} }()
+
+	n, err := SetBadge(AppleFormat, 3)
+	if err != nil || string(n.Payload) != `{"aps":{"badge":3}}` {
+		t.Errorf(errfmt, "badge payload", `{"aps":{"badge":3}}`, n)
+	}
+	if encoder.marshals != 1 {
+		t.Errorf(errfmt, "payload builder marshals", 1, encoder.marshals)
+	}
+}
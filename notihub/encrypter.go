@@ -0,0 +1,83 @@
+package notihub
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrEncryptedEnvelope is returned when an encrypted notification envelope is decoded without Encrypter
+var ErrEncryptedEnvelope = errors.New("notification envelope is encrypted")
+
+type (
+	// Encrypter encrypts notification payloads persisted in envelopes, e.g. by outboxes and queues,
+	// so that payloads containing personal data are encrypted at rest.
+	// Implementations backed by a key management service allow customer managed keys
+	Encrypter interface {
+		// Encrypt returns ciphertext of plaintext and id of the key it was encrypted with
+		Encrypt(plaintext []byte) (ciphertext []byte, keyID string, err error)
+		// Decrypt returns plaintext of ciphertext encrypted with key keyID
+		Decrypt(ciphertext []byte, keyID string) ([]byte, error)
+	}
+
+	// AESEncrypter is Encrypter using AES-GCM with keys held in process memory.
+	// Payloads are encrypted with the current key and decrypted with any known key,
+	// so that keys can be rotated while encrypted envelopes are still stored
+	AESEncrypter struct {
+		keyID string
+		aeads map[string]cipher.AEAD
+	}
+)
+
+// NewAESEncrypter initializes and returns AESEncrypter pointer encrypting with key keyID of keys.
+// Keys must be 16, 24 or 32 bytes long
+func NewAESEncrypter(keyID string, keys map[string][]byte) (*AESEncrypter, error) {
+	if _, ok := keys[keyID]; !ok {
+		return nil, fmt.Errorf("NewAESEncrypter: unknown current key '%s'", keyID)
+	}
+
+	e := &AESEncrypter{keyID: keyID, aeads: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("NewAESEncrypter: key '%s': %w", id, err)
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("NewAESEncrypter: key '%s': %w", id, err)
+		}
+		e.aeads[id] = aead
+	}
+
+	return e, nil
+}
+
+// Encrypt seals plaintext with the current key, prefixed by random nonce
+func (e *AESEncrypter) Encrypt(plaintext []byte) ([]byte, string, error) {
+	aead := e.aeads[e.keyID]
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, "", err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), e.keyID, nil
+}
+
+// Decrypt opens ciphertext sealed with key keyID
+func (e *AESEncrypter) Decrypt(ciphertext []byte, keyID string) ([]byte, error) {
+	aead, ok := e.aeads[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key '%s'", keyID)
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, nil)
+}
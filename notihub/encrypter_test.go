@@ -0,0 +1,86 @@
+package notihub
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_AESEncrypter(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	keys := map[string][]byte{"old": bytes.Repeat([]byte{1}, 16), "new": bytes.Repeat([]byte{2}, 32)}
+	old, err := NewAESEncrypter("old", keys)
+	if err != nil {
+		t.Fatalf(errfmt, "encrypter error", nil, err)
+	}
+	current, _ := NewAESEncrypter("new", keys)
+
+	plaintext := []byte(`{"data":{"code":"123456"}}`)
+	ciphertext, keyID, err := old.Encrypt(plaintext)
+	if err != nil || keyID != "old" || bytes.Contains(ciphertext, []byte("123456")) {
+		t.Fatalf(errfmt, "ciphertext", "sealed with old key", string(ciphertext))
+	}
+
+	if decrypted, err := current.Decrypt(ciphertext, keyID); err != nil || !bytes.Equal(decrypted, plaintext) {
+		t.Errorf(errfmt, "rotated decryption", string(plaintext), err)
+	}
+
+	ciphertext[len(ciphertext)-1] ^= 1
+	if _, err := current.Decrypt(ciphertext, keyID); err == nil {
+		t.Errorf(errfmt, "tampered ciphertext error", "error", err)
+	}
+	if _, err := current.Decrypt(ciphertext, "missing"); err == nil {
+		t.Errorf(errfmt, "unknown key error", "error", err)
+	}
+	if _, err := current.Decrypt([]byte{1}, "new"); err == nil {
+		t.Errorf(errfmt, "short ciphertext error", "error", err)
+	}
+
+	if _, err := NewAESEncrypter("missing", keys); err == nil {
+		t.Errorf(errfmt, "unknown current key error", "error", err)
+	}
+	if _, err := NewAESEncrypter("bad", map[string][]byte{"bad": []byte("short")}); err == nil {
+		t.Errorf(errfmt, "invalid key error", "error", err)
+	}
+}
+
+func Test_NotificationEnvelopeEncrypted(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	enc, _ := NewAESEncrypter("k1", map[string][]byte{"k1": bytes.Repeat([]byte{1}, 16)})
+	envelope := NotificationEnvelope{
+		Notification: &Notification{AndroidFormat, []byte(`{"data":{"code":"123456"}}`)},
+		Headers:      map[string]string{"X-Priority": "high"},
+	}
+
+	b, err := envelope.MarshalEncrypted(enc)
+	if err != nil {
+		t.Fatalf(errfmt, "marshal error", nil, err)
+	}
+	if strings.Contains(string(b), "123456") || !strings.Contains(string(b), `"keyId":"k1"`) || !strings.Contains(string(b), `"format":"gcm"`) {
+		t.Errorf(errfmt, "encrypted envelope", "payload encrypted with k1", string(b))
+	}
+
+	var decoded NotificationEnvelope
+	if err := decoded.UnmarshalEncrypted(b, enc); err != nil || !reflect.DeepEqual(decoded, envelope) {
+		t.Errorf(errfmt, "decrypted envelope", envelope, decoded)
+	}
+
+	var plain Notification
+	if err := json.Unmarshal(b, &plain); !errors.Is(err, ErrEncryptedEnvelope) {
+		t.Errorf(errfmt, "plain decoding error", ErrEncryptedEnvelope, err)
+	}
+
+	plainText, _ := json.Marshal(envelope)
+	if err := decoded.UnmarshalEncrypted(plainText, enc); err != nil || !reflect.DeepEqual(decoded, envelope) {
+		t.Errorf(errfmt, "plain text envelope", envelope, decoded)
+	}
+
+	if _, err := envelope.MarshalEncrypted(nil); err == nil {
+		t.Errorf(errfmt, "nil encrypter error", "error", err)
+	}
+}
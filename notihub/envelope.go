@@ -0,0 +1,202 @@
+package notihub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// NotificationEnvelopeVersion is the version of the notification wire format written by the client
+const NotificationEnvelopeVersion = 1
+
+// ErrUnsupportedEnvelope is returned when a stored notification can not be decoded
+var ErrUnsupportedEnvelope = errors.New("unsupported notification envelope")
+
+type (
+	// NotificationEnvelope is a notification with send headers persisted
+	// as {"v":1,"format":...,"payload":...,"headers":...}. Later versions
+	// only add fields, so any version is decoded as long as it has a format.
+	// Notifications persisted with the default Go JSON encoding before
+	// the envelope was introduced are decoded as well
+	NotificationEnvelope struct {
+		Notification *Notification
+		Headers      map[string]string
+	}
+
+	notificationEnvelopeJSON struct {
+		V       int                `json:"v"`
+		Format  NotificationFormat `json:"format"`
+		Payload string             `json:"payload,omitempty"`
+		// PayloadBase64 holds payloads which are not valid UTF-8
+		PayloadBase64 []byte            `json:"payloadBase64,omitempty"`
+		Headers       map[string]string `json:"headers,omitempty"`
+		// Ciphertext holds payloads encrypted by Encrypter with key KeyID
+		Ciphertext []byte `json:"ciphertext,omitempty"`
+		KeyID      string `json:"keyId,omitempty"`
+	}
+
+	// legacyNotificationJSON is the default Go JSON encoding of Notification
+	legacyNotificationJSON struct {
+		Format  NotificationFormat
+		Payload []byte
+	}
+)
+
+// addressingHeaders are request headers selecting notification format and recipients,
+// set by the hub only
+var addressingHeaders = []string{
+	"ServiceBusNotification-Format",
+	"ServiceBusNotification-Tags",
+	"ServiceBusNotification-DeviceHandle",
+}
+
+// isAddressingHeader reports whether header is one of addressingHeaders
+func isAddressingHeader(header string) bool {
+	for _, h := range addressingHeaders {
+		if strings.EqualFold(header, h) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithHeaders adds headers to the notification request,
+// taking precedence over the headers set by the hub.
+// Headers selecting format and recipients, like ServiceBusNotification-Tags, are ignored
+// as they would redirect the send, also when replayed from NotificationEnvelope headers
+func WithHeaders(headers map[string]string) SendOption {
+	return func(o *sendOptions) {
+		if o.headers == nil {
+			o.headers = make(map[string]string, len(headers))
+		}
+		for header, val := range headers {
+			o.headers[header] = val
+		}
+	}
+}
+
+// SendOptions returns options sending the notification with the envelope headers
+func (e *NotificationEnvelope) SendOptions() []SendOption {
+	if len(e.Headers) == 0 {
+		return nil
+	}
+
+	return []SendOption{WithHeaders(e.Headers)}
+}
+
+// MarshalJSON encodes envelope in the current wire format version
+func (e NotificationEnvelope) MarshalJSON() ([]byte, error) {
+	return e.marshal(nil)
+}
+
+// MarshalEncrypted encodes envelope like MarshalJSON with the payload encrypted by enc,
+// format and headers are kept in plain text
+func (e NotificationEnvelope) MarshalEncrypted(enc Encrypter) ([]byte, error) {
+	if enc == nil {
+		return nil, errors.New("no encrypter")
+	}
+
+	return e.marshal(enc)
+}
+
+// marshal encodes envelope, encrypting the payload when enc is set
+func (e NotificationEnvelope) marshal(enc Encrypter) ([]byte, error) {
+	if e.Notification == nil {
+		return nil, fmt.Errorf("%w: no notification", ErrUnsupportedEnvelope)
+	}
+
+	v := notificationEnvelopeJSON{
+		V:       NotificationEnvelopeVersion,
+		Format:  e.Notification.Format,
+		Headers: e.Headers,
+	}
+
+	switch {
+	case enc != nil:
+		ciphertext, keyID, err := enc.Encrypt(e.Notification.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt payload: %w", err)
+		}
+		v.Ciphertext, v.KeyID = ciphertext, keyID
+	case utf8.Valid(e.Notification.Payload):
+		v.Payload = string(e.Notification.Payload)
+	default:
+		v.PayloadBase64 = e.Notification.Payload
+	}
+
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes envelope of any version.
+// ErrEncryptedEnvelope is returned for envelopes written by MarshalEncrypted
+func (e *NotificationEnvelope) UnmarshalJSON(b []byte) error {
+	return e.unmarshal(b, nil)
+}
+
+// UnmarshalEncrypted decodes envelope of any version, decrypting the payload by enc.
+// Envelopes written in plain text are decoded as well
+func (e *NotificationEnvelope) UnmarshalEncrypted(b []byte, enc Encrypter) error {
+	return e.unmarshal(b, enc)
+}
+
+// unmarshal decodes envelope, decrypting the payload by enc when encrypted
+func (e *NotificationEnvelope) unmarshal(b []byte, enc Encrypter) error {
+	var v notificationEnvelopeJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	// keys are matched case insensitively, so legacy fields land in v as well
+	if v.V == 0 {
+		var legacy legacyNotificationJSON
+		if err := json.Unmarshal(b, &legacy); err != nil {
+			return err
+		}
+		v.Format, v.Payload, v.PayloadBase64 = legacy.Format, "", legacy.Payload
+	}
+
+	if v.Format == "" {
+		return fmt.Errorf("%w: version %d without format", ErrUnsupportedEnvelope, v.V)
+	}
+
+	payload := v.PayloadBase64
+	if v.Payload != "" {
+		payload = []byte(v.Payload)
+	}
+
+	if v.Ciphertext != nil {
+		if enc == nil {
+			return ErrEncryptedEnvelope
+		}
+
+		var err error
+		if payload, err = enc.Decrypt(v.Ciphertext, v.KeyID); err != nil {
+			return fmt.Errorf("failed to decrypt payload with key '%s': %w", v.KeyID, err)
+		}
+	}
+
+	e.Notification = &Notification{Format: v.Format, Payload: payload}
+	e.Headers = v.Headers
+
+	return nil
+}
+
+// MarshalJSON encodes notification as NotificationEnvelope without headers
+func (n Notification) MarshalJSON() ([]byte, error) {
+	return NotificationEnvelope{Notification: &n}.MarshalJSON()
+}
+
+// UnmarshalJSON decodes notification from NotificationEnvelope of any version,
+// envelope headers are discarded
+func (n *Notification) UnmarshalJSON(b []byte) error {
+	var e NotificationEnvelope
+	if err := e.UnmarshalJSON(b); err != nil {
+		return err
+	}
+
+	*n = *e.Notification
+	return nil
+}
@@ -0,0 +1,140 @@
+package notihub
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func Test_NotificationEnvelopeRoundTrip(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	envelope := NotificationEnvelope{
+		Notification: &Notification{AppleFormat, []byte(`{"aps":{"alert":"hi"}}`)},
+		Headers:      map[string]string{"X-Apns-Priority": "5"},
+	}
+
+	b, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatalf(errfmt, "marshal error", nil, err)
+	}
+
+	expected := `{"v":1,"format":"apple","payload":"{\"aps\":{\"alert\":\"hi\"}}","headers":{"X-Apns-Priority":"5"}}`
+	if string(b) != expected {
+		t.Errorf(errfmt, "envelope", expected, string(b))
+	}
+
+	var decoded NotificationEnvelope
+	if err := json.Unmarshal(b, &decoded); err != nil || !reflect.DeepEqual(decoded, envelope) {
+		t.Errorf(errfmt, "decoded envelope", envelope, decoded)
+	}
+
+	binary := &Notification{WindowsFormat, []byte{0xff, 0xfe}}
+	b, _ = json.Marshal(binary)
+	var decodedBinary Notification
+	if err := json.Unmarshal(b, &decodedBinary); err != nil || !reflect.DeepEqual(&decodedBinary, binary) {
+		t.Errorf(errfmt, "binary payload", binary, decodedBinary)
+	}
+}
+
+func Test_NotificationEnvelopeVersions(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	payload := []byte(`{"data":{"msg":"hi"}}`)
+
+	testPatterns := []struct {
+		name     string
+		stored   string
+		expected *Notification
+	}{
+		{"legacy", `{"Format":"gcm","Payload":"` + base64.StdEncoding.EncodeToString(payload) + `"}`, &Notification{AndroidFormat, payload}},
+		{"v1", `{"v":1,"format":"gcm","payload":"{\"data\":{\"msg\":\"hi\"}}"}`, &Notification{AndroidFormat, payload}},
+		{"future", `{"v":3,"format":"gcm","payload":"{\"data\":{\"msg\":\"hi\"}}","priority":"high"}`, &Notification{AndroidFormat, payload}},
+		{"without format", `{"v":2,"kind":"gcm"}`, nil},
+	}
+
+	for _, testData := range testPatterns {
+		var n Notification
+		err := json.Unmarshal([]byte(testData.stored), &n)
+		if testData.expected == nil {
+			if !errors.Is(err, ErrUnsupportedEnvelope) {
+				t.Errorf(errfmt, testData.name+" error", ErrUnsupportedEnvelope, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(&n, testData.expected) {
+			t.Errorf(errfmt, testData.name+" notification", testData.expected, n)
+		}
+	}
+}
+
+func Test_NotificationHubSendWithHeaders(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var header http.Header
+	mockClient := &mockHubHttpClient{execFunc: func(req *http.Request) ([]byte, error) {
+		header = req.Header
+		return nil, nil
+	}}
+
+	nhub := &NotificationHub{
+		sasKeyValue:    "testKeyValue",
+		sasKeyName:     "testKeyName",
+		hubURL:         &url.URL{Host: "testHost", Scheme: schemeDefault, Path: "testPath"},
+		client:         mockClient,
+		expiryTimeFunc: TimeFunc(mockExpiryTime),
+	}
+
+	var envelope NotificationEnvelope
+	json.Unmarshal([]byte(`{"v":1,"format":"apple","payload":"{}","headers":{"X-Apns-Priority":"5","X-Apns-Collapse-Id":"score"}}`), &envelope)
+
+	if _, err := nhub.Send(context.Background(), envelope.Notification, nil, append(envelope.SendOptions(), BroadcastAll())...); err != nil {
+		t.Fatalf(errfmt, "send error", nil, err)
+	}
+
+	if header.Get("X-Apns-Priority") != "5" || header.Get("X-Apns-Collapse-Id") != "score" {
+		t.Errorf(errfmt, "envelope headers", envelope.Headers, header)
+	}
+}
+
+func Test_NotificationHubSendAddressingHeaders(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var header http.Header
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		header = req.Header
+		return nil, nil
+	})
+	WithDefaultHeaders(map[string]string{"ServiceBusNotification-Tags": "everyone", "X-Default": "1"})(nhub)
+
+	var envelope NotificationEnvelope
+	json.Unmarshal([]byte(`{"v":1,"format":"template","payload":"{}","headers":{"servicebusnotification-tags":"admins","ServiceBusNotification-Format":"gcm","ServiceBusNotification-DeviceHandle":"handle","X-Custom":"1"}}`), &envelope)
+
+	if _, err := nhub.Send(context.Background(), envelope.Notification, []string{"news"}, envelope.SendOptions()...); err != nil {
+		t.Fatalf(errfmt, "send error", nil, err)
+	}
+	if tags := header.Get("ServiceBusNotification-Tags"); tags != "news" {
+		t.Errorf(errfmt, "tags", "news", tags)
+	}
+	if format := header.Get("ServiceBusNotification-Format"); format != string(Template) {
+		t.Errorf(errfmt, "format", Template, format)
+	}
+	if handle := header.Get("ServiceBusNotification-DeviceHandle"); handle != "" {
+		t.Errorf(errfmt, "device handle", "none", handle)
+	}
+	if header.Get("X-Custom") != "1" || header.Get("X-Default") != "1" {
+		t.Errorf(errfmt, "other headers", "kept", header)
+	}
+
+	if _, err := nhub.Send(context.Background(), envelope.Notification, nil, BroadcastAll()); err != nil {
+		t.Fatalf(errfmt, "broadcast error", nil, err)
+	}
+	if tags := header.Get("ServiceBusNotification-Tags"); tags != "" {
+		t.Errorf(errfmt, "broadcast tags", "none", tags)
+	}
+}
@@ -0,0 +1,55 @@
+package notihub
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrEnvironmentMismatch is returned when a send is attempted on
+// a hub which does not match the environment configured by WithEnvironmentGuard
+var ErrEnvironmentMismatch = errors.New("notification hub does not match the configured environment")
+
+// WithEnvironmentGuard refuses all sends unless the hub name ends with environment
+// as whole labels, e.g. "prod" or "-prod" match "app-prod" but not "app-nonprod",
+// or environment is one of the hub default tags.
+// It protects against test notifications reaching production users
+func WithEnvironmentGuard(environment string) HubOption {
+	return func(h *NotificationHub) {
+		h.environment = environment
+	}
+}
+
+// checkEnvironment verifies the hub matches the configured environment
+func (h *NotificationHub) checkEnvironment() error {
+	if h.environment == "" {
+		return nil
+	}
+
+	if hasLabelSuffix(h.hubPath(), h.environment) {
+		return nil
+	}
+
+	for _, tag := range h.defaultTags {
+		if tag == h.environment {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: expected '%s', hub '%s' with default tags %v", ErrEnvironmentMismatch, h.environment, h.hubURL.Path, h.defaultTags)
+}
+
+// hasLabelSuffix reports whether name ends with suffix starting at a label boundary,
+// labels being separated by '-', '_', '.' and '/'
+func hasLabelSuffix(name, suffix string) bool {
+	if !strings.HasSuffix(name, suffix) {
+		return false
+	}
+
+	isSeparator := func(c byte) bool {
+		return strings.IndexByte("-_./", c) >= 0
+	}
+	rest := name[:len(name)-len(suffix)]
+
+	return rest == "" || isSeparator(rest[len(rest)-1]) || (suffix != "" && isSeparator(suffix[0]))
+}
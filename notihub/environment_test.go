@@ -0,0 +1,86 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func Test_NotificationHubEnvironmentGuard(t *testing.T) {
+	connectionString := "Endpoint=sb://testhub-ns.servicebus.windows.net/;SharedAccessKeyName=name;SharedAccessKey=key"
+	notification := &Notification{Template, []byte("{}")}
+
+	testCases := []struct {
+		hubPath   string
+		opts      []HubOption
+		allowSend bool
+	}{
+		{
+			hubPath:   "app-dev",
+			allowSend: true,
+		},
+		{
+			hubPath:   "app-prod",
+			opts:      []HubOption{WithEnvironmentGuard("-prod")},
+			allowSend: true,
+		},
+		{
+			hubPath:   "app-dev",
+			opts:      []HubOption{WithEnvironmentGuard("-prod")},
+			allowSend: false,
+		},
+		{
+			hubPath:   "app-prod",
+			opts:      []HubOption{WithEnvironmentGuard("prod")},
+			allowSend: true,
+		},
+		{
+			hubPath:   "app-nonprod",
+			opts:      []HubOption{WithEnvironmentGuard("prod")},
+			allowSend: false,
+		},
+		{
+			hubPath:   "app-nonprod",
+			opts:      []HubOption{WithEnvironmentGuard("-prod")},
+			allowSend: false,
+		},
+		{
+			hubPath:   "app",
+			opts:      []HubOption{WithDefaultTags("env:prod"), WithEnvironmentGuard("env:prod")},
+			allowSend: true,
+		},
+		{
+			hubPath:   "app",
+			opts:      []HubOption{WithDefaultTags("env:dev"), WithEnvironmentGuard("env:prod")},
+			allowSend: false,
+		},
+	}
+
+	for i, testCase := range testCases {
+		requests := 0
+		nhub := NewNotificationHub(connectionString, testCase.hubPath, nil, testCase.opts...)
+		nhub.client = &mockHubHttpClient{execFunc: func(*http.Request) ([]byte, error) {
+			requests++
+			return nil, nil
+		}}
+
+		_, sendErr := nhub.Send(context.Background(), notification, nil, BroadcastAll())
+		_, directErr := nhub.SendDirect(context.Background(), notification, "handle")
+		_, scheduleErr := nhub.Schedule(context.Background(), notification, nil, time.Now().Add(time.Hour), BroadcastAll())
+
+		for _, err := range []error{sendErr, directErr, scheduleErr} {
+			if testCase.allowSend && err != nil {
+				t.Errorf("test case %d. Expected no error, got: %v", i, err)
+			}
+			if !testCase.allowSend && !errors.Is(err, ErrEnvironmentMismatch) {
+				t.Errorf("test case %d. Expected error: %v, got: %v", i, ErrEnvironmentMismatch, err)
+			}
+		}
+
+		if !testCase.allowSend && requests != 0 {
+			t.Errorf("test case %d. Expected no requests, got: %d", i, requests)
+		}
+	}
+}
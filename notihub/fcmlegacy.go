@@ -0,0 +1,43 @@
+package notihub
+
+import "fmt"
+
+// WithFcmV1Conversion converts gcm notifications to fcmv1 before they are sent,
+// so that producers of legacy FCM payloads keep working after the hub is switched to fcmv1
+func WithFcmV1Conversion() HubOption {
+	return func(h *NotificationHub) {
+		h.fcmV1Convert = true
+	}
+}
+
+// ConvertToFcmV1 returns fcmv1 notification equivalent to gcm notification n,
+// fcmv1 notifications are returned as is. See ConvertGcmPayload for the conversion rules
+func ConvertToFcmV1(n *Notification) (*Notification, error) {
+	return convertToFcmV1(DefaultEncoder, n)
+}
+
+// convertToFcmV1 converts n like ConvertToFcmV1, marshaling the payload with encoder
+func convertToFcmV1(encoder Encoder, n *Notification) (*Notification, error) {
+	switch n.Format {
+	case FcmV1Format:
+		return n, nil
+	case AndroidFormat:
+		payload, err := convertGcmPayload(encoder, n.Payload)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Notification{FcmV1Format, payload}, nil
+	}
+
+	return nil, fmt.Errorf("format '%s' is not gcm or fcmv1", n.Format)
+}
+
+// fcmV1Notification converts gcm notification n when the hub converts legacy FCM payloads
+func (h *NotificationHub) fcmV1Notification(n *Notification) (*Notification, error) {
+	if !h.fcmV1Convert || n.Format != AndroidFormat {
+		return n, nil
+	}
+
+	return convertToFcmV1(h.encoder(), n)
+}
@@ -0,0 +1,90 @@
+package notihub
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func Test_NotificationHubFcmV1Conversion(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var format, payload string
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		format = req.Header.Get("ServiceBusNotification-Format")
+		b, _ := ioutil.ReadAll(req.Body)
+		payload = string(b)
+		return []byte(testNotificationOutcome), nil
+	})
+	legacy := &Notification{AndroidFormat, []byte(`{"priority":"high","data":{"msg":"hi"}}`)}
+	converted := `{"message":{"android":{"priority":"HIGH"},"data":{"msg":"hi"}}}`
+
+	if _, err := nhub.Send(context.Background(), legacy, []string{"news"}); err != nil || format != string(AndroidFormat) || payload != string(legacy.Payload) {
+		t.Errorf(errfmt, "send without conversion", string(legacy.Payload), format+" "+payload)
+	}
+
+	WithFcmV1Conversion()(nhub)
+
+	sends := map[string]func() error{
+		"send": func() error {
+			_, err := nhub.Send(context.Background(), legacy, []string{"news"})
+			return err
+		},
+		"direct send": func() error {
+			_, err := nhub.SendDirect(context.Background(), legacy, "handle")
+			return err
+		},
+		"test send": func() error {
+			_, err := nhub.SendTest(context.Background(), legacy, []string{"news"})
+			return err
+		},
+	}
+
+	for name, send := range sends {
+		format, payload = "", ""
+		if err := send(); err != nil {
+			t.Errorf(errfmt, name+" error", nil, err)
+			continue
+		}
+		if format != string(FcmV1Format) || payload != converted {
+			t.Errorf(errfmt, name+" notification", converted, format+" "+payload)
+		}
+	}
+
+	apple := &Notification{AppleFormat, []byte(`{"aps":{"alert":"hi"}}`)}
+	if _, err := nhub.Send(context.Background(), apple, []string{"news"}); err != nil || format != string(AppleFormat) {
+		t.Errorf(errfmt, "apple notification format", AppleFormat, format)
+	}
+
+	if _, err := nhub.Send(context.Background(), &Notification{AndroidFormat, []byte(`{"to":"handle"}`)}, []string{"news"}); err == nil {
+		t.Errorf(errfmt, "unconvertible payload error", "error", err)
+	}
+	if _, err := ConvertToFcmV1(apple); err == nil {
+		t.Errorf(errfmt, "apple conversion error", "error", err)
+	}
+}
+
+func Test_NotificationHubFcmV1ConversionEncoder(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var payload string
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		b, _ := ioutil.ReadAll(req.Body)
+		payload = string(b)
+		return []byte(testNotificationOutcome), nil
+	})
+	encoder := &countingEncoder{}
+	WithEncoder(encoder)(nhub)
+	WithFcmV1Conversion()(nhub)
+
+	legacy := &Notification{AndroidFormat, []byte(`{"data":{"count":2}}`)}
+	converted := `{"message":{"data":{"count":"2"}}}`
+
+	if _, err := nhub.Send(context.Background(), legacy, []string{"news"}); err != nil || payload != converted {
+		t.Errorf(errfmt, "converted payload", converted, payload)
+	}
+	if encoder.marshals != 2 {
+		t.Errorf(errfmt, "hub encoder marshals of data value and message", 2, encoder.marshals)
+	}
+}
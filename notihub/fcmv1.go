@@ -0,0 +1,342 @@
+package notihub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrFcmV1VerificationFailed is returned when the test send of a migrated notification is not delivered
+var ErrFcmV1VerificationFailed = errors.New("fcmv1 verification failed")
+
+// gcmAndroidNotificationFields maps legacy notification fields
+// to fields of android.notification of FCM v1 message
+var gcmAndroidNotificationFields = map[string]string{
+	"icon":               "icon",
+	"sound":              "sound",
+	"tag":                "tag",
+	"color":              "color",
+	"click_action":       "click_action",
+	"body_loc_key":       "body_loc_key",
+	"body_loc_args":      "body_loc_args",
+	"title_loc_key":      "title_loc_key",
+	"title_loc_args":     "title_loc_args",
+	"android_channel_id": "channel_id",
+}
+
+type (
+	// FcmV1MigrationResult is the outcome of migrating a single installation or registration.
+	// Skipped is set for installations and registrations of other platforms
+	FcmV1MigrationResult struct {
+		// Id is the installation or registration id
+		Id      string
+		Skipped bool
+		Err     error
+	}
+
+	fcmV1RegistrationEntry struct {
+		XMLName xml.Name                 `xml:"http://www.w3.org/2005/Atom entry"`
+		Content fcmV1RegistrationContent `xml:"content"`
+	}
+
+	fcmV1RegistrationContent struct {
+		Type         string `xml:"type,attr"`
+		Registration fcmV1RegistrationXML
+	}
+
+	fcmV1RegistrationXML struct {
+		XMLName             xml.Name
+		Tags                string `xml:"Tags,omitempty"`
+		FcmV1RegistrationId string `xml:"FcmV1RegistrationId"`
+		BodyTemplate        string `xml:"BodyTemplate,omitempty"`
+		TemplateName        string `xml:"TemplateName,omitempty"`
+	}
+)
+
+// ConvertGcmPayload converts legacy GCM payload to FCM v1 message payload:
+//   - title, body and image stay in notification, other notification fields move to android.notification
+//   - collapse_key and restricted_package_name move to android
+//   - priority is upper cased and time_to_live seconds become android.ttl
+//   - data values which are not strings are JSON encoded, as FCM v1 accepts string values only
+//
+// Targeting fields like to and registration_ids, and unknown fields are rejected,
+// so that nothing is lost silently. Payloads already in FCM v1 format are returned as is
+func ConvertGcmPayload(payload []byte) ([]byte, error) {
+	return convertGcmPayload(DefaultEncoder, payload)
+}
+
+// convertGcmPayload converts payload like ConvertGcmPayload, marshaling it with encoder
+func convertGcmPayload(encoder Encoder, payload []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+
+	var legacy map[string]interface{}
+	if err := dec.Decode(&legacy); err != nil {
+		return nil, fmt.Errorf("failed to parse gcm payload: %w", err)
+	}
+
+	if _, ok := legacy["message"]; ok && len(legacy) == 1 {
+		return payload, nil
+	}
+
+	message := map[string]interface{}{}
+	android := map[string]interface{}{}
+
+	for key, value := range legacy {
+		switch key {
+		case "notification":
+			notification, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("gcm payload notification must be an object")
+			}
+			if err := convertGcmNotification(notification, message, android); err != nil {
+				return nil, err
+			}
+		case "data":
+			data, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("gcm payload data must be an object")
+			}
+			converted, err := convertGcmData(encoder, data)
+			if err != nil {
+				return nil, err
+			}
+			message["data"] = converted
+		case "collapse_key", "restricted_package_name":
+			android[key] = value
+		case "priority":
+			priority, ok := value.(string)
+			if !ok || priority != "high" && priority != "normal" {
+				return nil, fmt.Errorf("gcm payload priority must be high or normal, got %v", value)
+			}
+			android["priority"] = map[string]string{"high": "HIGH", "normal": "NORMAL"}[priority]
+		case "time_to_live":
+			number, ok := value.(json.Number)
+			ttl, err := number.Int64()
+			if !ok || err != nil || ttl < 0 {
+				return nil, fmt.Errorf("gcm payload time_to_live must be non-negative seconds, got %v", value)
+			}
+			android["ttl"] = strconv.FormatInt(ttl, 10) + "s"
+		default:
+			return nil, fmt.Errorf("gcm payload field '%s' has no fcmv1 equivalent", key)
+		}
+	}
+
+	if len(android) > 0 {
+		message["android"] = android
+	}
+
+	return encoder.Marshal(map[string]interface{}{"message": message})
+}
+
+// convertGcmNotification splits legacy notification into notification and android.notification of message
+func convertGcmNotification(notification, message, android map[string]interface{}) error {
+	common := map[string]interface{}{}
+	specific := map[string]interface{}{}
+
+	for key, value := range notification {
+		switch key {
+		case "title", "body", "image":
+			common[key] = value
+		default:
+			field, ok := gcmAndroidNotificationFields[key]
+			if !ok {
+				return fmt.Errorf("gcm payload notification field '%s' has no fcmv1 equivalent", key)
+			}
+			specific[field] = value
+		}
+	}
+
+	if len(common) > 0 {
+		message["notification"] = common
+	}
+	if len(specific) > 0 {
+		android["notification"] = specific
+	}
+
+	return nil
+}
+
+// convertGcmData encodes data values which are not strings as JSON with encoder
+func convertGcmData(encoder Encoder, data map[string]interface{}) (map[string]string, error) {
+	converted := make(map[string]string, len(data))
+	for key, value := range data {
+		if s, ok := value.(string); ok {
+			converted[key] = s
+			continue
+		}
+
+		b, err := encoder.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		converted[key] = string(b)
+	}
+
+	return converted, nil
+}
+
+// MigrateInstallationsToFcmV1 moves gcm installations to fcmv1, converting bodies of their templates.
+// Installations are migrated one at a time, so that the migration does not compete with sends.
+// Results are returned in the order of ids even when some of the installations fail
+func (h *NotificationHub) MigrateInstallationsToFcmV1(ctx context.Context, ids []string) ([]FcmV1MigrationResult, error) {
+	results := make([]FcmV1MigrationResult, 0, len(ids))
+	for _, id := range ids {
+		results = append(results, h.migrateInstallationToFcmV1(ctx, id))
+	}
+
+	if err := fcmV1MigrationError(results, "installations"); err != nil {
+		return results, fmt.Errorf("NotificationHub.MigrateInstallationsToFcmV1: %w", err)
+	}
+
+	return results, nil
+}
+
+// migrateInstallationToFcmV1 migrates a single installation
+func (h *NotificationHub) migrateInstallationToFcmV1(ctx context.Context, id string) FcmV1MigrationResult {
+	result := FcmV1MigrationResult{Id: id}
+
+	installation, err := h.getInstallation(ctx, id)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	if installation.Platform != PlatformGcm {
+		result.Skipped = true
+		return result
+	}
+
+	for name, template := range installation.Templates {
+		body, err := convertGcmPayload(h.encoder(), []byte(template.Body))
+		if err != nil {
+			result.Err = fmt.Errorf("template '%s': %w", name, err)
+			return result
+		}
+		template.Body = string(body)
+		installation.Templates[name] = template
+	}
+	installation.Platform = PlatformFcmV1
+
+	result.Err = h.putInstallation(ctx, installation)
+	return result
+}
+
+// MigrateRegistrationsToFcmV1 replaces gcm registrations, all or those with tag when set,
+// by fcmv1 registrations of the same id, converting template bodies.
+// Results are returned in the order of listing even when some of the registrations fail
+func (h *NotificationHub) MigrateRegistrationsToFcmV1(ctx context.Context, tag string) ([]FcmV1MigrationResult, error) {
+	registrations, err := h.registrationPager(tag).All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.MigrateRegistrationsToFcmV1: %w", err)
+	}
+
+	results := make([]FcmV1MigrationResult, 0, len(registrations))
+	for _, r := range registrations {
+		result := FcmV1MigrationResult{Id: r.RegistrationId}
+		if r.Platform() != PlatformGcm {
+			result.Skipped = true
+		} else {
+			result.Err = h.putFcmV1Registration(ctx, r)
+		}
+		results = append(results, result)
+	}
+
+	if err := fcmV1MigrationError(results, "registrations"); err != nil {
+		return results, fmt.Errorf("NotificationHub.MigrateRegistrationsToFcmV1: %w", err)
+	}
+
+	return results, nil
+}
+
+// putFcmV1Registration overwrites gcm registration r by fcmv1 registration of the same handle and tags
+func (h *NotificationHub) putFcmV1Registration(ctx context.Context, r RegistrationDescription) error {
+	registration := fcmV1RegistrationXML{
+		XMLName:             xml.Name{Space: servicebusNamespace, Local: "FcmV1" + registrationDescriptionSuffix},
+		Tags:                r.Tags,
+		FcmV1RegistrationId: r.GcmRegistrationId,
+	}
+
+	if r.IsTemplate() {
+		body, err := convertGcmPayload(h.encoder(), []byte(r.BodyTemplate))
+		if err != nil {
+			return fmt.Errorf("template: %w", err)
+		}
+
+		registration.XMLName.Local = "FcmV1Template" + registrationDescriptionSuffix
+		registration.BodyTemplate = string(body)
+		registration.TemplateName = r.TemplateName
+	}
+
+	body, err := xml.Marshal(fcmV1RegistrationEntry{
+		Content: fcmV1RegistrationContent{Type: "application/xml", Registration: registration},
+	})
+	if err != nil {
+		return err
+	}
+
+	relPath, err := registrationPath(r.RegistrationId)
+	if err != nil {
+		return err
+	}
+
+	req, err := h.newRequest(withSensitiveBodies(ctx), "PUT", relPath, h.hubURL.Query(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", atomEntryType)
+
+	_, err = h.exec(req, nil)
+	return err
+}
+
+// fcmV1MigrationError summarizes failed results of kind, nil when none failed
+func fcmV1MigrationError(results []FcmV1MigrationResult, kind string) error {
+	failed := 0
+	var first *FcmV1MigrationResult
+	for i := range results {
+		if results[i].Err == nil {
+			continue
+		}
+		if first == nil {
+			first = &results[i]
+		}
+		failed++
+	}
+
+	if first == nil {
+		return nil
+	}
+
+	return fmt.Errorf("%d of %d %s failed, first: %s: %w", failed, len(results), kind, first.Id, first.Err)
+}
+
+// VerifyFcmV1Migration converts gcm notification n to fcmv1 and test sends it to at most 10
+// registrations of orTags. ErrFcmV1VerificationFailed is returned unless the hub
+// reports at least one delivery and no failures
+func (h *NotificationHub) VerifyFcmV1Migration(ctx context.Context, n *Notification, orTags []string, opts ...SendOption) (*NotificationOutcome, error) {
+	converted, err := convertToFcmV1(h.encoder(), n)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.VerifyFcmV1Migration: %w", err)
+	}
+
+	b, err := h.sendTest(ctx, converted, orTags, newSendOptions(opts))
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.VerifyFcmV1Migration: %w", err)
+	}
+
+	outcome, err := ParseNotificationOutcome(b)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.VerifyFcmV1Migration: %w", err)
+	}
+
+	if outcome.Success == 0 || outcome.Failure > 0 {
+		return outcome, fmt.Errorf("NotificationHub.VerifyFcmV1Migration: %w: %d delivered, %d failed", ErrFcmV1VerificationFailed, outcome.Success, outcome.Failure)
+	}
+
+	return outcome, nil
+}
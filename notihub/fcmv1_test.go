@@ -0,0 +1,177 @@
+package notihub
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func Test_ConvertGcmPayload(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	testPatterns := []struct {
+		name     string
+		legacy   string
+		expected string
+	}{
+		{"data", `{"data":{"msg":"hi","count":2,"nested":{"a":true}}}`, `{"message":{"data":{"count":"2","msg":"hi","nested":"{\"a\":true}"}}}`},
+		{
+			"notification",
+			`{"notification":{"title":"t","body":"b","icon":"ic","android_channel_id":"news","click_action":"OPEN"}}`,
+			`{"message":{"android":{"notification":{"channel_id":"news","click_action":"OPEN","icon":"ic"}},"notification":{"body":"b","title":"t"}}}`,
+		},
+		{
+			"delivery options",
+			`{"priority":"high","time_to_live":3600,"collapse_key":"updates","data":{"msg":"$(msg)"}}`,
+			`{"message":{"android":{"collapse_key":"updates","priority":"HIGH","ttl":"3600s"},"data":{"msg":"$(msg)"}}}`,
+		},
+		{"already fcmv1", `{"message":{"data":{"msg":"hi"}}}`, `{"message":{"data":{"msg":"hi"}}}`},
+		{"targeting", `{"to":"handle","data":{}}`, ""},
+		{"priority", `{"priority":"urgent"}`, ""},
+		{"ttl", `{"time_to_live":"1h"}`, ""},
+		{"notification field", `{"notification":{"badge":"1"}}`, ""},
+		{"invalid", `{"data":$(data)}`, ""},
+	}
+
+	for _, testData := range testPatterns {
+		b, err := ConvertGcmPayload([]byte(testData.legacy))
+		if testData.expected == "" {
+			if err == nil {
+				t.Errorf(errfmt, testData.name+" error", "error", string(b))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf(errfmt, testData.name+" error", nil, err)
+			continue
+		}
+		if string(b) != testData.expected {
+			t.Errorf(errfmt, testData.name+" payload", testData.expected, string(b))
+		}
+	}
+}
+
+func Test_NotificationHubMigrateInstallationsToFcmV1(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newInstallationServer(
+		Installation{InstallationId: "gcm", Platform: PlatformGcm, PushChannel: "handle", Templates: map[string]InstallationTemplate{
+			"news": {Body: `{"data":{"msg":"$(msg)"}}`, Tags: []string{"news"}},
+		}},
+		Installation{InstallationId: "apns", Platform: PlatformApns, PushChannel: "token"},
+		Installation{InstallationId: "unconvertible", Platform: PlatformGcm, PushChannel: "handle", Templates: map[string]InstallationTemplate{
+			"badge": {Body: `{"data":{"count":$(count)}}`},
+		}},
+	)
+	defer server.Close()
+
+	results, err := server.hub().MigrateInstallationsToFcmV1(context.Background(), []string{"gcm", "apns", "unconvertible", "missing"})
+	if err == nil || !strings.Contains(err.Error(), "2 of 4 installations failed, first: unconvertible") {
+		t.Errorf(errfmt, "migration error", "2 of 4 failed", err)
+	}
+	if len(results) != 4 || results[0].Err != nil || results[0].Skipped || !results[1].Skipped || results[2].Err == nil || results[3].Err == nil {
+		t.Fatalf(errfmt, "results", "migrated, skipped, failed, failed", results)
+	}
+
+	migrated := server.installations["gcm"]
+	if migrated.Platform != PlatformFcmV1 || migrated.Templates["news"].Body != `{"message":{"data":{"msg":"$(msg)"}}}` {
+		t.Errorf(errfmt, "migrated installation", "fcmv1 with converted template", migrated)
+	}
+	if server.installations["unconvertible"].Platform != PlatformGcm || server.writes != 1 {
+		t.Errorf(errfmt, "writes", 1, server.writes)
+	}
+}
+
+func Test_NotificationHubMigrateRegistrationsToFcmV1(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	feed := `<feed xmlns="http://www.w3.org/2005/Atom">` +
+		`<entry><content type="application/xml"><GcmRegistrationDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><Tags>news</Tags><RegistrationId>1</RegistrationId><GcmRegistrationId>handle-1</GcmRegistrationId></GcmRegistrationDescription></content></entry>` +
+		`<entry><content type="application/xml"><AppleRegistrationDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><RegistrationId>2</RegistrationId><DeviceToken>token</DeviceToken></AppleRegistrationDescription></content></entry>` +
+		`<entry><content type="application/xml"><GcmTemplateRegistrationDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><RegistrationId>3</RegistrationId><GcmRegistrationId>handle-3</GcmRegistrationId><BodyTemplate><![CDATA[{"data":{"msg":"$(msg)"}}]]></BodyTemplate><TemplateName>greeting</TemplateName></GcmTemplateRegistrationDescription></content></entry>` +
+		`</feed>`
+
+	puts := map[string][]byte{}
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		if req.Method == "GET" {
+			return []byte(feed), nil
+		}
+		puts[req.URL.Path], _ = ioutil.ReadAll(req.Body)
+		return nil, nil
+	})
+
+	results, err := nhub.MigrateRegistrationsToFcmV1(context.Background(), "")
+	if err != nil {
+		t.Fatalf(errfmt, "migration error", nil, err)
+	}
+	if len(results) != 3 || results[0].Skipped || !results[1].Skipped || results[2].Skipped {
+		t.Errorf(errfmt, "results", "migrated, skipped, migrated", results)
+	}
+	if len(puts) != 2 {
+		t.Fatalf(errfmt, "registration writes", 2, len(puts))
+	}
+
+	var registrations []RegistrationDescription
+	for _, p := range []string{"/testPath/registrations/1", "/testPath/registrations/3"} {
+		dec := NewRegistrationDecoder(strings.NewReader(string(puts[p])))
+		if !dec.Next() {
+			t.Fatalf(errfmt, p+" registration", "fcmv1 registration", string(puts[p]))
+		}
+		registrations = append(registrations, dec.Registration())
+	}
+
+	if r := registrations[0]; r.Type != "FcmV1RegistrationDescription" || r.FcmV1RegistrationId != "handle-1" || r.Tags != "news" || r.Format() != FcmV1Format {
+		t.Errorf(errfmt, "native registration", "fcmv1 handle-1 with tags", r)
+	}
+	if r := registrations[1]; !r.IsTemplate() || r.PnsHandle() != "handle-3" || r.TemplateName != "greeting" || r.BodyTemplate != `{"message":{"data":{"msg":"$(msg)"}}}` {
+		t.Errorf(errfmt, "template registration", "fcmv1 template with converted body", r)
+	}
+}
+
+func Test_NotificationHubVerifyFcmV1Migration(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var format, payload string
+	response := testNotificationOutcome
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		format = req.Header.Get("ServiceBusNotification-Format")
+		b, _ := ioutil.ReadAll(req.Body)
+		payload = string(b)
+		return []byte(response), nil
+	})
+
+	n := &Notification{AndroidFormat, []byte(`{"data":{"msg":"hi"}}`)}
+	outcome, err := nhub.VerifyFcmV1Migration(context.Background(), n, []string{"user:1"})
+	if err != nil || outcome.Success != 1 {
+		t.Fatalf(errfmt, "verification", "1 delivery", err)
+	}
+	if format != string(FcmV1Format) || payload != `{"message":{"data":{"msg":"hi"}}}` {
+		t.Errorf(errfmt, "test send", "fcmv1 converted payload", format+" "+payload)
+	}
+
+	response = `<NotificationOutcome xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><Success>0</Success><Failure>1</Failure></NotificationOutcome>`
+	if _, err := nhub.VerifyFcmV1Migration(context.Background(), n, []string{"user:1"}); !errors.Is(err, ErrFcmV1VerificationFailed) {
+		t.Errorf(errfmt, "failed delivery error", ErrFcmV1VerificationFailed, err)
+	}
+
+	if _, err := nhub.VerifyFcmV1Migration(context.Background(), &Notification{AppleFormat, []byte(`{}`)}, []string{"user:1"}); err == nil {
+		t.Errorf(errfmt, "apple format error", "error", err)
+	}
+}
+
+func Test_FcmV1RegistrationXML(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	b, err := xml.Marshal(fcmV1RegistrationEntry{Content: fcmV1RegistrationContent{
+		Type:         "application/xml",
+		Registration: fcmV1RegistrationXML{XMLName: xml.Name{Space: servicebusNamespace, Local: "FcmV1RegistrationDescription"}, FcmV1RegistrationId: "a&b"},
+	}})
+	expected := `<entry xmlns="http://www.w3.org/2005/Atom"><content type="application/xml"><FcmV1RegistrationDescription xmlns="` + servicebusNamespace + `"><FcmV1RegistrationId>a&amp;b</FcmV1RegistrationId></FcmV1RegistrationDescription></content></entry>`
+	if err != nil || string(b) != expected {
+		t.Errorf(errfmt, "registration entry", expected, string(b))
+	}
+}
@@ -0,0 +1,228 @@
+package notihub
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type (
+	// FeedbackRecord is a failed delivery reported in a PNS feedback file
+	FeedbackRecord struct {
+		Platform       Platform
+		PnsHandle      string
+		RegistrationId string
+		InstallationId string
+		Error          string
+		Time           time.Time
+	}
+
+	// FeedbackReader reads records one at a time from a feedback file,
+	// either CSV with a header row or JSON lines
+	FeedbackReader struct {
+		next    func() (map[string]string, error)
+		line    int
+		current FeedbackRecord
+		err     error
+	}
+)
+
+// feedbackFieldAliases maps lower case column names to record fields
+var feedbackFieldAliases = map[string]string{
+	"platform":       "platform",
+	"pnstype":        "platform",
+	"pnshandle":      "pnshandle",
+	"handle":         "pnshandle",
+	"devicehandle":   "pnshandle",
+	"registrationid": "registrationid",
+	"installationid": "installationid",
+	"error":          "error",
+	"errorcode":      "error",
+	"outcome":        "error",
+	"time":           "time",
+	"timestamp":      "time",
+}
+
+// NewFeedbackReader returns reader of the feedback file r,
+// the format is detected from the first non blank character
+func NewFeedbackReader(r io.Reader) *FeedbackReader {
+	br := bufio.NewReader(r)
+	fr := &FeedbackReader{}
+
+	first, err := peekNonSpace(br)
+	switch {
+	case err == io.EOF:
+		fr.next = func() (map[string]string, error) { return nil, io.EOF }
+	case err != nil:
+		fr.err = err
+	case first == '{':
+		fr.next = jsonLinesFields(br)
+	default:
+		fr.next = csvFields(br)
+	}
+
+	return fr
+}
+
+// Next advances the reader to the next record.
+// It returns false when input is exhausted or reading fails
+func (r *FeedbackReader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+
+	fields, err := r.next()
+	if err == io.EOF {
+		return false
+	}
+	r.line++
+	if err == nil {
+		r.current, err = feedbackRecord(fields)
+	}
+	if err != nil {
+		r.err = fmt.Errorf("feedback record %d: %w", r.line, err)
+		return false
+	}
+
+	return true
+}
+
+// Record returns the record read by the last Next call
+func (r *FeedbackReader) Record() FeedbackRecord {
+	return r.current
+}
+
+// Err returns the first reading error
+func (r *FeedbackReader) Err() error {
+	return r.err
+}
+
+// HandleExpired identifies whether the handle will never be valid again
+// and the registration or installation can be removed
+func (r FeedbackRecord) HandleExpired() bool {
+	return r.PnsError().Remediation == RemediationDeleteHandle
+}
+
+// feedbackRecord builds record of fields keyed by lower case column names
+func feedbackRecord(fields map[string]string) (FeedbackRecord, error) {
+	values := make(map[string]string, len(fields))
+	for name, value := range fields {
+		if field, ok := feedbackFieldAliases[strings.ToLower(strings.TrimSpace(name))]; ok {
+			values[field] = strings.TrimSpace(value)
+		}
+	}
+
+	r := FeedbackRecord{
+		PnsHandle:      values["pnshandle"],
+		RegistrationId: values["registrationid"],
+		InstallationId: values["installationid"],
+		Error:          values["error"],
+	}
+
+	if r.PnsHandle == "" && r.RegistrationId == "" && r.InstallationId == "" {
+		return r, fmt.Errorf("no handle, registration or installation id")
+	}
+
+	if p := values["platform"]; p != "" {
+		platform, err := ParsePlatform(p)
+		if err != nil {
+			if platform, err = PlatformForFormat(NotificationFormat(strings.ToLower(p))); err != nil {
+				return r, fmt.Errorf("unknown platform '%s'", p)
+			}
+		}
+		r.Platform = platform
+	}
+
+	if t := values["time"]; t != "" {
+		parsed, err := parseHubTime(t)
+		if err != nil {
+			return r, err
+		}
+		r.Time = parsed
+	}
+
+	return r, nil
+}
+
+// peekNonSpace returns the first non blank character without consuming it,
+// skipping byte order mark written by some blob producers
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	if bom, err := br.Peek(3); err == nil && bytes.Equal(bom, []byte{0xef, 0xbb, 0xbf}) {
+		br.Discard(3)
+	}
+
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b)) {
+			return b, br.UnreadByte()
+		}
+	}
+}
+
+// jsonLinesFields returns function reading objects of JSON lines, skipping blank lines
+func jsonLinesFields(br *bufio.Reader) func() (map[string]string, error) {
+	return func() (map[string]string, error) {
+		for {
+			line, err := br.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) == 0 {
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			var raw map[string]interface{}
+			if jerr := json.Unmarshal(line, &raw); jerr != nil {
+				return nil, jerr
+			}
+
+			fields := make(map[string]string, len(raw))
+			for name, value := range raw {
+				if value != nil {
+					fields[name] = fmt.Sprint(value)
+				}
+			}
+
+			return fields, nil
+		}
+	}
+}
+
+// csvFields returns function reading rows of CSV keyed by the header row
+func csvFields(br *bufio.Reader) func() (map[string]string, error) {
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var header []string
+	return func() (map[string]string, error) {
+		if header == nil {
+			var err error
+			if header, err = cr.Read(); err != nil {
+				return nil, err
+			}
+		}
+
+		row, err := cr.Read()
+		if err != nil {
+			return nil, err
+		}
+
+		fields := make(map[string]string, len(header))
+		for i, name := range header {
+			if i < len(row) {
+				fields[name] = row[i]
+			}
+		}
+
+		return fields, nil
+	}
+}
@@ -0,0 +1,106 @@
+package notihub
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readFeedback(t *testing.T, input string) ([]FeedbackRecord, error) {
+	t.Helper()
+
+	r := NewFeedbackReader(strings.NewReader(input))
+	var records []FeedbackRecord
+	for r.Next() {
+		records = append(records, r.Record())
+	}
+
+	return records, r.Err()
+}
+
+func Test_FeedbackReader(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	expected := []FeedbackRecord{
+		{Platform: PlatformApns, PnsHandle: "token-1", RegistrationId: "reg-1", Error: "InvalidToken", Time: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{Platform: PlatformWns, PnsHandle: "https://channel", InstallationId: "inst-2", Error: "ChannelExpired"},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name: "csv",
+			input: "Platform,PnsHandle,RegistrationId,InstallationId,ErrorCode,Timestamp\n" +
+				"apns,token-1,reg-1,,InvalidToken,2020-01-01T12:00:00Z\n" +
+				"windows, https://channel,,inst-2,ChannelExpired,\n",
+		},
+		{
+			name: "csv with byte order mark",
+			input: "\xef\xbb\xbfpnstype,handle,registrationid,installationid,error,time\r\n" +
+				"APNS,token-1,reg-1,,InvalidToken,2020-01-01T12:00:00Z\r\n" +
+				"wns,https://channel,,inst-2,ChannelExpired,\r\n",
+		},
+		{
+			name: "json lines",
+			input: `{"platform":"apple","pnsHandle":"token-1","registrationId":"reg-1","error":"InvalidToken","timestamp":"2020-01-01T12:00:00Z"}` + "\n\n" +
+				`{"platform":"wns","pnsHandle":"https://channel","installationId":"inst-2","error":"ChannelExpired","extra":null}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			records, err := readFeedback(t, test.input)
+			if err != nil {
+				t.Fatalf(errfmt, "read error", nil, err)
+			}
+			if !reflect.DeepEqual(records, expected) {
+				t.Errorf(errfmt, "records", expected, records)
+			}
+		})
+	}
+
+	if !expected[0].HandleExpired() || !expected[1].HandleExpired() {
+		t.Errorf(errfmt, "handles expired", true, false)
+	}
+	if (FeedbackRecord{Error: "Throttled"}).HandleExpired() {
+		t.Errorf(errfmt, "throttled handle expired", false, true)
+	}
+	if (FeedbackRecord{Platform: PlatformApns, Error: "BadDeviceToken"}).HandleExpired() {
+		t.Errorf(errfmt, "handle of another environment expired", false, true)
+	}
+}
+
+func Test_FeedbackReaderErrors(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	records, err := readFeedback(t, "")
+	if err != nil || len(records) != 0 {
+		t.Errorf(errfmt, "empty input", "no records", err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		read  int
+	}{
+		{"unknown platform", "platform,pnshandle\napns,token-1\npager,123\n", 1},
+		{"missing handle", "platform,error\napns,InvalidToken\n", 0},
+		{"invalid time", `{"pnsHandle":"token-1","time":"yesterday"}`, 0},
+		{"invalid json", "{\"pnsHandle\":\"token-1\"}\n{broken\n", 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			records, err := readFeedback(t, test.input)
+			if err == nil {
+				t.Errorf(errfmt, "read error", "error", err)
+			}
+			if len(records) != test.read {
+				t.Errorf(errfmt, "records before error", test.read, len(records))
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package notihub
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/vippsas/gozure/notihub/tags"
+)
+
+const (
+	// maxOrTags is the hub limit of tags in expressions consisting of alternatives only
+	maxOrTags = 20
+	// maxMixedTags is the hub limit of tags in expressions combining different operators
+	maxMixedTags = 6
+)
+
+type (
+	// GeoTaxonomy is a hierarchy of regions, e.g. country, regions and cities
+	GeoTaxonomy interface {
+		// Subregions returns direct subregions of region, none for the smallest regions
+		Subregions(region string) []string
+	}
+
+	// MapGeoTaxonomy is GeoTaxonomy mapping regions to their subregions
+	MapGeoTaxonomy map[string][]string
+
+	// ChunkResult is the outcome of sending to a single chunk of tags
+	ChunkResult struct {
+		OrTags []string
+		Result SendResult
+		Err    error
+	}
+)
+
+// Subregions returns direct subregions of region
+func (t MapGeoTaxonomy) Subregions(region string) []string {
+	return t[region]
+}
+
+// ExpandGeo returns geo tags of regions and all their subregions, sorted and without duplicates.
+// Devices are expected to have a single geo tag of the smallest region known for them.
+// Returns error if a region does not make a valid tag
+func ExpandGeo(taxonomy GeoTaxonomy, regions ...string) ([]string, error) {
+	seen := map[string]bool{}
+
+	var expand func(region string) error
+	expand = func(region string) error {
+		tag, err := tags.GeoTag(region)
+		if err != nil {
+			return err
+		}
+		if seen[tag.String()] {
+			return nil
+		}
+		seen[tag.String()] = true
+
+		for _, subregion := range taxonomy.Subregions(region) {
+			if err := expand(subregion); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for _, region := range regions {
+		if err := expand(region); err != nil {
+			return nil, fmt.Errorf("ExpandGeo: %w", err)
+		}
+	}
+
+	expanded := make([]string, 0, len(seen))
+	for tag := range seen {
+		expanded = append(expanded, tag)
+	}
+	sort.Strings(expanded)
+
+	return expanded, nil
+}
+
+// chunkOrTags splits orTags into chunks which combined with andTagCount
+// other tags stay within the hub tag expression limits
+func chunkOrTags(orTags []string, andTagCount int) ([][]string, error) {
+	size := maxOrTags
+	if andTagCount > 0 {
+		size = maxMixedTags - andTagCount
+	}
+	if size < 1 {
+		return nil, fmt.Errorf("%d required tags leave no room for alternatives within the limit of %d tags", andTagCount, maxMixedTags)
+	}
+
+	chunks := make([][]string, 0, (len(orTags)+size-1)/size)
+	for start := 0; start < len(orTags); start += size {
+		end := start + size
+		if end > len(orTags) {
+			end = len(orTags)
+		}
+		chunks = append(chunks, orTags[start:end])
+	}
+
+	return chunks, nil
+}
+
+// checkTagLimits checks that tag expression expr stays within the hub limits,
+// maxOrTags tags for alternatives only and maxMixedTags tags otherwise
+func checkTagLimits(expr string) error {
+	tokens, err := tokenizeTagExpression(expr)
+	if err != nil {
+		return err
+	}
+
+	count, limit := 0, maxOrTags
+	for _, token := range tokens {
+		switch token {
+		case "(", ")", "||":
+		case "&&", "!":
+			limit = maxMixedTags
+		default:
+			count++
+		}
+	}
+	if count > limit {
+		return fmt.Errorf("tag expression '%s' has %d tags, the hub allows %d", expr, count, limit)
+	}
+
+	return nil
+}
+
+// SendGeo sends notification to devices in regions or any of their subregions.
+// Geo tags are split into as many sends as needed to stay within the hub
+// tag expression limits. Results of all chunks are returned even when some fail
+func (h *NotificationHub) SendGeo(ctx context.Context, n *Notification, taxonomy GeoTaxonomy, regions []string, opts ...SendOption) ([]ChunkResult, error) {
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("NotificationHub.SendGeo: no regions")
+	}
+
+	geoTags, err := ExpandGeo(taxonomy, regions...)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.SendGeo: %w", err)
+	}
+
+	results, err := h.sendChunked(ctx, n, geoTags, opts)
+	if err != nil {
+		return results, fmt.Errorf("NotificationHub.SendGeo: %w", err)
+	}
+
+	return results, nil
+}
+
+// sendChunked sends notification to orTags split into chunks within the hub tag expression limits
+func (h *NotificationHub) sendChunked(ctx context.Context, n *Notification, orTags []string, opts []SendOption) ([]ChunkResult, error) {
+	chunks, err := chunkOrTags(orTags, len(h.sendAndTags(ctx, newSendOptions(opts))))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ChunkResult, len(chunks))
+	failed := 0
+	var firstErr error
+	for i, chunk := range chunks {
+		o := newSendOptions(opts)
+		o.result = &results[i].Result
+
+		results[i].OrTags = chunk
+		if _, results[i].Err = h.send(ctx, n, chunk, o); results[i].Err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = results[i].Err
+			}
+		}
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("%d of %d chunks failed: %w", failed, len(chunks), firstErr)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,154 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/vippsas/gozure/notihub/tags"
+)
+
+var testGeoTaxonomy = MapGeoTaxonomy{
+	"no":    {"no-03", "no-46"},
+	"no-03": {"oslo"},
+	"no-46": {"bergen", "voss"},
+	"se":    {"stockholm"},
+}
+
+func Test_ExpandGeo(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	expected := []string{"geo:bergen", "geo:no", "geo:no-03", "geo:no-46", "geo:oslo", "geo:voss"}
+	if expanded, err := ExpandGeo(testGeoTaxonomy, "no", "no-46"); err != nil || !reflect.DeepEqual(expanded, expected) {
+		t.Errorf(errfmt, "expanded tags", expected, expanded)
+	}
+
+	if expanded, err := ExpandGeo(testGeoTaxonomy, "dk"); err != nil || !reflect.DeepEqual(expanded, []string{"geo:dk"}) {
+		t.Errorf(errfmt, "region without subregions", []string{"geo:dk"}, expanded)
+	}
+
+	if _, err := ExpandGeo(MapGeoTaxonomy{"no": {"oslo", "nord norge"}}, "no"); !errors.Is(err, tags.ErrInvalid) {
+		t.Errorf(errfmt, "invalid subregion error", tags.ErrInvalid, err)
+	}
+}
+
+func Test_ChunkOrTags(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	orTags := make([]string, 45)
+	for i := range orTags {
+		orTags[i] = "tag"
+	}
+
+	testPatterns := []struct {
+		andTagCount int
+		sizes       []int
+	}{
+		{0, []int{20, 20, 5}},
+		{1, []int{5, 5, 5, 5, 5, 5, 5, 5, 5}},
+		{4, []int{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1}},
+	}
+
+	for _, testData := range testPatterns {
+		chunks, err := chunkOrTags(orTags, testData.andTagCount)
+		if err != nil {
+			t.Fatalf(errfmt, "chunk error", nil, err)
+		}
+		sizes := make([]int, len(chunks))
+		for i := range chunks {
+			sizes[i] = len(chunks[i])
+		}
+		if !reflect.DeepEqual(sizes, testData.sizes) {
+			t.Errorf(errfmt, "chunk sizes", testData.sizes, sizes)
+		}
+	}
+
+	if _, err := chunkOrTags(orTags, maxMixedTags); err == nil {
+		t.Errorf(errfmt, "error without room for alternatives", "error", err)
+	}
+}
+
+func Test_NotificationHubSendGeo(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var expressions []string
+	mockClient := &mockHubHttpClient{execFunc: func(req *http.Request) ([]byte, error) {
+		expressions = append(expressions, req.Header.Get("ServiceBusNotification-Tags"))
+		return nil, nil
+	}}
+
+	nhub := &NotificationHub{
+		sasKeyValue:    "testKeyValue",
+		sasKeyName:     "testKeyName",
+		hubURL:         &url.URL{Host: "testHost", Scheme: schemeDefault, Path: "testPath"},
+		client:         mockClient,
+		expiryTimeFunc: TimeFunc(mockExpiryTime),
+		defaultTags:    []string{"env:prod"},
+	}
+
+	n := &Notification{AndroidFormat, []byte(`{"data":{}}`)}
+	results, err := nhub.SendGeo(context.Background(), n, testGeoTaxonomy, []string{"no"})
+	if err != nil {
+		t.Fatalf(errfmt, "send error", nil, err)
+	}
+
+	expected := []string{
+		"(geo:bergen || geo:no || geo:no-03 || geo:no-46 || geo:oslo) && env:prod",
+		"geo:voss && env:prod",
+	}
+	if !reflect.DeepEqual(expressions, expected) {
+		t.Errorf(errfmt, "tag expressions", expected, expressions)
+	}
+	if len(results) != 2 || results[1].Result.Attempts != 1 || strings.Join(results[1].OrTags, "") != "geo:voss" {
+		t.Errorf(errfmt, "chunk results", 2, results)
+	}
+
+	if _, err := nhub.SendGeo(context.Background(), n, testGeoTaxonomy, nil); err == nil {
+		t.Errorf(errfmt, "error without regions", "error", err)
+	}
+
+	expressions = nil
+	ctx := WithContextTags(context.Background(), "tenant:a")
+	if _, err := nhub.SendGeo(ctx, n, testGeoTaxonomy, []string{"no"}, withAndTags("beta")); err != nil {
+		t.Fatalf(errfmt, "send error", nil, err)
+	}
+
+	expected = []string{
+		"(geo:bergen || geo:no || geo:no-03) && env:prod && tenant:a && beta",
+		"(geo:no-46 || geo:oslo || geo:voss) && env:prod && tenant:a && beta",
+	}
+	if !reflect.DeepEqual(expressions, expected) {
+		t.Errorf(errfmt, "tag expressions with context and send tags", expected, expressions)
+	}
+}
+
+func Test_CheckTagLimits(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	alternatives := make([]string, maxOrTags+1)
+	for i := range alternatives {
+		alternatives[i] = "t" + strconv.Itoa(i)
+	}
+
+	testPatterns := []struct {
+		expr  string
+		valid bool
+	}{
+		{strings.Join(alternatives[:maxOrTags], " || "), true},
+		{strings.Join(alternatives, " || "), false},
+		{"(a || b) && !(c || d || e || f)", true},
+		{"(a || b) && !(c || d || e || f || g)", false},
+		{"a && b && c && d && e && f && g", false},
+	}
+
+	for _, testData := range testPatterns {
+		if err := checkTagLimits(testData.expr); (err == nil) != testData.valid {
+			t.Errorf(errfmt, testData.expr+" valid", testData.valid, err)
+		}
+	}
+}
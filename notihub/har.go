@@ -0,0 +1,182 @@
+package notihub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// harVersion is the version of HTTP Archive format written by WriteHAR
+const harVersion = "1.2"
+
+type (
+	harLog struct {
+		Log harLogContent `json:"log"`
+	}
+
+	harLogContent struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	}
+
+	harCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+
+	harEntry struct {
+		StartedDateTime string      `json:"startedDateTime"`
+		Time            float64     `json:"time"`
+		Request         harRequest  `json:"request"`
+		Response        harResponse `json:"response"`
+		Cache           struct{}    `json:"cache"`
+		Timings         harTimings  `json:"timings"`
+		// Error is the transport error of failed requests, custom fields start with underscore
+		Error string `json:"_error,omitempty"`
+	}
+
+	harRequest struct {
+		Method      string         `json:"method"`
+		URL         string         `json:"url"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harNameValue `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		QueryString []harNameValue `json:"queryString"`
+		PostData    *harPostData   `json:"postData,omitempty"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+
+	harResponse struct {
+		Status      int            `json:"status"`
+		StatusText  string         `json:"statusText"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harNameValue `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		Content     harContent     `json:"content"`
+		RedirectURL string         `json:"redirectURL"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+
+	harNameValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	harPostData struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+
+	harContent struct {
+		Size     int    `json:"size"`
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text,omitempty"`
+	}
+
+	harTimings struct {
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+	}
+)
+
+// WriteHAR writes traffic captured by WithPayloadSampling as HTTP Archive (HAR) 1.2 document,
+// for sharing with Azure support or replaying in other tooling.
+// Samples are sanitized: credential headers and bodies of management, installation and registration
+// requests are redacted and other bodies truncated. Notification payloads are exported as sent,
+// review them for personal data before sharing
+func (h *NotificationHub) WriteHAR(w io.Writer) error {
+	if h.sampler == nil {
+		return errors.New("NotificationHub.WriteHAR: payload sampling is not enabled")
+	}
+
+	if err := WriteHAR(w, h.Samples()); err != nil {
+		return fmt.Errorf("NotificationHub.WriteHAR: %w", err)
+	}
+
+	return nil
+}
+
+// WriteHAR writes samples as HTTP Archive (HAR) 1.2 document
+func WriteHAR(w io.Writer, samples []PayloadSample) error {
+	har := harLog{Log: harLogContent{
+		Version: harVersion,
+		Creator: harCreator{Name: "gozure/notihub", Version: Version},
+		Entries: make([]harEntry, 0, len(samples)),
+	}}
+
+	for _, sample := range samples {
+		har.Log.Entries = append(har.Log.Entries, newHAREntry(sample))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(har)
+}
+
+// newHAREntry converts sample to HAR entry
+func newHAREntry(sample PayloadSample) harEntry {
+	millis := float64(sample.Duration) / float64(time.Millisecond)
+
+	entry := harEntry{
+		StartedDateTime: sample.Time.UTC().Format(time.RFC3339Nano),
+		Time:            millis,
+		Request: harRequest{
+			Method:      sample.Method,
+			URL:         sample.URL,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(sample.RequestHeader),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(sample.RequestBody),
+		},
+		Response: harResponse{
+			Status:      sample.StatusCode,
+			StatusText:  http.StatusText(sample.StatusCode),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(sample.ResponseHeader),
+			Content: harContent{
+				Size:     len(sample.ResponseBody),
+				MimeType: sample.ResponseHeader.Get("Content-Type"),
+				Text:     string(sample.ResponseBody),
+			},
+			HeadersSize: -1,
+			BodySize:    len(sample.ResponseBody),
+		},
+		Timings: harTimings{Wait: millis},
+		Error:   sample.Err,
+	}
+
+	if u, err := url.Parse(sample.URL); err == nil {
+		entry.Request.QueryString = harHeaders(http.Header(u.Query()))
+	}
+
+	if len(sample.RequestBody) > 0 {
+		entry.Request.PostData = &harPostData{MimeType: sample.RequestHeader.Get("Content-Type"), Text: string(sample.RequestBody)}
+	}
+
+	return entry
+}
+
+// harHeaders returns header or query values as name and value pairs sorted by name
+func harHeaders(header http.Header) []harNameValue {
+	pairs := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+
+	return pairs
+}
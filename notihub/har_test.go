@@ -0,0 +1,108 @@
+package notihub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_NotificationHubWriteHAR(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("<ok/>"))
+	}))
+	defer server.Close()
+
+	nhub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(), WithPayloadSampling(1, 10))
+	if _, err := nhub.SendDirect(context.Background(), &Notification{AndroidFormat, []byte(`{"data":{}}`)}, "device-handle-secret"); err != nil {
+		t.Fatalf(errfmt, "send error", nil, err)
+	}
+
+	var buf bytes.Buffer
+	if err := nhub.WriteHAR(&buf); err != nil {
+		t.Fatalf(errfmt, "write error", nil, err)
+	}
+	if strings.Contains(buf.String(), "SharedAccessSignature") || strings.Contains(buf.String(), "device-handle-secret") {
+		t.Errorf(errfmt, "sanitized archive", "no credentials or device handles", buf.String())
+	}
+
+	var har struct {
+		Log struct {
+			Version string
+			Entries []struct {
+				Request struct {
+					Method      string
+					QueryString []harNameValue
+					PostData    harPostData
+				}
+				Response struct {
+					Status  int
+					Content harContent
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &har); err != nil || har.Log.Version != "1.2" || len(har.Log.Entries) != 1 {
+		t.Fatalf(errfmt, "archive", "HAR 1.2 with 1 entry", buf.String())
+	}
+
+	entry := har.Log.Entries[0]
+	if entry.Request.Method != "POST" || entry.Request.PostData.Text != `{"data":{}}` || entry.Request.PostData.MimeType != "application/json" {
+		t.Errorf(errfmt, "request", "POST with payload", entry.Request)
+	}
+	if len(entry.Request.QueryString) != 2 || entry.Request.QueryString[0].Name != "api-version" {
+		t.Errorf(errfmt, "query string", "api-version and direct", entry.Request.QueryString)
+	}
+	if entry.Response.Status != http.StatusCreated || entry.Response.Content.Text != "<ok/>" || entry.Response.Content.MimeType != "application/xml" {
+		t.Errorf(errfmt, "response", "201 <ok/>", entry.Response)
+	}
+
+	if err := newBulkTestHub(nil).WriteHAR(&buf); err == nil {
+		t.Errorf(errfmt, "error without sampling", "error", err)
+	}
+}
+
+func Test_WriteHARError(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var buf bytes.Buffer
+	sample := PayloadSample{Time: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC), Method: "GET", URL: "https://ns/hub/jobs/1", Duration: 1500 * time.Microsecond, Err: "connection refused"}
+	if err := WriteHAR(&buf, []PayloadSample{sample}); err != nil {
+		t.Fatalf(errfmt, "write error", nil, err)
+	}
+
+	for _, expected := range []string{`"startedDateTime": "2020-01-01T12:00:00Z"`, `"time": 1.5`, `"_error": "connection refused"`, `"status": 0`} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf(errfmt, "archive entry", expected, buf.String())
+		}
+	}
+}
+
+func Test_NotificationHubWriteHARManagement(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newHubDescriptionServer(testHubDescription)
+	defer server.Close()
+
+	nhub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(), WithPayloadSampling(1, 10))
+	public, private := newTestVapidKeys(t)
+	if err := NewManagementClient(nhub).SetBrowserCredential(context.Background(), BrowserCredential{"mailto:push@example.com", public, private}); err != nil {
+		t.Fatalf(errfmt, "set error", nil, err)
+	}
+
+	var buf bytes.Buffer
+	if err := nhub.WriteHAR(&buf); err != nil {
+		t.Fatalf(errfmt, "write error", nil, err)
+	}
+	if har := buf.String(); strings.Contains(har, private) || !strings.Contains(har, redactedDebugBody) {
+		t.Errorf(errfmt, "exported management traffic", "redacted bodies", har)
+	}
+}
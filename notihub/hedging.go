@@ -0,0 +1,98 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// WithHedging makes the hub send a duplicate of idempotent requests, like installation
+// reads and writes, not answered within delay and use whichever response comes first.
+// Non-idempotent requests, like notification sends, are never hedged
+func WithHedging(delay time.Duration) HubOption {
+	return func(h *NotificationHub) {
+		h.hedgeDelay = delay
+	}
+}
+
+// isIdempotentRequest identifies whether executing request more than once
+// has the same effect as executing it once
+func isIdempotentRequest(req *http.Request) bool {
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+
+	return false
+}
+
+// isRetryableRequest identifies whether failed request may be retried.
+// Non-idempotent requests are retried only when the hub certainly did not process them:
+// throttled or unavailable responses and failures to connect
+func isRetryableRequest(req *http.Request, err error) bool {
+	if !isRetryableError(err) {
+		return false
+	}
+	if isIdempotentRequest(req) {
+		return true
+	}
+
+	var resErr *ResponseError
+	if errors.As(err, &resErr) {
+		return resErr.StatusCode == http.StatusTooManyRequests || resErr.StatusCode == http.StatusServiceUnavailable
+	}
+
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return timeoutErr.Phase == PhaseConnect || timeoutErr.Phase == PhaseTLSHandshake
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// execHedged executes request, hedging idempotent requests
+// when the hub is configured with WithHedging
+func (h *NotificationHub) execHedged(req *http.Request) ([]byte, http.Header, error) {
+	if h.hedgeDelay <= 0 || !isIdempotentRequest(req) {
+		return h.execOnce(req)
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	type response struct {
+		b      []byte
+		header http.Header
+		err    error
+	}
+	responses := make(chan response, 2)
+	run := func(req *http.Request) {
+		b, header, err := h.execOnce(req)
+		responses <- response{b, header, err}
+	}
+
+	hedge, cerr := cloneRequest(req.WithContext(ctx))
+	go run(req.WithContext(ctx))
+
+	timer := time.NewTimer(h.hedgeDelay)
+	defer timer.Stop()
+
+	inflight := 1
+	for {
+		select {
+		case <-timer.C:
+			if cerr == nil {
+				inflight++
+				go run(hedge)
+			}
+		case r := <-responses:
+			inflight--
+			if r.err == nil || inflight == 0 {
+				return r.b, r.header, r.err
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_IsRetryableRequest(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	get, _ := http.NewRequest("GET", "https://testhost/hub/installations/a", nil)
+	post, _ := http.NewRequest("POST", "https://testhost/hub/messages", nil)
+
+	tests := []struct {
+		name string
+		err  error
+		get  bool
+		post bool
+	}{
+		{"throttled", &ResponseError{StatusCode: http.StatusTooManyRequests}, true, true},
+		{"unavailable", &ResponseError{StatusCode: http.StatusServiceUnavailable}, true, true},
+		{"internal error", &ResponseError{StatusCode: http.StatusInternalServerError}, true, false},
+		{"bad request", &ResponseError{StatusCode: http.StatusBadRequest}, false, false},
+		{"connect timeout", &TimeoutError{Phase: PhaseConnect}, true, true},
+		{"response timeout", &TimeoutError{Phase: PhaseResponseHeader}, true, false},
+		{"dial error", &net.OpError{Op: "dial", Err: errors.New("refused")}, true, true},
+		{"connection reset", &net.OpError{Op: "read", Err: errors.New("reset")}, true, false},
+		{"canceled", context.Canceled, false, false},
+	}
+
+	for _, test := range tests {
+		if got := isRetryableRequest(get, test.err); got != test.get {
+			t.Errorf(errfmt, test.name+" GET retryable", test.get, got)
+		}
+		if got := isRetryableRequest(post, test.err); got != test.post {
+			t.Errorf(errfmt, test.name+" POST retryable", test.post, got)
+		}
+	}
+}
+
+func Test_NotificationHubHedging(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var (
+		mu       sync.Mutex
+		requests = map[string]int{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.Method]++
+		first := requests[r.Method] == 1
+		mu.Unlock()
+
+		if first {
+			select {
+			case <-time.After(300 * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if r.Method == "GET" {
+			w.Write([]byte(`{"installationId":"a"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(), WithHedging(20*time.Millisecond))
+
+	started := time.Now()
+	installation, err := hub.getInstallation(context.Background(), "a")
+	if err != nil || installation.InstallationId != "a" {
+		t.Fatalf(errfmt, "hedged read", "installation a", err)
+	}
+	if elapsed := time.Since(started); elapsed > 200*time.Millisecond {
+		t.Errorf(errfmt, "hedged read duration", "hedge response", elapsed)
+	}
+
+	if _, err := hub.SendDirect(context.Background(), &Notification{AndroidFormat, []byte(`{"data":{}}`)}, "handle"); err != nil {
+		t.Fatalf(errfmt, "send error", nil, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests["GET"] != 2 || requests["POST"] != 1 {
+		t.Errorf(errfmt, "requests", "2 GET, 1 POST", requests)
+	}
+}
@@ -0,0 +1,195 @@
+package notihub
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vippsas/gozure/notihub/tags"
+)
+
+type (
+	// HistoryRecord is a notification send recorded in HistoryStore
+	HistoryRecord struct {
+		Time time.Time
+		// Target is the tag expression of recipients, empty for sends to every device
+		Target string
+		// Users are ids of user tags of Target, see tags.UserTag
+		Users          []string
+		Format         NotificationFormat
+		Campaign       string
+		CorrelationID  string
+		NotificationID string
+		Attempts       int
+		// Err is the error text of failed sends
+		Err string
+	}
+
+	// HistoryQuery selects history records, zero fields match every record
+	HistoryQuery struct {
+		User     string
+		Campaign string
+		// From and To limit record times, inclusively
+		From time.Time
+		To   time.Time
+		// Limit caps the number of records when positive
+		Limit int
+	}
+
+	// HistoryStore records notification sends for customer support lookups.
+	// Query returns the newest records first
+	HistoryStore interface {
+		Record(ctx context.Context, record HistoryRecord) error
+		Query(ctx context.Context, query HistoryQuery) ([]HistoryRecord, error)
+	}
+
+	// HistoryMetrics is Metrics also receiving failures to record sends in HistoryStore
+	HistoryMetrics interface {
+		Metrics
+		ObserveHistoryError(record HistoryRecord, err error)
+	}
+
+	// MemoryHistoryStore is HistoryStore keeping records in memory, e.g. for tests
+	MemoryHistoryStore struct {
+		mu      sync.Mutex
+		records []HistoryRecord
+	}
+)
+
+// WithHistory records every notification send in store. Failures to record do not fail sends,
+// they are reported to the hub metrics when it is HistoryMetrics
+func WithHistory(store HistoryStore) HubOption {
+	return func(h *NotificationHub) {
+		h.history = store
+	}
+}
+
+// UserHistory returns sends targeting user, newest first
+func UserHistory(ctx context.Context, store HistoryStore, userID string, limit int) ([]HistoryRecord, error) {
+	return queryHistory(ctx, store, HistoryQuery{User: userID, Limit: limit})
+}
+
+// CampaignHistory returns sends of campaign, newest first
+func CampaignHistory(ctx context.Context, store HistoryStore, campaign string, limit int) ([]HistoryRecord, error) {
+	return queryHistory(ctx, store, HistoryQuery{Campaign: campaign, Limit: limit})
+}
+
+// HistoryBetween returns sends made from from to to, newest first
+func HistoryBetween(ctx context.Context, store HistoryStore, from, to time.Time, limit int) ([]HistoryRecord, error) {
+	return queryHistory(ctx, store, HistoryQuery{From: from, To: to, Limit: limit})
+}
+
+func queryHistory(ctx context.Context, store HistoryStore, query HistoryQuery) ([]HistoryRecord, error) {
+	records, err := store.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("HistoryStore.Query: %w", err)
+	}
+
+	return records, nil
+}
+
+// Matches identifies whether record is selected by q
+func (q HistoryQuery) Matches(record HistoryRecord) bool {
+	if q.Campaign != "" && record.Campaign != q.Campaign {
+		return false
+	}
+	if !q.From.IsZero() && record.Time.Before(q.From) || !q.To.IsZero() && record.Time.After(q.To) {
+		return false
+	}
+	if q.User == "" {
+		return true
+	}
+
+	for _, user := range record.Users {
+		if user == q.User {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewMemoryHistoryStore initializes and returns MemoryHistoryStore pointer
+func NewMemoryHistoryStore() *MemoryHistoryStore {
+	return &MemoryHistoryStore{}
+}
+
+// Record keeps record
+func (s *MemoryHistoryStore) Record(ctx context.Context, record HistoryRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = append(s.records, record)
+	return nil
+}
+
+// Query returns records matching query, newest first
+func (s *MemoryHistoryStore) Query(ctx context.Context, query HistoryQuery) ([]HistoryRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []HistoryRecord
+	for _, record := range s.records {
+		if query.Matches(record) {
+			records = append(records, record)
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.After(records[j].Time) })
+	if query.Limit > 0 && len(records) > query.Limit {
+		records = records[:query.Limit]
+	}
+
+	return records, nil
+}
+
+// recordHistory records the send in the hub history store
+func (h *NotificationHub) recordHistory(ctx context.Context, n *Notification, headers map[string]string, o *sendOptions, err error) {
+	if h.history == nil {
+		return
+	}
+
+	record := HistoryRecord{
+		Time:   h.now(),
+		Target: headers["ServiceBusNotification-Tags"],
+		Format: n.Format,
+	}
+	record.Users = tagExpressionUsers(record.Target)
+	if o != nil {
+		record.Campaign = o.campaign
+		if o.result != nil {
+			record.CorrelationID = o.result.CorrelationID
+			record.NotificationID = o.result.NotificationID
+			record.Attempts = o.result.Attempts
+		}
+	}
+	if err != nil {
+		record.Err = err.Error()
+	}
+
+	if rerr := h.history.Record(ctx, record); rerr != nil {
+		if metrics, ok := h.metrics.(HistoryMetrics); ok {
+			metrics.ObserveHistoryError(record, rerr)
+		}
+	}
+}
+
+// tagExpressionUsers returns ids of user tags in tag expression
+func tagExpressionUsers(expression string) []string {
+	var users []string
+	for _, token := range strings.FieldsFunc(expression, isTagExpressionOperator) {
+		if tag, err := tags.Parse(token); err == nil && tag.Namespace == tags.User {
+			users = append(users, tag.Value)
+		}
+	}
+
+	return users
+}
+
+// isTagExpressionOperator identifies characters of tag expression operators and grouping
+func isTagExpressionOperator(c rune) bool {
+	return strings.ContainsRune(" ()&|!", c)
+}
@@ -0,0 +1,107 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type failingHistoryStore struct {
+	MemoryHistoryStore
+}
+
+func (s *failingHistoryStore) Record(ctx context.Context, record HistoryRecord) error {
+	return errors.New("disk full")
+}
+
+type historyMetrics struct {
+	errs []error
+}
+
+func (m *historyMetrics) ObserveSend(event SendEvent) {}
+
+func (m *historyMetrics) ObserveHistoryError(record HistoryRecord, err error) {
+	m.errs = append(m.errs, err)
+}
+
+func Test_NotificationHubHistory(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("ServiceBusNotification-Tags") == "user:2" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Location", "https://testhub-ns.servicebus.windows.net/hub/messages/notification-id?api-version=2015-01")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := &mockClock{now: start}
+	store := NewMemoryHistoryStore()
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(),
+		WithClock(clock), WithHistory(store))
+
+	n := &Notification{Template, []byte("{}")}
+	sends := []struct {
+		orTags []string
+		opts   []SendOption
+	}{
+		{[]string{"user:1", "user:2"}, []SendOption{WithCampaign("welcome"), WithCorrelationID("c1")}},
+		{[]string{"user:2"}, nil},
+		{[]string{"topic:news"}, []SendOption{WithCampaign("welcome")}},
+	}
+	for _, send := range sends {
+		hub.Send(context.Background(), n, send.orTags, send.opts...)
+		clock.now = clock.now.Add(time.Minute)
+	}
+
+	records, err := UserHistory(context.Background(), store, "2", 0)
+	if err != nil || len(records) != 2 {
+		t.Fatalf(errfmt, "user 2 history", 2, records)
+	}
+	if records[0].Err == "" || records[0].Target != "user:2" {
+		t.Errorf(errfmt, "newest failed record", "user:2 with error", records[0])
+	}
+	expected := HistoryRecord{
+		Time:           start,
+		Target:         "user:1 || user:2",
+		Users:          []string{"1", "2"},
+		Format:         Template,
+		Campaign:       "welcome",
+		CorrelationID:  "c1",
+		NotificationID: "notification-id",
+		Attempts:       1,
+	}
+	if !reflect.DeepEqual(records[1], expected) {
+		t.Errorf(errfmt, "oldest record", expected, records[1])
+	}
+
+	if records, _ := CampaignHistory(context.Background(), store, "welcome", 1); len(records) != 1 || records[0].Target != "topic:news" {
+		t.Errorf(errfmt, "latest campaign send", "topic:news", records)
+	}
+	if records, _ := HistoryBetween(context.Background(), store, start.Add(time.Minute), start.Add(time.Hour), 0); len(records) != 2 {
+		t.Errorf(errfmt, "sends after the first", 2, len(records))
+	}
+
+	metrics := &historyMetrics{}
+	failing := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(),
+		WithMetrics(metrics), WithHistory(&failingHistoryStore{}))
+	if _, err := failing.Send(context.Background(), n, []string{"user:1"}); err != nil || len(metrics.errs) != 1 {
+		t.Errorf(errfmt, "unrecorded send", "succeeds and reports history error", err)
+	}
+}
+
+func Test_TagExpressionUsers(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	users := tagExpressionUsers("(user:1 || user:b-2) && !user:3 && topic:news")
+	if expected := []string{"1", "b-2", "3"}; !reflect.DeepEqual(users, expected) {
+		t.Errorf(errfmt, "users", expected, users)
+	}
+}
@@ -0,0 +1,161 @@
+package notihub
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// sqliteHistorySchema creates the history table and its indexes
+const sqliteHistorySchema = `CREATE TABLE IF NOT EXISTS notification_history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	time_unix_nano INTEGER NOT NULL,
+	target TEXT NOT NULL,
+	users TEXT NOT NULL,
+	format TEXT NOT NULL,
+	campaign TEXT NOT NULL,
+	correlation_id TEXT NOT NULL,
+	notification_id TEXT NOT NULL,
+	attempts INTEGER NOT NULL,
+	error TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS notification_history_time ON notification_history (time_unix_nano);
+CREATE INDEX IF NOT EXISTS notification_history_campaign ON notification_history (campaign, time_unix_nano)`
+
+// historyUsersSeparator separates and encloses user ids in the users column, so that
+// a user is matched by looking up ",id," regardless of its position
+const historyUsersSeparator = ","
+
+// SQLiteHistoryStore is the reference HistoryStore keeping records in a SQLite database.
+// The database is opened by the caller with a SQLite driver of their choice:
+//
+//	db, err := sql.Open("sqlite3", "history.db")
+//	store, err := notihub.NewSQLiteHistoryStore(ctx, db)
+type SQLiteHistoryStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteHistoryStore creates the history table in db unless it exists
+// and returns SQLiteHistoryStore pointer
+func NewSQLiteHistoryStore(ctx context.Context, db *sql.DB) (*SQLiteHistoryStore, error) {
+	for _, statement := range strings.Split(sqliteHistorySchema, ";\n") {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return nil, fmt.Errorf("NewSQLiteHistoryStore: %w", err)
+		}
+	}
+
+	return &SQLiteHistoryStore{db: db}, nil
+}
+
+// Record inserts record
+func (s *SQLiteHistoryStore) Record(ctx context.Context, record HistoryRecord) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO notification_history
+	(time_unix_nano, target, users, format, campaign, correlation_id, notification_id, attempts, error)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.Time.UnixNano(), record.Target, joinHistoryUsers(record.Users), string(record.Format), record.Campaign,
+		record.CorrelationID, record.NotificationID, record.Attempts, record.Err)
+	if err != nil {
+		return fmt.Errorf("SQLiteHistoryStore.Record: %w", err)
+	}
+
+	return nil
+}
+
+// Query returns records matching query, newest first
+func (s *SQLiteHistoryStore) Query(ctx context.Context, query HistoryQuery) ([]HistoryRecord, error) {
+	records, err := s.query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteHistoryStore.Query: %w", err)
+	}
+
+	return records, nil
+}
+
+func (s *SQLiteHistoryStore) query(ctx context.Context, query HistoryQuery) ([]HistoryRecord, error) {
+	statement, args := historyQuerySQL(query)
+	rows, err := s.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []HistoryRecord
+	for rows.Next() {
+		var (
+			record   HistoryRecord
+			unixNano int64
+			users    string
+			format   string
+		)
+		if err := rows.Scan(&unixNano, &record.Target, &users, &format, &record.Campaign,
+			&record.CorrelationID, &record.NotificationID, &record.Attempts, &record.Err); err != nil {
+			return nil, err
+		}
+
+		record.Time = time.Unix(0, unixNano)
+		record.Users = splitHistoryUsers(users)
+		record.Format = NotificationFormat(format)
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// historyQuerySQL returns SELECT statement of query and its arguments
+func historyQuerySQL(query HistoryQuery) (string, []interface{}) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	if query.User != "" {
+		conditions = append(conditions, "instr(users, ?) > 0")
+		args = append(args, historyUsersSeparator+query.User+historyUsersSeparator)
+	}
+	if query.Campaign != "" {
+		conditions = append(conditions, "campaign = ?")
+		args = append(args, query.Campaign)
+	}
+	if !query.From.IsZero() {
+		conditions = append(conditions, "time_unix_nano >= ?")
+		args = append(args, query.From.UnixNano())
+	}
+	if !query.To.IsZero() {
+		conditions = append(conditions, "time_unix_nano <= ?")
+		args = append(args, query.To.UnixNano())
+	}
+
+	statement := "SELECT time_unix_nano, target, users, format, campaign, correlation_id, notification_id, attempts, error " +
+		"FROM notification_history"
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	statement += " ORDER BY time_unix_nano DESC, id DESC"
+	if query.Limit > 0 {
+		statement += " LIMIT ?"
+		args = append(args, query.Limit)
+	}
+
+	return statement, args
+}
+
+// joinHistoryUsers encodes users as ",id1,id2,", empty when there are none
+func joinHistoryUsers(users []string) string {
+	if len(users) == 0 {
+		return ""
+	}
+
+	return historyUsersSeparator + strings.Join(users, historyUsersSeparator) + historyUsersSeparator
+}
+
+// splitHistoryUsers decodes users encoded by joinHistoryUsers
+func splitHistoryUsers(users string) []string {
+	users = strings.Trim(users, historyUsersSeparator)
+	if users == "" {
+		return nil
+	}
+
+	return strings.Split(users, historyUsersSeparator)
+}
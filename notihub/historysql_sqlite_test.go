@@ -0,0 +1,93 @@
+//go:build sqlite
+
+// Tests of SQLiteHistoryStore against a real database, run with: go test -tags sqlite ./notihub
+package notihub
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func Test_SQLiteHistoryStore(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// every connection to :memory: opens a database of its own
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	if _, err := NewSQLiteHistoryStore(ctx, db); err != nil {
+		t.Fatalf(errfmt, "schema error", nil, err)
+	}
+	store, err := NewSQLiteHistoryStore(ctx, db)
+	if err != nil {
+		t.Fatalf(errfmt, "repeated schema error", nil, err)
+	}
+
+	base := time.Unix(1700000000, 123456789)
+	records := []HistoryRecord{
+		{Time: base, Target: "user:1", Users: []string{"1"}, Format: AppleFormat, Campaign: "welcome", CorrelationID: "c1", NotificationID: "n1", Attempts: 1},
+		{Time: base.Add(time.Minute), Target: "tag:all", Users: []string{"1", "12"}, Format: FcmV1Format, Campaign: "sale", NotificationID: "n2", Attempts: 2},
+		{Time: base.Add(2 * time.Minute), Target: "user:12", Users: []string{"12"}, Format: Template, Campaign: "welcome", Attempts: 3, Err: "throttled"},
+		{Time: base.Add(3 * time.Minute), Target: "tag:none", Format: WindowsFormat, Campaign: "sale", Attempts: 1},
+	}
+	for _, record := range records {
+		if err := store.Record(ctx, record); err != nil {
+			t.Fatalf(errfmt, "record error", nil, err)
+		}
+	}
+
+	all, err := store.Query(ctx, HistoryQuery{})
+	if err != nil {
+		t.Fatalf(errfmt, "query error", nil, err)
+	}
+	if len(all) != len(records) {
+		t.Fatalf(errfmt, "number of records", len(records), len(all))
+	}
+	for i, record := range all {
+		expected := records[len(records)-1-i]
+		if !record.Time.Equal(expected.Time) {
+			t.Errorf(errfmt, "time", expected.Time, record.Time)
+		}
+		record.Time = expected.Time
+		if !reflect.DeepEqual(record, expected) {
+			t.Errorf(errfmt, "record", expected, record)
+		}
+	}
+
+	testPatterns := []struct {
+		query   HistoryQuery
+		targets []string
+	}{
+		{HistoryQuery{User: "1"}, []string{"tag:all", "user:1"}},
+		{HistoryQuery{User: "12"}, []string{"user:12", "tag:all"}},
+		{HistoryQuery{User: "2"}, nil},
+		{HistoryQuery{Campaign: "welcome"}, []string{"user:12", "user:1"}},
+		{HistoryQuery{From: base.Add(time.Minute), To: base.Add(2 * time.Minute)}, []string{"user:12", "tag:all"}},
+		{HistoryQuery{Campaign: "sale", Limit: 1}, []string{"tag:none"}},
+	}
+
+	for _, testData := range testPatterns {
+		matched, err := store.Query(ctx, testData.query)
+		if err != nil {
+			t.Fatalf(errfmt, "query error", nil, err)
+		}
+
+		var targets []string
+		for _, record := range matched {
+			targets = append(targets, record.Target)
+		}
+		if !reflect.DeepEqual(targets, testData.targets) {
+			t.Errorf(errfmt, "targets of "+testData.query.User+testData.query.Campaign, testData.targets, targets)
+		}
+	}
+}
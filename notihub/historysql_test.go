@@ -0,0 +1,59 @@
+package notihub
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_HistoryQuerySQL(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	const selectHistory = "SELECT time_unix_nano, target, users, format, campaign, correlation_id, notification_id, attempts, error FROM notification_history"
+	from, to := time.Unix(0, 100), time.Unix(0, 200)
+
+	testPatterns := []struct {
+		query     HistoryQuery
+		statement string
+		args      []interface{}
+	}{
+		{HistoryQuery{}, selectHistory + " ORDER BY time_unix_nano DESC, id DESC", nil},
+		{HistoryQuery{User: "42", Limit: 10}, selectHistory + " WHERE instr(users, ?) > 0 ORDER BY time_unix_nano DESC, id DESC LIMIT ?", []interface{}{",42,", 10}},
+		{
+			HistoryQuery{Campaign: "welcome", From: from, To: to},
+			selectHistory + " WHERE campaign = ? AND time_unix_nano >= ? AND time_unix_nano <= ? ORDER BY time_unix_nano DESC, id DESC",
+			[]interface{}{"welcome", int64(100), int64(200)},
+		},
+	}
+
+	for _, testData := range testPatterns {
+		statement, args := historyQuerySQL(testData.query)
+		if statement != testData.statement || !reflect.DeepEqual(args, testData.args) {
+			t.Errorf(errfmt, "statement", testData.statement, statement)
+			t.Errorf(errfmt, "arguments", testData.args, args)
+		}
+	}
+}
+
+func Test_HistoryUsersEncoding(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	testPatterns := []struct {
+		users   []string
+		encoded string
+	}{
+		{nil, ""},
+		{[]string{"1"}, ",1,"},
+		{[]string{"1", "b-2"}, ",1,b-2,"},
+	}
+
+	for _, testData := range testPatterns {
+		encoded := joinHistoryUsers(testData.users)
+		if encoded != testData.encoded {
+			t.Errorf(errfmt, "encoded users", testData.encoded, encoded)
+		}
+		if decoded := splitHistoryUsers(encoded); !reflect.DeepEqual(decoded, testData.users) {
+			t.Errorf(errfmt, "decoded users", testData.users, decoded)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package notihub
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// defaultImportFileSize is the default import file size limit in bytes
+const defaultImportFileSize = 8 << 20
+
+type (
+	// BlobWriter uploads import files to blob storage
+	BlobWriter interface {
+		// WriteBlob uploads data as blob name and returns
+		// the blob SAS URI readable by the notification hub
+		WriteBlob(ctx context.Context, name string, data []byte) (string, error)
+	}
+
+	// ImportOptions configures ImportRegistrations
+	ImportOptions struct {
+		// OutputContainerUri is the blob container SAS URI job output is written to
+		OutputContainerUri string
+		// MaxFileSize limits the size of a single import file in bytes, 8MB by default
+		MaxFileSize int
+		// BlobPrefix is prepended to import file blob names
+		BlobPrefix string
+	}
+
+	// registrationImportXML is the import file and request body representation
+	// of RegistrationDescription, element order follows the hub data contract
+	registrationImportXML struct {
+		XMLName             xml.Name
+		RegistrationId      string `xml:"RegistrationId,omitempty"`
+		Tags                string `xml:"Tags,omitempty"`
+		DeviceToken         string `xml:"DeviceToken,omitempty"`
+		GcmRegistrationId   string `xml:"GcmRegistrationId,omitempty"`
+		FcmV1RegistrationId string `xml:"FcmV1RegistrationId,omitempty"`
+		ChannelUri          string `xml:"ChannelUri,omitempty"`
+		AdmRegistrationId   string `xml:"AdmRegistrationId,omitempty"`
+		BaiduChannelId      string `xml:"BaiduChannelId,omitempty"`
+		BaiduUserId         string `xml:"BaiduUserId,omitempty"`
+		BodyTemplate        string `xml:"BodyTemplate,omitempty"`
+		TemplateName        string `xml:"TemplateName,omitempty"`
+	}
+)
+
+// ImportRegistrations builds import files from registrations, uploads them
+// using blobs and submits one import job per file.
+// Jobs submitted before a failure are returned along with the error
+func (h *NotificationHub) ImportRegistrations(ctx context.Context, jobType JobType, registrations []RegistrationDescription, blobs BlobWriter, opts ImportOptions) ([]*Job, error) {
+	if !strings.HasPrefix(string(jobType), "Import") {
+		return nil, fmt.Errorf("NotificationHub.ImportRegistrations: '%s' is not an import job type", jobType)
+	}
+
+	maxFileSize := opts.MaxFileSize
+	if maxFileSize <= 0 {
+		maxFileSize = defaultImportFileSize
+	}
+
+	files, err := BuildImportFiles(registrations, maxFileSize)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.ImportRegistrations: %w", err)
+	}
+
+	jobs := make([]*Job, 0, len(files))
+	for i, file := range files {
+		name := fmt.Sprintf("%simport-%04d.txt", opts.BlobPrefix, i)
+
+		fileURI, err := blobs.WriteBlob(ctx, name, file)
+		if err != nil {
+			return jobs, fmt.Errorf("NotificationHub.ImportRegistrations: failed to upload '%s': %w", name, err)
+		}
+
+		job, err := h.submitJob(ctx, &Job{
+			Type:               jobType,
+			OutputContainerUri: opts.OutputContainerUri,
+			ImportFileUri:      fileURI,
+		})
+		if err != nil {
+			return jobs, fmt.Errorf("NotificationHub.ImportRegistrations: failed to submit '%s': %w", name, err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// BuildImportFiles serializes registrations into import files
+// with one registration description per line,
+// each file being at most maxFileSize bytes
+func BuildImportFiles(registrations []RegistrationDescription, maxFileSize int) ([][]byte, error) {
+	var (
+		files [][]byte
+		file  bytes.Buffer
+	)
+
+	for i, r := range registrations {
+		line, err := marshalImportLine(r)
+		if err != nil {
+			return nil, fmt.Errorf("registration %d: %w", i, err)
+		}
+
+		if len(line) > maxFileSize {
+			return nil, fmt.Errorf("registration %d: %d bytes exceed file size limit %d", i, len(line), maxFileSize)
+		}
+
+		if file.Len()+len(line) > maxFileSize {
+			files = append(files, append([]byte(nil), file.Bytes()...))
+			file.Reset()
+		}
+		file.Write(line)
+	}
+
+	if file.Len() > 0 {
+		files = append(files, file.Bytes())
+	}
+
+	return files, nil
+}
+
+// newRegistrationXML returns the wire representation of registration r
+func newRegistrationXML(r RegistrationDescription) registrationImportXML {
+	return registrationImportXML{
+		XMLName:             xml.Name{Space: servicebusNamespace, Local: r.Type},
+		RegistrationId:      r.RegistrationId,
+		Tags:                r.Tags,
+		DeviceToken:         r.DeviceToken,
+		GcmRegistrationId:   r.GcmRegistrationId,
+		FcmV1RegistrationId: r.FcmV1RegistrationId,
+		ChannelUri:          r.ChannelUri,
+		AdmRegistrationId:   r.AdmRegistrationId,
+		BaiduChannelId:      r.BaiduChannelId,
+		BaiduUserId:         r.BaiduUserId,
+		BodyTemplate:        r.BodyTemplate,
+		TemplateName:        r.TemplateName,
+	}
+}
+
+// marshalImportLine serializes registration into import file line
+func marshalImportLine(r RegistrationDescription) ([]byte, error) {
+	if !strings.HasSuffix(r.Type, registrationDescriptionSuffix) {
+		return nil, errors.New("unknown registration description type '" + r.Type + "'")
+	}
+
+	b, err := xml.Marshal(newRegistrationXML(r))
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, '\n'), nil
+}
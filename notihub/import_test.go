@@ -0,0 +1,166 @@
+package notihub
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testJobEntryTemplate = `<entry xmlns="http://www.w3.org/2005/Atom">
+    <id>https://testhub-ns.servicebus.windows.net/hub/jobs/%[1]s?api-version=2015-01</id>
+    <title type="text">%[1]s</title>
+    <content type="application/xml">
+        <NotificationHubJob xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect" xmlns:i="http://www.w3.org/2001/XMLSchema-instance">
+            <JobId>%[1]s</JobId>
+            <Progress>%[2]s</Progress>
+            <Type>%[3]s</Type>
+            <Status>%[4]s</Status>
+            <OutputContainerUri>https://storage/output</OutputContainerUri>
+            <ImportFileUri>%[5]s</ImportFileUri>
+            <CreatedAt>2020-01-01T12:00:00Z</CreatedAt>
+            <UpdatedAt>2020-01-01T12:00:01Z</UpdatedAt>
+        </NotificationHubJob>
+    </content>
+</entry>`
+
+type mockBlobWriter struct {
+	blobs map[string][]byte
+}
+
+func (w *mockBlobWriter) WriteBlob(ctx context.Context, name string, data []byte) (string, error) {
+	w.blobs[name] = data
+	return "https://storage/import/" + name, nil
+}
+
+func Test_BuildImportFiles(t *testing.T) {
+	registrations := []RegistrationDescription{
+		{Type: "AppleRegistrationDescription", Tags: "tag1,tag2", DeviceToken: "token-1"},
+		{Type: "GcmRegistrationDescription", GcmRegistrationId: "gcm-1"},
+		{Type: "AppleTemplateRegistrationDescription", DeviceToken: "token-2", BodyTemplate: `{"aps":{"alert":"$(msg)"}}`, TemplateName: "v1"},
+	}
+
+	line, err := marshalImportLine(registrations[0])
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expectedLine := `<AppleRegistrationDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><Tags>tag1,tag2</Tags><DeviceToken>token-1</DeviceToken></AppleRegistrationDescription>` + "\n"
+	if string(line) != expectedLine {
+		t.Errorf("Expected line: %s, got: %s", expectedLine, line)
+	}
+
+	files, err := BuildImportFiles(registrations, 2*len(line))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 files, got: %d", len(files))
+	}
+
+	var decoded []RegistrationDescription
+	for _, file := range files {
+		if len(file) > 2*len(line) {
+			t.Errorf("Expected file of at most %d bytes, got: %d", 2*len(line), len(file))
+		}
+
+		dec := NewRegistrationDecoder(bytes.NewReader(file))
+		for dec.Next() {
+			decoded = append(decoded, dec.Registration())
+		}
+		if err := dec.Err(); err != nil {
+			t.Fatalf("Expected no decoding error, got: %v", err)
+		}
+	}
+
+	if !reflect.DeepEqual(decoded, registrations) {
+		t.Errorf("Expected decoded registrations: %v, got: %v", registrations, decoded)
+	}
+
+	if _, err := BuildImportFiles(registrations, 10); err == nil {
+		t.Error("Expected error for registration exceeding file size limit, got nil")
+	}
+
+	if _, err := BuildImportFiles([]RegistrationDescription{{Type: "Unknown"}}, 100); err == nil {
+		t.Error("Expected error for unknown registration type, got nil")
+	}
+}
+
+func Test_NotificationHubImportRegistrations(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var submitted []jobXML
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/hub/jobs" {
+			t.Errorf(errfmt, "request", "POST /hub/jobs", r.Method+" "+r.URL.Path)
+		}
+
+		if ct := r.Header.Get("Content-Type"); ct != atomEntryType {
+			t.Errorf(errfmt, "Content-Type", atomEntryType, ct)
+		}
+
+		b, _ := ioutil.ReadAll(r.Body)
+		var entry jobEntry
+		if err := xml.Unmarshal(b, &entry); err != nil {
+			t.Fatalf("failed to parse job entry %s: %s", b, err)
+		}
+		submitted = append(submitted, entry.Content.Job)
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, testJobEntryTemplate, fmt.Sprintf("job-%d", len(submitted)), "0", entry.Content.Job.Type, "Started", entry.Content.Job.ImportFileUri)
+	}))
+	defer server.Close()
+
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client())
+	blobs := &mockBlobWriter{blobs: map[string][]byte{}}
+
+	registrations := []RegistrationDescription{
+		{Type: "AppleRegistrationDescription", DeviceToken: strings.Repeat("a", 64)},
+		{Type: "AppleRegistrationDescription", DeviceToken: strings.Repeat("b", 64)},
+	}
+
+	jobs, err := hub.ImportRegistrations(context.Background(), ImportCreateRegistrations, registrations, blobs, ImportOptions{
+		OutputContainerUri: "https://storage/output",
+		MaxFileSize:        300,
+		BlobPrefix:         "batch/",
+	})
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	if len(jobs) != 2 || len(blobs.blobs) != 2 {
+		t.Fatalf(errfmt, "jobs and blobs", 2, fmt.Sprintf("%d jobs, %d blobs", len(jobs), len(blobs.blobs)))
+	}
+
+	expectedJob := &Job{
+		JobId:              "job-1",
+		Type:               ImportCreateRegistrations,
+		Status:             "Started",
+		OutputContainerUri: "https://storage/output",
+		ImportFileUri:      "https://storage/import/batch/import-0000.txt",
+		CreatedAt:          jobs[0].CreatedAt,
+		UpdatedAt:          jobs[0].UpdatedAt,
+	}
+	if !reflect.DeepEqual(jobs[0], expectedJob) {
+		t.Errorf(errfmt, "job", expectedJob, jobs[0])
+	}
+
+	if jobs[0].CreatedAt.IsZero() || jobs[0].UpdatedAt.IsZero() {
+		t.Errorf(errfmt, "job timestamps", "parsed", jobs[0])
+	}
+
+	if submitted[1].ImportFileUri != "https://storage/import/batch/import-0001.txt" || submitted[1].OutputContainerUri != "https://storage/output" {
+		t.Errorf(errfmt, "submitted job", "second import file", submitted[1])
+	}
+
+	if _, err := hub.ImportRegistrations(context.Background(), ExportRegistrations, registrations, blobs, ImportOptions{}); err == nil {
+		t.Error("Expected error for export job type, got nil")
+	}
+}
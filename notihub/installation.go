@@ -0,0 +1,279 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxConcurrentInstallationGets limits concurrent requests of GetInstallations
+const maxConcurrentInstallationGets = 10
+
+// ErrInvalidResourceID is returned for ids which are empty or would address a path outside of their resource
+var ErrInvalidResourceID = errors.New("invalid resource id")
+
+// JSON-Patch operations of installation patches
+const (
+	PatchAdd     PatchOp = "add"
+	PatchRemove  PatchOp = "remove"
+	PatchReplace PatchOp = "replace"
+)
+
+type (
+	// Installation describes a device registered through the installations API
+	Installation struct {
+		InstallationId     string                          `json:"installationId"`
+		UserId             string                          `json:"userId,omitempty"`
+		Platform           Platform                        `json:"platform"`
+		PushChannel        string                          `json:"pushChannel"`
+		ExpiredPushChannel bool                            `json:"expiredPushChannel,omitempty"`
+		Tags               []string                        `json:"tags,omitempty"`
+		Templates          map[string]InstallationTemplate `json:"templates,omitempty"`
+		ExpirationTime     *time.Time                      `json:"expirationTime,omitempty"`
+	}
+
+	// InstallationResult is the outcome of reading a single installation
+	InstallationResult struct {
+		Installation *Installation
+		Err          error
+	}
+
+	// PatchOp is a JSON-Patch operation
+	PatchOp string
+
+	// InstallationPatch is a JSON-Patch operation on an installation, e.g. adding a tag:
+	// {Op: PatchAdd, Path: "/tags", Value: "news"}, or replacing the push channel:
+	// {Op: PatchReplace, Path: "/pushChannel", Value: "handle"}
+	InstallationPatch struct {
+		Op    PatchOp     `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value,omitempty"`
+	}
+
+	// InstallationTemplate is a named template of an installation
+	InstallationTemplate struct {
+		Body    string            `json:"body"`
+		Headers map[string]string `json:"headers,omitempty"`
+		Expiry  string            `json:"expiry,omitempty"`
+		Tags    []string          `json:"tags,omitempty"`
+	}
+)
+
+// GetInstallations reads installations concurrently, results are keyed by installation id.
+// The hub has no batch read endpoint, so one request is made per installation.
+// Results are returned even when some of the reads fail
+func (h *NotificationHub) GetInstallations(ctx context.Context, ids []string) (map[string]InstallationResult, error) {
+	results := make(map[string]InstallationResult, len(ids))
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		sem    = make(chan struct{}, maxConcurrentInstallationGets)
+		unique []string
+		seen   = make(map[string]bool, len(ids))
+	)
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+
+		wg.Add(1)
+		sem <- struct{}{}
+		id := id
+		h.goLabeled(ctx, "installations", func(ctx context.Context) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			installation, err := h.cachedInstallation(ctx, id)
+
+			mu.Lock()
+			results[id] = InstallationResult{Installation: installation, Err: err}
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+
+	failed := 0
+	var first string
+	for _, id := range unique {
+		if results[id].Err != nil {
+			if failed == 0 {
+				first = id
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("NotificationHub.GetInstallations: %d of %d reads failed, first: %s: %w", failed, len(results), first, results[first].Err)
+	}
+
+	return results, nil
+}
+
+// CreateOrUpdateInstallation creates installation or overwrites the installation of the same id.
+// The hub applies the change asynchronously, reads may return the previous state for a few seconds
+func (h *NotificationHub) CreateOrUpdateInstallation(ctx context.Context, installation *Installation) error {
+	if installation.InstallationId == "" {
+		return fmt.Errorf("NotificationHub.CreateOrUpdateInstallation: installation id is required")
+	}
+
+	if err := h.putInstallation(ctx, installation); err != nil {
+		return fmt.Errorf("NotificationHub.CreateOrUpdateInstallation: %w", err)
+	}
+
+	return nil
+}
+
+// GetInstallation reads installation by id, *ResponseError with status 404 is returned when it does not exist
+func (h *NotificationHub) GetInstallation(ctx context.Context, installationID string) (*Installation, error) {
+	installation, err := h.cachedInstallation(ctx, installationID)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.GetInstallation: %w", err)
+	}
+
+	return installation, nil
+}
+
+// PatchInstallation applies JSON-Patch operations to installation by id, e.g. adding or removing tags
+// or replacing the push channel, without uploading the whole installation
+func (h *NotificationHub) PatchInstallation(ctx context.Context, installationID string, patches []InstallationPatch) error {
+	if len(patches) == 0 {
+		return nil
+	}
+
+	if err := h.patchInstallation(ctx, installationID, patches); err != nil {
+		return fmt.Errorf("NotificationHub.PatchInstallation: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteInstallation deletes installation by id, deleting installation which does not exist succeeds
+func (h *NotificationHub) DeleteInstallation(ctx context.Context, installationID string) error {
+	if err := h.deleteInstallation(ctx, installationID); err != nil {
+		return fmt.Errorf("NotificationHub.DeleteInstallation: %w", err)
+	}
+
+	return nil
+}
+
+// putInstallation creates or overwrites installation
+func (h *NotificationHub) putInstallation(ctx context.Context, installation *Installation) error {
+	body, err := h.encoder().Marshal(installation)
+	if err != nil {
+		return err
+	}
+
+	return h.putInstallationDocument(ctx, installation.InstallationId, body)
+}
+
+// putInstallationDocument creates or overwrites installation by id with JSON document body
+func (h *NotificationHub) putInstallationDocument(ctx context.Context, installationID string, body []byte) error {
+	relPath, err := installationPath(installationID)
+	if err != nil {
+		return err
+	}
+
+	req, err := h.newRequest(withSensitiveBodies(ctx), "PUT", relPath, h.hubURL.Query(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = h.exec(req, nil)
+	h.installations.invalidate(installationID, h.now())
+	return err
+}
+
+// patchInstallation applies patches to installation by id
+func (h *NotificationHub) patchInstallation(ctx context.Context, installationID string, patches []InstallationPatch) error {
+	body, err := h.encoder().Marshal(patches)
+	if err != nil {
+		return err
+	}
+
+	relPath, err := installationPath(installationID)
+	if err != nil {
+		return err
+	}
+
+	req, err := h.newRequest(withSensitiveBodies(ctx), "PATCH", relPath, h.hubURL.Query(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json-patch+json")
+
+	_, err = h.exec(req, nil)
+	h.installations.invalidate(installationID, h.now())
+	return err
+}
+
+// getInstallation reads installation by id
+func (h *NotificationHub) getInstallation(ctx context.Context, installationID string) (*Installation, error) {
+	b, err := h.getInstallationDocument(ctx, installationID)
+	if err != nil {
+		return nil, err
+	}
+
+	installation := &Installation{}
+	if err := h.encoder().Unmarshal(b, installation); err != nil {
+		return nil, err
+	}
+
+	return installation, nil
+}
+
+// getInstallationDocument reads JSON document of installation by id
+func (h *NotificationHub) getInstallationDocument(ctx context.Context, installationID string) ([]byte, error) {
+	relPath, err := installationPath(installationID)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := h.newRequest(withSensitiveBodies(ctx), "GET", relPath, h.hubURL.Query(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.exec(req, nil)
+}
+
+// deleteInstallation deletes installation by id
+func (h *NotificationHub) deleteInstallation(ctx context.Context, installationID string) error {
+	relPath, err := installationPath(installationID)
+	if err != nil {
+		return err
+	}
+
+	req, err := h.newRequest(withSensitiveBodies(ctx), "DELETE", relPath, h.hubURL.Query(), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.exec(req, nil)
+	h.installations.invalidate(installationID, h.now())
+	return err
+}
+
+// installationPath returns escaped path of installation by id
+func installationPath(installationID string) (string, error) {
+	return resourcePath("installations", installationID)
+}
+
+// resourcePath returns escaped path of resource id in collection. Empty ids, which address the collection,
+// and ids with path separators or dot segments, which address other resources, are rejected
+func resourcePath(collection, id string) (string, error) {
+	if id == "" || id == "." || strings.Contains(id, "/") || strings.Contains(id, "..") {
+		return "", fmt.Errorf("%w: '%s'", ErrInvalidResourceID, id)
+	}
+
+	return collection + "/" + url.PathEscape(id), nil
+}
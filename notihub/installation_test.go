@@ -0,0 +1,131 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func Test_NotificationHubGetInstallations(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newInstallationServer(
+		Installation{InstallationId: "a", Platform: PlatformApns, PushChannel: "token-a"},
+		Installation{InstallationId: "b", Platform: PlatformWns, PushChannel: "https://b"},
+	)
+	defer server.Close()
+
+	results, err := server.hub().GetInstallations(context.Background(), []string{"a", "missing", "b", "a"})
+	if err == nil || !isNotFoundError(results["missing"].Err) {
+		t.Errorf(errfmt, "missing installation error", "not found", err)
+	}
+
+	if len(results) != 3 {
+		t.Errorf(errfmt, "results", 3, len(results))
+	}
+	for _, id := range []string{"a", "b"} {
+		if result := results[id]; result.Err != nil || result.Installation == nil || result.Installation.InstallationId != id {
+			t.Errorf(errfmt, "installation "+id, id, result)
+		}
+	}
+
+	results, err = server.hub().GetInstallations(context.Background(), nil)
+	if err != nil || len(results) != 0 {
+		t.Errorf(errfmt, "empty read", "no results", results)
+	}
+}
+
+func Test_NotificationHubInstallationCRUD(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newInstallationServer()
+	defer server.Close()
+	hub := server.hub()
+
+	installation := &Installation{
+		InstallationId: "a",
+		Platform:       PlatformFcmV1,
+		PushChannel:    "token-a",
+		Tags:           []string{"user:1"},
+		Templates:      map[string]InstallationTemplate{"greeting": {Body: `{"message":{}}`}},
+	}
+	if err := hub.CreateOrUpdateInstallation(context.Background(), installation); err != nil {
+		t.Fatalf(errfmt, "create error", nil, err)
+	}
+
+	read, err := hub.GetInstallation(context.Background(), "a")
+	if err != nil || !reflect.DeepEqual(read, installation) {
+		t.Errorf(errfmt, "read installation", installation, read)
+	}
+
+	if err := hub.DeleteInstallation(context.Background(), "a"); err != nil {
+		t.Errorf(errfmt, "delete error", nil, err)
+	}
+	if _, err := hub.GetInstallation(context.Background(), "a"); !isNotFoundError(err) {
+		t.Errorf(errfmt, "deleted installation error", "not found", err)
+	}
+
+	if err := hub.CreateOrUpdateInstallation(context.Background(), &Installation{Platform: PlatformApns}); err == nil || server.writes != 2 {
+		t.Errorf(errfmt, "installation without id error", "error without request", err)
+	}
+}
+
+func Test_NotificationHubPatchInstallation(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var requests []string
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		b, _ := ioutil.ReadAll(req.Body)
+		requests = append(requests, req.Method+" "+req.URL.Path+" "+req.Header.Get("Content-Type")+" "+string(b))
+		return nil, nil
+	})
+
+	patches := []InstallationPatch{
+		{Op: PatchAdd, Path: "/tags", Value: "news"},
+		{Op: PatchReplace, Path: "/pushChannel", Value: "token-b"},
+	}
+	if err := nhub.PatchInstallation(context.Background(), "a", patches); err != nil {
+		t.Errorf(errfmt, "patch error", nil, err)
+	}
+	if err := nhub.PatchInstallation(context.Background(), "a", nil); err != nil {
+		t.Errorf(errfmt, "empty patch error", nil, err)
+	}
+
+	expected := []string{`PATCH /testPath/installations/a application/json-patch+json [{"op":"add","path":"/tags","value":"news"},{"op":"replace","path":"/pushChannel","value":"token-b"}]`}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf(errfmt, "requests", expected, requests)
+	}
+}
+
+func Test_NotificationHubInstallationIDs(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var paths []string
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		paths = append(paths, req.URL.EscapedPath())
+		return []byte(`{}`), nil
+	})
+
+	for _, id := range []string{"", ".", "..", "../../other/installations/b", "a/b"} {
+		if _, err := nhub.GetInstallation(context.Background(), id); !errors.Is(err, ErrInvalidResourceID) {
+			t.Errorf(errfmt, "get error of id '"+id+"'", ErrInvalidResourceID, err)
+		}
+		if err := nhub.DeleteInstallation(context.Background(), id); !errors.Is(err, ErrInvalidResourceID) {
+			t.Errorf(errfmt, "delete error of id '"+id+"'", ErrInvalidResourceID, err)
+		}
+	}
+	if len(paths) != 0 {
+		t.Errorf(errfmt, "requests of invalid ids", 0, paths)
+	}
+
+	if _, err := nhub.GetInstallation(context.Background(), "a b?c#d%"); err != nil {
+		t.Errorf(errfmt, "get error", nil, err)
+	}
+	expected := []string{"/testPath/installations/a%20b%3Fc%23d%25"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf(errfmt, "escaped paths", expected, paths)
+	}
+}
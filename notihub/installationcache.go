@@ -0,0 +1,200 @@
+package notihub
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// installationWriteDelay is the time the hub may take to apply installation writes,
+// reads of installations written meanwhile are not cached
+const installationWriteDelay = 10 * time.Second
+
+type (
+	// installationCache keeps installations read by GetInstallation until they expire
+	installationCache struct {
+		ttl        time.Duration
+		maxEntries int
+
+		mu      sync.Mutex
+		entries map[string]installationCacheEntry
+		// writes are installations written within installationWriteDelay
+		writes map[string]installationWrite
+		// generation counts writes, reads started before the write of a greater generation are not cached
+		generation uint64
+		// settled is the greatest generation of writes dropped from writes once applied by the hub
+		settled uint64
+	}
+
+	installationCacheEntry struct {
+		installation *Installation
+		expires      time.Time
+	}
+
+	installationWrite struct {
+		generation uint64
+		applied    time.Time
+	}
+)
+
+// WithInstallationCache makes GetInstallation and GetInstallations serve installations read
+// within ttl from memory, e.g. for reads on hot paths before sends. At most maxEntries installations
+// are kept, those expiring first are evicted. Installations written through the hub client
+// are evicted right away and not cached again until the hub applied the write,
+// writes made by other clients are seen once the cached copy expires
+func WithInstallationCache(ttl time.Duration, maxEntries int) HubOption {
+	return func(h *NotificationHub) {
+		h.installations = newInstallationCache(ttl, maxEntries)
+	}
+}
+
+func newInstallationCache(ttl time.Duration, maxEntries int) *installationCache {
+	return &installationCache{ttl: ttl, maxEntries: maxEntries, entries: map[string]installationCacheEntry{}, writes: map[string]installationWrite{}}
+}
+
+// cachedInstallation returns cached installation by id, reading it from the hub when not cached
+func (h *NotificationHub) cachedInstallation(ctx context.Context, installationID string) (*Installation, error) {
+	if installation, ok := h.installations.get(installationID, h.now()); ok {
+		return installation, nil
+	}
+
+	generation := h.installations.readGeneration()
+	installation, err := h.getInstallation(ctx, installationID)
+	if err != nil {
+		return nil, err
+	}
+
+	h.installations.put(installationID, installation, generation, h.now())
+	return installation, nil
+}
+
+// get returns copy of installation cached at now
+func (c *installationCache) get(installationID string, now time.Time) (*Installation, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[installationID]
+	if !ok {
+		return nil, false
+	}
+	if !now.Before(entry.expires) {
+		delete(c.entries, installationID)
+		return nil, false
+	}
+
+	return copyInstallation(entry.installation), true
+}
+
+// readGeneration returns the write generation reads starting now must be cached with
+func (c *installationCache) readGeneration() uint64 {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+// put caches copy of installation read at now by read started at write generation.
+// Reads started before writes, or made before the hub applied writes, are not cached
+func (c *installationCache) put(installationID string, installation *Installation, generation uint64, now time.Time) {
+	if c == nil || c.ttl <= 0 || c.maxEntries <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.settle(now)
+	if generation < c.settled {
+		return
+	}
+	if write, ok := c.writes[installationID]; ok && (generation < write.generation || now.Before(write.applied)) {
+		return
+	}
+
+	if _, ok := c.entries[installationID]; !ok && len(c.entries) >= c.maxEntries {
+		c.evict(now)
+	}
+	c.entries[installationID] = installationCacheEntry{installation: copyInstallation(installation), expires: now.Add(c.ttl)}
+}
+
+// evict drops expired entries, or the entry expiring first when none expired
+func (c *installationCache) evict(now time.Time) {
+	var (
+		first   string
+		expires time.Time
+	)
+	for id, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, id)
+			continue
+		}
+		if first == "" || entry.expires.Before(expires) {
+			first, expires = id, entry.expires
+		}
+	}
+
+	if len(c.entries) >= c.maxEntries {
+		delete(c.entries, first)
+	}
+}
+
+// settle drops writes applied by the hub at now
+func (c *installationCache) settle(now time.Time) {
+	for id, write := range c.writes {
+		if now.Before(write.applied) {
+			continue
+		}
+		if write.generation > c.settled {
+			c.settled = write.generation
+		}
+		delete(c.writes, id)
+	}
+}
+
+// invalidate drops installation written by the hub client at now
+func (c *installationCache) invalidate(installationID string, now time.Time) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.writes[installationID] = installationWrite{generation: c.generation, applied: now.Add(installationWriteDelay)}
+	delete(c.entries, installationID)
+}
+
+// copyInstallation returns deep copy of installation, so that callers can not modify cached copies
+func copyInstallation(installation *Installation) *Installation {
+	copied := *installation
+	copied.Tags = append([]string(nil), installation.Tags...)
+	if installation.ExpirationTime != nil {
+		expiration := *installation.ExpirationTime
+		copied.ExpirationTime = &expiration
+	}
+	if installation.Templates != nil {
+		copied.Templates = make(map[string]InstallationTemplate, len(installation.Templates))
+		for name, template := range installation.Templates {
+			template.Tags = append([]string(nil), template.Tags...)
+			if template.Headers != nil {
+				headers := make(map[string]string, len(template.Headers))
+				for header, value := range template.Headers {
+					headers[header] = value
+				}
+				template.Headers = headers
+			}
+			copied.Templates[name] = template
+		}
+	}
+
+	return &copied
+}
@@ -0,0 +1,123 @@
+package notihub
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_NotificationHubInstallationCache(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newInstallationServer(Installation{InstallationId: "a", Platform: PlatformApns, PushChannel: "handle", Tags: []string{"news"}})
+	defer server.Close()
+
+	clock := &mockClock{now: time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)}
+	nhub := server.hub(WithClock(clock), WithInstallationCache(time.Minute, 10))
+	ctx := context.Background()
+
+	setTags := func(tags ...string) {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		installation := server.installations["a"]
+		installation.Tags = tags
+		server.installations["a"] = installation
+	}
+	tags := func() []string {
+		installation, err := nhub.GetInstallation(ctx, "a")
+		if err != nil {
+			t.Fatalf(errfmt, "get error", nil, err)
+		}
+		return installation.Tags
+	}
+
+	tags()[0] = "modified"
+	setTags("sports")
+	if got := tags(); len(got) != 1 || got[0] != "news" {
+		t.Errorf(errfmt, "cached tags", []string{"news"}, got)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	if got := tags(); len(got) != 1 || got[0] != "sports" {
+		t.Errorf(errfmt, "tags after expiry", []string{"sports"}, got)
+	}
+
+	if err := nhub.CreateOrUpdateInstallation(ctx, &Installation{InstallationId: "a", Platform: PlatformApns, PushChannel: "handle", Tags: []string{"weather"}}); err != nil {
+		t.Fatalf(errfmt, "write error", nil, err)
+	}
+	if got := tags(); len(got) != 1 || got[0] != "weather" {
+		t.Errorf(errfmt, "tags after write", []string{"weather"}, got)
+	}
+
+	setTags("storm")
+	if got := tags(); len(got) != 1 || got[0] != "storm" {
+		t.Errorf(errfmt, "tags before write applied", []string{"storm"}, got)
+	}
+
+	clock.now = clock.now.Add(installationWriteDelay)
+	tags()
+	setTags("sun")
+	if got := tags(); len(got) != 1 || got[0] != "storm" {
+		t.Errorf(errfmt, "cached tags after write applied", []string{"storm"}, got)
+	}
+}
+
+func Test_InstallationCacheEviction(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := newInstallationCache(time.Minute, 2)
+
+	cache.put("a", &Installation{InstallationId: "a"}, 0, now)
+	cache.put("b", &Installation{InstallationId: "b"}, 0, now.Add(time.Second))
+	cache.put("c", &Installation{InstallationId: "c"}, 0, now.Add(2*time.Second))
+
+	for id, expected := range map[string]bool{"a": false, "b": true, "c": true} {
+		if _, ok := cache.get(id, now.Add(2*time.Second)); ok != expected {
+			t.Errorf(errfmt, "cached "+id, expected, ok)
+		}
+	}
+
+	cache.put("d", &Installation{InstallationId: "d"}, 0, now.Add(61*time.Second+time.Millisecond))
+	if len(cache.entries) != 2 {
+		t.Errorf(errfmt, "entries after expired eviction", 2, len(cache.entries))
+	}
+
+	cache.invalidate("d", now.Add(62*time.Second))
+	if _, ok := cache.get("d", now.Add(62*time.Second)); ok {
+		t.Errorf(errfmt, "invalidated", false, ok)
+	}
+}
+
+func Test_InstallationCacheWrites(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := newInstallationCache(time.Minute, 10)
+
+	inflight := cache.readGeneration()
+	cache.invalidate("a", now)
+
+	cache.put("a", &Installation{InstallationId: "a"}, inflight, now.Add(time.Second))
+	if _, ok := cache.get("a", now.Add(time.Second)); ok {
+		t.Errorf(errfmt, "cached read started before write", false, ok)
+	}
+
+	cache.put("a", &Installation{InstallationId: "a"}, cache.readGeneration(), now.Add(time.Second))
+	if _, ok := cache.get("a", now.Add(time.Second)); ok {
+		t.Errorf(errfmt, "cached read before write applied", false, ok)
+	}
+
+	cache.put("a", &Installation{InstallationId: "a"}, inflight, now.Add(installationWriteDelay))
+	if _, ok := cache.get("a", now.Add(installationWriteDelay)); ok {
+		t.Errorf(errfmt, "cached read started before applied write", false, ok)
+	}
+	if len(cache.writes) != 0 {
+		t.Errorf(errfmt, "writes after applied", 0, len(cache.writes))
+	}
+
+	cache.put("a", &Installation{InstallationId: "a"}, cache.readGeneration(), now.Add(installationWriteDelay))
+	if _, ok := cache.get("a", now.Add(installationWriteDelay)); !ok {
+		t.Errorf(errfmt, "cached read after write applied", true, ok)
+	}
+}
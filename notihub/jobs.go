@@ -0,0 +1,246 @@
+package notihub
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	atomNamespace       = "http://www.w3.org/2005/Atom"
+	servicebusNamespace = "http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"
+	atomEntryType       = "application/atom+xml;type=entry;charset=utf-8"
+)
+
+const (
+	ExportRegistrations       JobType = "ExportRegistrations"
+	ImportCreateRegistrations JobType = "ImportCreateRegistrations"
+	ImportUpdateRegistrations JobType = "ImportUpdateRegistrations"
+	ImportDeleteRegistrations JobType = "ImportDeleteRegistrations"
+	ImportUpsertRegistrations JobType = "ImportUpsertRegistrations"
+)
+
+const (
+	JobStarted   JobStatus = "Started"
+	JobRunning   JobStatus = "Running"
+	JobCompleted JobStatus = "Completed"
+	JobFailed    JobStatus = "Failed"
+)
+
+const (
+	jobOutputFileProperty = "OutputFilePath"
+	jobFailedFileProperty = "FailedFilePath"
+)
+
+var (
+	// jobPollInterval is the initial delay between job status polls
+	jobPollInterval = time.Second
+	// jobPollMaxInterval caps the delay between job status polls
+	jobPollMaxInterval = 30 * time.Second
+)
+
+type (
+	// JobType is the type of notification hub job
+	JobType string
+
+	// JobStatus is the state of notification hub job
+	JobStatus string
+
+	// JobOutput locates files written by a finished job
+	JobOutput struct {
+		// OutputFileUri lists the successfully processed registrations
+		OutputFileUri string
+		// FailedFileUri lists the registrations which failed to process
+		FailedFileUri string
+	}
+
+	// JobFailedError is returned by WaitForJob when the job fails
+	JobFailedError struct {
+		Job *Job
+	}
+
+	// Job is a notification hub import or export job
+	Job struct {
+		JobId              string
+		Type               JobType
+		Status             JobStatus
+		Progress           float64
+		OutputContainerUri string
+		ImportFileUri      string
+		Failure            string
+		Output             JobOutput
+		CreatedAt          time.Time
+		UpdatedAt          time.Time
+	}
+
+	jobEntry struct {
+		XMLName xml.Name        `xml:"http://www.w3.org/2005/Atom entry"`
+		Content jobEntryContent `xml:"content"`
+	}
+
+	jobEntryContent struct {
+		Type string `xml:"type,attr"`
+		Job  jobXML `xml:"NotificationHubJob"`
+	}
+
+	jobXML struct {
+		XMLName            xml.Name       `xml:"http://schemas.microsoft.com/netservices/2010/10/servicebus/connect NotificationHubJob"`
+		JobId              string         `xml:"JobId,omitempty"`
+		Progress           float64        `xml:"Progress,omitempty"`
+		Type               JobType        `xml:"Type"`
+		Status             JobStatus      `xml:"Status,omitempty"`
+		OutputContainerUri string         `xml:"OutputContainerUri"`
+		ImportFileUri      string         `xml:"ImportFileUri,omitempty"`
+		Failure            string         `xml:"Failure,omitempty"`
+		OutputProperties   []jobOutputXML `xml:"OutputProperties>KeyValueOfstringstring,omitempty"`
+		CreatedAt          string         `xml:"CreatedAt,omitempty"`
+		UpdatedAt          string         `xml:"UpdatedAt,omitempty"`
+	}
+
+	jobOutputXML struct {
+		Key   string `xml:"Key"`
+		Value string `xml:"Value"`
+	}
+)
+
+// SubmitJob submits import or export job to the hub.
+// OutputContainerUri and, for import jobs, ImportFileUri
+// must be blob storage SAS URIs accessible by the hub
+func (h *NotificationHub) SubmitJob(ctx context.Context, job *Job) (*Job, error) {
+	submitted, err := h.submitJob(ctx, job)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.SubmitJob: %w", err)
+	}
+
+	return submitted, nil
+}
+
+// GetJob returns job by id
+func (h *NotificationHub) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	job, err := h.getJob(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.GetJob: %w", err)
+	}
+
+	return job, nil
+}
+
+// WaitForJob polls job with increasing intervals until it completes or fails.
+// onProgress, when not nil, is called with every polled job state.
+// *JobFailedError is returned when the job fails
+func (h *NotificationHub) WaitForJob(ctx context.Context, jobID string, onProgress func(*Job)) (*Job, error) {
+	job, err := h.jobPoller(jobID).pollUntilDone(ctx, onProgress)
+	if err != nil {
+		var failed *JobFailedError
+		if errors.As(err, &failed) {
+			return job, err
+		}
+		return job, fmt.Errorf("NotificationHub.WaitForJob: %w", err)
+	}
+
+	return job, nil
+}
+
+// IsFinished identifies whether job completed or failed
+func (j *Job) IsFinished() bool {
+	return j.Status == JobCompleted || j.Status == JobFailed
+}
+
+// Error returns JobFailedError string representation
+func (e *JobFailedError) Error() string {
+	return fmt.Sprintf("job '%s' failed: %s", e.Job.JobId, e.Job.Failure)
+}
+
+func (h *NotificationHub) getJob(ctx context.Context, jobID string) (*Job, error) {
+	relPath, err := resourcePath("jobs", jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := h.newRequest(ctx, "GET", relPath, h.hubURL.Query(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := h.exec(req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseJob(b)
+}
+
+func (h *NotificationHub) submitJob(ctx context.Context, job *Job) (*Job, error) {
+	entry := jobEntry{
+		Content: jobEntryContent{
+			Type: "application/xml",
+			Job: jobXML{
+				Type:               job.Type,
+				OutputContainerUri: job.OutputContainerUri,
+				ImportFileUri:      job.ImportFileUri,
+			},
+		},
+	}
+
+	body, err := xml.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := h.newRequest(ctx, "POST", "jobs", h.hubURL.Query(), append([]byte(xml.Header), body...))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", atomEntryType)
+
+	b, err := h.exec(req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseJob(b)
+}
+
+// parseJob parses job entry returned by the hub
+func parseJob(b []byte) (*Job, error) {
+	var entry jobEntry
+	if err := xml.Unmarshal(b, &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse job: %w", err)
+	}
+
+	raw := entry.Content.Job
+	job := &Job{
+		JobId:              raw.JobId,
+		Type:               raw.Type,
+		Status:             raw.Status,
+		Progress:           raw.Progress,
+		OutputContainerUri: raw.OutputContainerUri,
+		ImportFileUri:      raw.ImportFileUri,
+		Failure:            raw.Failure,
+	}
+
+	for _, property := range raw.OutputProperties {
+		switch property.Key {
+		case jobOutputFileProperty:
+			job.Output.OutputFileUri = property.Value
+		case jobFailedFileProperty:
+			job.Output.FailedFileUri = property.Value
+		}
+	}
+
+	var err error
+	if raw.CreatedAt != "" {
+		if job.CreatedAt, err = parseHubTime(raw.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+	if raw.UpdatedAt != "" {
+		if job.UpdatedAt, err = parseHubTime(raw.UpdatedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	return job, nil
+}
@@ -0,0 +1,123 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testCompletedJobEntry = `<entry xmlns="http://www.w3.org/2005/Atom">
+    <content type="application/xml">
+        <NotificationHubJob xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect" xmlns:i="http://www.w3.org/2001/XMLSchema-instance">
+            <JobId>job-1</JobId>
+            <Progress>100</Progress>
+            <Type>ExportRegistrations</Type>
+            <Status>Completed</Status>
+            <OutputContainerUri>https://storage/output</OutputContainerUri>
+            <OutputProperties xmlns:d2p1="http://schemas.microsoft.com/2003/10/Serialization/Arrays">
+                <d2p1:KeyValueOfstringstring>
+                    <d2p1:Key>OutputFilePath</d2p1:Key>
+                    <d2p1:Value>https://storage/output/job-1/Output.txt</d2p1:Value>
+                </d2p1:KeyValueOfstringstring>
+                <d2p1:KeyValueOfstringstring>
+                    <d2p1:Key>FailedFilePath</d2p1:Key>
+                    <d2p1:Value>https://storage/output/job-1/Failed.txt</d2p1:Value>
+                </d2p1:KeyValueOfstringstring>
+            </OutputProperties>
+            <CreatedAt>2020-01-01T12:00:00Z</CreatedAt>
+            <UpdatedAt>2020-01-01T12:05:00Z</UpdatedAt>
+        </NotificationHubJob>
+    </content>
+</entry>`
+
+func Test_NotificationHubWaitForJob(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	defer func(interval time.Duration) { jobPollInterval = interval }(jobPollInterval)
+	jobPollInterval = time.Millisecond
+
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/hub/jobs/job-1" {
+			t.Errorf(errfmt, "request", "GET /hub/jobs/job-1", r.Method+" "+r.URL.Path)
+		}
+
+		polls++
+		switch polls {
+		case 1:
+			fmt.Fprintf(w, testJobEntryTemplate, "job-1", "0", ExportRegistrations, JobStarted, "")
+		case 2:
+			fmt.Fprintf(w, testJobEntryTemplate, "job-1", "50.5", ExportRegistrations, JobRunning, "")
+		default:
+			w.Write([]byte(testCompletedJobEntry))
+		}
+	}))
+	defer server.Close()
+
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client())
+
+	var progress []float64
+	job, err := hub.WaitForJob(context.Background(), "job-1", func(job *Job) {
+		progress = append(progress, job.Progress)
+	})
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	if fmt.Sprint(progress) != "[0 50.5 100]" {
+		t.Errorf(errfmt, "progress", "[0 50.5 100]", progress)
+	}
+
+	if !job.IsFinished() || job.Status != JobCompleted {
+		t.Errorf(errfmt, "status", JobCompleted, job.Status)
+	}
+
+	expectedOutput := JobOutput{
+		OutputFileUri: "https://storage/output/job-1/Output.txt",
+		FailedFileUri: "https://storage/output/job-1/Failed.txt",
+	}
+	if job.Output != expectedOutput {
+		t.Errorf(errfmt, "output", expectedOutput, job.Output)
+	}
+}
+
+func Test_NotificationHubWaitForJobFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, testJobEntryTemplate, "job-1", "10", ImportCreateRegistrations, JobFailed, "https://storage/import")
+	}))
+	defer server.Close()
+
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client())
+
+	job, err := hub.WaitForJob(context.Background(), "job-1", nil)
+
+	var failedErr *JobFailedError
+	if !errors.As(err, &failedErr) || failedErr.Job != job {
+		t.Errorf("Expected *JobFailedError, got: %v", err)
+	}
+}
+
+func Test_NotificationHubGetJobInvalidID(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client())
+
+	for _, id := range []string{"", "..", "../registrations", "a/b"} {
+		if _, err := hub.GetJob(context.Background(), id); !errors.Is(err, ErrInvalidResourceID) {
+			t.Errorf(errfmt, "error of job id "+id, ErrInvalidResourceID, err)
+		}
+	}
+	if requests != 0 {
+		t.Errorf(errfmt, "requests", 0, requests)
+	}
+}
@@ -0,0 +1,77 @@
+package notihub
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+)
+
+// ErrSendsDisabled is returned by mutating operations while a kill switch of the hub is engaged
+var ErrSendsDisabled = errors.New("sends disabled by kill switch")
+
+type (
+	// KillSwitch stops all mutating hub operations while engaged,
+	// used during incidents to stop pushes without redeploying
+	KillSwitch interface {
+		Engaged() bool
+	}
+
+	// KillSwitchFunc is KillSwitch backed by a callback, e.g. a remote config lookup.
+	// It is called before every mutating request, so it should be cheap
+	KillSwitchFunc func() bool
+
+	// AtomicKillSwitch is KillSwitch flipped at runtime, safe for concurrent use
+	AtomicKillSwitch struct {
+		engaged int32
+	}
+)
+
+// GlobalKillSwitch is checked by every hub in addition to the hub kill switches
+var GlobalKillSwitch = &AtomicKillSwitch{}
+
+// WithKillSwitch adds kill switch checked before every mutating request of the hub
+func WithKillSwitch(s KillSwitch) HubOption {
+	return func(h *NotificationHub) {
+		h.killSwitches = append(h.killSwitches, s)
+	}
+}
+
+// Engaged calls f
+func (f KillSwitchFunc) Engaged() bool {
+	return f()
+}
+
+// Engage disables mutating operations
+func (s *AtomicKillSwitch) Engage() {
+	atomic.StoreInt32(&s.engaged, 1)
+}
+
+// Release enables mutating operations
+func (s *AtomicKillSwitch) Release() {
+	atomic.StoreInt32(&s.engaged, 0)
+}
+
+// Engaged identifies whether mutating operations are disabled
+func (s *AtomicKillSwitch) Engaged() bool {
+	return atomic.LoadInt32(&s.engaged) == 1
+}
+
+// checkKillSwitch returns ErrSendsDisabled if req is mutating and any kill switch is engaged.
+// Reads are allowed, so that the hub can be inspected during incidents
+func (h *NotificationHub) checkKillSwitch(req *http.Request) error {
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS":
+		return nil
+	}
+
+	if GlobalKillSwitch.Engaged() {
+		return ErrSendsDisabled
+	}
+	for _, s := range h.killSwitches {
+		if s.Engaged() {
+			return ErrSendsDisabled
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func Test_NotificationHubKillSwitch(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	requests := 0
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		requests++
+		return []byte(`{"installationId":"id","platform":"gcm","pushChannel":"handle"}`), nil
+	})
+
+	hubSwitch := &AtomicKillSwitch{}
+	remote := false
+	WithKillSwitch(hubSwitch)(nhub)
+	WithKillSwitch(KillSwitchFunc(func() bool { return remote }))(nhub)
+
+	n := &Notification{AndroidFormat, []byte(`{"data":{}}`)}
+	ctx := context.Background()
+
+	testPatterns := []struct {
+		name    string
+		engage  func()
+		release func()
+	}{
+		{"hub switch", hubSwitch.Engage, hubSwitch.Release},
+		{"remote switch", func() { remote = true }, func() { remote = false }},
+		{"global switch", GlobalKillSwitch.Engage, GlobalKillSwitch.Release},
+	}
+
+	for _, testData := range testPatterns {
+		testData.engage()
+		requests = 0
+
+		_, sendErr := nhub.Send(ctx, n, []string{"news"})
+		deleteErr := nhub.deleteInstallation(ctx, "id")
+		_, getErr := nhub.getInstallation(ctx, "id")
+
+		testData.release()
+
+		if !errors.Is(sendErr, ErrSendsDisabled) || !errors.Is(deleteErr, ErrSendsDisabled) {
+			t.Errorf(errfmt, testData.name+" mutating errors", ErrSendsDisabled, []error{sendErr, deleteErr})
+		}
+		if getErr != nil || requests != 1 {
+			t.Errorf(errfmt, testData.name+" reads", 1, requests)
+		}
+	}
+
+	if _, err := nhub.Send(ctx, n, []string{"news"}); err != nil {
+		t.Errorf(errfmt, "released send error", nil, err)
+	}
+}
@@ -0,0 +1,247 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/pprof"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// asyncLatencyWeight is the weight of the latest send in the moving average of async send durations
+	asyncLatencyWeight = 0.2
+	// asyncLaneCapacity is the number of sends queued in each lane of WithAsyncLanes
+	asyncLaneCapacity = 10000
+)
+
+// ErrAsyncLaneFull is returned by SendAsync when the lane of the send has no room
+var ErrAsyncLaneFull = errors.New("async lane full")
+
+// Priority lanes of SendAsync
+const (
+	// PriorityCritical is the lane of transactional sends like one time passwords and alerts, the default
+	PriorityCritical Priority = iota
+	// PriorityBulk is the lane of campaign traffic, served only when no critical sends are queued
+	PriorityBulk
+)
+
+type (
+	// Priority is the SendAsync lane of a send
+	Priority int
+
+	// AsyncStats describes SendAsync load, e.g. for custom metrics scaling push workers
+	AsyncStats struct {
+		// Queued counts sends waiting in the lanes, always 0 without WithAsyncLanes
+		Queued int
+		// InFlight counts sends running
+		InFlight int
+		// AverageLatency is the moving average of send durations
+		AverageLatency time.Duration
+		// EstimatedDrain is the time to complete queued and running sends at the average latency
+		EstimatedDrain time.Duration
+	}
+
+	// AsyncMetrics is Metrics also receiving SendAsync load whenever a send is queued or completes
+	AsyncMetrics interface {
+		Metrics
+		ObserveAsync(stats AsyncStats)
+	}
+
+	// asyncLanes runs queued sends with limited concurrency, critical lane first.
+	// Bulk jobs run on all workers but one, which is kept for critical jobs.
+	// Workers are started on demand and exit once no queued job may run
+	asyncLanes struct {
+		workers  int
+		capacity int
+
+		mu         sync.Mutex
+		active     int
+		activeBulk int
+		critical   []func()
+		bulk       []func()
+	}
+)
+
+// WithAsyncLanes limits SendAsync to workers concurrent sends, at least 2. Sends beyond the limit are queued
+// in priority lanes, queued critical sends always run before queued bulk sends and one of the workers
+// runs critical sends only, so that transactional pushes are not starved by campaign traffic sharing
+// the hub client. Each lane queues up to 10000 sends, further sends fail with ErrAsyncLaneFull.
+// By default SendAsync does not queue
+func WithAsyncLanes(workers int) HubOption {
+	return func(h *NotificationHub) {
+		if workers < 2 {
+			workers = 2
+		}
+		h.lanes = &asyncLanes{workers: workers, capacity: asyncLaneCapacity}
+	}
+}
+
+// WithPriority sets the SendAsync lane of the send, PriorityCritical by default
+func WithPriority(priority Priority) SendOption {
+	return func(o *sendOptions) {
+		o.priority = priority
+	}
+}
+
+// goAsync runs f in a new goroutine, or queues it in the lane of priority when the hub has lanes.
+// ErrAsyncLaneFull is returned without running f when the lane has no room
+func (h *NotificationHub) goAsync(ctx context.Context, priority Priority, operation string, f func(ctx context.Context)) error {
+	tracked := func(ctx context.Context) {
+		h.stats.startAsync()
+		started := time.Now()
+		f(ctx)
+		h.stats.finishAsync(time.Since(started))
+		h.observeAsync()
+	}
+
+	if h.lanes == nil {
+		h.goLabeled(ctx, operation, tracked)
+		h.observeAsync()
+		return nil
+	}
+
+	labels := h.profileLabels(operation)
+	if err := h.lanes.submit(priority, func() {
+		pprof.Do(ctx, labels, tracked)
+	}); err != nil {
+		return err
+	}
+	h.observeAsync()
+
+	return nil
+}
+
+// asyncStats returns the current SendAsync load
+func (h *NotificationHub) asyncStats() AsyncStats {
+	var stats AsyncStats
+	workers := 1
+	if h.lanes != nil {
+		stats.Queued = h.lanes.depth()
+		workers = h.lanes.workers
+	}
+	if h.stats == nil {
+		return stats
+	}
+
+	stats.InFlight = int(atomic.LoadInt64(&h.stats.asyncInFlight))
+	stats.AverageLatency = time.Duration(atomic.LoadInt64(&h.stats.asyncLatency))
+
+	if h.lanes == nil && stats.InFlight > 0 {
+		workers = stats.InFlight
+	}
+	pending := stats.Queued + stats.InFlight
+	rounds := (pending + workers - 1) / workers
+	stats.EstimatedDrain = time.Duration(rounds) * stats.AverageLatency
+
+	return stats
+}
+
+// observeAsync reports SendAsync load to the hub metrics when they are AsyncMetrics
+func (h *NotificationHub) observeAsync() {
+	if metrics, ok := h.metrics.(AsyncMetrics); ok {
+		metrics.ObserveAsync(h.asyncStats())
+	}
+}
+
+// startAsync counts running async send
+func (s *hubStats) startAsync() {
+	if s != nil {
+		atomic.AddInt64(&s.asyncInFlight, 1)
+	}
+}
+
+// finishAsync counts completed async send and updates the moving average of durations
+func (s *hubStats) finishAsync(latency time.Duration) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddInt64(&s.asyncInFlight, -1)
+	for {
+		old := atomic.LoadInt64(&s.asyncLatency)
+		updated := int64(latency)
+		if old > 0 {
+			updated = int64(float64(old)*(1-asyncLatencyWeight) + float64(latency)*asyncLatencyWeight)
+		}
+		if atomic.CompareAndSwapInt64(&s.asyncLatency, old, updated) {
+			return
+		}
+	}
+}
+
+// depth returns the number of queued jobs in both lanes
+func (l *asyncLanes) depth() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.critical) + len(l.bulk)
+}
+
+// submit queues job in the lane of priority and starts a worker if below the limit
+func (l *asyncLanes) submit(priority Priority, job func()) error {
+	l.mu.Lock()
+	lane := &l.critical
+	if priority == PriorityBulk {
+		lane = &l.bulk
+	}
+	if len(*lane) >= l.capacity {
+		l.mu.Unlock()
+		return fmt.Errorf("%w: %d sends queued", ErrAsyncLaneFull, len(*lane))
+	}
+	*lane = append(*lane, job)
+
+	start := l.active < l.workers && (priority != PriorityBulk || l.activeBulk < l.workers-1)
+	if start {
+		l.active++
+	}
+	l.mu.Unlock()
+
+	if start {
+		go l.work()
+	}
+
+	return nil
+}
+
+// work runs queued jobs until none may run
+func (l *asyncLanes) work() {
+	for {
+		job, bulk, ok := l.next()
+		if !ok {
+			return
+		}
+		job()
+
+		if bulk {
+			l.mu.Lock()
+			l.activeBulk--
+			l.mu.Unlock()
+		}
+	}
+}
+
+// next dequeues critical job, or bulk job when no critical job is queued and a worker
+// other than the one kept for critical jobs is free. The worker is released when no job may run
+func (l *asyncLanes) next() (job func(), bulk bool, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch {
+	case len(l.critical) > 0:
+		job, l.critical[0] = l.critical[0], nil
+		l.critical = l.critical[1:]
+	case len(l.bulk) > 0 && l.activeBulk < l.workers-1:
+		job, l.bulk[0] = l.bulk[0], nil
+		l.bulk = l.bulk[1:]
+		l.activeBulk++
+		bulk = true
+	default:
+		l.active--
+		return nil, false, false
+	}
+
+	return job, bulk, true
+}
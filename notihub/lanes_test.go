@@ -0,0 +1,168 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_NotificationHubAsyncLanes(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	started, unblock := make(chan struct{}), make(chan struct{})
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		lane := req.Header.Get("X-Lane")
+		if lane == "blocking" {
+			close(started)
+			<-unblock
+		}
+
+		mu.Lock()
+		order = append(order, lane)
+		mu.Unlock()
+		return nil, nil
+	})
+	WithAsyncLanes(2)(nhub)
+
+	n := &Notification{AndroidFormat, []byte(`{"data":{}}`)}
+	send := func(lane string, opts ...SendOption) <-chan SendResult {
+		return nhub.SendAsync(context.Background(), n, []string{"news"}, append(opts, WithHeaders(map[string]string{"X-Lane": lane}))...)
+	}
+
+	results := []<-chan SendResult{send("blocking", WithPriority(PriorityBulk))}
+	<-started
+	for i := 0; i < 3; i++ {
+		results = append(results, send("bulk", WithPriority(PriorityBulk)))
+	}
+
+	select {
+	case result := <-send("critical"):
+		if result.Err != nil {
+			t.Errorf(errfmt, "critical send error", nil, result.Err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf(errfmt, "critical send", "run by the reserved worker", "starved by bulk sends")
+	}
+	close(unblock)
+
+	for _, r := range results {
+		if result := <-r; result.Err != nil {
+			t.Errorf(errfmt, "send error", nil, result.Err)
+		}
+	}
+
+	expected := []string{"critical", "blocking", "bulk", "bulk", "bulk"}
+	if len(order) != len(expected) || order[0] != "critical" || order[4] != "bulk" {
+		t.Errorf(errfmt, "send order", expected, order)
+	}
+}
+
+func Test_AsyncLanesCapacity(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	unblock := make(chan struct{})
+	lanes := &asyncLanes{workers: 2, capacity: 1}
+	for _, priority := range []Priority{PriorityBulk, PriorityCritical} {
+		if err := lanes.submit(priority, func() { <-unblock }); err != nil {
+			t.Fatalf(errfmt, "running job error", nil, err)
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	for _, priority := range []Priority{PriorityBulk, PriorityCritical} {
+		if err := lanes.submit(priority, func() {}); err != nil {
+			t.Errorf(errfmt, "queued job error", nil, err)
+		}
+		if err := lanes.submit(priority, func() {}); !errors.Is(err, ErrAsyncLaneFull) {
+			t.Errorf(errfmt, "full lane error", ErrAsyncLaneFull, err)
+		}
+	}
+	close(unblock)
+}
+
+type asyncMetrics struct {
+	mu       sync.Mutex
+	observed []AsyncStats
+}
+
+func (m *asyncMetrics) ObserveSend(event SendEvent) {}
+
+func (m *asyncMetrics) ObserveAsync(stats AsyncStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observed = append(m.observed, stats)
+}
+
+func Test_NotificationHubAsyncStats(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	started, unblock := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		once.Do(func() {
+			close(started)
+			<-unblock
+		})
+		return nil, nil
+	})
+	nhub.stats = &hubStats{}
+	metrics := &asyncMetrics{}
+	WithMetrics(metrics)(nhub)
+	WithAsyncLanes(2)(nhub)
+
+	n := &Notification{AndroidFormat, []byte(`{"data":{}}`)}
+	results := []<-chan SendResult{nhub.SendAsync(context.Background(), n, []string{"news"})}
+	<-started
+	for i := 0; i < 2; i++ {
+		results = append(results, nhub.SendAsync(context.Background(), n, []string{"news"}, WithPriority(PriorityBulk)))
+	}
+
+	if stats := nhub.Stats().Async; stats.Queued != 2 || stats.InFlight != 1 {
+		t.Errorf(errfmt, "load while blocked", "2 queued, 1 in flight", stats)
+	}
+
+	close(unblock)
+	for _, r := range results {
+		<-r
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for nhub.Stats().Async.InFlight > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if stats := nhub.Stats().Async; stats.Queued != 0 || stats.InFlight != 0 || stats.AverageLatency <= 0 || stats.EstimatedDrain != 0 {
+		t.Errorf(errfmt, "load after drain", "idle with average latency", stats)
+	}
+
+	metrics.mu.Lock()
+	observed := len(metrics.observed)
+	metrics.mu.Unlock()
+	if observed < 6 {
+		t.Errorf(errfmt, "observed load", "on every submit and completion", observed)
+	}
+}
+
+func Test_AsyncStatsDrainEstimate(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	nhub := newBulkTestHub(nil)
+	nhub.stats = &hubStats{asyncInFlight: 2, asyncLatency: int64(100 * time.Millisecond)}
+	nhub.lanes = &asyncLanes{workers: 2, critical: []func(){nil, nil}, bulk: []func(){nil}}
+
+	expected := AsyncStats{Queued: 3, InFlight: 2, AverageLatency: 100 * time.Millisecond, EstimatedDrain: 300 * time.Millisecond}
+	if stats := nhub.asyncStats(); stats != expected {
+		t.Errorf(errfmt, "stats", expected, stats)
+	}
+
+	nhub.stats.finishAsync(200 * time.Millisecond)
+	if latency := time.Duration(nhub.stats.asyncLatency); latency != 120*time.Millisecond {
+		t.Errorf(errfmt, "moving average", 120*time.Millisecond, latency)
+	}
+}
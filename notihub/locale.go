@@ -0,0 +1,282 @@
+package notihub
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vippsas/gozure/notihub/tags"
+)
+
+type (
+	// LocaleBundle holds messages per locale and key
+	LocaleBundle struct {
+		// Fallback is the locale used for devices without any of the bundle locales
+		Fallback string
+
+		messages map[string]map[string]string
+	}
+
+	// LocalizedRenderer builds notifications, one per format, of a localized message
+	LocalizedRenderer func(locale, message string) ([]*Notification, error)
+
+	// Localizer sends messages of a bundle to devices in their locale
+	Localizer struct {
+		hub       *NotificationHub
+		bundle    *LocaleBundle
+		render    LocalizedRenderer
+		localeTag func(locale string) (string, error)
+	}
+
+	// LocaleResult is the outcome of the send of a single locale.
+	// Fallback is set for the send to devices without any of the bundle locales
+	LocaleResult struct {
+		Locale   string
+		Fallback bool
+		Results  []PlatformResult
+		Err      error
+	}
+)
+
+// NewLocaleBundle initializes and returns LocaleBundle pointer
+func NewLocaleBundle(fallback string) *LocaleBundle {
+	return &LocaleBundle{Fallback: fallback, messages: map[string]map[string]string{}}
+}
+
+// LoadJSON adds messages of locale from JSON object mapping keys to messages
+func (b *LocaleBundle) LoadJSON(locale string, r io.Reader) error {
+	var messages map[string]string
+	if err := json.NewDecoder(r).Decode(&messages); err != nil {
+		return fmt.Errorf("LocaleBundle.LoadJSON: %s: %w", locale, err)
+	}
+
+	b.add(locale, messages)
+	return nil
+}
+
+// LoadPO adds messages of locale from gettext PO file, msgid being the key.
+// Untranslated entries are skipped and only the first plural form is used
+func (b *LocaleBundle) LoadPO(locale string, r io.Reader) error {
+	messages, err := parsePO(r)
+	if err != nil {
+		return fmt.Errorf("LocaleBundle.LoadPO: %s: %w", locale, err)
+	}
+
+	b.add(locale, messages)
+	return nil
+}
+
+// Locales returns sorted locales of the bundle
+func (b *LocaleBundle) Locales() []string {
+	locales := make([]string, 0, len(b.messages))
+	for locale := range b.messages {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+
+	return locales
+}
+
+// Message returns message of key in locale with "{name}" placeholders replaced by args
+func (b *LocaleBundle) Message(locale, key string, args map[string]string) (string, bool) {
+	message, ok := b.messages[locale][key]
+	if !ok {
+		return "", false
+	}
+
+	replacements := make([]string, 0, 2*len(args))
+	for name, value := range args {
+		replacements = append(replacements, "{"+name+"}", value)
+	}
+
+	return strings.NewReplacer(replacements...).Replace(message), true
+}
+
+func (b *LocaleBundle) add(locale string, messages map[string]string) {
+	if b.messages[locale] == nil {
+		b.messages[locale] = make(map[string]string, len(messages))
+	}
+	for key, message := range messages {
+		b.messages[locale][key] = message
+	}
+}
+
+// parsePO reads msgid to msgstr mapping of PO file
+func parsePO(r io.Reader) (map[string]string, error) {
+	messages := map[string]string{}
+
+	var (
+		id, str, ignored string
+		field            *string
+	)
+
+	flush := func() {
+		if id != "" && str != "" {
+			messages[id] = str
+		}
+		id, str, field = "", "", nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		var keyword, value string
+		switch {
+		case text == "" || strings.HasPrefix(text, "#"):
+			continue
+		case strings.HasPrefix(text, `"`):
+			value = text
+		default:
+			i := strings.IndexAny(text, " \t")
+			if i < 0 {
+				return nil, fmt.Errorf("line %d: unexpected '%s'", line, text)
+			}
+			keyword, value = text[:i], strings.TrimSpace(text[i:])
+		}
+
+		s, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		switch keyword {
+		case "":
+			if field == nil {
+				return nil, fmt.Errorf("line %d: string outside of entry", line)
+			}
+		case "msgctxt":
+			flush()
+			field = &ignored
+		case "msgid":
+			flush()
+			field = &id
+		case "msgid_plural":
+			field = &ignored
+		case "msgstr", "msgstr[0]":
+			field = &str
+		default:
+			if !strings.HasPrefix(keyword, "msgstr[") {
+				return nil, fmt.Errorf("line %d: unknown keyword '%s'", line, keyword)
+			}
+			field = &ignored
+		}
+
+		*field += s
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	return messages, nil
+}
+
+// NewLocalizer initializes and returns Localizer pointer.
+// localeTag maps locale to the tag of devices using it, tags.LocaleTag is used when nil
+func NewLocalizer(hub *NotificationHub, bundle *LocaleBundle, render LocalizedRenderer, localeTag func(locale string) string) *Localizer {
+	l := &Localizer{hub: hub, bundle: bundle, render: render}
+	if localeTag == nil {
+		l.localeTag = func(locale string) (string, error) {
+			tag, err := tags.LocaleTag(locale)
+			return tag.String(), err
+		}
+	} else {
+		l.localeTag = func(locale string) (string, error) {
+			return localeTag(locale), nil
+		}
+	}
+
+	return l
+}
+
+// SendLocalized broadcasts message key to devices matching baseTagExpr, empty for all devices,
+// in every bundle locale having the message. Devices without any of the bundle locale tags
+// receive the message of the fallback locale. Nothing is sent when the tag expression of any locale,
+// typically that of the fallback excluding all the others, exceeds the hub tag limits.
+// Results of all locales are returned even when some fail
+func (l *Localizer) SendLocalized(ctx context.Context, key string, args map[string]string, baseTagExpr string, opts ...SendOption) ([]LocaleResult, error) {
+	var orTags []string
+	if baseTagExpr != "" {
+		orTags = []string{baseTagExpr}
+	}
+
+	locales := l.bundle.Locales()
+	localeTags := make([]string, len(locales))
+	for i, locale := range locales {
+		tag, err := l.localeTag(locale)
+		if err != nil {
+			return nil, fmt.Errorf("Localizer.SendLocalized: %s: %w", locale, err)
+		}
+		localeTags[i] = tag
+	}
+
+	type localeSend struct {
+		locale, message string
+		opts            []SendOption
+		fallback        bool
+	}
+
+	var sends []localeSend
+	for i, locale := range locales {
+		if message, ok := l.bundle.Message(locale, key, args); ok {
+			sends = append(sends, localeSend{locale, message, append(opts[:len(opts):len(opts)], withAndTags(localeTags[i])), false})
+		}
+	}
+	if message, ok := l.bundle.Message(l.bundle.Fallback, key, args); ok && len(localeTags) > 0 {
+		without := "!(" + strings.Join(localeTags, " || ") + ")"
+		sends = append(sends, localeSend{l.bundle.Fallback, message, append(opts[:len(opts):len(opts)], withAndTags(without)), true})
+	}
+
+	for _, send := range sends {
+		if err := checkTagLimits(l.hub.sendTagExpression(ctx, orTags, newSendOptions(send.opts))); err != nil {
+			return nil, fmt.Errorf("Localizer.SendLocalized: %s: %w", send.locale, err)
+		}
+	}
+
+	var results []LocaleResult
+	for _, send := range sends {
+		result := l.send(ctx, send.locale, send.message, orTags, send.opts)
+		result.Fallback = send.fallback
+		results = append(results, result)
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("Localizer.SendLocalized: no locale has message '%s'", key)
+	}
+
+	failed := 0
+	var firstErr error
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", result.Locale, result.Err)
+			}
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("Localizer.SendLocalized: %d of %d locale sends failed, first: %w", failed, len(results), firstErr)
+	}
+
+	return results, nil
+}
+
+// send renders and broadcasts message of a single locale
+func (l *Localizer) send(ctx context.Context, locale, message string, orTags []string, opts []SendOption) LocaleResult {
+	result := LocaleResult{Locale: locale}
+
+	notifications, err := l.render(locale, message)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Results, result.Err = l.hub.Broadcast(ctx, notifications, orTags, opts...)
+	return result
+}
@@ -0,0 +1,138 @@
+package notihub
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testPO = `# Norwegian translations
+msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#: app/messages.go:10
+msgid "greeting"
+msgstr "Hei {name}!"
+
+msgctxt "menu"
+msgid "untranslated"
+msgstr ""
+
+msgid "goal"
+msgstr ""
+"{team} scoret "
+"et mål"
+
+msgid "one goal"
+msgid_plural "many goals"
+msgstr[0] "ett mål"
+msgstr[1] "mange mål"
+`
+
+func Test_LocaleBundleLoad(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	bundle := NewLocaleBundle("en")
+	if err := bundle.LoadJSON("en", strings.NewReader(`{"greeting": "Hi {name}!", "goal": "{team} scored"}`)); err != nil {
+		t.Fatalf(errfmt, "JSON load error", nil, err)
+	}
+	if err := bundle.LoadPO("nb", strings.NewReader(testPO)); err != nil {
+		t.Fatalf(errfmt, "PO load error", nil, err)
+	}
+
+	if locales := bundle.Locales(); !reflect.DeepEqual(locales, []string{"en", "nb"}) {
+		t.Errorf(errfmt, "locales", []string{"en", "nb"}, locales)
+	}
+
+	expected := map[string]string{"greeting": "Hei {name}!", "goal": "{team} scoret et mål", "one goal": "ett mål"}
+	if !reflect.DeepEqual(bundle.messages["nb"], expected) {
+		t.Errorf(errfmt, "PO messages", expected, bundle.messages["nb"])
+	}
+
+	if message, ok := bundle.Message("en", "greeting", map[string]string{"name": "Kari"}); !ok || message != "Hi Kari!" {
+		t.Errorf(errfmt, "rendered message", "Hi Kari!", message)
+	}
+	if _, ok := bundle.Message("nb", "untranslated", nil); ok {
+		t.Errorf(errfmt, "untranslated message", false, ok)
+	}
+
+	if err := bundle.LoadPO("sv", strings.NewReader("msgid \"a\"\nmsgstr unquoted")); err == nil {
+		t.Errorf(errfmt, "invalid PO error", "error", err)
+	}
+	if err := bundle.LoadJSON("sv", strings.NewReader(`["a"]`)); err == nil {
+		t.Errorf(errfmt, "invalid JSON error", "error", err)
+	}
+}
+
+func Test_LocalizerSendLocalized(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var (
+		mu    sync.Mutex
+		sends []string
+	)
+	mockClient := &mockHubHttpClient{execFunc: func(req *http.Request) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		b, _ := ioutil.ReadAll(req.Body)
+		sends = append(sends, req.Header.Get("ServiceBusNotification-Tags")+" => "+string(b))
+		return nil, nil
+	}}
+
+	nhub := &NotificationHub{
+		sasKeyValue:    "testKeyValue",
+		sasKeyName:     "testKeyName",
+		hubURL:         &url.URL{Host: "testHost", Scheme: schemeDefault, Path: "testPath"},
+		client:         mockClient,
+		expiryTimeFunc: TimeFunc(mockExpiryTime),
+	}
+
+	bundle := NewLocaleBundle("en")
+	bundle.LoadJSON("en", strings.NewReader(`{"goal": "{team} scored"}`))
+	bundle.LoadJSON("nb", strings.NewReader(`{"goal": "{team} scoret"}`))
+	bundle.LoadJSON("sv_SE", strings.NewReader(`{"other": "annan"}`))
+
+	render := func(locale, message string) ([]*Notification, error) {
+		return []*Notification{{AndroidFormat, []byte(message)}}, nil
+	}
+
+	localizer := NewLocalizer(nhub, bundle, render, nil)
+	results, err := localizer.SendLocalized(context.Background(), "goal", map[string]string{"team": "Vålerenga"}, "team:vif || team:lsk")
+	if err != nil {
+		t.Fatalf(errfmt, "send error", nil, err)
+	}
+
+	if len(results) != 3 || results[0].Locale != "en" || results[1].Locale != "nb" || !results[2].Fallback {
+		t.Errorf(errfmt, "locale results", "en, nb and fallback", results)
+	}
+
+	sort.Strings(sends)
+	expected := []string{
+		"(team:vif || team:lsk) && !(locale:en || locale:nb || locale:sv-se) => Vålerenga scored",
+		"(team:vif || team:lsk) && locale:en => Vålerenga scored",
+		"(team:vif || team:lsk) && locale:nb => Vålerenga scoret",
+	}
+	if !reflect.DeepEqual(sends, expected) {
+		t.Errorf(errfmt, "sends", expected, sends)
+	}
+
+	if _, err := localizer.SendLocalized(context.Background(), "missing", nil, ""); err == nil {
+		t.Errorf(errfmt, "missing key error", "error", err)
+	}
+
+	for _, locale := range []string{"da", "fi"} {
+		bundle.LoadJSON(locale, strings.NewReader(`{"goal": "mål"}`))
+	}
+	sends = nil
+	if _, err := localizer.SendLocalized(context.Background(), "goal", map[string]string{"team": "Vålerenga"}, "team:vif || team:lsk"); err == nil || len(sends) != 0 {
+		t.Errorf(errfmt, "fallback over tag limit", "error without sends", sends)
+	}
+}
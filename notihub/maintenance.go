@@ -0,0 +1,51 @@
+package notihub
+
+import "time"
+
+// MaintenanceWindow is a period during which bulk sends are deferred
+type MaintenanceWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// WithMaintenanceWindows defers bulk priority sends due within any of windows to the end of the window,
+// by scheduling them, while critical sends still go through. Scheduled sends require Standard tier hubs
+func WithMaintenanceWindows(windows ...MaintenanceWindow) HubOption {
+	return func(h *NotificationHub) {
+		h.maintenance = append(h.maintenance, windows...)
+	}
+}
+
+// contains identifies whether t is within the window, the end excluded
+func (w MaintenanceWindow) contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// deferMaintenance returns delivery time of the send deferred past maintenance windows.
+// deliverTime is nil for immediate sends, nil is returned for sends which are not deferred
+func (h *NotificationHub) deferMaintenance(deliverTime *time.Time, o *sendOptions) *time.Time {
+	if len(h.maintenance) == 0 || o == nil || o.priority != PriorityBulk {
+		return deliverTime
+	}
+
+	due := h.now()
+	if deliverTime != nil {
+		due = *deliverTime
+	}
+
+	deferred := false
+	for moved := true; moved; {
+		moved = false
+		for _, w := range h.maintenance {
+			if w.contains(due) {
+				due, moved, deferred = w.End, true, true
+			}
+		}
+	}
+
+	if !deferred {
+		return deliverTime
+	}
+
+	return &due
+}
@@ -0,0 +1,59 @@
+package notihub
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"testing"
+	"time"
+)
+
+func Test_NotificationHubMaintenanceWindows(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	clock := &mockClock{now: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	var endpoint, scheduleTime string
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		endpoint = path.Base(req.URL.Path)
+		scheduleTime = req.Header.Get("ServiceBusNotification-ScheduleTime")
+		return nil, nil
+	})
+	WithClock(clock)(nhub)
+	WithMaintenanceWindows(
+		MaintenanceWindow{Start: clock.now.Add(-time.Hour), End: clock.now.Add(time.Hour)},
+		MaintenanceWindow{Start: clock.now.Add(time.Hour), End: clock.now.Add(2 * time.Hour)},
+		MaintenanceWindow{Start: clock.now.Add(5 * time.Hour), End: clock.now.Add(6 * time.Hour)},
+	)(nhub)
+
+	bulk := WithPriority(PriorityBulk)
+	tests := []struct {
+		name         string
+		opts         []SendOption
+		endpoint     string
+		scheduleTime string
+	}{
+		{"critical", nil, "messages", ""},
+		{"bulk in adjacent windows", []SendOption{bulk}, "schedulednotifications", "2020-01-01T14:00:00"},
+		{"bulk scheduled between windows", []SendOption{bulk, WithDeliveryTime(clock.now.Add(3 * time.Hour))}, "schedulednotifications", "2020-01-01T15:00:00"},
+		{"bulk scheduled in window", []SendOption{bulk, WithDeliveryTime(clock.now.Add(5 * time.Hour))}, "schedulednotifications", "2020-01-01T18:00:00"},
+		{"critical scheduled in window", []SendOption{WithDeliveryTime(clock.now.Add(5 * time.Hour))}, "schedulednotifications", "2020-01-01T17:00:00"},
+	}
+
+	for _, test := range tests {
+		endpoint, scheduleTime = "", ""
+
+		if _, err := nhub.Send(context.Background(), &Notification{Template, []byte("{}")}, []string{"tag"}, test.opts...); err != nil {
+			t.Errorf(errfmt, test.name+" error", nil, err)
+			continue
+		}
+		if endpoint != test.endpoint || scheduleTime != test.scheduleTime {
+			t.Errorf(errfmt, test.name+" endpoint and schedule time", test.endpoint+" "+test.scheduleTime, endpoint+" "+scheduleTime)
+		}
+	}
+
+	clock.now = clock.now.Add(2 * time.Hour)
+	if _, err := nhub.Send(context.Background(), &Notification{Template, []byte("{}")}, []string{"tag"}, bulk); err != nil || endpoint != "messages" {
+		t.Errorf(errfmt, "bulk after windows endpoint", "messages", endpoint)
+	}
+}
@@ -0,0 +1,176 @@
+package notihub
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const xmlSchemaInstanceNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+// ErrInvalidCredential is returned when PNS credential fails local validation
+var ErrInvalidCredential = errors.New("invalid pns credential")
+
+type (
+	// ManagementClient manages the hub configuration, like PNS credentials.
+	// The hub connection string must grant Manage rights
+	ManagementClient struct {
+		hub *NotificationHub
+	}
+
+	hubDescriptionEntry struct {
+		XMLName xml.Name              `xml:"http://www.w3.org/2005/Atom entry"`
+		Content hubDescriptionContent `xml:"content"`
+	}
+
+	hubDescriptionContent struct {
+		Type        string            `xml:"type,attr"`
+		Description hubDescriptionXML `xml:"NotificationHubDescription"`
+	}
+
+	// hubDescriptionXML keeps description elements verbatim, in order,
+	// so that updates preserve settings the client does not model
+	hubDescriptionXML struct {
+		XMLName xml.Name `xml:"http://schemas.microsoft.com/netservices/2010/10/servicebus/connect NotificationHubDescription"`
+		// XMLNSInstance declares the instance namespace prefix used by raw elements
+		XMLNSInstance string              `xml:"xmlns:i,attr,omitempty"`
+		Elements      []hubDescriptionRaw `xml:",any"`
+	}
+
+	hubDescriptionRaw struct {
+		XMLName xml.Name
+		Attrs   []xml.Attr `xml:",any,attr"`
+		Inner   []byte     `xml:",innerxml"`
+	}
+
+	// credentialXML is the PNS credential element of the hub description
+	credentialXML struct {
+		XMLName    xml.Name
+		Properties []credentialProperty `xml:"Properties>Property"`
+	}
+
+	credentialProperty struct {
+		Name  string `xml:"Name"`
+		Value string `xml:"Value"`
+	}
+)
+
+// NewManagementClient initializes and returns ManagementClient pointer
+func NewManagementClient(hub *NotificationHub) *ManagementClient {
+	return &ManagementClient{hub: hub}
+}
+
+// getDescription reads the hub description
+func (m *ManagementClient) getDescription(ctx context.Context) (*hubDescriptionXML, error) {
+	req, err := m.hub.newRequest(withSensitiveBodies(ctx), "GET", "", m.hub.hubURL.Query(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := m.hub.exec(req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry hubDescriptionEntry
+	if err := xml.Unmarshal(b, &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse hub description: %w", err)
+	}
+
+	return &entry.Content.Description, nil
+}
+
+// putDescription overwrites the hub description
+func (m *ManagementClient) putDescription(ctx context.Context, description *hubDescriptionXML) error {
+	description.XMLNSInstance = xmlSchemaInstanceNamespace
+
+	body, err := xml.Marshal(hubDescriptionEntry{
+		Content: hubDescriptionContent{Type: "application/xml", Description: *description},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := m.hub.newRequest(withSensitiveBodies(ctx), "PUT", "", m.hub.hubURL.Query(), append([]byte(xml.Header), body...))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", atomEntryType)
+	req.Header.Set("If-Match", "*")
+
+	_, err = m.hub.exec(req, nil)
+	return err
+}
+
+// updateCredential replaces PNS credential of the hub description, keeping other settings
+func (m *ManagementClient) updateCredential(ctx context.Context, name string, properties []credentialProperty) error {
+	description, err := m.getDescription(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := description.setCredential(name, properties); err != nil {
+		return err
+	}
+
+	return m.putDescription(ctx, description)
+}
+
+// credential returns properties of PNS credential element,
+// false when the hub has no such credential
+func (d *hubDescriptionXML) credential(name string) (map[string]string, bool, error) {
+	for _, element := range d.Elements {
+		if element.XMLName.Local != name {
+			continue
+		}
+
+		var credential credentialXML
+		if err := xml.Unmarshal(element.wrapped(), &credential); err != nil {
+			return nil, false, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+
+		properties := make(map[string]string, len(credential.Properties))
+		for _, property := range credential.Properties {
+			properties[property.Name] = property.Value
+		}
+
+		return properties, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// setCredential replaces PNS credential element, adding it after the other credentials when missing
+func (d *hubDescriptionXML) setCredential(name string, properties []credentialProperty) error {
+	b, err := xml.Marshal(credentialXML{XMLName: xml.Name{Local: name}, Properties: properties})
+	if err != nil {
+		return err
+	}
+	inner := b[len("<"+name+">") : len(b)-len("</"+name+">")]
+
+	element := hubDescriptionRaw{XMLName: xml.Name{Space: servicebusNamespace, Local: name}, Inner: inner}
+
+	insertAt := len(d.Elements)
+	for i, existing := range d.Elements {
+		if existing.XMLName.Local == name {
+			d.Elements[i] = element
+			return nil
+		}
+		if strings.HasSuffix(existing.XMLName.Local, "Credential") {
+			insertAt = i + 1
+		}
+	}
+
+	d.Elements = append(d.Elements, hubDescriptionRaw{})
+	copy(d.Elements[insertAt+1:], d.Elements[insertAt:])
+	d.Elements[insertAt] = element
+
+	return nil
+}
+
+// wrapped returns the element as a standalone document
+func (e hubDescriptionRaw) wrapped() []byte {
+	return []byte("<" + e.XMLName.Local + ">" + string(e.Inner) + "</" + e.XMLName.Local + ">")
+}
@@ -0,0 +1,107 @@
+package notihub
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testHubDescription = `<entry xmlns="http://www.w3.org/2005/Atom"><title type="text">hub</title><content type="application/xml">` +
+	`<NotificationHubDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect" xmlns:i="http://www.w3.org/2001/XMLSchema-instance">` +
+	`<RegistrationTtl>P90D</RegistrationTtl>` +
+	`<ApnsCredential><Properties><Property><Name>Endpoint</Name><Value>https://api.push.apple.com:443/3/device</Value></Property><Property><Name>KeyId</Name><Value i:nil="true"/></Property></Properties></ApnsCredential>` +
+	`<AuthorizationRules/>` +
+	`</NotificationHubDescription></content></entry>`
+
+// hubDescriptionServer serves the hub description and records updates
+type hubDescriptionServer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	description string
+	updates     []*http.Request
+}
+
+func newHubDescriptionServer(description string) *hubDescriptionServer {
+	s := &hubDescriptionServer{description: description}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if r.URL.Path != "/hub" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		switch r.Method {
+		case "GET":
+			w.Write([]byte(s.description))
+		case "PUT":
+			b, _ := ioutil.ReadAll(r.Body)
+			s.description = string(b)
+			s.updates = append(s.updates, r)
+			w.Write(b)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+
+	return s
+}
+
+func (s *hubDescriptionServer) client() *ManagementClient {
+	return NewManagementClient(NewNotificationHub("Endpoint="+s.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", s.Client()))
+}
+
+func Test_ManagementClientUpdateCredential(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newHubDescriptionServer(testHubDescription)
+	defer server.Close()
+
+	client := server.client()
+	ctx := context.Background()
+
+	if err := client.updateCredential(ctx, "WnsCredential", []credentialProperty{{"PackageSid", "sid"}, {"SecretKey", "a<b"}}); err != nil {
+		t.Fatalf(errfmt, "update error", nil, err)
+	}
+	if err := client.updateCredential(ctx, "ApnsCredential", []credentialProperty{{"Endpoint", "https://api.sandbox.push.apple.com:443/3/device"}}); err != nil {
+		t.Fatalf(errfmt, "replace error", nil, err)
+	}
+
+	if len(server.updates) != 2 || server.updates[0].Header.Get("If-Match") != "*" || server.updates[0].Header.Get("Content-Type") != atomEntryType {
+		t.Fatalf(errfmt, "update requests", "2 conditional atom updates", server.updates)
+	}
+
+	description, err := client.getDescription(ctx)
+	if err != nil {
+		t.Fatalf(errfmt, "read error", nil, err)
+	}
+
+	var names []string
+	for _, element := range description.Elements {
+		names = append(names, element.XMLName.Local)
+		if element.XMLName.Space != servicebusNamespace {
+			t.Errorf(errfmt, element.XMLName.Local+" namespace", servicebusNamespace, element.XMLName.Space)
+		}
+	}
+	if got := strings.Join(names, ","); got != "RegistrationTtl,ApnsCredential,WnsCredential,AuthorizationRules" {
+		t.Errorf(errfmt, "description elements", "credentials grouped, other settings kept", got)
+	}
+
+	wns, ok, err := description.credential("WnsCredential")
+	if err != nil || !ok || wns["PackageSid"] != "sid" || wns["SecretKey"] != "a<b" {
+		t.Errorf(errfmt, "wns credential", "sid and escaped secret", wns)
+	}
+	apns, _, _ := description.credential("ApnsCredential")
+	if len(apns) != 1 || apns["Endpoint"] != "https://api.sandbox.push.apple.com:443/3/device" {
+		t.Errorf(errfmt, "apns credential", "replaced endpoint", apns)
+	}
+	if _, ok, _ := description.credential("GcmCredential"); ok {
+		t.Errorf(errfmt, "missing credential", false, ok)
+	}
+}
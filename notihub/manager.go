@@ -0,0 +1,170 @@
+package notihub
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+type (
+	// InstallationManagerOptions configures InstallationManager
+	InstallationManagerOptions struct {
+		// CoalesceWindow is the time patches of an installation are collected for,
+		// before they are applied by a single PATCH request. Patches are applied right away when zero
+		CoalesceWindow time.Duration
+		// PatchMode selects how patches are applied, PatchModeJSONPatch by default
+		PatchMode PatchMode
+	}
+
+	// InstallationManager updates installations on behalf of chatty clients,
+	// coalescing patches of the same installation into one request
+	InstallationManager struct {
+		h    *NotificationHub
+		opts InstallationManagerOptions
+
+		mu      sync.Mutex
+		pending map[string]*pendingPatch
+	}
+
+	// pendingPatch collects patches of an installation until the coalesce window closes
+	pendingPatch struct {
+		ctx     context.Context
+		patches []InstallationPatch
+		done    chan struct{}
+		err     error
+	}
+)
+
+// NewInstallationManager initializes and returns InstallationManager pointer
+func (h *NotificationHub) NewInstallationManager(opts InstallationManagerOptions) *InstallationManager {
+	return &InstallationManager{h: h, opts: opts, pending: map[string]*pendingPatch{}}
+}
+
+// AddTags adds tags to installation installationID. Updates of the same installation submitted
+// within the coalesce window are merged and applied by one PATCH request, whose error is returned to
+// all submitters. AddTags waits for the request, returning early when ctx is done, though the tags
+// are still added. The request carries context tags of the first submitter's ctx
+func (m *InstallationManager) AddTags(ctx context.Context, installationID string, tags ...string) error {
+	patches := make([]InstallationPatch, 0, len(tags))
+	for _, tag := range tags {
+		patches = append(patches, InstallationPatch{Op: PatchAdd, Path: "/tags", Value: tag})
+	}
+
+	if err := m.patch(ctx, installationID, patches...); err != nil {
+		return fmt.Errorf("InstallationManager.AddTags: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveTags removes tags from installation installationID, coalescing updates as AddTags does
+func (m *InstallationManager) RemoveTags(ctx context.Context, installationID string, tags ...string) error {
+	patches := make([]InstallationPatch, 0, len(tags))
+	for _, tag := range tags {
+		patches = append(patches, InstallationPatch{Op: PatchRemove, Path: "/tags/" + tag})
+	}
+
+	if err := m.patch(ctx, installationID, patches...); err != nil {
+		return fmt.Errorf("InstallationManager.RemoveTags: %w", err)
+	}
+
+	return nil
+}
+
+// Patch applies patches to installation installationID, coalescing updates as AddTags does
+func (m *InstallationManager) Patch(ctx context.Context, installationID string, patches ...InstallationPatch) error {
+	if err := m.patch(ctx, installationID, patches...); err != nil {
+		return fmt.Errorf("InstallationManager.Patch: %w", err)
+	}
+
+	return nil
+}
+
+// patch applies patches to installation installationID, merging patches submitted
+// for the same installation within the coalesce window
+func (m *InstallationManager) patch(ctx context.Context, installationID string, patches ...InstallationPatch) error {
+	if len(patches) == 0 {
+		return nil
+	}
+
+	if m.opts.CoalesceWindow <= 0 {
+		if err := m.apply(ctx, installationID, patches); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	p := m.enqueue(ctx, installationID, patches)
+
+	select {
+	case <-p.done:
+		if p.err != nil {
+			return p.err
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// enqueue adds patches to the pending patch of installationID, scheduling a new one when none is pending
+func (m *InstallationManager) enqueue(ctx context.Context, installationID string, patches []InstallationPatch) *pendingPatch {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.pending[installationID]
+	if !ok {
+		p = &pendingPatch{
+			ctx:  WithContextTags(context.Background(), ContextTags(ctx)...),
+			done: make(chan struct{}),
+		}
+		m.pending[installationID] = p
+		time.AfterFunc(m.opts.CoalesceWindow, func() { m.flush(installationID, p) })
+	}
+	p.patches = append(p.patches, patches...)
+
+	return p
+}
+
+// flush applies the merged patches of p once its window closes
+func (m *InstallationManager) flush(installationID string, p *pendingPatch) {
+	m.mu.Lock()
+	delete(m.pending, installationID)
+	patches := mergePatches(p.patches)
+	m.mu.Unlock()
+
+	p.err = m.apply(p.ctx, installationID, patches)
+	close(p.done)
+}
+
+// apply applies patches to installationID in the configured patch mode
+func (m *InstallationManager) apply(ctx context.Context, installationID string, patches []InstallationPatch) error {
+	if m.opts.PatchMode == PatchModeMerge {
+		return m.h.mergeInstallation(ctx, installationID, patches)
+	}
+
+	return m.h.patchInstallation(ctx, installationID, patches)
+}
+
+// mergePatches drops operations overridden by later ones: repeated identical operations
+// and replacements of the same path, keeping the order of the remaining operations
+func mergePatches(patches []InstallationPatch) []InstallationPatch {
+	merged := make([]InstallationPatch, 0, len(patches))
+	for i, patch := range patches {
+		overridden := false
+		for _, later := range patches[i+1:] {
+			if later.Path == patch.Path && (later.Op == patch.Op && reflect.DeepEqual(later.Value, patch.Value) ||
+				later.Op == PatchReplace && patch.Op == PatchReplace) {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged = append(merged, patch)
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,129 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_InstallationManagerCoalescing(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var (
+		mu      sync.Mutex
+		patches = map[string][]string{}
+	)
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		b, _ := ioutil.ReadAll(req.Body)
+		mu.Lock()
+		patches[req.URL.Path] = append(patches[req.URL.Path], string(b))
+		mu.Unlock()
+
+		if req.URL.Path == "/testPath/installations/missing" {
+			return nil, &ResponseError{StatusCode: http.StatusNotFound, Header: http.Header{}}
+		}
+		return nil, nil
+	})
+	manager := nhub.NewInstallationManager(InstallationManagerOptions{CoalesceWindow: 20 * time.Millisecond})
+
+	submits := []struct {
+		id      string
+		patches []InstallationPatch
+	}{
+		{"a", []InstallationPatch{{Op: PatchAdd, Path: "/tags", Value: "news"}}},
+		{"a", []InstallationPatch{{Op: PatchReplace, Path: "/pushChannel", Value: "old"}}},
+		{"b", []InstallationPatch{{Op: PatchAdd, Path: "/tags", Value: "sports"}}},
+		{"a", []InstallationPatch{{Op: PatchRemove, Path: "/tags/news"}, {Op: PatchAdd, Path: "/tags", Value: "news"}}},
+		{"a", []InstallationPatch{{Op: PatchReplace, Path: "/pushChannel", Value: "new"}}},
+		{"missing", []InstallationPatch{{Op: PatchAdd, Path: "/tags", Value: "news"}}},
+	}
+
+	errs := make([]error, len(submits))
+	var wg sync.WaitGroup
+	for i, submit := range submits {
+		wg.Add(1)
+		go func(i int, id string, patches []InstallationPatch) {
+			defer wg.Done()
+			errs[i] = manager.Patch(context.Background(), id, patches...)
+		}(i, submit.id, submit.patches)
+		time.Sleep(time.Millisecond)
+	}
+	wg.Wait()
+
+	expected := map[string][]string{
+		"/testPath/installations/a":       {`[{"op":"remove","path":"/tags/news"},{"op":"add","path":"/tags","value":"news"},{"op":"replace","path":"/pushChannel","value":"new"}]`},
+		"/testPath/installations/b":       {`[{"op":"add","path":"/tags","value":"sports"}]`},
+		"/testPath/installations/missing": {`[{"op":"add","path":"/tags","value":"news"}]`},
+	}
+	if !reflect.DeepEqual(patches, expected) {
+		t.Errorf(errfmt, "coalesced patches", expected, patches)
+	}
+
+	for i, err := range errs {
+		if submits[i].id == "missing" {
+			if !isNotFoundError(err) {
+				t.Errorf(errfmt, "missing installation error", http.StatusNotFound, err)
+			}
+		} else if err != nil {
+			t.Errorf(errfmt, submits[i].id+" error", nil, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := manager.Patch(ctx, "c", InstallationPatch{Op: PatchAdd, Path: "/tags", Value: "news"}); !errors.Is(err, context.Canceled) {
+		t.Errorf(errfmt, "canceled patch error", context.Canceled, err)
+	}
+}
+
+func Test_InstallationManagerWithoutWindow(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	requests := 0
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		requests++
+		return nil, nil
+	})
+	manager := nhub.NewInstallationManager(InstallationManagerOptions{})
+
+	for i := 0; i < 2; i++ {
+		if err := manager.Patch(context.Background(), "a", InstallationPatch{Op: PatchAdd, Path: "/tags", Value: "news"}); err != nil {
+			t.Errorf(errfmt, "patch error", nil, err)
+		}
+	}
+	if err := manager.Patch(context.Background(), "a"); err != nil || requests != 2 {
+		t.Errorf(errfmt, "requests", 2, requests)
+	}
+}
+
+func Test_InstallationManagerTags(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var patches []string
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		b, _ := ioutil.ReadAll(req.Body)
+		patches = append(patches, req.Method+" "+req.URL.Path+" "+string(b))
+		return nil, nil
+	})
+	manager := nhub.NewInstallationManager(InstallationManagerOptions{})
+
+	if err := manager.AddTags(context.Background(), "a", "news", "sports"); err != nil {
+		t.Errorf(errfmt, "add tags error", nil, err)
+	}
+	if err := manager.RemoveTags(context.Background(), "a", "news"); err != nil {
+		t.Errorf(errfmt, "remove tags error", nil, err)
+	}
+
+	expected := []string{
+		`PATCH /testPath/installations/a [{"op":"add","path":"/tags","value":"news"},{"op":"add","path":"/tags","value":"sports"}]`,
+		`PATCH /testPath/installations/a [{"op":"remove","path":"/tags/news"}]`,
+	}
+	if !reflect.DeepEqual(patches, expected) {
+		t.Errorf(errfmt, "patches", expected, patches)
+	}
+}
@@ -0,0 +1,134 @@
+package notihub
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Modes of InstallationManager updates
+const (
+	// PatchModeJSONPatch sends patches as application/json-patch+json PATCH requests
+	PatchModeJSONPatch PatchMode = iota
+	// PatchModeMerge applies patches to the installation document read from the hub and replaces it
+	// by a PUT request, for networks whose proxies strip JSON-Patch content types. Fields unknown
+	// to Installation are kept. The hub has no conditional installation writes, so concurrent
+	// updates of the installation made by others between the read and the write are lost.
+	// Prefer PatchModeJSONPatch wherever the network allows it
+	PatchModeMerge
+)
+
+// PatchMode selects how InstallationManager updates installations
+type PatchMode int
+
+// mergeInstallation reads JSON document of installation installationID, applies patches to it and writes it back
+func (h *NotificationHub) mergeInstallation(ctx context.Context, installationID string, patches []InstallationPatch) error {
+	b, err := h.getInstallationDocument(ctx, installationID)
+	if err != nil {
+		return err
+	}
+
+	var doc map[string]interface{}
+	if err := h.encoder().Unmarshal(b, &doc); err != nil {
+		return err
+	}
+
+	for _, patch := range patches {
+		if err := applyInstallationPatch(h.encoder(), doc, patch); err != nil {
+			return err
+		}
+	}
+	doc["installationId"] = installationID
+
+	body, err := h.encoder().Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	return h.putInstallationDocument(ctx, installationID, body)
+}
+
+// applyInstallationPatch applies patch to installation document doc the way the hub applies JSON-Patch:
+// adding to an array appends the value and removing "/tags/news" removes element "news" of the array.
+// Patch values are converted to JSON values by encoder
+func applyInstallationPatch(encoder Encoder, doc map[string]interface{}, patch InstallationPatch) error {
+	var value interface{}
+	if err := remarshalJSON(encoder, patch.Value, &value); err != nil {
+		return err
+	}
+
+	segments := strings.Split(strings.TrimPrefix(patch.Path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+	}
+
+	parent := doc
+	for i, segment := range segments[:len(segments)-1] {
+		switch child := parent[segment].(type) {
+		case map[string]interface{}:
+			parent = child
+			continue
+		case []interface{}:
+			if patch.Op == PatchRemove && i == len(segments)-2 {
+				parent[segment] = removeElement(child, segments[i+1])
+				return nil
+			}
+		case nil:
+			if patch.Op != PatchRemove {
+				created := map[string]interface{}{}
+				parent[segment], parent = created, created
+				continue
+			}
+			return nil
+		}
+		return fmt.Errorf("patch %s %s: '%s' is not an object", patch.Op, patch.Path, segment)
+	}
+
+	key := segments[len(segments)-1]
+	switch patch.Op {
+	case PatchAdd:
+		if elements, ok := parent[key].([]interface{}); ok {
+			if values, ok := value.([]interface{}); ok {
+				parent[key] = append(elements, values...)
+			} else {
+				parent[key] = append(elements, value)
+			}
+			return nil
+		}
+		if _, ok := value.(string); ok && key == "tags" {
+			value = []interface{}{value}
+		}
+		parent[key] = value
+	case PatchReplace:
+		parent[key] = value
+	case PatchRemove:
+		delete(parent, key)
+	default:
+		return fmt.Errorf("patch %s %s: unknown operation", patch.Op, patch.Path)
+	}
+
+	return nil
+}
+
+// removeElement returns elements without those equal to value
+func removeElement(elements []interface{}, value string) []interface{} {
+	kept := make([]interface{}, 0, len(elements))
+	for _, element := range elements {
+		if !reflect.DeepEqual(element, value) {
+			kept = append(kept, element)
+		}
+	}
+
+	return kept
+}
+
+// remarshalJSON converts src to dst through its JSON representation by encoder
+func remarshalJSON(encoder Encoder, src, dst interface{}) error {
+	b, err := encoder.Marshal(src)
+	if err != nil {
+		return err
+	}
+
+	return encoder.Unmarshal(b, dst)
+}
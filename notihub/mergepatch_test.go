@@ -0,0 +1,110 @@
+package notihub
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func Test_ApplyInstallationPatch(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	testPatterns := []struct {
+		patch    InstallationPatch
+		expected string
+	}{
+		{InstallationPatch{Op: PatchAdd, Path: "/tags", Value: "news"}, `{"pushChannel":"old","tags":["sports","news"],"templates":{"t":{"body":"{}"}}}`},
+		{InstallationPatch{Op: PatchAdd, Path: "/tags", Value: []string{"a", "b"}}, `{"pushChannel":"old","tags":["sports","a","b"],"templates":{"t":{"body":"{}"}}}`},
+		{InstallationPatch{Op: PatchRemove, Path: "/tags/sports"}, `{"pushChannel":"old","tags":[],"templates":{"t":{"body":"{}"}}}`},
+		{InstallationPatch{Op: PatchReplace, Path: "/pushChannel", Value: "new"}, `{"pushChannel":"new","tags":["sports"],"templates":{"t":{"body":"{}"}}}`},
+		{InstallationPatch{Op: PatchAdd, Path: "/templates/u", Value: InstallationTemplate{Body: "{}"}}, `{"pushChannel":"old","tags":["sports"],"templates":{"t":{"body":"{}"},"u":{"body":"{}"}}}`},
+		{InstallationPatch{Op: PatchAdd, Path: "/templates/t/tags", Value: "news"}, `{"pushChannel":"old","tags":["sports"],"templates":{"t":{"body":"{}","tags":["news"]}}}`},
+		{InstallationPatch{Op: PatchRemove, Path: "/templates/t"}, `{"pushChannel":"old","tags":["sports"],"templates":{}}`},
+		{InstallationPatch{Op: PatchRemove, Path: "/userId"}, `{"pushChannel":"old","tags":["sports"],"templates":{"t":{"body":"{}"}}}`},
+	}
+
+	for _, testData := range testPatterns {
+		var doc map[string]interface{}
+		json.Unmarshal([]byte(`{"pushChannel":"old","tags":["sports"],"templates":{"t":{"body":"{}"}}}`), &doc)
+
+		if err := applyInstallationPatch(StdEncoder{}, doc, testData.patch); err != nil {
+			t.Errorf(errfmt, testData.patch.Path+" error", nil, err)
+		}
+		if b, _ := json.Marshal(doc); string(b) != testData.expected {
+			t.Errorf(errfmt, string(testData.patch.Op)+" "+testData.patch.Path, testData.expected, string(b))
+		}
+	}
+
+	doc := map[string]interface{}{"pushChannel": "old"}
+	if err := applyInstallationPatch(StdEncoder{}, doc, InstallationPatch{Op: PatchAdd, Path: "/pushChannel/x", Value: "y"}); err == nil {
+		t.Errorf(errfmt, "non-object path error", "error", err)
+	}
+	if err := applyInstallationPatch(StdEncoder{}, doc, InstallationPatch{Op: "move", Path: "/pushChannel"}); err == nil {
+		t.Errorf(errfmt, "unknown operation error", "error", err)
+	}
+}
+
+func Test_InstallationManagerMergeMode(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var requests []string
+	var put Installation
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		requests = append(requests, req.Method+" "+req.Header.Get("Content-Type"))
+		if req.Method == "PUT" {
+			b, _ := ioutil.ReadAll(req.Body)
+			json.Unmarshal(b, &put)
+			return nil, nil
+		}
+		return []byte(`{"installationId":"a","platform":"fcmv1","pushChannel":"old","tags":["sports"]}`), nil
+	})
+	manager := nhub.NewInstallationManager(InstallationManagerOptions{PatchMode: PatchModeMerge})
+
+	err := manager.Patch(context.Background(), "a",
+		InstallationPatch{Op: PatchAdd, Path: "/tags", Value: "news"},
+		InstallationPatch{Op: PatchReplace, Path: "/pushChannel", Value: "new"})
+	if err != nil {
+		t.Fatalf(errfmt, "patch error", nil, err)
+	}
+
+	if expected := []string{"GET ", "PUT application/json"}; !reflect.DeepEqual(requests, expected) {
+		t.Errorf(errfmt, "requests", expected, requests)
+	}
+
+	expected := Installation{InstallationId: "a", Platform: PlatformFcmV1, PushChannel: "new", Tags: []string{"sports", "news"}}
+	if !reflect.DeepEqual(put, expected) {
+		t.Errorf(errfmt, "merged installation", expected, put)
+	}
+}
+
+func Test_InstallationManagerMergeModeDocument(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var put string
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		if req.Method == "PUT" {
+			b, _ := ioutil.ReadAll(req.Body)
+			put = string(b)
+			return nil, nil
+		}
+		return []byte(`{"installationId":"a","platform":"apns","pushChannel":"old","pushVariables":{"name":"Ola"}}`), nil
+	})
+	encoder := &countingEncoder{}
+	WithEncoder(encoder)(nhub)
+	manager := nhub.NewInstallationManager(InstallationManagerOptions{PatchMode: PatchModeMerge})
+
+	if err := manager.Patch(context.Background(), "a", InstallationPatch{Op: PatchAdd, Path: "/tags", Value: "news"}); err != nil {
+		t.Fatalf(errfmt, "patch error", nil, err)
+	}
+
+	expected := `{"installationId":"a","platform":"apns","pushChannel":"old","pushVariables":{"name":"Ola"},"tags":["news"]}`
+	if put != expected {
+		t.Errorf(errfmt, "merged document", expected, put)
+	}
+	if encoder.marshals != 2 || encoder.unmarshals != 2 {
+		t.Errorf(errfmt, "encoder calls", "2 marshals, 2 unmarshals", encoder)
+	}
+}
@@ -0,0 +1,185 @@
+package notihub
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// Metrics receives telemetry of notification sends
+	Metrics interface {
+		ObserveSend(event SendEvent)
+	}
+
+	// SendEvent describes a completed notification send, successful or not
+	SendEvent struct {
+		// Campaign is the name supplied with WithCampaign, empty if none
+		Campaign string
+		// TagExpression targeted by the send, empty for direct and untagged sends
+		TagExpression string
+		Format        NotificationFormat
+		// StatusCode of the failed response, 0 for successful sends and transport errors
+		StatusCode int
+		Attempts   int
+		Duration   time.Duration
+		Err        error
+	}
+
+	// CampaignStats aggregates sends of a single campaign
+	CampaignStats struct {
+		Sent     int
+		Failed   int
+		Attempts int
+		Duration time.Duration
+		// StatusCodes counts failed sends per response status code, 0 for transport errors
+		StatusCodes map[int]int
+	}
+
+	// CampaignMetrics is Metrics aggregating sends per campaign,
+	// sends without campaign are aggregated per tag expression
+	CampaignMetrics struct {
+		mu    sync.Mutex
+		stats map[string]*CampaignStats
+	}
+)
+
+// WithMetrics sets the hub telemetry receiver
+func WithMetrics(metrics Metrics) HubOption {
+	return func(h *NotificationHub) {
+		h.metrics = metrics
+	}
+}
+
+// WithCallMetrics overrides the hub telemetry receiver for a single send
+func WithCallMetrics(metrics Metrics) SendOption {
+	return func(o *sendOptions) {
+		o.metrics = metrics
+	}
+}
+
+// WithCampaign labels the send telemetry with campaign name
+func WithCampaign(name string) SendOption {
+	return func(o *sendOptions) {
+		o.campaign = name
+	}
+}
+
+// observeSend reports the send to the hub metrics
+func (h *NotificationHub) observeSend(n *Notification, headers map[string]string, o *sendOptions, started time.Time, err error) {
+	metrics := h.metrics
+	if o != nil && o.metrics != nil {
+		metrics = o.metrics
+	}
+	if metrics == nil {
+		return
+	}
+
+	event := SendEvent{
+		TagExpression: headers["ServiceBusNotification-Tags"],
+		Format:        n.Format,
+		Duration:      time.Since(started),
+		Err:           err,
+	}
+	if o != nil {
+		event.Campaign = o.campaign
+		if o.result != nil {
+			event.Attempts = o.result.Attempts
+		}
+	}
+
+	var resErr *ResponseError
+	if errors.As(err, &resErr) {
+		event.StatusCode = resErr.StatusCode
+	}
+
+	metrics.ObserveSend(event)
+}
+
+// NewCampaignMetrics initializes and returns CampaignMetrics pointer
+func NewCampaignMetrics() *CampaignMetrics {
+	return &CampaignMetrics{stats: map[string]*CampaignStats{}}
+}
+
+// ObserveSend aggregates the send
+func (m *CampaignMetrics) ObserveSend(event SendEvent) {
+	key := event.Campaign
+	if key == "" {
+		key = event.TagExpression
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.stats[key]
+	if !ok {
+		stats = &CampaignStats{StatusCodes: map[int]int{}}
+		m.stats[key] = stats
+	}
+
+	stats.Attempts += event.Attempts
+	stats.Duration += event.Duration
+	if event.Err != nil {
+		stats.Failed++
+		stats.StatusCodes[event.StatusCode]++
+	} else {
+		stats.Sent++
+	}
+}
+
+// Snapshot returns a copy of stats per campaign
+func (m *CampaignMetrics) Snapshot() map[string]CampaignStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make(map[string]CampaignStats, len(m.stats))
+	for key, stats := range m.stats {
+		copied := *stats
+		copied.StatusCodes = make(map[int]int, len(stats.StatusCodes))
+		for code, count := range stats.StatusCodes {
+			copied.StatusCodes[code] = count
+		}
+		snapshot[key] = copied
+	}
+
+	return snapshot
+}
+
+// WritePrometheus writes stats in Prometheus text exposition format
+func (m *CampaignMetrics) WritePrometheus(w io.Writer) error {
+	snapshot := m.Snapshot()
+
+	keys := make([]string, 0, len(snapshot))
+	for key := range snapshot {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("# TYPE notihub_sends_total counter\n")
+	for _, key := range keys {
+		label := prometheusLabelValue(key)
+		fmt.Fprintf(&b, "notihub_sends_total{campaign=\"%s\",result=\"success\"} %d\n", label, snapshot[key].Sent)
+		fmt.Fprintf(&b, "notihub_sends_total{campaign=\"%s\",result=\"failure\"} %d\n", label, snapshot[key].Failed)
+	}
+	b.WriteString("# TYPE notihub_send_attempts_total counter\n")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "notihub_send_attempts_total{campaign=\"%s\"} %d\n", prometheusLabelValue(key), snapshot[key].Attempts)
+	}
+	b.WriteString("# TYPE notihub_send_duration_seconds_total counter\n")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "notihub_send_duration_seconds_total{campaign=\"%s\"} %g\n", prometheusLabelValue(key), snapshot[key].Duration.Seconds())
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// prometheusLabelValue escapes label value
+func prometheusLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
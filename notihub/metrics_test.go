@@ -0,0 +1,66 @@
+package notihub
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func Test_NotificationHubCampaignMetrics(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	mockClient := &mockHubHttpClient{execFunc: func(req *http.Request) ([]byte, error) {
+		if strings.Contains(req.Header.Get("ServiceBusNotification-Tags"), "broken") {
+			return nil, &ResponseError{StatusCode: http.StatusBadRequest}
+		}
+		return nil, nil
+	}}
+
+	metrics := NewCampaignMetrics()
+	nhub := &NotificationHub{
+		sasKeyValue:    "testKeyValue",
+		sasKeyName:     "testKeyName",
+		hubURL:         &url.URL{Host: "testHost", Scheme: schemeDefault, Path: "testPath"},
+		client:         mockClient,
+		expiryTimeFunc: TimeFunc(mockExpiryTime),
+		metrics:        metrics,
+	}
+
+	n := &Notification{AndroidFormat, []byte(`{"data":{}}`)}
+	ctx := context.Background()
+
+	nhub.Send(ctx, n, []string{"news"}, WithCampaign("weekly \"digest\""))
+	nhub.Send(ctx, n, []string{"broken"}, WithCampaign("weekly \"digest\""))
+	nhub.Send(ctx, n, []string{"sport"})
+	nhub.SendDirect(ctx, n, "handle")
+
+	snapshot := metrics.Snapshot()
+
+	weekly := snapshot[`weekly "digest"`]
+	if weekly.Sent != 1 || weekly.Failed != 1 || weekly.Attempts != 2 || weekly.StatusCodes[http.StatusBadRequest] != 1 {
+		t.Errorf(errfmt, "campaign stats", "1 sent, 1 failed with 400", weekly)
+	}
+	if sport := snapshot["sport"]; sport.Sent != 1 {
+		t.Errorf(errfmt, "tag expression stats", 1, sport.Sent)
+	}
+	if direct := snapshot[""]; direct.Sent != 1 {
+		t.Errorf(errfmt, "direct send stats", 1, direct.Sent)
+	}
+
+	var b bytes.Buffer
+	if err := metrics.WritePrometheus(&b); err != nil {
+		t.Fatalf(errfmt, "export error", nil, err)
+	}
+	for _, line := range []string{
+		`notihub_sends_total{campaign="weekly \"digest\"",result="failure"} 1`,
+		`notihub_sends_total{campaign="sport",result="success"} 1`,
+		`notihub_send_attempts_total{campaign="weekly \"digest\""} 2`,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf(errfmt, "exported line", line, b.String())
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package notihub
+
+import "net/http"
+
+// Namespace holds the connection string and options shared by hubs of a notification hub namespace,
+// so that retry, metrics and logging are configured once for many per-tenant hubs.
+// Settings cascade: namespace options are applied first, hub options override them
+// and send options like WithCallRetry and WithCallMetrics override both for a single send.
+// Options accumulating values, like WithDefaultTags and WithDefaultHeaders, combine instead
+type Namespace struct {
+	connectionString string
+	client           *http.Client
+	opts             []HubOption
+}
+
+// NewNamespace initializes and returns Namespace pointer
+func NewNamespace(connectionString string, client *http.Client, opts ...HubOption) *Namespace {
+	return &Namespace{connectionString: connectionString, client: client, opts: opts}
+}
+
+// Hub returns client of hub hubPath in the namespace, configured with namespace options and opts
+func (ns *Namespace) Hub(hubPath string, opts ...HubOption) *NotificationHub {
+	hubOpts := make([]HubOption, 0, len(ns.opts)+len(opts))
+	hubOpts = append(append(hubOpts, ns.opts...), opts...)
+
+	return NewNotificationHub(ns.connectionString, hubPath, ns.client, hubOpts...)
+}
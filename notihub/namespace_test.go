@@ -0,0 +1,67 @@
+package notihub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_NamespaceSettingsCascade(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var (
+		mu       sync.Mutex
+		requests = map[string]int{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	namespaceMetrics, callMetrics := NewCampaignMetrics(), NewCampaignMetrics()
+	ns := NewNamespace("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", server.Client(),
+		WithRetry(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}),
+		WithMetrics(namespaceMetrics),
+		WithDefaultTags("env:prod"),
+	)
+
+	tenantA := ns.Hub("tenant-a")
+	tenantB := ns.Hub("tenant-b", WithRetry(RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}), WithDefaultTags("tier:gold"))
+
+	if len(tenantB.defaultTags) != 2 || len(tenantA.defaultTags) != 1 {
+		t.Errorf(errfmt, "accumulated default tags", "env:prod and tier:gold", tenantB.defaultTags)
+	}
+
+	n := &Notification{AndroidFormat, []byte(`{"data":{}}`)}
+	ctx := context.Background()
+	tenantA.Send(ctx, n, []string{"news"}, WithCampaign("a"))
+	tenantB.Send(ctx, n, []string{"news"}, WithCampaign("b"))
+	tenantB.Send(ctx, n, []string{"news"}, WithCampaign("call"), WithCallRetry(RetryPolicy{}), WithCallMetrics(callMetrics))
+
+	testPatterns := []struct {
+		path     string
+		expected int
+	}{
+		{"/tenant-a/messages", 3},
+		{"/tenant-b/messages", 2 + 1},
+	}
+
+	for _, testData := range testPatterns {
+		if requests[testData.path] != testData.expected {
+			t.Errorf(errfmt, testData.path+" attempts", testData.expected, requests[testData.path])
+		}
+	}
+
+	if stats := namespaceMetrics.Snapshot(); len(stats) != 2 || stats["a"].Failed != 1 || stats["b"].Failed != 1 {
+		t.Errorf(errfmt, "namespace metrics", "campaigns a and b", stats)
+	}
+	if stats := callMetrics.Snapshot(); len(stats) != 1 || stats["call"].Attempts != 1 {
+		t.Errorf(errfmt, "call metrics", "single attempt of campaign call", stats)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -29,6 +30,10 @@ const (
 	apiVersionParam = "api-version"
 	apiVersionValue = "2015-01"
 	directParam     = "direct"
+	testParam       = "test"
+
+	correlationIdHeader = "x-ms-client-request-id"
+	trackingIdHeader    = "TrackingId"
 
 	// for connection string parsing
 	schemeServiceBus  = "sb"
@@ -41,6 +46,7 @@ const (
 const (
 	Template           NotificationFormat = "template"
 	AndroidFormat      NotificationFormat = "gcm"
+	FcmV1Format        NotificationFormat = "fcmv1"
 	AppleFormat        NotificationFormat = "apple"
 	BaiduFormat        NotificationFormat = "baidu"
 	KindleFormat       NotificationFormat = "adm"
@@ -98,6 +104,34 @@ type (
 		hubURL         *url.URL
 		client         HubClient
 		expiryTimeFunc TimeFunc // use buildExpiryTimeFunc
+		clock          Clock
+		skew           skewTracker
+		defaultTags    []string
+		defaultHeaders map[string]string
+		environment    string
+		retry          RetryPolicy
+		approval       *approval
+		metrics        Metrics
+		transportOpts  []transportOption
+		roundTripper   http.RoundTripper
+		timeouts       *Timeouts
+		debugWriter    io.Writer
+		sampler        *payloadSampler
+		appID          string
+		jsonEncoder    Encoder
+		hedgeDelay     time.Duration
+		fcmV1Convert   bool
+		killSwitches   []KillSwitch
+		shadow         *shadowHub
+		stats          *hubStats
+		lanes          *asyncLanes
+		maintenance    []MaintenanceWindow
+		auditKey       *auditKey
+		history        HistoryStore
+		preferences    Preferences
+		installations  *installationCache
+		latency        *latencyTracker
+		transformers   []PayloadTransformer
 
 		regIdPath *xmlpath.Path
 		eTagPath  *xmlpath.Path
@@ -113,6 +147,14 @@ type (
 	hubHttpClient struct {
 		httpClient *http.Client
 	}
+
+	// ResponseError is returned when notification hub
+	// responds with an unexpected status code
+	ResponseError struct {
+		StatusCode int
+		Header     http.Header
+		Body       []byte
+	}
 )
 
 // UnixTimestamp calls f()
@@ -126,6 +168,18 @@ func (hc *hubHttpClient) Exec(req *http.Request) ([]byte, error) {
 	return handleResponse(hc.httpClient.Do(req))
 }
 
+// execWithHeader executes notification hub http request
+// and returns response headers along with the handled response
+func (hc *hubHttpClient) execWithHeader(req *http.Request) ([]byte, http.Header, error) {
+	resp, err := hc.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := handleResponse(resp, nil)
+	return b, resp.Header, err
+}
+
 // GetContentType returns Content-Type
 // associated with NotificationFormat
 func (f NotificationFormat) GetContentType() string {
@@ -133,6 +187,7 @@ func (f NotificationFormat) GetContentType() string {
 	case Template,
 		AppleFormat,
 		AndroidFormat,
+		FcmV1Format,
 		KindleFormat,
 		BaiduFormat:
 		return "application/json"
@@ -145,6 +200,7 @@ func (f NotificationFormat) GetContentType() string {
 func (f NotificationFormat) IsValid() bool {
 	return f == Template ||
 		f == AndroidFormat ||
+		f == FcmV1Format ||
 		f == AppleFormat ||
 		f == BaiduFormat ||
 		f == KindleFormat ||
@@ -167,11 +223,13 @@ func (n *Notification) String() string {
 }
 
 // NewNotificationHub initializes and returns NotificationHub pointer
-func NewNotificationHub(connectionString, hubPath string, client *http.Client) *NotificationHub {
+func NewNotificationHub(connectionString, hubPath string, client *http.Client, opts ...HubOption) *NotificationHub {
 	connData := strings.Split(connectionString, ";")
 
 	hub := &NotificationHub{
 		hubURL: &url.URL{},
+		clock:  systemClock{},
+		stats:  &hubStats{},
 	}
 
 	for _, connItem := range connData {
@@ -202,7 +260,13 @@ func NewNotificationHub(connectionString, hubPath string, client *http.Client) *
 	hub.hubURL.RawQuery = url.Values{apiVersionParam: {apiVersionValue}}.Encode()
 
 	hub.client = &hubHttpClient{httpClient: client}
-	hub.expiryTimeFunc = buildExpiryTimeFunc(time.Hour)
+
+	for _, opt := range opts {
+		opt(hub)
+	}
+	hub.configureClient()
+
+	hub.expiryTimeFunc = buildClockExpiryTimeFunc(hub.clock, time.Hour)
 
 	hub.regIdPath = xmlpath.MustCompile("/entry/content/*/RegistrationId")
 	hub.eTagPath = xmlpath.MustCompile("/entry/content/*/ETag")
@@ -212,100 +276,223 @@ func NewNotificationHub(connectionString, hubPath string, client *http.Client) *
 }
 
 // Send publishes notification to the azure hub
-func (h *NotificationHub) Send(ctx context.Context, n *Notification, orTags []string) ([]byte, error) {
-	b, err := h.send(ctx, n, orTags, nil)
+func (h *NotificationHub) Send(ctx context.Context, n *Notification, orTags []string, opts ...SendOption) ([]byte, error) {
+	b, err := h.send(ctx, n, orTags, newSendOptions(opts))
 	if err != nil {
-		return nil, fmt.Errorf("NotificationHub.Send: %s", err)
+		return nil, fmt.Errorf("NotificationHub.Send: %w", err)
 	}
 
 	return b, nil
 }
 
-func (h *NotificationHub) SendDirect(ctx context.Context, n *Notification, deviceHandle string) ([]byte, error) {
-	b, err := h.sendDirect(ctx, n, deviceHandle)
+func (h *NotificationHub) SendDirect(ctx context.Context, n *Notification, deviceHandle string, opts ...SendOption) ([]byte, error) {
+	b, err := h.sendDirect(ctx, n, deviceHandle, newSendOptions(opts))
 	if err != nil {
-		return nil, fmt.Errorf("NotificationHub.SendDirect: %s", err)
+		return nil, fmt.Errorf("NotificationHub.SendDirect: %w", err)
 	}
 
 	return b, nil
 }
 
-// Schedule publishes a scheduled notification to azure notification hub
-func (h *NotificationHub) Schedule(ctx context.Context, n *Notification, orTags []string, deliverTime time.Time) ([]byte, error) {
-	b, err := h.send(ctx, n, orTags, &deliverTime)
+// SendTest publishes notification to at most 10 recipients
+// and returns the per-registration outcome reported by the hub.
+// ErrApnsEnvironmentMismatch is returned when outcome indicates
+// the device token belongs to another APNS environment than the hub
+func (h *NotificationHub) SendTest(ctx context.Context, n *Notification, orTags []string, opts ...SendOption) ([]byte, error) {
+	b, err := h.sendTest(ctx, n, orTags, newSendOptions(opts))
 	if err != nil {
-		return nil, fmt.Errorf("NotificationHub.Schedule: %s", err)
+		return nil, fmt.Errorf("NotificationHub.SendTest: %w", err)
 	}
 
 	return b, nil
 }
 
-// send sends notification to the azure hub
-func (h *NotificationHub) send(ctx context.Context, n *Notification, orTags []string, deliverTime *time.Time) ([]byte, error) {
-	token := h.generateSasToken()
-	buf := bytes.NewBuffer(n.Payload)
+// Schedule publishes a scheduled notification to azure notification hub.
+// Notifications with deliverTime not after the hub clock are sent immediately.
+// It is equivalent to Send with WithDeliveryTime and WithSendImmediatelyIfPast options
+func (h *NotificationHub) Schedule(ctx context.Context, n *Notification, orTags []string, deliverTime time.Time, opts ...SendOption) ([]byte, error) {
+	o := newSendOptions(append(opts[:len(opts):len(opts)], WithDeliveryTime(deliverTime), WithSendImmediatelyIfPast()))
 
-	headers := map[string]string{
-		"Authorization":                 token,
-		"Content-Type":                  n.Format.GetContentType(),
-		"ServiceBusNotification-Format": string(n.Format),
-		"X-Apns-Expiration":             h.expiryTimeFunc.UnixTimestamp(),
+	b, err := h.send(ctx, n, orTags, o)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.Schedule: %w", err)
 	}
 
-	if len(orTags) > 0 {
-		headers["ServiceBusNotification-Tags"] = strings.Join(orTags, " || ")
+	return b, nil
+}
+
+// send sends notification to the azure hub,
+// scheduling it when the options set delivery time
+func (h *NotificationHub) send(ctx context.Context, n *Notification, orTags []string, o *sendOptions) ([]byte, error) {
+	if err := h.checkEnvironment(); err != nil {
+		return nil, err
 	}
 
-	//IOS 13 and upwards require these headers to be set. They are not set by Notification Hub at the moment, so we need to send them
-	if n.Format == AppleFormat {
-		if isAppleBackgroundNotification(n.Payload) {
-			headers["X-Apns-Push-Type"] = "background"
-			headers["X-Apns-Priority"] = "5"
-		} else {
-			headers["X-Apns-Push-Type"] = "alert"
-			headers["X-Apns-Priority"] = "10"
-		}
+	orTags, skip, err := h.applyPreferences(ctx, orTags, o)
+	if err != nil || skip {
+		return nil, err
 	}
 
-	url_ := &url.URL{
-		Host:     h.hubURL.Host,
-		Scheme:   h.hubURL.Scheme,
-		Path:     h.hubURL.Path,
-		RawQuery: h.hubURL.RawQuery,
+	if err := h.checkBroadcast(ctx, orTags, o); err != nil {
+		return nil, err
+	}
+
+	deliverTime, err := o.scheduledTime(h.now())
+	if err != nil {
+		return nil, err
+	}
+	deliverTime = h.deferMaintenance(deliverTime, o)
+
+	if err := h.checkApproval(ctx, n, orTags, o); err != nil {
+		return nil, err
 	}
 
-	if deliverTime != nil && deliverTime.Unix() > time.Now().Unix() {
-		url_.Path = path.Join(url_.Path, "schedulednotifications")
+	original := n
+	n, err = h.fcmV1Notification(n)
+	if err != nil {
+		return nil, err
+	}
+	if n, err = h.transform(ctx, n, o); err != nil {
+		return nil, err
+	}
+
+	headers := h.taggedNotificationHeaders(ctx, n, orTags, o)
+
+	relPath := "messages"
+	if deliverTime != nil {
+		relPath = "schedulednotifications"
 		headers["ServiceBusNotification-ScheduleTime"] = deliverTime.Format("2006-01-02T15:04:05")
-	} else {
-		url_.Path = path.Join(url_.Path, "messages")
 	}
 
-	req, err := http.NewRequest("POST", url_.String(), buf)
+	b, err := h.postNotification(ctx, n, relPath, h.hubURL.Query(), headers, o)
+	if h.shadow != nil {
+		mirrored := o.mirrored()
+		h.mirror(ctx, original, headers["ServiceBusNotification-Tags"], err, func(ctx context.Context, shadow *NotificationHub) error {
+			_, err := shadow.send(ctx, original, orTags, mirrored)
+			return err
+		})
+	}
+
+	return b, err
+}
+
+func (h *NotificationHub) sendDirect(ctx context.Context, n *Notification, deviceHandle string, o *sendOptions) ([]byte, error) {
+	if err := h.checkEnvironment(); err != nil {
+		return nil, err
+	}
+	if err := o.requireImmediate("direct"); err != nil {
+		return nil, err
+	}
+
+	original := n
+	n, err := h.fcmV1Notification(n)
+	if err != nil {
+		return nil, err
+	}
+	if n, err = h.transform(ctx, n, o); err != nil {
+		return nil, err
+	}
+
+	headers := h.notificationHeaders(n)
+	headers["ServiceBusNotification-DeviceHandle"] = deviceHandle
+
+	query := h.hubURL.Query()
+	query.Add(directParam, "")
+
+	b, err := h.postNotification(ctx, n, "messages", query, headers, o)
+	if h.shadow != nil {
+		mirrored := o.mirrored()
+		h.mirror(ctx, original, deviceHandle, err, func(ctx context.Context, shadow *NotificationHub) error {
+			_, err := shadow.sendDirect(ctx, original, deviceHandle, mirrored)
+			return err
+		})
+	}
+
+	return b, err
+}
+
+// postNotification posts notification payload
+// to the hub path relPath with provided query and headers
+func (h *NotificationHub) postNotification(ctx context.Context, n *Notification, relPath string, query url.Values, headers map[string]string, o *sendOptions) ([]byte, error) {
+	req, err := h.newRequest(ctx, "POST", relPath, query, n.Payload)
 	if err != nil {
 		return nil, err
 	}
-	req = req.WithContext(ctx)
 
 	for header, val := range headers {
 		req.Header.Set(header, val)
 	}
+	if o != nil {
+		for header, val := range o.headers {
+			if !isAddressingHeader(header) {
+				req.Header.Set(header, val)
+			}
+		}
+	}
+
+	if n.Format == AppleFormat {
+		if err := checkApnsPush(n.Payload, req.Header); err != nil {
+			return nil, err
+		}
+	}
+
+	if (h.metrics != nil || h.history != nil) && o == nil {
+		o = &sendOptions{}
+	}
+
+	started := time.Now()
+	b, err := h.exec(req, o)
+	h.stats.observeSend(time.Since(started), err)
+	h.latency.observe(time.Since(started))
+	h.observeSend(n, headers, o, started, err)
+	h.recordHistory(ctx, n, headers, o, err)
 
-	return h.client.Exec(req)
+	return b, err
 }
 
-func (h *NotificationHub) sendDirect(ctx context.Context, n *Notification, deviceHandle string) ([]byte, error) {
-	token := h.generateSasToken()
-	buf := bytes.NewBuffer(n.Payload)
+// newRequest creates request to the hub path relPath, whose segments are escaped as by url.PathEscape
+func (h *NotificationHub) newRequest(ctx context.Context, method, relPath string, query url.Values, body []byte) (*http.Request, error) {
+	escapedPath := path.Join(h.hubURL.EscapedPath(), relPath)
+	unescapedPath, err := url.PathUnescape(escapedPath)
+	if err != nil {
+		return nil, err
+	}
 
-	headers := map[string]string{
-		"Authorization":                       token,
-		"Content-Type":                        n.Format.GetContentType(),
-		"ServiceBusNotification-Format":       string(n.Format),
-		"ServiceBusNotification-DeviceHandle": deviceHandle,
-		"X-Apns-Expiration":                   h.expiryTimeFunc.UnixTimestamp(),
+	url_ := &url.URL{
+		Host:     h.hubURL.Host,
+		Scheme:   h.hubURL.Scheme,
+		Path:     unescapedPath,
+		RawPath:  escapedPath,
+		RawQuery: query.Encode(),
 	}
 
+	var buf io.Reader
+	if body != nil {
+		buf = bytes.NewBuffer(body)
+	}
+
+	req, err := http.NewRequest(method, url_.String(), buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return req.WithContext(ctx), nil
+}
+
+// notificationHeaders returns request headers
+// common for all notification sends
+func (h *NotificationHub) notificationHeaders(n *Notification) map[string]string {
+	headers := make(map[string]string, len(h.defaultHeaders)+5)
+	for header, val := range h.defaultHeaders {
+		if !isAddressingHeader(header) {
+			headers[header] = val
+		}
+	}
+
+	headers["Content-Type"] = n.Format.GetContentType()
+	headers["ServiceBusNotification-Format"] = string(n.Format)
+	headers["X-Apns-Expiration"] = h.expiryTimeFunc.UnixTimestamp()
+
 	//IOS 13 and upwards require these headers to be set. They are not set by Notification Hub at the moment, so we need to send them
 	if n.Format == AppleFormat {
 		if isAppleBackgroundNotification(n.Payload) {
@@ -317,39 +504,176 @@ func (h *NotificationHub) sendDirect(ctx context.Context, n *Notification, devic
 		}
 	}
 
-	query := h.hubURL.Query()
-	query.Add(directParam, "")
+	// WNS rejects notifications without X-WNS-Type header
+	if n.Format == WindowsFormat && headers["X-WNS-Type"] == "" {
+		if wnsType := windowsNotificationType(n.Payload); wnsType != "" {
+			headers["X-WNS-Type"] = wnsType
+		}
+	}
 
-	url_ := &url.URL{
-		Host:     h.hubURL.Host,
-		Scheme:   h.hubURL.Scheme,
-		Path:     path.Join(h.hubURL.Path, "messages"),
-		RawQuery: query.Encode(),
+	return headers
+}
+
+// taggedNotificationHeaders returns notification headers targeting
+// recipients matching orTags, hub default tags and send tags
+func (h *NotificationHub) taggedNotificationHeaders(ctx context.Context, n *Notification, orTags []string, o *sendOptions) map[string]string {
+	headers := h.notificationHeaders(n)
+	if tags := h.sendTagExpression(ctx, orTags, o); tags != "" {
+		headers["ServiceBusNotification-Tags"] = tags
 	}
 
-	req, err := http.NewRequest("POST", url_.String(), buf)
-	if err != nil {
+	return headers
+}
+
+// sendTagExpression returns tag expression of recipients
+// matching orTags, hub default tags, context tags and send tags
+func (h *NotificationHub) sendTagExpression(ctx context.Context, orTags []string, o *sendOptions) string {
+	return tagExpression(orTags, h.sendAndTags(ctx, o))
+}
+
+// sendAndTags returns tags every recipient must have:
+// hub default tags, context tags and send tags
+func (h *NotificationHub) sendAndTags(ctx context.Context, o *sendOptions) []string {
+	andTags := h.defaultTags
+	if contextTags := ContextTags(ctx); len(contextTags) > 0 {
+		andTags = append(append([]string(nil), andTags...), contextTags...)
+	}
+	if o != nil && len(o.andTags) > 0 {
+		andTags = append(append([]string(nil), andTags...), o.andTags...)
+	}
+
+	return andTags
+}
+
+// tagExpression combines orTags alternatives with
+// andTags which every notification recipient must have
+func tagExpression(orTags, andTags []string) string {
+	expr := make([]string, 0, len(andTags)+1)
+
+	if len(orTags) > 0 {
+		alternatives := strings.Join(orTags, " || ")
+		if len(andTags) > 0 && (len(orTags) > 1 || strings.ContainsAny(alternatives, "|&!")) {
+			alternatives = "(" + alternatives + ")"
+		}
+		expr = append(expr, alternatives)
+	}
+
+	return strings.Join(append(expr, andTags...), " && ")
+}
+
+// exec signs and executes notification hub request
+// retrying it according to the send or the hub retry policy.
+// Mutating requests fail while a kill switch is engaged.
+// When the token is rejected as expired due to clock skew,
+// token expiry gets padded and the request is retried once
+func (h *NotificationHub) exec(req *http.Request, o *sendOptions) ([]byte, error) {
+	if err := h.checkKillSwitch(req); err != nil {
 		return nil, err
 	}
-	req = req.WithContext(ctx)
 
-	for header, val := range headers {
-		req.Header.Set(header, val)
+	result := o.newResult()
+	if result.CorrelationID == "" {
+		result.CorrelationID = newCorrelationID()
+	}
+	req.Header.Set(correlationIdHeader, result.CorrelationID)
+	req.Header.Set(userAgentHeader, h.userAgent())
+
+	var (
+		b          []byte
+		header     http.Header
+		err        error
+		failures   int
+		skewRetry  bool
+		attemptReq = req
+		policy     = o.retryPolicy(h.retry)
+	)
+
+	for {
+		if result.Attempts > 0 {
+			h.stats.observeRetry()
+			var cerr error
+			if attemptReq, cerr = cloneRequest(req); cerr != nil {
+				return b, err
+			}
+		}
+
+		attemptReq.Header.Set("Authorization", h.generateSasToken())
+		b, header, err = h.execHedged(attemptReq)
+		result.track(header)
+		if o != nil {
+			o.header = header
+		}
+
+		if err == nil {
+			return b, nil
+		}
+
+		if resErr, ok := isExpiryRejection(err); ok && !skewRetry {
+			skewRetry = true
+			now := h.now()
+			h.skew.observe(now, responseClockSkew(resErr, now))
+			continue
+		}
+
+		failures++
+		if failures >= policy.maxAttempts() || !isRetryableRequest(req, err) {
+			return b, err
+		}
+
+		delay := policy.backoff(failures, err)
+		if derr := deadlineError(req.Context(), delay, result.Attempts, err); derr != nil {
+			return b, derr
+		}
+
+		if werr := sleepContext(req.Context(), delay); werr != nil {
+			return b, err
+		}
+	}
+}
+
+// execOnce executes request using the hub client
+func (h *NotificationHub) execOnce(req *http.Request) ([]byte, http.Header, error) {
+	parent := req.Context()
+	req, cancel := h.timeouts.withAttemptTimeout(req)
+	defer cancel()
+
+	if hc, ok := h.client.(*hubHttpClient); ok {
+		b, header, err := hc.execWithHeader(req)
+		return b, header, h.timeouts.timeoutError(parent, err)
+	}
+
+	b, err := h.client.Exec(req)
+	return b, nil, h.timeouts.timeoutError(parent, err)
+}
+
+// cloneRequest returns a copy of req with a fresh body
+func cloneRequest(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return clone, nil
+	}
+
+	if req.GetBody == nil {
+		return nil, errors.New("request body can not be replayed")
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
 	}
+	clone.Body = body
 
-	return h.client.Exec(req)
+	return clone, nil
 }
 
 // generateSasToken generates and returns
 // azure notification hub shared access signatue token
 func (h *NotificationHub) generateSasToken() string {
-	uri := &url.URL{
-		Host: h.hubURL.Host,
-		Scheme: h.hubURL.Scheme,
-	}
-	targetUri := strings.ToLower(uri.String())
+	h.stats.observeToken()
+	targetUri := sasAudience(h.hubURL)
 
-	expires := h.expiryTimeFunc.UnixTimestamp()
+	expiry := h.expiryTimeFunc().Add(h.skew.current(h.now()))
+	expires := strconv.FormatInt(expiry.Unix(), 10)
 	toSign := fmt.Sprintf("%s\n%s", url.QueryEscape(targetUri), expires)
 
 	mac := hmac.New(sha256.New, []byte(h.sasKeyValue))
@@ -369,11 +693,15 @@ func (h *NotificationHub) generateSasToken() string {
 }
 
 func buildExpiryTimeFunc(delta time.Duration) TimeFunc {
+	return buildClockExpiryTimeFunc(systemClock{}, delta)
+}
+
+func buildClockExpiryTimeFunc(clock Clock, delta time.Duration) TimeFunc {
 	if delta <= 0 {
 		panic("Attempted to build expiry TimeFunc with non-positive delta!")
 	}
 	return func() time.Time {
-		return time.Now().Add(delta)
+		return clock.Now().Add(delta)
 	}
 }
 
@@ -394,9 +722,10 @@ func handleResponse(resp *http.Response, inErr error) (b []byte, err error) {
 	if err != nil {
 		return nil, err
 	}
+	b = decodeResponseBody(resp.Header, b)
 
 	if !isOKResponseCode(resp.StatusCode) {
-		return nil, fmt.Errorf("got unexpected response status code: %d. response: %s", resp.StatusCode, b)
+		return nil, &ResponseError{StatusCode: resp.StatusCode, Header: resp.Header, Body: b}
 	}
 
 	if len(b) == 0 {
@@ -406,20 +735,29 @@ func handleResponse(resp *http.Response, inErr error) (b []byte, err error) {
 	return
 }
 
+// Error returns ResponseError string representation with readable response text
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("got unexpected response status code: %d. response: %s", e.StatusCode, responseText(e.Header, e.Body))
+}
+
 // isOKResponseCode identifies whether provided
 // response code matches the expected OK code
 func isOKResponseCode(code int) bool {
-	return code == http.StatusCreated || code == http.StatusOK
+	return code == http.StatusCreated || code == http.StatusOK || code == http.StatusNoContent
+}
+
+// isNotFoundError identifies whether err reports missing hub entity
+func isNotFoundError(err error) bool {
+	var resErr *ResponseError
+	return errors.As(err, &resErr) && resErr.StatusCode == http.StatusNotFound
 }
 
 // Register sends registration to the azure hub
 func (h *NotificationHub) Register(r Registration) (RegistrationRes, []byte, error) {
 	regRes := RegistrationRes{}
-	token := h.generateSasToken()
 
 	headers := map[string]string{
-		"Authorization": token,
-		"Content-Type":  "application/atom+xml;type=entry;charset=utf-8",
+		"Content-Type": "application/atom+xml;type=entry;charset=utf-8",
 	}
 
 	payload := ""
@@ -453,12 +791,13 @@ func (h *NotificationHub) Register(r Registration) (RegistrationRes, []byte, err
 	if err != nil {
 		return regRes, nil, err
 	}
+	req = req.WithContext(withSensitiveBodies(req.Context()))
 
 	for header, val := range headers {
 		req.Header.Set(header, val)
 	}
 
-	res, err := h.client.Exec(req)
+	res, err := h.exec(req, nil)
 	if err == nil {
 		if err = xml.Unmarshal(res, &regRes); err != nil {
 			return regRes, res, err
@@ -493,9 +832,16 @@ type iosBackgroundNotification struct {
 	Aps aps `json:"aps"`
 }
 type aps struct {
-	ContentAvailable int `json:"content-available"`
+	ContentAvailable int             `json:"content-available"`
+	Alert            json.RawMessage `json:"alert,omitempty"`
+	Badge            json.RawMessage `json:"badge,omitempty"`
+	Sound            json.RawMessage `json:"sound,omitempty"`
+	// InterruptionLevel of time sensitive and critical pushes requires immediate priority
+	InterruptionLevel AppleInterruptionLevel `json:"interruption-level,omitempty"`
 }
 
+// isAppleBackgroundNotification identifies content-available payloads
+// without alert, badge or sound, which APNS accepts as background pushes only
 func isAppleBackgroundNotification(payload []byte) bool {
 	var backgroundNot iosBackgroundNotification
 	err := json.Unmarshal(payload, &backgroundNot)
@@ -503,5 +849,36 @@ func isAppleBackgroundNotification(payload []byte) bool {
 		return false
 	}
 
-	return backgroundNot.Aps.ContentAvailable == 1
+	return backgroundNot.Aps.ContentAvailable == 1 && !backgroundNot.Aps.isVisible()
+}
+
+// isVisible identifies whether aps alerts the user by alert, badge or sound
+func (a aps) isVisible() bool {
+	for _, field := range []json.RawMessage{a.Alert, a.Badge, a.Sound} {
+		if len(field) > 0 && string(field) != "null" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// windowsNotificationType returns X-WNS-Type header value
+// derived from the payload root element
+func windowsNotificationType(payload []byte) string {
+	dec := xml.NewDecoder(bytes.NewReader(payload))
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return ""
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			switch start.Name.Local {
+			case "badge", "tile", "toast":
+				return "wns/" + start.Name.Local
+			}
+			return ""
+		}
+	}
 }
@@ -348,7 +348,7 @@ func Test_NotificationHubSendFanout(t *testing.T) {
 		return nil, nil
 	}
 
-	b, err := nhub.Send(context.Background(), notification, nil)
+	b, err := nhub.Send(context.Background(), notification, nil, BroadcastAll())
 	if b != nil {
 		t.Errorf(errfmt, "byte", nil, b)
 	}
@@ -441,7 +441,7 @@ func Test_NotificationSendError(t *testing.T) {
 		expiryTimeFunc: TimeFunc(mockExpiryTime),
 	}
 
-	b, obtainedErr := nhub.Send(context.Background(), &Notification{AndroidFormat, []byte("test payload")}, nil)
+	b, obtainedErr := nhub.Send(context.Background(), &Notification{AndroidFormat, []byte("test payload")}, nil, BroadcastAll())
 	if b != nil {
 		t.Errorf(errfmt, "Send []byte", nil, b)
 	}
@@ -498,7 +498,7 @@ func Test_NotificationHubSendAppleBackgroundNotification(t *testing.T) {
 		return nil, nil
 	}
 
-	b, err := nhub.Send(context.Background(), notification, nil)
+	b, err := nhub.Send(context.Background(), notification, nil, BroadcastAll())
 	if b != nil {
 		t.Errorf(errfmt, "byte", nil, b)
 	}
@@ -550,7 +550,7 @@ func Test_NotificationHubSendAppleAlertNotification(t *testing.T) {
 		return nil, nil
 	}
 
-	b, err := nhub.Send(context.Background(), notification, nil)
+	b, err := nhub.Send(context.Background(), notification, nil, BroadcastAll())
 	if b != nil {
 		t.Errorf(errfmt, "byte", nil, b)
 	}
@@ -593,7 +593,7 @@ func Test_NotificationScheduleSuccess(t *testing.T) {
 		return nil, nil
 	}
 
-	b, err := nhub.Schedule(context.Background(), notification, nil, time.Now().Add(time.Minute))
+	b, err := nhub.Schedule(context.Background(), notification, nil, time.Now().Add(time.Minute), BroadcastAll())
 	if b != nil {
 		t.Errorf(errfmt, "byte", nil, b)
 	}
@@ -636,7 +636,7 @@ func Test_NotificationScheduleOutdated(t *testing.T) {
 		return nil, nil
 	}
 
-	b, err := nhub.Schedule(context.Background(), notification, nil, time.Now().Add(-time.Minute))
+	b, err := nhub.Schedule(context.Background(), notification, nil, time.Now().Add(-time.Minute), BroadcastAll())
 	if b != nil {
 		t.Errorf(errfmt, "byte", nil, b)
 	}
@@ -679,7 +679,7 @@ func Test_NotificationScheduleError(t *testing.T) {
 		expiryTimeFunc: TimeFunc(mockExpiryTime),
 	}
 
-	b, obtainedErr := nhub.Schedule(context.Background(), &Notification{AndroidFormat, []byte("test payload")}, nil, time.Now().Add(time.Minute))
+	b, obtainedErr := nhub.Schedule(context.Background(), &Notification{AndroidFormat, []byte("test payload")}, nil, time.Now().Add(time.Minute), BroadcastAll())
 	if b != nil {
 		t.Errorf(errfmt, "Send []byte", nil, b)
 	}
@@ -688,3 +688,56 @@ func Test_NotificationScheduleError(t *testing.T) {
 		t.Errorf(errfmt, "Send error", expectedError, obtainedErr)
 	}
 }
+
+func Test_NotificationScheduleUsesHubClock(t *testing.T) {
+	var (
+		errfmt       = "Expected %s: %v, got: %v"
+		notification = &Notification{Template, []byte("test_payload")}
+		clock        = &mockClock{now: time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+		baseURL = &url.URL{
+			Host:     "testHost",
+			Scheme:   schemeDefault,
+			Path:     "testPath",
+			RawQuery: url.Values{"queryParam": {"queryValue"}}.Encode(),
+		}
+	)
+
+	testCases := []struct {
+		deliverTime time.Time
+		expectedURL string
+	}{
+		{
+			deliverTime: clock.now.Add(time.Minute),
+			expectedURL: "https://testHost/testPath/schedulednotifications?queryParam=queryValue",
+		},
+		{
+			deliverTime: clock.now.Add(-time.Minute),
+			expectedURL: "https://testHost/testPath/messages?queryParam=queryValue",
+		},
+	}
+
+	for i, testCase := range testCases {
+		mockClient := &mockHubHttpClient{}
+		mockClient.execFunc = func(obtainedReq *http.Request) ([]byte, error) {
+			if gotURL := obtainedReq.URL.String(); gotURL != testCase.expectedURL {
+				t.Errorf("test case %d: "+errfmt, i, "URL", testCase.expectedURL, gotURL)
+			}
+
+			return nil, nil
+		}
+
+		nhub := &NotificationHub{
+			sasKeyValue:    "testKeyValue",
+			sasKeyName:     "testKeyName",
+			hubURL:         baseURL,
+			client:         mockClient,
+			expiryTimeFunc: TimeFunc(mockExpiryTime),
+			clock:          clock,
+		}
+
+		if _, err := nhub.Schedule(context.Background(), notification, nil, testCase.deliverTime, BroadcastAll()); err != nil {
+			t.Errorf("test case %d: "+errfmt, i, "error", nil, err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package notihub
+
+// HubOption configures optional NotificationHub behaviour
+type HubOption func(*NotificationHub)
+
+// WithClock sets the time source used by the hub
+func WithClock(clock Clock) HubOption {
+	return func(h *NotificationHub) {
+		if clock != nil {
+			h.clock = clock
+		}
+	}
+}
+
+// WithDefaultTags sets tags every notification recipient must have.
+// They are combined with tags passed to Send and Schedule using logical AND
+func WithDefaultTags(tags ...string) HubOption {
+	return func(h *NotificationHub) {
+		h.defaultTags = append(h.defaultTags, tags...)
+	}
+}
+
+// WithDefaultHeaders sets headers added to every notification request.
+// Headers set by the hub itself take precedence over the defaults,
+// headers selecting format and recipients are ignored
+func WithDefaultHeaders(headers map[string]string) HubOption {
+	return func(h *NotificationHub) {
+		if h.defaultHeaders == nil {
+			h.defaultHeaders = make(map[string]string, len(headers))
+		}
+		for header, val := range headers {
+			h.defaultHeaders[header] = val
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package notihub
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func Test_TagExpression(t *testing.T) {
+	testCases := []struct {
+		orTags   []string
+		andTags  []string
+		expected string
+	}{
+		{
+			expected: "",
+		},
+		{
+			orTags:   []string{"tag1", "tag2"},
+			expected: "tag1 || tag2",
+		},
+		{
+			andTags:  []string{"env:prod"},
+			expected: "env:prod",
+		},
+		{
+			orTags:   []string{"tag1"},
+			andTags:  []string{"env:prod"},
+			expected: "tag1 && env:prod",
+		},
+		{
+			orTags:   []string{"tag1", "tag2"},
+			andTags:  []string{"env:prod", "tenant:a"},
+			expected: "(tag1 || tag2) && env:prod && tenant:a",
+		},
+		{
+			orTags:   []string{"tag1 && !tag2"},
+			andTags:  []string{"env:prod"},
+			expected: "(tag1 && !tag2) && env:prod",
+		},
+	}
+
+	for i, testCase := range testCases {
+		if obtained := tagExpression(testCase.orTags, testCase.andTags); obtained != testCase.expected {
+			t.Errorf("tagExpression test case %d. Expected '%s', got '%s'", i, testCase.expected, obtained)
+		}
+	}
+}
+
+func Test_NotificationHubDefaultTagsAndHeaders(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	mockClient := &mockHubHttpClient{}
+	nhub := NewNotificationHub("Endpoint=sb://testhub-ns.servicebus.windows.net/;SharedAccessKeyName=name;SharedAccessKey=key", "hub", nil,
+		WithDefaultTags("env:prod"),
+		WithDefaultHeaders(map[string]string{
+			"X-Custom-Header":               "custom",
+			"ServiceBusNotification-Format": "overridden",
+		}),
+	)
+	nhub.client = mockClient
+
+	mockClient.execFunc = func(req *http.Request) ([]byte, error) {
+		if got := req.Header.Get("ServiceBusNotification-Tags"); got != "(tag1 || tag2) && env:prod" {
+			t.Errorf(errfmt, "ServiceBusNotification-Tags", "(tag1 || tag2) && env:prod", got)
+		}
+
+		if got := req.Header.Get("X-Custom-Header"); got != "custom" {
+			t.Errorf(errfmt, "X-Custom-Header", "custom", got)
+		}
+
+		if got := req.Header.Get("ServiceBusNotification-Format"); got != string(Template) {
+			t.Errorf(errfmt, "ServiceBusNotification-Format", Template, got)
+		}
+
+		return nil, nil
+	}
+
+	if _, err := nhub.Send(context.Background(), &Notification{Template, []byte("{}")}, []string{"tag1", "tag2"}); err != nil {
+		t.Errorf(errfmt, "error", nil, err)
+	}
+}
@@ -0,0 +1,144 @@
+package notihub
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrApnsEnvironmentMismatch is returned when APNS rejects a device token
+// issued for another environment (sandbox or production) than the hub is configured for
+var ErrApnsEnvironmentMismatch = errors.New("apns environment mismatch")
+
+// apnsEnvironmentErrors are APNS failure reasons caused by environment mismatch
+var apnsEnvironmentErrors = []string{
+	"BadDeviceToken",
+	"BadCertificateEnvironment",
+	"BadEnvironmentKeyInToken",
+}
+
+type (
+	// NotificationOutcome is the response of test sends
+	NotificationOutcome struct {
+		XMLName xml.Name             `xml:"NotificationOutcome"`
+		Success int                  `xml:"Success"`
+		Failure int                  `xml:"Failure"`
+		Results []RegistrationResult `xml:"Results>RegistrationResult"`
+	}
+
+	// RegistrationResult is the outcome of a test send to a single registration
+	RegistrationResult struct {
+		// ApplicationPlatform is the platform of the registration, the hub names it
+		// by notification format, e.g. "apple" is decoded as PlatformApns
+		ApplicationPlatform Platform `xml:"ApplicationPlatform"`
+		PnsHandle           string   `xml:"PnsHandle"`
+		RegistrationId      string   `xml:"RegistrationId"`
+		Outcome             string   `xml:"Outcome"`
+	}
+)
+
+// UnmarshalXML decodes registration result, mapping the hub format names of platforms
+func (r *RegistrationResult) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain RegistrationResult
+	if err := d.DecodeElement((*plain)(r), &start); err != nil {
+		return err
+	}
+
+	r.ApplicationPlatform = outcomePlatform(string(r.ApplicationPlatform))
+	return nil
+}
+
+// outcomePlatform returns platform named by format or platform name s,
+// unknown names are kept as they are
+func outcomePlatform(s string) Platform {
+	if p, err := PlatformForFormat(NotificationFormat(strings.ToLower(s))); err == nil {
+		return p
+	}
+	if p, err := ParsePlatform(s); err == nil {
+		return p
+	}
+
+	return Platform(s)
+}
+
+// sendTest sends notification in test mode and inspects the outcome
+func (h *NotificationHub) sendTest(ctx context.Context, n *Notification, orTags []string, o *sendOptions) ([]byte, error) {
+	if err := h.checkEnvironment(); err != nil {
+		return nil, err
+	}
+	if err := o.requireImmediate("test"); err != nil {
+		return nil, err
+	}
+
+	if err := h.checkBroadcast(ctx, orTags, o); err != nil {
+		return nil, err
+	}
+
+	n, err := h.fcmV1Notification(n)
+	if err != nil {
+		return nil, err
+	}
+	if n, err = h.transform(ctx, n, o); err != nil {
+		return nil, err
+	}
+
+	query := h.hubURL.Query()
+	query.Add(testParam, "")
+
+	b, err := h.postNotification(ctx, n, "messages", query, h.taggedNotificationHeaders(ctx, n, orTags, o), o)
+	if err != nil {
+		return nil, err
+	}
+
+	outcome, err := ParseNotificationOutcome(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := outcome.apnsEnvironmentError(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// ParseNotificationOutcome parses NotificationOutcome XML returned by test sends
+func ParseNotificationOutcome(b []byte) (*NotificationOutcome, error) {
+	outcome := &NotificationOutcome{}
+	if err := xml.Unmarshal(b, outcome); err != nil {
+		return nil, fmt.Errorf("failed to parse notification outcome: %w", err)
+	}
+
+	return outcome, nil
+}
+
+// apnsEnvironmentError returns ErrApnsEnvironmentMismatch
+// if any apple registration failed due to environment mismatch
+func (o *NotificationOutcome) apnsEnvironmentError() error {
+	for _, result := range o.Results {
+		if result.ApplicationPlatform != PlatformApns || !isApnsEnvironmentFailure(result.Outcome) {
+			continue
+		}
+
+		return fmt.Errorf("%w: registration '%s' outcome '%s'. "+
+			"Make sure the device token was issued for the APNS environment the hub is configured for: "+
+			"debug builds produce sandbox tokens, App Store and TestFlight builds produce production tokens",
+			ErrApnsEnvironmentMismatch, result.RegistrationId, result.Outcome)
+	}
+
+	return nil
+}
+
+// isApnsEnvironmentFailure identifies whether outcome
+// reports APNS failure caused by environment mismatch
+func isApnsEnvironmentFailure(outcome string) bool {
+	for _, reason := range apnsEnvironmentErrors {
+		if strings.Contains(outcome, reason) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,104 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func Test_NotificationHubSendTest(t *testing.T) {
+	errfmt := "test case %d. Expected %s: %v, got: %v"
+
+	testCases := []struct {
+		response    string
+		expectedErr error
+	}{
+		{
+			response: `<NotificationOutcome xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect">
+    <Success>1</Success>
+    <Failure>0</Failure>
+    <Results>
+        <RegistrationResult>
+            <ApplicationPlatform>apple</ApplicationPlatform>
+            <PnsHandle>handle</PnsHandle>
+            <RegistrationId>regid</RegistrationId>
+            <Outcome>The Notification was successfully sent to the Push Notification System</Outcome>
+        </RegistrationResult>
+    </Results>
+</NotificationOutcome>`,
+		},
+		{
+			response: `<NotificationOutcome xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect">
+    <Success>0</Success>
+    <Failure>1</Failure>
+    <Results>
+        <RegistrationResult>
+            <ApplicationPlatform>apple</ApplicationPlatform>
+            <PnsHandle>handle</PnsHandle>
+            <RegistrationId>regid</RegistrationId>
+            <Outcome>The Push Notification System returned error: BadDeviceToken</Outcome>
+        </RegistrationResult>
+    </Results>
+</NotificationOutcome>`,
+			expectedErr: ErrApnsEnvironmentMismatch,
+		},
+		{
+			response: `<NotificationOutcome xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect">
+    <Success>0</Success>
+    <Failure>1</Failure>
+    <Results>
+        <RegistrationResult>
+            <ApplicationPlatform>gcm</ApplicationPlatform>
+            <PnsHandle>handle</PnsHandle>
+            <RegistrationId>regid</RegistrationId>
+            <Outcome>BadDeviceToken</Outcome>
+        </RegistrationResult>
+    </Results>
+</NotificationOutcome>`,
+		},
+	}
+
+	baseURL := &url.URL{
+		Host:     "testHost",
+		Scheme:   schemeDefault,
+		Path:     "testPath",
+		RawQuery: url.Values{"queryParam": {"queryValue"}}.Encode(),
+	}
+	testURL := "https://testHost/testPath/messages?queryParam=queryValue&test="
+
+	for i, testCase := range testCases {
+		response := testCase.response
+		mockClient := &mockHubHttpClient{execFunc: func(req *http.Request) ([]byte, error) {
+			if gotURL := req.URL.String(); gotURL != testURL {
+				t.Errorf(errfmt, i, "URL", testURL, gotURL)
+			}
+
+			return []byte(response), nil
+		}}
+
+		nhub := &NotificationHub{
+			sasKeyValue:    "testKeyValue",
+			sasKeyName:     "testKeyName",
+			hubURL:         baseURL,
+			client:         mockClient,
+			expiryTimeFunc: TimeFunc(mockExpiryTime),
+		}
+
+		b, err := nhub.SendTest(context.Background(), &Notification{AppleFormat, []byte("{}")}, nil, BroadcastAll())
+		if testCase.expectedErr == nil {
+			if err != nil {
+				t.Errorf(errfmt, i, "error", nil, err)
+			}
+			if string(b) != response {
+				t.Errorf(errfmt, i, "response", response, string(b))
+			}
+			continue
+		}
+
+		if !errors.Is(err, testCase.expectedErr) {
+			t.Errorf(errfmt, i, "error", testCase.expectedErr, err)
+		}
+	}
+}
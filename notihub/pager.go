@@ -0,0 +1,72 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoMorePages is returned by NextPage called after the last page
+var ErrNoMorePages = errors.New("no more pages")
+
+type (
+	// PageFunc reads the page following continuation, the first page for empty continuation.
+	// The returned continuation is empty on the last page
+	PageFunc[T any] func(ctx context.Context, continuation string) (page []T, next string, err error)
+
+	// Pager pages through results of a list operation, all list operations return one:
+	//
+	//	for pager.More() {
+	//		page, err := pager.NextPage(ctx)
+	//		...
+	//	}
+	//
+	// Pager is not safe for concurrent use
+	Pager[T any] struct {
+		fetch        PageFunc[T]
+		continuation string
+		done         bool
+	}
+)
+
+// NewPager initializes and returns Pager pointer reading pages by fetch,
+// e.g. to page through endpoints called by Do
+func NewPager[T any](fetch PageFunc[T]) *Pager[T] {
+	return &Pager[T]{fetch: fetch}
+}
+
+// More reports whether there are pages left to read
+func (p *Pager[T]) More() bool {
+	return !p.done
+}
+
+// NextPage reads the next page. A failed page may be read again by calling NextPage again
+func (p *Pager[T]) NextPage(ctx context.Context) ([]T, error) {
+	if p.done {
+		return nil, ErrNoMorePages
+	}
+
+	page, next, err := p.fetch(ctx, p.continuation)
+	if err != nil {
+		return nil, err
+	}
+
+	p.continuation = next
+	p.done = next == "" || len(page) == 0
+
+	return page, nil
+}
+
+// All reads the remaining pages and returns their items,
+// items of pages read before a failure are returned along with the error
+func (p *Pager[T]) All(ctx context.Context) ([]T, error) {
+	var items []T
+	for p.More() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return items, err
+		}
+		items = append(items, page...)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,56 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func Test_Pager(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	pages := map[string][]int{"": {1, 2}, "2": {3, 4}, "4": {5}}
+	fail := true
+	fetch := func(ctx context.Context, continuation string) ([]int, string, error) {
+		if continuation == "4" && fail {
+			fail = false
+			return nil, "", errors.New("throttled")
+		}
+
+		page := pages[continuation]
+		next := ""
+		if continuation != "4" {
+			next = strconv.Itoa(page[len(page)-1])
+		}
+		return page, next, nil
+	}
+
+	pager := NewPager(fetch)
+	page, err := pager.NextPage(context.Background())
+	if err != nil || !reflect.DeepEqual(page, []int{1, 2}) || !pager.More() {
+		t.Errorf(errfmt, "first page", []int{1, 2}, page)
+	}
+
+	items, err := pager.All(context.Background())
+	if err == nil || !reflect.DeepEqual(items, []int{3, 4}) || !pager.More() {
+		t.Errorf(errfmt, "items before failure", []int{3, 4}, items)
+	}
+
+	items, err = pager.All(context.Background())
+	if err != nil || !reflect.DeepEqual(items, []int{5}) || pager.More() {
+		t.Errorf(errfmt, "items after failure", []int{5}, items)
+	}
+
+	if _, err := pager.NextPage(context.Background()); !errors.Is(err, ErrNoMorePages) {
+		t.Errorf(errfmt, "error after last page", ErrNoMorePages, err)
+	}
+
+	empty := NewPager(func(ctx context.Context, continuation string) ([]int, string, error) {
+		return nil, "token", nil
+	})
+	if items, err := empty.All(context.Background()); err != nil || len(items) != 0 || empty.More() {
+		t.Errorf(errfmt, "empty page", "last page", items)
+	}
+}
@@ -0,0 +1,69 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrPayloadTooLarge is returned when notification payload
+// exceeds the push notification system size limit
+var ErrPayloadTooLarge = errors.New("notification payload too large")
+
+// maxPayloadSizes are payload size limits of push notification systems in bytes
+var maxPayloadSizes = map[NotificationFormat]int{
+	Template:           4096,
+	AndroidFormat:      4096,
+	FcmV1Format:        4096,
+	AppleFormat:        4096,
+	BaiduFormat:        4096,
+	KindleFormat:       6144,
+	WindowsFormat:      5120,
+	WindowsPhoneFormat: 3072,
+}
+
+type (
+	// PayloadReference locates notification content uploaded to blob storage
+	PayloadReference struct {
+		URL  string
+		Size int
+	}
+
+	// ReferenceBuilder builds notification payload carrying
+	// the content reference instead of the content itself
+	ReferenceBuilder func(ref PayloadReference) ([]byte, error)
+)
+
+// MaxPayloadSize returns payload size limit of the format in bytes
+func (f NotificationFormat) MaxPayloadSize() int {
+	return maxPayloadSizes[f]
+}
+
+// NewOffloadedNotification returns notification with payload when it fits the format
+// size limit. Otherwise payload is uploaded with blobs and the notification carries
+// the payload built by build, e.g. the blob URL along with a short summary
+func NewOffloadedNotification(ctx context.Context, format NotificationFormat, payload []byte, blobs BlobWriter, build ReferenceBuilder) (*Notification, error) {
+	n, err := NewNotification(format, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) <= format.MaxPayloadSize() {
+		return n, nil
+	}
+
+	url, err := blobs.WriteBlob(ctx, newCorrelationID()+".payload", payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload payload: %w", err)
+	}
+
+	if n.Payload, err = build(PayloadReference{URL: url, Size: len(payload)}); err != nil {
+		return nil, fmt.Errorf("failed to build reference payload: %w", err)
+	}
+
+	if len(n.Payload) > format.MaxPayloadSize() {
+		return nil, fmt.Errorf("%w: reference payload of %d bytes exceeds %s limit %d", ErrPayloadTooLarge, len(n.Payload), format, format.MaxPayloadSize())
+	}
+
+	return n, nil
+}
@@ -0,0 +1,55 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func Test_NewOffloadedNotification(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+	blobs := &mockBlobWriter{blobs: map[string][]byte{}}
+
+	build := func(ref PayloadReference) ([]byte, error) {
+		return []byte(fmt.Sprintf(`{"aps":{"alert":"New article"},"contentUrl":"%s","size":%d}`, ref.URL, ref.Size)), nil
+	}
+
+	small := []byte(`{"aps":{"alert":"short"}}`)
+	n, err := NewOffloadedNotification(context.Background(), AppleFormat, small, blobs, build)
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+	if string(n.Payload) != string(small) || len(blobs.blobs) != 0 {
+		t.Errorf(errfmt, "payload", string(small), string(n.Payload))
+	}
+
+	large := []byte(`{"aps":{"alert":"` + strings.Repeat("a", AppleFormat.MaxPayloadSize()) + `"}}`)
+	n, err = NewOffloadedNotification(context.Background(), AppleFormat, large, blobs, build)
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	if len(blobs.blobs) != 1 {
+		t.Fatalf(errfmt, "uploaded blobs", 1, len(blobs.blobs))
+	}
+
+	for name, data := range blobs.blobs {
+		if string(data) != string(large) {
+			t.Errorf(errfmt, "uploaded payload", len(large), len(data))
+		}
+
+		expected := fmt.Sprintf(`{"aps":{"alert":"New article"},"contentUrl":"https://storage/import/%s","size":%d}`, name, len(large))
+		if string(n.Payload) != expected {
+			t.Errorf(errfmt, "reference payload", expected, string(n.Payload))
+		}
+	}
+
+	tooLarge := func(ref PayloadReference) ([]byte, error) {
+		return large, nil
+	}
+	if _, err := NewOffloadedNotification(context.Background(), AppleFormat, large, blobs, tooLarge); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Errorf(errfmt, "error", ErrPayloadTooLarge, err)
+	}
+}
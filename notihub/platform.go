@@ -0,0 +1,119 @@
+package notihub
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Platforms of push notification services as named by installations and the hub telemetry
+const (
+	PlatformApns    Platform = "apns"
+	PlatformGcm     Platform = "gcm"
+	PlatformFcmV1   Platform = "fcmv1"
+	PlatformWns     Platform = "wns"
+	PlatformMpns    Platform = "mpns"
+	PlatformAdm     Platform = "adm"
+	PlatformBaidu   Platform = "baidu"
+	PlatformBrowser Platform = "browser"
+	PlatformXiaomi  Platform = "xiaomi"
+)
+
+// Platform is a push notification service
+type Platform string
+
+// registrationPlatforms maps registration description type prefixes to platforms
+var registrationPlatforms = []struct {
+	prefix   string
+	platform Platform
+}{
+	{"Apple", PlatformApns},
+	{"FcmV1", PlatformFcmV1},
+	{"Gcm", PlatformGcm},
+	{"Windows", PlatformWns},
+	{"Mpns", PlatformMpns},
+	{"Adm", PlatformAdm},
+	{"Baidu", PlatformBaidu},
+	{"Browser", PlatformBrowser},
+	{"Xiaomi", PlatformXiaomi},
+}
+
+// platformFormats maps platforms to native notification formats
+var platformFormats = map[Platform]NotificationFormat{
+	PlatformApns:  AppleFormat,
+	PlatformGcm:   AndroidFormat,
+	PlatformFcmV1: FcmV1Format,
+	PlatformWns:   WindowsFormat,
+	PlatformMpns:  WindowsPhoneFormat,
+	PlatformAdm:   KindleFormat,
+	PlatformBaidu: BaiduFormat,
+}
+
+// ParsePlatform parses platform name ignoring case
+func ParsePlatform(s string) (Platform, error) {
+	p := Platform(strings.ToLower(s))
+	if !p.IsValid() {
+		return "", fmt.Errorf("unknown platform '%s'", s)
+	}
+
+	return p, nil
+}
+
+// IsValid identifies whether platform is known
+func (p Platform) IsValid() bool {
+	switch p {
+	case PlatformApns,
+		PlatformGcm,
+		PlatformFcmV1,
+		PlatformWns,
+		PlatformMpns,
+		PlatformAdm,
+		PlatformBaidu,
+		PlatformBrowser,
+		PlatformXiaomi:
+		return true
+	}
+
+	return false
+}
+
+// Platform returns push notification service of the registration
+func (r RegistrationDescription) Platform() Platform {
+	for _, rp := range registrationPlatforms {
+		if strings.HasPrefix(r.Type, rp.prefix) {
+			return rp.platform
+		}
+	}
+
+	return ""
+}
+
+// FormatForPlatform returns native notification format of platform.
+// Platforms without a notification format supported by the client are rejected
+func FormatForPlatform(p Platform) (NotificationFormat, error) {
+	if !p.IsValid() {
+		return "", fmt.Errorf("unknown platform '%s'", p)
+	}
+
+	format, ok := platformFormats[p]
+	if !ok {
+		return "", fmt.Errorf("platform '%s' has no supported notification format", p)
+	}
+
+	return format, nil
+}
+
+// PlatformForFormat returns platform of native notification format.
+// Template notifications are rejected as they are delivered to every platform
+func PlatformForFormat(f NotificationFormat) (Platform, error) {
+	if !f.IsValid() {
+		return "", fmt.Errorf("unknown format '%s'", f)
+	}
+
+	for p, format := range platformFormats {
+		if format == f {
+			return p, nil
+		}
+	}
+
+	return "", fmt.Errorf("format '%s' is not specific to a platform", f)
+}
@@ -0,0 +1,101 @@
+package notihub
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func Test_ParsePlatform(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	testPatterns := []struct {
+		s        string
+		expected Platform
+		valid    bool
+	}{
+		{"apns", PlatformApns, true},
+		{"APNS", PlatformApns, true},
+		{"FcmV1", PlatformFcmV1, true},
+		{"xiaomi", PlatformXiaomi, true},
+		{"apple", "", false},
+		{"", "", false},
+	}
+
+	for _, testData := range testPatterns {
+		p, err := ParsePlatform(testData.s)
+		if p != testData.expected || (err == nil) != testData.valid {
+			t.Errorf(errfmt, testData.s, testData.expected, p)
+		}
+	}
+}
+
+func Test_RegistrationDescriptionPlatform(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	testPatterns := []struct {
+		descriptionType string
+		expected        Platform
+	}{
+		{"AppleRegistrationDescription", PlatformApns},
+		{"AppleTemplateRegistrationDescription", PlatformApns},
+		{"GcmRegistrationDescription", PlatformGcm},
+		{"FcmV1TemplateRegistrationDescription", PlatformFcmV1},
+		{"WindowsRegistrationDescription", PlatformWns},
+		{"MpnsRegistrationDescription", PlatformMpns},
+		{"BrowserRegistrationDescription", PlatformBrowser},
+		{"UnknownRegistrationDescription", ""},
+	}
+
+	for _, testData := range testPatterns {
+		if p := (RegistrationDescription{Type: testData.descriptionType}).Platform(); p != testData.expected {
+			t.Errorf(errfmt, testData.descriptionType, testData.expected, p)
+		}
+	}
+}
+
+func Test_InstallationPlatformJSON(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	b, _ := json.Marshal(Installation{InstallationId: "id", Platform: PlatformWns, PushChannel: "uri"})
+	if string(b) != `{"installationId":"id","platform":"wns","pushChannel":"uri"}` {
+		t.Errorf(errfmt, "installation JSON", `{"installationId":"id","platform":"wns","pushChannel":"uri"}`, string(b))
+	}
+}
+
+func Test_FormatPlatformConversion(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	testPatterns := []struct {
+		platform Platform
+		format   NotificationFormat
+	}{
+		{PlatformApns, AppleFormat},
+		{PlatformGcm, AndroidFormat},
+		{PlatformFcmV1, FcmV1Format},
+		{PlatformWns, WindowsFormat},
+		{PlatformMpns, WindowsPhoneFormat},
+		{PlatformAdm, KindleFormat},
+		{PlatformBaidu, BaiduFormat},
+	}
+
+	for _, testData := range testPatterns {
+		if format, err := FormatForPlatform(testData.platform); err != nil || format != testData.format {
+			t.Errorf(errfmt, "format of "+string(testData.platform), testData.format, format)
+		}
+		if platform, err := PlatformForFormat(testData.format); err != nil || platform != testData.platform {
+			t.Errorf(errfmt, "platform of "+string(testData.format), testData.platform, platform)
+		}
+	}
+
+	for _, p := range []Platform{PlatformBrowser, PlatformXiaomi, "apple"} {
+		if _, err := FormatForPlatform(p); err == nil {
+			t.Errorf(errfmt, "format of "+string(p)+" error", "error", err)
+		}
+	}
+
+	for _, f := range []NotificationFormat{Template, "apns"} {
+		if _, err := PlatformForFormat(f); err == nil {
+			t.Errorf(errfmt, "platform of "+string(f)+" error", "error", err)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package notihub
+
+import (
+	"sort"
+	"strings"
+)
+
+// Error codes reported by the hub in notification outcomes and by PNSes in feedback and test sends
+const (
+	PnsWrongToken                PnsErrorCode = "WrongToken"
+	PnsExpiredChannel            PnsErrorCode = "ExpiredChannel"
+	PnsChannelExpired            PnsErrorCode = "ChannelExpired"
+	PnsBadChannel                PnsErrorCode = "BadChannel"
+	PnsInvalidToken              PnsErrorCode = "InvalidToken"
+	PnsChannelDisconnected       PnsErrorCode = "ChannelDisconnected"
+	PnsChannelThrottled          PnsErrorCode = "ChannelThrottled"
+	PnsThrottled                 PnsErrorCode = "Throttled"
+	PnsInvalidCredentials        PnsErrorCode = "InvalidCredentials"
+	PnsInvalidNotificationFormat PnsErrorCode = "InvalidNotificationFormat"
+	PnsInvalidNotificationSize   PnsErrorCode = "InvalidNotificationSize"
+	PnsInterfaceError            PnsErrorCode = "PnsInterfaceError"
+	PnsServerError               PnsErrorCode = "PnsServerError"
+	PnsUnavailable               PnsErrorCode = "PnsUnavailable"
+	PnsUnreachable               PnsErrorCode = "PnsUnreachable"
+	PnsDropped                   PnsErrorCode = "Dropped"
+	PnsAbandoned                 PnsErrorCode = "Abandoned"
+	PnsNoTargets                 PnsErrorCode = "NoTargets"
+
+	ApnsBadDeviceToken            PnsErrorCode = "BadDeviceToken"
+	ApnsUnregistered              PnsErrorCode = "Unregistered"
+	ApnsDeviceTokenNotForTopic    PnsErrorCode = "DeviceTokenNotForTopic"
+	ApnsBadCertificateEnvironment PnsErrorCode = "BadCertificateEnvironment"
+	ApnsBadEnvironmentKeyInToken  PnsErrorCode = "BadEnvironmentKeyInToken"
+	ApnsPayloadTooLarge           PnsErrorCode = "PayloadTooLarge"
+	ApnsTooManyRequests           PnsErrorCode = "TooManyRequests"
+	ApnsExpiredProviderToken      PnsErrorCode = "ExpiredProviderToken"
+	ApnsInvalidProviderToken      PnsErrorCode = "InvalidProviderToken"
+	ApnsTopicDisallowed           PnsErrorCode = "TopicDisallowed"
+
+	FcmNotRegistered             PnsErrorCode = "NotRegistered"
+	FcmInvalidRegistration       PnsErrorCode = "InvalidRegistration"
+	FcmMismatchSenderId          PnsErrorCode = "MismatchSenderId"
+	FcmMessageTooBig             PnsErrorCode = "MessageTooBig"
+	FcmDeviceMessageRateExceeded PnsErrorCode = "DeviceMessageRateExceeded"
+	FcmUnregistered              PnsErrorCode = "UNREGISTERED"
+	FcmSenderIdMismatch          PnsErrorCode = "SENDER_ID_MISMATCH"
+	FcmInvalidArgument           PnsErrorCode = "INVALID_ARGUMENT"
+	FcmQuotaExceeded             PnsErrorCode = "QUOTA_EXCEEDED"
+	FcmUnavailable               PnsErrorCode = "UNAVAILABLE"
+	FcmThirdPartyAuthError       PnsErrorCode = "THIRD_PARTY_AUTH_ERROR"
+)
+
+// Remediations of PNS errors
+const (
+	// RemediationDeleteHandle means the handle will never be valid again,
+	// the installation or registration should be deleted
+	RemediationDeleteHandle Remediation = "delete-handle"
+	// RemediationFixCredentials means the hub PNS credentials are wrong, expired or of another app
+	RemediationFixCredentials Remediation = "fix-credentials"
+	// RemediationFixEnvironment means the handle was issued for another APNS environment than the hub uses
+	RemediationFixEnvironment Remediation = "fix-environment"
+	// RemediationFixPayload means the notification is malformed or too large
+	RemediationFixPayload Remediation = "fix-payload"
+	// RemediationRetry means the failure is transient
+	RemediationRetry Remediation = "retry"
+	// RemediationInvestigate means the error is unknown or has no automated fix
+	RemediationInvestigate Remediation = "investigate"
+)
+
+type (
+	// PnsErrorCode is an error code reported by the hub or a PNS
+	PnsErrorCode string
+
+	// Remediation is the action fixing a PNS error
+	Remediation string
+
+	// PnsError describes a PNS error code
+	PnsError struct {
+		Code PnsErrorCode
+		// Platforms reporting the code, nil for codes reported by the hub for every platform
+		Platforms   []Platform
+		Remediation Remediation
+		Description string
+	}
+
+	// PnsFailure counts occurrences of a PNS error in notification details
+	PnsFailure struct {
+		PnsError
+		Platform Platform
+		Count    int
+	}
+)
+
+// pnsErrors is the dictionary of known PNS error codes
+var pnsErrors = []PnsError{
+	{PnsWrongToken, nil, RemediationDeleteHandle, "the PNS rejected the handle as invalid"},
+	{PnsExpiredChannel, nil, RemediationDeleteHandle, "the handle expired"},
+	{PnsChannelExpired, nil, RemediationDeleteHandle, "the handle expired"},
+	{PnsBadChannel, nil, RemediationDeleteHandle, "the handle is not recognized by the PNS"},
+	{PnsInvalidToken, nil, RemediationDeleteHandle, "the handle is malformed"},
+	{PnsChannelDisconnected, nil, RemediationRetry, "the device is temporarily unreachable"},
+	{PnsChannelThrottled, nil, RemediationRetry, "the PNS throttled sends to the handle"},
+	{PnsThrottled, nil, RemediationRetry, "the PNS throttled sends of the hub"},
+	{PnsInvalidCredentials, nil, RemediationFixCredentials, "the PNS rejected the hub credentials"},
+	{PnsInvalidNotificationFormat, nil, RemediationFixPayload, "the PNS rejected the notification format"},
+	{PnsInvalidNotificationSize, nil, RemediationFixPayload, "the notification exceeds the PNS size limit"},
+	{PnsInterfaceError, nil, RemediationRetry, "the PNS failed to process the notification"},
+	{PnsServerError, nil, RemediationRetry, "the PNS reported an internal error"},
+	{PnsUnavailable, nil, RemediationRetry, "the PNS is unavailable"},
+	{PnsUnreachable, nil, RemediationRetry, "the hub could not reach the PNS"},
+	{PnsDropped, nil, RemediationRetry, "the hub dropped the notification"},
+	{PnsAbandoned, nil, RemediationRetry, "the hub gave up delivering the notification"},
+	{PnsNoTargets, nil, RemediationInvestigate, "no installation or registration matched the tags"},
+
+	{ApnsBadDeviceToken, []Platform{PlatformApns}, RemediationFixEnvironment, "the device token is of another APNS environment or malformed"},
+	{ApnsUnregistered, []Platform{PlatformApns, PlatformFcmV1}, RemediationDeleteHandle, "the app was uninstalled or the token revoked"},
+	{ApnsDeviceTokenNotForTopic, []Platform{PlatformApns}, RemediationDeleteHandle, "the device token belongs to another app"},
+	{ApnsBadCertificateEnvironment, []Platform{PlatformApns}, RemediationFixEnvironment, "the certificate is of another APNS environment"},
+	{ApnsBadEnvironmentKeyInToken, []Platform{PlatformApns}, RemediationFixEnvironment, "the token key is of another APNS environment"},
+	{ApnsPayloadTooLarge, []Platform{PlatformApns}, RemediationFixPayload, "the payload exceeds the APNS size limit"},
+	{ApnsTooManyRequests, []Platform{PlatformApns}, RemediationRetry, "too many pushes to the same device"},
+	{ApnsExpiredProviderToken, []Platform{PlatformApns}, RemediationFixCredentials, "the APNS token credential expired"},
+	{ApnsInvalidProviderToken, []Platform{PlatformApns}, RemediationFixCredentials, "the APNS token credential is invalid"},
+	{ApnsTopicDisallowed, []Platform{PlatformApns}, RemediationFixCredentials, "the credential does not allow the app bundle id"},
+
+	{FcmNotRegistered, []Platform{PlatformGcm}, RemediationDeleteHandle, "the app was uninstalled or the token revoked"},
+	{FcmInvalidRegistration, []Platform{PlatformGcm}, RemediationDeleteHandle, "the registration token is malformed"},
+	{FcmMismatchSenderId, []Platform{PlatformGcm}, RemediationFixCredentials, "the token belongs to another sender"},
+	{FcmMessageTooBig, []Platform{PlatformGcm}, RemediationFixPayload, "the payload exceeds the FCM size limit"},
+	{FcmDeviceMessageRateExceeded, []Platform{PlatformGcm}, RemediationRetry, "too many messages to the same device"},
+	{FcmSenderIdMismatch, []Platform{PlatformFcmV1}, RemediationFixCredentials, "the token belongs to another sender"},
+	{FcmInvalidArgument, []Platform{PlatformFcmV1}, RemediationFixPayload, "the message or the token is invalid"},
+	{FcmQuotaExceeded, []Platform{PlatformFcmV1}, RemediationRetry, "the sending quota is exceeded"},
+	{FcmUnavailable, []Platform{PlatformGcm, PlatformFcmV1}, RemediationRetry, "FCM is unavailable"},
+	{FcmThirdPartyAuthError, []Platform{PlatformFcmV1}, RemediationFixCredentials, "the APNS or web push credential of the FCM project is invalid"},
+}
+
+// LookupPnsError returns description of error code, case insensitive.
+// Unknown codes are described with RemediationInvestigate and false
+func LookupPnsError(code string) (PnsError, bool) {
+	for _, e := range pnsErrors {
+		if strings.EqualFold(string(e.Code), code) {
+			return e, true
+		}
+	}
+
+	return PnsError{Code: PnsErrorCode(code), Remediation: RemediationInvestigate}, false
+}
+
+// findPnsError returns the longest known error code mentioned in text, e.g. test send outcome
+func findPnsError(text string) (PnsError, bool) {
+	found, ok := PnsError{}, false
+	for _, e := range pnsErrors {
+		if strings.Contains(text, string(e.Code)) && len(e.Code) > len(found.Code) {
+			found, ok = e, true
+		}
+	}
+
+	return found, ok
+}
+
+// PnsError returns the PNS error reported by failed test send outcome, false when none is recognized
+func (r RegistrationResult) PnsError() (PnsError, bool) {
+	return findPnsError(r.Outcome)
+}
+
+// PnsError returns description of the feedback error
+func (r FeedbackRecord) PnsError() PnsError {
+	e, _ := LookupPnsError(r.Error)
+	return e
+}
+
+// PnsFailures returns outcomes other than Success with their descriptions, ordered by platform and code
+func (d *NotificationDetails) PnsFailures() []PnsFailure {
+	var failures []PnsFailure
+	for platform, outcomes := range d.OutcomeCounts {
+		for outcome, count := range outcomes {
+			if outcome == outcomeSuccess {
+				continue
+			}
+
+			e, _ := LookupPnsError(outcome)
+			failures = append(failures, PnsFailure{PnsError: e, Platform: platform, Count: count})
+		}
+	}
+
+	sort.Slice(failures, func(i, j int) bool {
+		if failures[i].Platform != failures[j].Platform {
+			return failures[i].Platform < failures[j].Platform
+		}
+		return failures[i].Code < failures[j].Code
+	})
+
+	return failures
+}
@@ -0,0 +1,66 @@
+package notihub
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_LookupPnsError(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	testPatterns := []struct {
+		code        string
+		remediation Remediation
+		known       bool
+	}{
+		{"BadDeviceToken", RemediationFixEnvironment, true},
+		{"notregistered", RemediationDeleteHandle, true},
+		{"UNREGISTERED", RemediationDeleteHandle, true},
+		{"BadEnvironmentKeyInToken", RemediationFixEnvironment, true},
+		{"InvalidCredentials", RemediationFixCredentials, true},
+		{"MessageTooBig", RemediationFixPayload, true},
+		{"QUOTA_EXCEEDED", RemediationRetry, true},
+		{"Gremlins", RemediationInvestigate, false},
+	}
+
+	for _, testData := range testPatterns {
+		e, known := LookupPnsError(testData.code)
+		if known != testData.known || e.Remediation != testData.remediation {
+			t.Errorf(errfmt, testData.code+" remediation", testData.remediation, e.Remediation)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, e := range pnsErrors {
+		if e.Remediation == "" || e.Description == "" || seen[string(e.Code)] {
+			t.Errorf(errfmt, string(e.Code)+" entry", "unique with remediation and description", e)
+		}
+		seen[string(e.Code)] = true
+	}
+}
+
+func Test_PnsErrorEnrichment(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	result := RegistrationResult{ApplicationPlatform: PlatformApns, Outcome: "The Push Notification System rejected the request: BadDeviceToken"}
+	if e, ok := result.PnsError(); !ok || e.Code != ApnsBadDeviceToken {
+		t.Errorf(errfmt, "test send outcome error", ApnsBadDeviceToken, e.Code)
+	}
+	if _, ok := (RegistrationResult{Outcome: "The Notification was successfully sent to the Push Notification System"}).PnsError(); ok {
+		t.Errorf(errfmt, "successful outcome error", false, ok)
+	}
+
+	if e := (FeedbackRecord{Error: "InvalidRegistration"}).PnsError(); e.Code != FcmInvalidRegistration {
+		t.Errorf(errfmt, "feedback error", FcmInvalidRegistration, e.Code)
+	}
+
+	d, err := ParseNotificationDetails([]byte(testNotificationDetails))
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalidToken, _ := LookupPnsError("InvalidToken")
+	expected := []PnsFailure{{PnsError: invalidToken, Platform: PlatformApns, Count: 1}}
+	if failures := d.PnsFailures(); !reflect.DeepEqual(failures, expected) {
+		t.Errorf(errfmt, "details failures", expected, failures)
+	}
+}
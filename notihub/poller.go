@@ -0,0 +1,176 @@
+package notihub
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// pollerKindJob is the kind of pollers tracking hub jobs
+const pollerKindJob = "job"
+
+// ErrInvalidResumeToken is returned when a poller can not be resumed from token
+var ErrInvalidResumeToken = errors.New("invalid resume token")
+
+type (
+	// Poller tracks a long running operation, like an import or export job, until it finishes.
+	// Its state is persisted by ResumeToken, so that polling survives process restarts:
+	//
+	//	token, _ := poller.ResumeToken()
+	//	...
+	//	poller, err := hub.ResumePoller(token)
+	//	job, err := poller.PollUntilDone(ctx, nil)
+	//
+	// Poller is not safe for concurrent use
+	Poller struct {
+		h     *NotificationHub
+		state pollerState
+		job   *Job
+	}
+
+	// pollerState is the poller state encoded in resume tokens
+	pollerState struct {
+		Kind string `json:"kind"`
+		ID   string `json:"id"`
+		// Hub is the hub path, pollers are resumed by clients of the same hub only
+		Hub      string        `json:"hub"`
+		Interval time.Duration `json:"interval"`
+	}
+)
+
+// BeginJob submits job like SubmitJob and returns poller tracking it
+func (h *NotificationHub) BeginJob(ctx context.Context, job *Job) (*Poller, error) {
+	submitted, err := h.submitJob(ctx, job)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.BeginJob: %w", err)
+	}
+
+	p := h.jobPoller(submitted.JobId)
+	p.job = submitted
+	return p, nil
+}
+
+// ResumePoller returns poller continuing where the poller of token left off
+func (h *NotificationHub) ResumePoller(token string) (*Poller, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.ResumePoller: %w", ErrInvalidResumeToken)
+	}
+
+	var state pollerState
+	if err := json.Unmarshal(b, &state); err != nil || state.Kind != pollerKindJob {
+		return nil, fmt.Errorf("NotificationHub.ResumePoller: %w", ErrInvalidResumeToken)
+	}
+	// the id is joined into request paths, a tampered token must not reach other resources
+	if _, err := resourcePath("jobs", state.ID); err != nil {
+		return nil, fmt.Errorf("NotificationHub.ResumePoller: %w: %v", ErrInvalidResumeToken, err)
+	}
+	if state.Hub != h.hubPath() {
+		return nil, fmt.Errorf("NotificationHub.ResumePoller: %w: token of hub '%s'", ErrInvalidResumeToken, state.Hub)
+	}
+
+	return &Poller{h: h, state: state}, nil
+}
+
+// jobPoller returns poller of job jobID
+func (h *NotificationHub) jobPoller(jobID string) *Poller {
+	return &Poller{h: h, state: pollerState{Kind: pollerKindJob, ID: jobID, Hub: h.hubPath(), Interval: jobPollInterval}}
+}
+
+// hubPath returns the hub path without leading slash
+func (h *NotificationHub) hubPath() string {
+	return strings.TrimPrefix(h.hubURL.Path, "/")
+}
+
+// ResumeToken returns opaque token of the poller state, to be passed to ResumePoller
+func (p *Poller) ResumeToken() (string, error) {
+	b, err := json.Marshal(p.state)
+	if err != nil {
+		return "", fmt.Errorf("Poller.ResumeToken: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Done reports whether the last polled job state is finished
+func (p *Poller) Done() bool {
+	return p.job != nil && p.job.IsFinished()
+}
+
+// Result returns the last polled job state, nil before the first poll of resumed pollers
+func (p *Poller) Result() *Job {
+	return p.job
+}
+
+// Poll reads the current job state
+func (p *Poller) Poll(ctx context.Context) (*Job, error) {
+	job, err := p.poll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Poller.Poll: %w", err)
+	}
+
+	return job, nil
+}
+
+// PollUntilDone polls job with increasing intervals until it completes or fails.
+// onProgress, when not nil, is called with every polled job state.
+// *JobFailedError is returned when the job fails
+func (p *Poller) PollUntilDone(ctx context.Context, onProgress func(*Job)) (*Job, error) {
+	job, err := p.pollUntilDone(ctx, onProgress)
+	if err != nil {
+		var failed *JobFailedError
+		if errors.As(err, &failed) {
+			return job, err
+		}
+		return job, fmt.Errorf("Poller.PollUntilDone: %w", err)
+	}
+
+	return job, nil
+}
+
+// poll reads the current job state
+func (p *Poller) poll(ctx context.Context) (*Job, error) {
+	job, err := p.h.getJob(ctx, p.state.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p.job = job
+	return job, nil
+}
+
+// pollUntilDone polls job until it finishes, the poll interval is kept in the poller state
+func (p *Poller) pollUntilDone(ctx context.Context, onProgress func(*Job)) (*Job, error) {
+	for {
+		job, err := p.poll(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if onProgress != nil {
+			onProgress(job)
+		}
+
+		switch job.Status {
+		case JobCompleted:
+			return job, nil
+		case JobFailed:
+			return job, &JobFailedError{Job: job}
+		}
+
+		if p.state.Interval <= 0 {
+			p.state.Interval = jobPollInterval
+		}
+		if err := sleepContext(ctx, p.state.Interval); err != nil {
+			return job, err
+		}
+
+		if p.state.Interval *= 2; p.state.Interval > jobPollMaxInterval {
+			p.state.Interval = jobPollMaxInterval
+		}
+	}
+}
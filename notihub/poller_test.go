@@ -0,0 +1,75 @@
+package notihub
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_NotificationHubPollerResume(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	defer func(interval time.Duration) { jobPollInterval = interval }(jobPollInterval)
+	jobPollInterval = time.Millisecond
+
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			fmt.Fprintf(w, testJobEntryTemplate, "job-1", "0", ExportRegistrations, JobStarted, "")
+			return
+		}
+
+		polls++
+		if polls < 3 {
+			fmt.Fprintf(w, testJobEntryTemplate, "job-1", "50", ExportRegistrations, JobRunning, "")
+			return
+		}
+		w.Write([]byte(testCompletedJobEntry))
+	}))
+	defer server.Close()
+
+	connection := "Endpoint=" + server.URL + ";SharedAccessKeyName=name;SharedAccessKey=key"
+	hub := NewNotificationHub(connection, "hub", server.Client())
+
+	poller, err := hub.BeginJob(context.Background(), &Job{Type: ExportRegistrations, OutputContainerUri: "https://storage/out"})
+	if err != nil || poller.Result().JobId != "job-1" || poller.Done() {
+		t.Fatalf(errfmt, "started job", "job-1", err)
+	}
+
+	if job, err := poller.Poll(context.Background()); err != nil || job.Status != JobRunning || poller.Done() {
+		t.Errorf(errfmt, "polled job", JobRunning, err)
+	}
+
+	token, err := poller.ResumeToken()
+	if err != nil {
+		t.Fatalf(errfmt, "token error", nil, err)
+	}
+
+	restarted := NewNotificationHub(connection, "hub", server.Client())
+	resumed, err := restarted.ResumePoller(token)
+	if err != nil || resumed.Result() != nil {
+		t.Fatalf(errfmt, "resumed poller", "poller without result", err)
+	}
+
+	job, err := resumed.PollUntilDone(context.Background(), nil)
+	if err != nil || job.Status != JobCompleted || !resumed.Done() || polls != 3 {
+		t.Errorf(errfmt, "resumed polling", JobCompleted, err)
+	}
+
+	tampered := base64.RawURLEncoding.EncodeToString([]byte(`{"kind":"job","id":"../registrations","hub":"hub"}`))
+	if _, err := restarted.ResumePoller(tampered); !errors.Is(err, ErrInvalidResumeToken) {
+		t.Errorf(errfmt, "tampered token error", ErrInvalidResumeToken, err)
+	}
+
+	other := NewNotificationHub(connection, "other", server.Client())
+	for _, token := range []string{token, "not a token", "e30"} {
+		if _, err := other.ResumePoller(token); !errors.Is(err, ErrInvalidResumeToken) {
+			t.Errorf(errfmt, "invalid token error", ErrInvalidResumeToken, err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package notihub
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/vippsas/gozure/notihub/tags"
+)
+
+// Preferences provides notification categories users opted out of, e.g. "marketing"
+type Preferences interface {
+	OptedOut(ctx context.Context, userID string) ([]string, error)
+}
+
+// WithPreferences makes sends of a category, see WithCategory, skip users who opted out of it.
+// Users are recognized by user tags, see tags.UserTag, among orTags, hub default tags and context tags.
+// Opted-out users are removed from orTags, the send is skipped when none of orTags remain
+// or any of the required tags belongs to an opted-out user. Skipped users are counted in HubStats
+// and reported in SendResult. Failures of the provider fail the send
+func WithPreferences(preferences Preferences) HubOption {
+	return func(h *NotificationHub) {
+		h.preferences = preferences
+	}
+}
+
+// WithCategory sets the category of the send checked against user preferences.
+// Sends without category are not checked
+func WithCategory(category string) SendOption {
+	return func(o *sendOptions) {
+		o.category = category
+	}
+}
+
+// applyPreferences removes users who opted out of the send category from orTags.
+// skip is set when the send must not be made at all
+func (h *NotificationHub) applyPreferences(ctx context.Context, orTags []string, o *sendOptions) (allowed []string, skip bool, err error) {
+	if h.preferences == nil || o == nil || o.category == "" {
+		return orTags, false, nil
+	}
+
+	o.optedOut = nil
+	defer func() {
+		if len(o.optedOut) > 0 && h.stats != nil {
+			atomic.AddInt64(&h.stats.optedOut, int64(len(o.optedOut)))
+		}
+		if skip {
+			o.newResult()
+		}
+	}()
+
+	andTags := append(append(append([]string(nil), h.defaultTags...), ContextTags(ctx)...), o.andTags...)
+	for _, tag := range andTags {
+		optedOut, err := h.userOptedOut(ctx, tag, o.category)
+		if err != nil {
+			return nil, false, err
+		}
+		if optedOut {
+			o.optedOut = append(o.optedOut, userTagID(tag))
+			return nil, true, nil
+		}
+	}
+
+	allowed = make([]string, 0, len(orTags))
+	for _, tag := range orTags {
+		optedOut, err := h.userOptedOut(ctx, tag, o.category)
+		if err != nil {
+			return nil, false, err
+		}
+		if optedOut {
+			o.optedOut = append(o.optedOut, userTagID(tag))
+			continue
+		}
+		allowed = append(allowed, tag)
+	}
+
+	return allowed, len(orTags) > 0 && len(allowed) == 0, nil
+}
+
+// userOptedOut identifies whether tag is user tag of user who opted out of category
+func (h *NotificationHub) userOptedOut(ctx context.Context, tag, category string) (bool, error) {
+	userID := userTagID(tag)
+	if userID == "" {
+		return false, nil
+	}
+
+	categories, err := h.preferences.OptedOut(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("preferences of user '%s': %w", userID, err)
+	}
+
+	for _, c := range categories {
+		if c == category {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// userTagID returns user id of user tag, empty for other tags
+func userTagID(tag string) string {
+	parsed, err := tags.Parse(tag)
+	if err != nil || parsed.Namespace != tags.User {
+		return ""
+	}
+
+	return parsed.Value
+}
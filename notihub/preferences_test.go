@@ -0,0 +1,69 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type mapPreferences map[string][]string
+
+func (p mapPreferences) OptedOut(ctx context.Context, userID string) ([]string, error) {
+	if userID == "broken" {
+		return nil, errors.New("store unavailable")
+	}
+
+	return p[userID], nil
+}
+
+func Test_NotificationHubPreferences(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var sent []string
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		sent = append(sent, req.Header.Get("ServiceBusNotification-Tags"))
+		return nil, nil
+	})
+	nhub.stats = &hubStats{}
+	WithPreferences(mapPreferences{"1": {"marketing"}, "2": {"marketing", "news"}})(nhub)
+
+	n := &Notification{Template, []byte("{}")}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		orTags   []string
+		opts     []SendOption
+		sent     []string
+		optedOut []string
+		err      bool
+	}{
+		{"without category", context.Background(), []string{"user:1"}, nil, []string{"user:1"}, nil, false},
+		{"allowed category", context.Background(), []string{"user:1"}, []SendOption{WithCategory("news")}, []string{"user:1"}, nil, false},
+		{"partly opted out", context.Background(), []string{"user:1", "user:3", "topic:deals"}, []SendOption{WithCategory("marketing")}, []string{"user:3 || topic:deals"}, []string{"1"}, false},
+		{"all opted out", context.Background(), []string{"user:1", "user:2"}, []SendOption{WithCategory("marketing")}, nil, []string{"1", "2"}, false},
+		{"opted out context user", WithContextTags(context.Background(), "user:2"), []string{"topic:news"}, []SendOption{WithCategory("news")}, nil, []string{"2"}, false},
+		{"provider failure", context.Background(), []string{"user:broken"}, []SendOption{WithCategory("news")}, nil, nil, true},
+	}
+
+	for _, test := range tests {
+		sent = nil
+		var result SendResult
+		_, err := nhub.Send(test.ctx, n, test.orTags, append(test.opts, WithResult(&result))...)
+		if (err != nil) != test.err {
+			t.Errorf(errfmt, test.name+" error", test.err, err)
+		}
+		if !reflect.DeepEqual(sent, test.sent) {
+			t.Errorf(errfmt, test.name+" sends", test.sent, sent)
+		}
+		if !reflect.DeepEqual(result.OptedOut, test.optedOut) {
+			t.Errorf(errfmt, test.name+" opted out", test.optedOut, result.OptedOut)
+		}
+	}
+
+	if stats := nhub.Stats(); stats.OptedOut != 4 {
+		t.Errorf(errfmt, "opted out count", 4, stats.OptedOut)
+	}
+}
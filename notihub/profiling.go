@@ -0,0 +1,22 @@
+package notihub
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// goLabeled runs f in a new goroutine labeled with the hub and operation,
+// so that CPU profiles and traces attribute the work to the hub
+func (h *NotificationHub) goLabeled(ctx context.Context, operation string, f func(ctx context.Context)) {
+	go pprof.Do(ctx, h.profileLabels(operation), f)
+}
+
+// profileLabels returns pprof labels of hub operation
+func (h *NotificationHub) profileLabels(operation string) pprof.LabelSet {
+	hub := ""
+	if h.hubURL != nil {
+		hub = h.hubURL.Host + "/" + h.hubURL.Path
+	}
+
+	return pprof.Labels("hub", hub, "operation", operation)
+}
@@ -0,0 +1,50 @@
+package notihub
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"runtime/pprof"
+	"sync"
+	"testing"
+)
+
+func Test_NotificationHubProfileLabels(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var (
+		mu     sync.Mutex
+		labels []string
+	)
+	mockClient := &mockHubHttpClient{execFunc: func(req *http.Request) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		hub, _ := pprof.Label(req.Context(), "hub")
+		operation, _ := pprof.Label(req.Context(), "operation")
+		labels = append(labels, hub+" "+operation)
+		return nil, nil
+	}}
+
+	nhub := &NotificationHub{
+		sasKeyValue:    "testKeyValue",
+		sasKeyName:     "testKeyName",
+		hubURL:         &url.URL{Host: "testHost", Scheme: schemeDefault, Path: "testPath"},
+		client:         mockClient,
+		expiryTimeFunc: TimeFunc(mockExpiryTime),
+	}
+
+	notifications := []*Notification{{AndroidFormat, []byte(`{"data":{}}`)}}
+	if _, err := nhub.Broadcast(context.Background(), notifications, nil, BroadcastAll()); err != nil {
+		t.Fatalf(errfmt, "broadcast error", nil, err)
+	}
+
+	messages := []BulkMessage{{Notification: notifications[0]}}
+	if _, err := nhub.NewBulkSender("campaign", messages, BulkOptions{SendOptions: []SendOption{BroadcastAll()}}).Run(context.Background()); err != nil {
+		t.Fatalf(errfmt, "bulk send error", nil, err)
+	}
+
+	if len(labels) != 2 || labels[0] != "testHost/testPath broadcast" || labels[1] != "testHost/testPath bulk" {
+		t.Errorf(errfmt, "profile labels", []string{"testHost/testPath broadcast", "testHost/testPath bulk"}, labels)
+	}
+}
@@ -0,0 +1,93 @@
+package notihub
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrPropertyTooLarge is returned when template properties
+// exceed the payload size limit of a target platform
+var ErrPropertyTooLarge = errors.New("template property too large")
+
+// PropertyError reports template property exceeding platform size limit.
+// Property is empty when only the properties combined exceed the limit
+type PropertyError struct {
+	Property string
+	Platform Platform
+	Size     int
+	Limit    int
+}
+
+// Error returns actionable description of the oversized property
+func (e *PropertyError) Error() string {
+	if e.Property == "" {
+		return fmt.Sprintf("template properties total %d bytes, exceeding %s payload limit of %d bytes: shorten the values or offload content with NewOffloadedNotification",
+			e.Size, e.Platform, e.Limit)
+	}
+
+	return fmt.Sprintf("template property '%s' is %d bytes, exceeding %s payload limit of %d bytes: shorten the value or offload it with NewOffloadedNotification",
+		e.Property, e.Size, e.Platform, e.Limit)
+}
+
+// Unwrap returns ErrPropertyTooLarge
+func (e *PropertyError) Unwrap() error {
+	return ErrPropertyTooLarge
+}
+
+// ValidateTemplateProperties checks every property value, and the values combined,
+// fit the payload size limit of each target platform, so no platform silently truncates them.
+// The template format limit is used when no platform is given
+func ValidateTemplateProperties(properties map[string]string, platforms ...Platform) error {
+	names := make([]string, 0, len(properties))
+	total := 0
+	for name, value := range properties {
+		names = append(names, name)
+		total += len(value)
+	}
+	sort.Strings(names)
+
+	if len(platforms) == 0 {
+		platforms = []Platform{""}
+	}
+
+	for _, platform := range platforms {
+		limit := propertyLimit(platform)
+
+		for _, name := range names {
+			if size := len(properties[name]); size > limit {
+				return &PropertyError{Property: name, Platform: platform, Size: size, Limit: limit}
+			}
+		}
+
+		if total > limit {
+			return &PropertyError{Platform: platform, Size: total, Limit: limit}
+		}
+	}
+
+	return nil
+}
+
+// NewTemplateNotification validates properties against platforms and returns template notification
+func NewTemplateNotification(properties map[string]string, platforms ...Platform) (*Notification, error) {
+	if err := ValidateTemplateProperties(properties, platforms...); err != nil {
+		return nil, err
+	}
+
+	payload, err := DefaultEncoder.Marshal(properties)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Notification{Template, payload}, nil
+}
+
+// propertyLimit returns payload size limit of platform,
+// the template format limit for platforms without native format
+func propertyLimit(platform Platform) int {
+	if format, err := FormatForPlatform(platform); err == nil {
+		return format.MaxPayloadSize()
+	}
+
+	return Template.MaxPayloadSize()
+}
@@ -0,0 +1,60 @@
+package notihub
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_ValidateTemplateProperties(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	tests := []struct {
+		name       string
+		properties map[string]string
+		platforms  []Platform
+		expected   *PropertyError
+	}{
+		{"fits", map[string]string{"title": "hi", "body": strings.Repeat("b", 3000)}, []Platform{PlatformApns, PlatformWns}, nil},
+		{"no platform", map[string]string{"body": strings.Repeat("b", 4097)}, nil,
+			&PropertyError{Property: "body", Size: 4097, Limit: 4096}},
+		{"single property", map[string]string{"title": "hi", "body": strings.Repeat("b", 3500)}, []Platform{PlatformWns, PlatformMpns},
+			&PropertyError{Property: "body", Platform: PlatformMpns, Size: 3500, Limit: 3072}},
+		{"combined", map[string]string{"title": strings.Repeat("t", 3000), "body": strings.Repeat("b", 3000)}, []Platform{PlatformApns},
+			&PropertyError{Platform: PlatformApns, Size: 6000, Limit: 4096}},
+		{"larger kindle limit", map[string]string{"body": strings.Repeat("b", 5000)}, []Platform{PlatformAdm}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateTemplateProperties(test.properties, test.platforms...)
+			if test.expected == nil {
+				if err != nil {
+					t.Errorf(errfmt, "error", nil, err)
+				}
+				return
+			}
+
+			var propErr *PropertyError
+			if !errors.As(err, &propErr) || *propErr != *test.expected || !errors.Is(err, ErrPropertyTooLarge) {
+				t.Errorf(errfmt, "error", test.expected, err)
+			}
+			if test.expected.Property != "" && !strings.Contains(err.Error(), "'"+test.expected.Property+"'") {
+				t.Errorf(errfmt, "error naming property", test.expected.Property, err)
+			}
+		})
+	}
+}
+
+func Test_NewTemplateNotification(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	n, err := NewTemplateNotification(map[string]string{"title": "hi"}, PlatformApns)
+	if err != nil || n.Format != Template || string(n.Payload) != `{"title":"hi"}` {
+		t.Errorf(errfmt, "notification", `{"title":"hi"}`, n)
+	}
+
+	if _, err := NewTemplateNotification(map[string]string{"body": strings.Repeat("b", 5000)}, PlatformApns); !errors.Is(err, ErrPropertyTooLarge) {
+		t.Errorf(errfmt, "error", ErrPropertyTooLarge, err)
+	}
+}
@@ -0,0 +1,206 @@
+package notihub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vippsas/gozure/notihub/tags"
+)
+
+// installationTagPrefix prefixes the tag the hub adds to registrations of installations
+const installationTagPrefix = "$InstallationId:{"
+
+// ErrNoAuditKey is returned by PurgeUser of hubs without WithAuditKey
+var ErrNoAuditKey = errors.New("no audit key")
+
+type (
+	// PurgeRecord is the audit record of deleting all data of a user from a hub.
+	// Signature is HMAC-SHA256 of the JSON encoded record without signature,
+	// keyed by the WithAuditKey key
+	PurgeRecord struct {
+		UserID        string   `json:"userId"`
+		Hub           string   `json:"hub"`
+		Installations []string `json:"installations"`
+		Registrations []string `json:"registrations"`
+		// Failed lists installations and registrations which could not be deleted
+		Failed    []string  `json:"failed,omitempty"`
+		PurgedAt  time.Time `json:"purgedAt"`
+		KeyID     string    `json:"keyId"`
+		Signature []byte    `json:"signature"`
+	}
+
+	// auditKey signs purge records
+	auditKey struct {
+		id  string
+		key []byte
+	}
+)
+
+// WithAuditKey sets the key signing purge audit records, id identifies it in the records.
+// The key must be kept apart from the hub shared access keys, PurgeUser fails without it
+func WithAuditKey(id string, key []byte) HubOption {
+	return func(h *NotificationHub) {
+		h.auditKey = &auditKey{id: id, key: key}
+	}
+}
+
+// PurgeUser deletes all installations and registrations carrying the user tag of userID,
+// e.g. to fulfill a GDPR erasure request, and returns the audit record of the purge signed by the WithAuditKey key.
+// The record lists deleted and failed installations and registrations even when some deletions fail
+func (h *NotificationHub) PurgeUser(ctx context.Context, userID string) (*PurgeRecord, error) {
+	record, err := h.purgeUser(ctx, userID)
+	if err != nil {
+		return record, fmt.Errorf("NotificationHub.PurgeUser: %w", err)
+	}
+
+	return record, nil
+}
+
+// PurgeUser purges userID from all router hubs, ordered by residency region.
+// Records of all hubs are returned even when purging some of them fails
+func (r *Router) PurgeUser(ctx context.Context, userID string) ([]PurgeRecord, error) {
+	regions := make([]string, 0, len(r.Hubs))
+	for region := range r.Hubs {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	var (
+		records  []PurgeRecord
+		firstErr error
+	)
+	for _, region := range regions {
+		record, err := r.Hubs[region].purgeUser(ctx, userID)
+		if record != nil {
+			records = append(records, *record)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("residency '%s': %w", region, err)
+		}
+	}
+
+	if firstErr != nil {
+		return records, fmt.Errorf("Router.PurgeUser: %w", firstErr)
+	}
+
+	return records, nil
+}
+
+// Verify reports whether the record is signed by key
+func (r PurgeRecord) Verify(key []byte) bool {
+	signature, err := r.sign(key)
+	return err == nil && hmac.Equal(signature, r.Signature)
+}
+
+// sign returns signature of the record by key
+func (r PurgeRecord) sign(key []byte) ([]byte, error) {
+	r.Signature = nil
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+	return mac.Sum(nil), nil
+}
+
+// purgeUser deletes installations and registrations of userID
+func (h *NotificationHub) purgeUser(ctx context.Context, userID string) (*PurgeRecord, error) {
+	if h.auditKey == nil || len(h.auditKey.key) == 0 {
+		return nil, ErrNoAuditKey
+	}
+
+	userTag, err := tags.UserTag(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	registrations, err := h.registrationPager(userTag.String()).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &PurgeRecord{UserID: userID, Hub: strings.TrimPrefix(h.hubURL.Path, "/"), Installations: []string{}, Registrations: []string{}}
+	var firstErr error
+	fail := func(id string, err error) {
+		record.Failed = append(record.Failed, id)
+		if firstErr == nil {
+			firstErr = fmt.Errorf("%s: %w", id, err)
+		}
+	}
+
+	installations := map[string]bool{}
+	for _, r := range registrations {
+		installationID, ok := registrationInstallation(r)
+		if !ok {
+			if err := h.deleteRegistration(ctx, r.RegistrationId); err != nil {
+				fail(r.RegistrationId, err)
+				continue
+			}
+			record.Registrations = append(record.Registrations, r.RegistrationId)
+			continue
+		}
+
+		if installations[installationID] {
+			continue
+		}
+		installations[installationID] = true
+
+		if err := h.deleteInstallation(ctx, installationID); err != nil {
+			fail(installationID, err)
+			continue
+		}
+		record.Installations = append(record.Installations, installationID)
+	}
+
+	record.PurgedAt = h.now().UTC()
+	record.KeyID = h.auditKey.id
+
+	signature, err := record.sign(h.auditKey.key)
+	if err != nil {
+		return nil, err
+	}
+	record.Signature = signature
+
+	if firstErr != nil {
+		return record, fmt.Errorf("%d deletions failed, first: %w", len(record.Failed), firstErr)
+	}
+
+	return record, nil
+}
+
+// registrationInstallation returns id of the installation r belongs to, if any
+func registrationInstallation(r RegistrationDescription) (string, bool) {
+	for _, tag := range r.TagList() {
+		if strings.HasPrefix(tag, installationTagPrefix) && strings.HasSuffix(tag, "}") {
+			return tag[len(installationTagPrefix) : len(tag)-1], true
+		}
+	}
+
+	return "", false
+}
+
+// deleteRegistration deletes registration by id regardless of its etag
+func (h *NotificationHub) deleteRegistration(ctx context.Context, registrationID string) error {
+	relPath, err := registrationPath(registrationID)
+	if err != nil {
+		return err
+	}
+
+	req, err := h.newRequest(ctx, "DELETE", relPath, h.hubURL.Query(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("If-Match", "*")
+
+	_, err = h.exec(req, nil)
+	return err
+}
@@ -0,0 +1,126 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_NotificationHubPurgeUser(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	feed := `<feed xmlns="http://www.w3.org/2005/Atom">` +
+		`<entry><content type="application/xml"><GcmRegistrationDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><Tags>user:42,$InstallationId:{inst-1}</Tags><RegistrationId>1</RegistrationId><GcmRegistrationId>a</GcmRegistrationId></GcmRegistrationDescription></content></entry>` +
+		`<entry><content type="application/xml"><GcmTemplateRegistrationDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><Tags>user:42,$InstallationId:{inst-1}</Tags><RegistrationId>2</RegistrationId><GcmRegistrationId>a</GcmRegistrationId></GcmTemplateRegistrationDescription></content></entry>` +
+		`<entry><content type="application/xml"><AppleRegistrationDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><Tags>user:42</Tags><RegistrationId>3</RegistrationId><DeviceToken>token</DeviceToken></AppleRegistrationDescription></content></entry>` +
+		`<entry><content type="application/xml"><AppleRegistrationDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><Tags>user:42</Tags><RegistrationId>4</RegistrationId><DeviceToken>locked</DeviceToken></AppleRegistrationDescription></content></entry>` +
+		`</feed>`
+
+	var listed string
+	var deleted []string
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		if req.Method == "GET" {
+			listed = req.URL.Path
+			return []byte(feed), nil
+		}
+		if strings.HasSuffix(req.URL.Path, "/4") {
+			return nil, &ResponseError{StatusCode: http.StatusForbidden, Header: http.Header{}}
+		}
+		deleted = append(deleted, req.URL.Path+" "+req.Header.Get("If-Match"))
+		return nil, nil
+	})
+	nhub.clock = &mockClock{now: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)}
+	WithAuditKey("audit-1", []byte("secret"))(nhub)
+
+	record, err := nhub.PurgeUser(context.Background(), "42")
+	if err == nil || !strings.Contains(err.Error(), "1 deletions failed, first: 4") {
+		t.Errorf(errfmt, "purge error", "registration 4 failed", err)
+	}
+	if listed != "/testPath/tags/user:42/registrations" {
+		t.Errorf(errfmt, "listed registrations", "/testPath/tags/user:42/registrations", listed)
+	}
+
+	expectedDeleted := []string{"/testPath/installations/inst-1 ", "/testPath/registrations/3 *"}
+	if !reflect.DeepEqual(deleted, expectedDeleted) {
+		t.Errorf(errfmt, "deletions", expectedDeleted, deleted)
+	}
+
+	if record == nil {
+		t.Fatalf(errfmt, "record", "purge record", record)
+	}
+	expected := PurgeRecord{
+		UserID:        "42",
+		Hub:           "testPath",
+		Installations: []string{"inst-1"},
+		Registrations: []string{"3"},
+		Failed:        []string{"4"},
+		PurgedAt:      nhub.clock.Now(),
+		KeyID:         "audit-1",
+		Signature:     record.Signature,
+	}
+	if !reflect.DeepEqual(*record, expected) {
+		t.Errorf(errfmt, "record", expected, *record)
+	}
+
+	if !record.Verify([]byte("secret")) || record.Verify([]byte("other")) {
+		t.Errorf(errfmt, "signature", "verified by audit key only", record.Signature)
+	}
+	record.Registrations = nil
+	if record.Verify([]byte("secret")) {
+		t.Errorf(errfmt, "tampered record verification", false, true)
+	}
+
+	if _, err := nhub.PurgeUser(context.Background(), "a b"); err == nil {
+		t.Errorf(errfmt, "invalid user id error", "error", err)
+	}
+}
+
+func Test_RouterPurgeUser(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	hub := func(opts ...HubOption) *NotificationHub {
+		nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+			return []byte(`<feed xmlns="http://www.w3.org/2005/Atom"></feed>`), nil
+		})
+		for _, opt := range opts {
+			opt(nhub)
+		}
+		return nhub
+	}
+	router := &Router{Hubs: map[string]*NotificationHub{"us": hub(WithAuditKey("audit-1", []byte("secret"))), "eu": hub(WithAuditKey("audit-2", []byte("other")))}}
+
+	records, err := router.PurgeUser(context.Background(), "42")
+	if err != nil || len(records) != 2 {
+		t.Fatalf(errfmt, "records", 2, err)
+	}
+	if !records[0].Verify([]byte("other")) || records[0].KeyID != "audit-2" {
+		t.Errorf(errfmt, "signature by audit key of eu", "audit-2", records[0].KeyID)
+	}
+
+	router.Hubs["eu"] = hub()
+	records, err = router.PurgeUser(context.Background(), "42")
+	if !errors.Is(err, ErrNoAuditKey) || len(records) != 1 {
+		t.Errorf(errfmt, "error of hub without audit key", ErrNoAuditKey, err)
+	}
+}
+
+func Test_NotificationHubPurgeUserWithoutAuditKey(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		t.Errorf(errfmt, "request", nil, req.URL)
+		return nil, nil
+	})
+
+	if _, err := nhub.PurgeUser(context.Background(), "42"); !errors.Is(err, ErrNoAuditKey) {
+		t.Errorf(errfmt, "purge error", ErrNoAuditKey, err)
+	}
+	WithAuditKey("audit-1", nil)(nhub)
+	if _, err := nhub.PurgeUser(context.Background(), "42"); !errors.Is(err, ErrNoAuditKey) {
+		t.Errorf(errfmt, "purge error with empty key", ErrNoAuditKey, err)
+	}
+}
@@ -0,0 +1,226 @@
+package notihub
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ErrInvalidRegistration is returned for registrations of unknown platforms or without device handle
+var ErrInvalidRegistration = errors.New("invalid registration")
+
+type (
+	registrationEntryXML struct {
+		XMLName xml.Name                 `xml:"http://www.w3.org/2005/Atom entry"`
+		Content registrationEntryContent `xml:"content"`
+	}
+
+	registrationEntryContent struct {
+		Type         string                `xml:"type,attr"`
+		Registration registrationImportXML `xml:",any"`
+	}
+)
+
+// NewAppleRegistration returns registration of APNs device token with tags
+func NewAppleRegistration(deviceToken string, tags ...string) RegistrationDescription {
+	return RegistrationDescription{Type: "Apple" + registrationDescriptionSuffix, DeviceToken: deviceToken, Tags: strings.Join(tags, ",")}
+}
+
+// NewGcmRegistration returns registration of legacy GCM/FCM registration id with tags
+func NewGcmRegistration(gcmRegistrationID string, tags ...string) RegistrationDescription {
+	return RegistrationDescription{Type: "Gcm" + registrationDescriptionSuffix, GcmRegistrationId: gcmRegistrationID, Tags: strings.Join(tags, ",")}
+}
+
+// NewFcmV1Registration returns registration of FCM v1 registration token with tags
+func NewFcmV1Registration(fcmV1RegistrationID string, tags ...string) RegistrationDescription {
+	return RegistrationDescription{Type: "FcmV1" + registrationDescriptionSuffix, FcmV1RegistrationId: fcmV1RegistrationID, Tags: strings.Join(tags, ",")}
+}
+
+// NewWindowsRegistration returns registration of WNS channel uri with tags
+func NewWindowsRegistration(channelURI string, tags ...string) RegistrationDescription {
+	return RegistrationDescription{Type: "Windows" + registrationDescriptionSuffix, ChannelUri: channelURI, Tags: strings.Join(tags, ",")}
+}
+
+// NewMpnsRegistration returns registration of MPNS channel uri with tags
+func NewMpnsRegistration(channelURI string, tags ...string) RegistrationDescription {
+	return RegistrationDescription{Type: "Mpns" + registrationDescriptionSuffix, ChannelUri: channelURI, Tags: strings.Join(tags, ",")}
+}
+
+// NewBaiduRegistration returns registration of Baidu user and channel with tags
+func NewBaiduRegistration(userID, channelID string, tags ...string) RegistrationDescription {
+	return RegistrationDescription{Type: "Baidu" + registrationDescriptionSuffix, BaiduUserId: userID, BaiduChannelId: channelID, Tags: strings.Join(tags, ",")}
+}
+
+// AsTemplate returns template registration named name with body, for the same device as r
+func (r RegistrationDescription) AsTemplate(name, body string) RegistrationDescription {
+	if !r.IsTemplate() {
+		r.Type = strings.TrimSuffix(r.Type, registrationDescriptionSuffix) + "Template" + registrationDescriptionSuffix
+	}
+	r.TemplateName = name
+	r.BodyTemplate = body
+
+	return r
+}
+
+// CreateRegistration creates registration r, e.g. NewAppleRegistration(token, "news"),
+// and returns it as stored by the hub, with registration id and etag assigned
+func (h *NotificationHub) CreateRegistration(ctx context.Context, r RegistrationDescription) (*RegistrationDescription, error) {
+	r.RegistrationId = ""
+
+	registration, err := h.putRegistration(ctx, "POST", "registrations", r)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.CreateRegistration: %w", err)
+	}
+
+	return registration, nil
+}
+
+// CreateRegistrationID reserves registration id, so that the registration can be created
+// idempotently by UpdateRegistration, retries and concurrent attempts included
+func (h *NotificationHub) CreateRegistrationID(ctx context.Context) (string, error) {
+	req, err := h.newRequest(ctx, "POST", "registrationIDs", h.hubURL.Query(), nil)
+	if err != nil {
+		return "", fmt.Errorf("NotificationHub.CreateRegistrationID: %w", err)
+	}
+
+	o := &sendOptions{}
+	if _, err := h.exec(req, o); err != nil {
+		return "", fmt.Errorf("NotificationHub.CreateRegistrationID: %w", err)
+	}
+
+	registrationID, err := locationRegistrationID(o.header.Get("Location"))
+	if err != nil {
+		return "", fmt.Errorf("NotificationHub.CreateRegistrationID: %w", err)
+	}
+
+	return registrationID, nil
+}
+
+// GetRegistration reads registration by id
+func (h *NotificationHub) GetRegistration(ctx context.Context, registrationID string) (*RegistrationDescription, error) {
+	relPath, err := registrationPath(registrationID)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.GetRegistration: %w", err)
+	}
+
+	req, err := h.newRequest(withSensitiveBodies(ctx), "GET", relPath, h.hubURL.Query(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.GetRegistration: %w", err)
+	}
+
+	b, err := h.exec(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.GetRegistration: %w", err)
+	}
+
+	registration, err := decodeRegistration(b)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.GetRegistration: %w", err)
+	}
+
+	return registration, nil
+}
+
+// UpdateRegistration creates or replaces registration of r.RegistrationId by r and returns it as stored by the hub,
+// ids reserved by CreateRegistrationID included. The update fails when r.ETag is set and the registration changed since it was read
+func (h *NotificationHub) UpdateRegistration(ctx context.Context, r RegistrationDescription) (*RegistrationDescription, error) {
+	if r.RegistrationId == "" {
+		return nil, fmt.Errorf("NotificationHub.UpdateRegistration: %w: registration id is required", ErrInvalidRegistration)
+	}
+
+	relPath, err := registrationPath(r.RegistrationId)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.UpdateRegistration: %w", err)
+	}
+
+	registration, err := h.putRegistration(ctx, "PUT", relPath, r)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.UpdateRegistration: %w", err)
+	}
+
+	return registration, nil
+}
+
+// DeleteRegistration deletes registration by id
+func (h *NotificationHub) DeleteRegistration(ctx context.Context, registrationID string) error {
+	if err := h.deleteRegistration(ctx, registrationID); err != nil {
+		return fmt.Errorf("NotificationHub.DeleteRegistration: %w", err)
+	}
+
+	return nil
+}
+
+// putRegistration writes registration r to relPath with method and returns the stored registration
+func (h *NotificationHub) putRegistration(ctx context.Context, method, relPath string, r RegistrationDescription) (*RegistrationDescription, error) {
+	if r.Format() == "" || !strings.HasSuffix(r.Type, registrationDescriptionSuffix) {
+		return nil, fmt.Errorf("%w: unknown registration description type '%s'", ErrInvalidRegistration, r.Type)
+	}
+	if r.PnsHandle() == "" {
+		return nil, fmt.Errorf("%w: %s without device handle", ErrInvalidRegistration, r.Type)
+	}
+
+	registration := newRegistrationXML(r)
+	registration.RegistrationId = ""
+
+	body, err := xml.Marshal(registrationEntryXML{
+		Content: registrationEntryContent{Type: "application/xml", Registration: registration},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := h.newRequest(withSensitiveBodies(ctx), method, relPath, h.hubURL.Query(), append([]byte(xml.Header), body...))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", atomEntryType)
+	if method == "PUT" && r.ETag != "" {
+		req.Header.Set("If-Match", r.ETag)
+	}
+
+	b, err := h.exec(req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRegistration(b)
+}
+
+// registrationPath returns escaped path of registration by id
+func registrationPath(registrationID string) (string, error) {
+	return resourcePath("registrations", registrationID)
+}
+
+// locationRegistrationID returns registration id of location, e.g. https://ns.servicebus.windows.net/hub/registrationIDs/id?api-version=2015-01
+func locationRegistrationID(location string) (string, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid location '%s': %w", location, err)
+	}
+
+	registrationID := path.Base(u.Path)
+	if dir := path.Base(path.Dir(u.Path)); !strings.EqualFold(dir, "registrationIDs") && !strings.EqualFold(dir, "registrations") {
+		return "", fmt.Errorf("no registration id in location '%s'", location)
+	}
+
+	return registrationID, nil
+}
+
+// decodeRegistration parses registration entry returned by the hub
+func decodeRegistration(b []byte) (*RegistrationDescription, error) {
+	dec := NewRegistrationDecoder(bytes.NewReader(b))
+	if !dec.Next() {
+		if err := dec.Err(); err != nil {
+			return nil, fmt.Errorf("failed to parse registration: %w", err)
+		}
+		return nil, errors.New("failed to parse registration: no registration description")
+	}
+
+	registration := dec.Registration()
+	return &registration, nil
+}
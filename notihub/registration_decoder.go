@@ -0,0 +1,200 @@
+package notihub
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const registrationDescriptionSuffix = "RegistrationDescription"
+
+type (
+	// RegistrationDescription is a registration stored in the hub
+	RegistrationDescription struct {
+		// Type is the description element name,
+		// e.g. AppleRegistrationDescription or GcmTemplateRegistrationDescription
+		Type                string
+		RegistrationId      string
+		ETag                string
+		ExpirationTime      time.Time
+		Tags                string
+		DeviceToken         string
+		GcmRegistrationId   string
+		FcmV1RegistrationId string
+		ChannelUri          string
+		AdmRegistrationId   string
+		BaiduUserId         string
+		BaiduChannelId      string
+		BodyTemplate        string
+		TemplateName        string
+	}
+
+	// RegistrationDecoder reads registration descriptions one at a time
+	// from registrations ATOM feed or registrations export file
+	RegistrationDecoder struct {
+		dec     *xml.Decoder
+		current RegistrationDescription
+		err     error
+	}
+
+	// registrationDescriptionXML is the wire representation of RegistrationDescription
+	registrationDescriptionXML struct {
+		XMLName             xml.Name
+		RegistrationId      string `xml:"RegistrationId"`
+		ETag                string `xml:"ETag"`
+		ExpirationTime      string `xml:"ExpirationTime"`
+		Tags                string `xml:"Tags"`
+		DeviceToken         string `xml:"DeviceToken"`
+		GcmRegistrationId   string `xml:"GcmRegistrationId"`
+		FcmV1RegistrationId string `xml:"FcmV1RegistrationId"`
+		ChannelUri          string `xml:"ChannelUri"`
+		AdmRegistrationId   string `xml:"AdmRegistrationId"`
+		BaiduUserId         string `xml:"BaiduUserId"`
+		BaiduChannelId      string `xml:"BaiduChannelId"`
+		BodyTemplate        string `xml:"BodyTemplate"`
+		TemplateName        string `xml:"TemplateName"`
+	}
+)
+
+// NewRegistrationDecoder returns decoder reading registrations from r
+func NewRegistrationDecoder(r io.Reader) *RegistrationDecoder {
+	return &RegistrationDecoder{dec: xml.NewDecoder(r)}
+}
+
+// Next advances the decoder to the next registration.
+// It returns false when input is exhausted or decoding fails
+func (d *RegistrationDecoder) Next() bool {
+	if d.err != nil {
+		return false
+	}
+
+	for {
+		token, err := d.dec.Token()
+		if err == io.EOF {
+			return false
+		}
+		if err != nil {
+			d.err = err
+			return false
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || !strings.HasSuffix(start.Name.Local, registrationDescriptionSuffix) {
+			continue
+		}
+
+		var raw registrationDescriptionXML
+		if err := d.dec.DecodeElement(&raw, &start); err != nil {
+			d.err = err
+			return false
+		}
+
+		if d.current, err = raw.description(); err != nil {
+			d.err = err
+			return false
+		}
+
+		return true
+	}
+}
+
+// Registration returns the registration read by the last Next call
+func (d *RegistrationDecoder) Registration() RegistrationDescription {
+	return d.current
+}
+
+// Err returns the first decoding error
+func (d *RegistrationDecoder) Err() error {
+	return d.err
+}
+
+// Format returns notification format of the registration
+func (r RegistrationDescription) Format() NotificationFormat {
+	switch {
+	case strings.HasPrefix(r.Type, "Apple"):
+		return AppleFormat
+	case strings.HasPrefix(r.Type, "Gcm"):
+		return AndroidFormat
+	case strings.HasPrefix(r.Type, "FcmV1"):
+		return FcmV1Format
+	case strings.HasPrefix(r.Type, "Adm"):
+		return KindleFormat
+	case strings.HasPrefix(r.Type, "Baidu"):
+		return BaiduFormat
+	case strings.HasPrefix(r.Type, "Windows"):
+		return WindowsFormat
+	case strings.HasPrefix(r.Type, "Mpns"):
+		return WindowsPhoneFormat
+	}
+
+	return NotificationFormat("")
+}
+
+// IsTemplate identifies whether the registration is a template registration
+func (r RegistrationDescription) IsTemplate() bool {
+	return strings.HasSuffix(r.Type, "Template"+registrationDescriptionSuffix)
+}
+
+// PnsHandle returns the platform specific device handle of the registration
+func (r RegistrationDescription) PnsHandle() string {
+	for _, handle := range []string{r.DeviceToken, r.GcmRegistrationId, r.FcmV1RegistrationId, r.ChannelUri, r.AdmRegistrationId, r.BaiduChannelId} {
+		if handle != "" {
+			return handle
+		}
+	}
+
+	return ""
+}
+
+// TagList returns registration tags
+func (r RegistrationDescription) TagList() []string {
+	if r.Tags == "" {
+		return nil
+	}
+
+	tags := strings.Split(r.Tags, ",")
+	for i := range tags {
+		tags[i] = strings.TrimSpace(tags[i])
+	}
+
+	return tags
+}
+
+func (raw registrationDescriptionXML) description() (RegistrationDescription, error) {
+	r := RegistrationDescription{
+		Type:                raw.XMLName.Local,
+		RegistrationId:      raw.RegistrationId,
+		ETag:                raw.ETag,
+		Tags:                raw.Tags,
+		DeviceToken:         raw.DeviceToken,
+		GcmRegistrationId:   raw.GcmRegistrationId,
+		FcmV1RegistrationId: raw.FcmV1RegistrationId,
+		ChannelUri:          raw.ChannelUri,
+		AdmRegistrationId:   raw.AdmRegistrationId,
+		BaiduUserId:         raw.BaiduUserId,
+		BaiduChannelId:      raw.BaiduChannelId,
+		BodyTemplate:        raw.BodyTemplate,
+		TemplateName:        raw.TemplateName,
+	}
+
+	if raw.ExpirationTime != "" {
+		expTm, err := parseHubTime(raw.ExpirationTime)
+		if err != nil {
+			return r, fmt.Errorf("registration '%s': %w", raw.RegistrationId, err)
+		}
+		r.ExpirationTime = expTm
+	}
+
+	return r, nil
+}
+
+// parseHubTime parses time returned by the hub, with or without time zone
+func parseHubTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+
+	return time.Parse("2006-01-02T15:04:05.999", value)
+}
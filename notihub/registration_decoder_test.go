@@ -0,0 +1,133 @@
+package notihub
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testRegistrationsFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+    <title type="text">Registrations</title>
+    <id>https://testhub-ns.servicebus.windows.net/hub/registrations/?api-version=2015-01</id>
+    <updated>2020-01-01T12:00:00Z</updated>
+    <entry xmlns="http://www.w3.org/2005/Atom">
+        <id>https://testhub-ns.servicebus.windows.net/hub/registrations/reg-1?api-version=2015-01</id>
+        <title type="text">reg-1</title>
+        <updated>2020-01-01T12:00:00Z</updated>
+        <content type="application/xml">
+            <AppleRegistrationDescription xmlns:i="http://www.w3.org/2001/XMLSchema-instance" xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect">
+                <ETag>1</ETag>
+                <ExpirationTime>2020-04-01T12:00:00.123Z</ExpirationTime>
+                <RegistrationId>reg-1</RegistrationId>
+                <Tags>tag1, tag2</Tags>
+                <DeviceToken>device-token</DeviceToken>
+            </AppleRegistrationDescription>
+        </content>
+    </entry>
+    <entry xmlns="http://www.w3.org/2005/Atom">
+        <id>https://testhub-ns.servicebus.windows.net/hub/registrations/reg-2?api-version=2015-01</id>
+        <title type="text">reg-2</title>
+        <updated>2020-01-01T12:00:00Z</updated>
+        <content type="application/xml">
+            <GcmTemplateRegistrationDescription xmlns:i="http://www.w3.org/2001/XMLSchema-instance" xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect">
+                <ETag>2</ETag>
+                <ExpirationTime>2020-04-01T12:00:00</ExpirationTime>
+                <RegistrationId>reg-2</RegistrationId>
+                <GcmRegistrationId>gcm-id</GcmRegistrationId>
+                <BodyTemplate><![CDATA[{"data":{"msg":"$(msg)"}}]]></BodyTemplate>
+                <TemplateName>v1</TemplateName>
+            </GcmTemplateRegistrationDescription>
+        </content>
+    </entry>
+</feed>`
+
+func Test_RegistrationDecoderFeed(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	dec := NewRegistrationDecoder(strings.NewReader(testRegistrationsFeed))
+
+	var registrations []RegistrationDescription
+	for dec.Next() {
+		registrations = append(registrations, dec.Registration())
+	}
+
+	if err := dec.Err(); err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	expected := []RegistrationDescription{
+		{
+			Type:           "AppleRegistrationDescription",
+			RegistrationId: "reg-1",
+			ETag:           "1",
+			ExpirationTime: time.Date(2020, 4, 1, 12, 0, 0, 123000000, time.UTC),
+			Tags:           "tag1, tag2",
+			DeviceToken:    "device-token",
+		},
+		{
+			Type:              "GcmTemplateRegistrationDescription",
+			RegistrationId:    "reg-2",
+			ETag:              "2",
+			ExpirationTime:    time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC),
+			GcmRegistrationId: "gcm-id",
+			BodyTemplate:      `{"data":{"msg":"$(msg)"}}`,
+			TemplateName:      "v1",
+		},
+	}
+
+	if !reflect.DeepEqual(registrations, expected) {
+		t.Fatalf(errfmt, "registrations", expected, registrations)
+	}
+
+	if registrations[0].Format() != AppleFormat || registrations[0].IsTemplate() {
+		t.Errorf(errfmt, "first registration format", AppleFormat, registrations[0].Format())
+	}
+
+	if registrations[1].Format() != AndroidFormat || !registrations[1].IsTemplate() {
+		t.Errorf(errfmt, "second registration format", AndroidFormat, registrations[1].Format())
+	}
+
+	if tags := registrations[0].TagList(); !reflect.DeepEqual(tags, []string{"tag1", "tag2"}) {
+		t.Errorf(errfmt, "tags", []string{"tag1", "tag2"}, tags)
+	}
+
+	if handle := registrations[1].PnsHandle(); handle != "gcm-id" {
+		t.Errorf(errfmt, "pns handle", "gcm-id", handle)
+	}
+}
+
+func Test_RegistrationDecoderExportFile(t *testing.T) {
+	export := `<WindowsRegistrationDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><RegistrationId>reg-1</RegistrationId><ChannelUri>https://channel</ChannelUri></WindowsRegistrationDescription>
+<MpnsRegistrationDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><RegistrationId>reg-2</RegistrationId><ChannelUri>https://mpns</ChannelUri></MpnsRegistrationDescription>
+`
+
+	dec := NewRegistrationDecoder(strings.NewReader(export))
+
+	var formats []NotificationFormat
+	for dec.Next() {
+		formats = append(formats, dec.Registration().Format())
+	}
+
+	if err := dec.Err(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := []NotificationFormat{WindowsFormat, WindowsPhoneFormat}
+	if !reflect.DeepEqual(formats, expected) {
+		t.Errorf("Expected formats: %v, got: %v", expected, formats)
+	}
+}
+
+func Test_RegistrationDecoderMalformed(t *testing.T) {
+	dec := NewRegistrationDecoder(strings.NewReader(`<feed><entry><AppleRegistrationDescription><RegistrationId>`))
+
+	if dec.Next() {
+		t.Error("Expected Next to return false on malformed input")
+	}
+
+	if dec.Err() == nil {
+		t.Error("Expected error, got nil")
+	}
+}
@@ -0,0 +1,221 @@
+package notihub
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testRegistrationEntryTemplate = `<entry xmlns="http://www.w3.org/2005/Atom">
+    <content type="application/xml">
+        <%s xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect" xmlns:i="http://www.w3.org/2001/XMLSchema-instance">
+            <ExpirationTime>9999-12-31T23:59:59.9999999Z</ExpirationTime>
+            <RegistrationId>reg-1</RegistrationId>
+            <ETag>%d</ETag>
+            <Tags>%s</Tags>
+            <DeviceToken>%s</DeviceToken>
+        </%s>
+    </content>
+</entry>`
+
+func Test_NotificationHubRegistrationCRUD(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var (
+		stored *registrationImportXML
+		etag   int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /hub/registrations", "PUT /hub/registrations/reg-1":
+			if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && ifMatch != fmt.Sprint(etag) {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+
+			var entry registrationEntryXML
+			b, _ := ioutil.ReadAll(r.Body)
+			if err := xml.Unmarshal(b, &entry); err != nil || r.Header.Get("Content-Type") != atomEntryType {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			stored, etag = &entry.Content.Registration, etag+1
+		case "GET /hub/registrations/reg-1":
+		case "DELETE /hub/registrations/reg-1":
+			stored = nil
+			return
+		default:
+			t.Errorf(errfmt, "request", "registration request", r.Method+" "+r.URL.Path)
+		}
+
+		if stored == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, testRegistrationEntryTemplate, stored.XMLName.Local, etag, stored.Tags, stored.DeviceToken, stored.XMLName.Local)
+	}))
+	defer server.Close()
+
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client())
+	ctx := context.Background()
+
+	created, err := hub.CreateRegistration(ctx, NewAppleRegistration("token", "news", "sports"))
+	if err != nil {
+		t.Fatalf(errfmt, "create error", nil, err)
+	}
+	if created.RegistrationId != "reg-1" || created.ETag != "1" || created.Tags != "news,sports" || created.Format() != AppleFormat {
+		t.Errorf(errfmt, "created registration", "reg-1 apple registration", *created)
+	}
+
+	template := created.AsTemplate("alert", `{"aps":{"alert":"$(message)"}}`)
+	template.Tags = "news"
+	updated, err := hub.UpdateRegistration(ctx, template)
+	if err != nil {
+		t.Fatalf(errfmt, "update error", nil, err)
+	}
+	if updated.Type != "AppleTemplateRegistrationDescription" || updated.ETag != "2" || updated.Tags != "news" {
+		t.Errorf(errfmt, "updated registration", "apple template registration", *updated)
+	}
+
+	if _, err := hub.UpdateRegistration(ctx, template); !isResponseStatus(err, http.StatusPreconditionFailed) {
+		t.Errorf(errfmt, "stale etag error", http.StatusPreconditionFailed, err)
+	}
+
+	read, err := hub.GetRegistration(ctx, "reg-1")
+	if err != nil || read.ETag != "2" || read.PnsHandle() != "token" {
+		t.Errorf(errfmt, "read registration", "etag 2 of token", fmt.Sprint(read, err))
+	}
+
+	if err := hub.DeleteRegistration(ctx, "reg-1"); err != nil {
+		t.Errorf(errfmt, "delete error", nil, err)
+	}
+	if _, err := hub.GetRegistration(ctx, "reg-1"); !isNotFoundError(err) {
+		t.Errorf(errfmt, "error after delete", "not found", err)
+	}
+}
+
+func Test_NotificationHubInvalidRegistration(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	hub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		t.Errorf(errfmt, "request", nil, req.URL)
+		return nil, nil
+	})
+
+	tests := []struct {
+		name string
+		do   func() error
+	}{
+		{"unknown type", func() error {
+			_, err := hub.CreateRegistration(context.Background(), RegistrationDescription{Type: "Unknown", DeviceToken: "token"})
+			return err
+		}},
+		{"no handle", func() error {
+			_, err := hub.CreateRegistration(context.Background(), NewBaiduRegistration("user", ""))
+			return err
+		}},
+		{"no id", func() error {
+			_, err := hub.UpdateRegistration(context.Background(), NewWindowsRegistration("https://wns"))
+			return err
+		}},
+	}
+
+	for _, test := range tests {
+		if err := test.do(); !errors.Is(err, ErrInvalidRegistration) {
+			t.Errorf(errfmt, test.name+" error", ErrInvalidRegistration, err)
+		}
+	}
+}
+
+func Test_NotificationHubRegistrationIDs(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	hub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		t.Errorf(errfmt, "request", nil, req.URL)
+		return nil, nil
+	})
+
+	for _, id := range []string{"", "..", "../installations/a", "a/b"} {
+		if _, err := hub.GetRegistration(context.Background(), id); !errors.Is(err, ErrInvalidResourceID) {
+			t.Errorf(errfmt, "get error of id '"+id+"'", ErrInvalidResourceID, err)
+		}
+		if err := hub.DeleteRegistration(context.Background(), id); !errors.Is(err, ErrInvalidResourceID) {
+			t.Errorf(errfmt, "delete error of id '"+id+"'", ErrInvalidResourceID, err)
+		}
+		if id == "" {
+			continue
+		}
+
+		r := NewAppleRegistration("token")
+		r.RegistrationId = id
+		if _, err := hub.UpdateRegistration(context.Background(), r); !errors.Is(err, ErrInvalidResourceID) {
+			t.Errorf(errfmt, "update error of id '"+id+"'", ErrInvalidResourceID, err)
+		}
+	}
+}
+
+func Test_NotificationHubCreateRegistrationID(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("If-Match"))
+		switch r.Method {
+		case "POST":
+			w.Header().Set("Location", "https://ns.servicebus.windows.net/hub/registrationIDs/7686-reserved?api-version=2015-01")
+			w.WriteHeader(http.StatusCreated)
+		case "PUT":
+			fmt.Fprintf(w, testRegistrationEntryTemplate, "AppleRegistrationDescription", 1, "news", "token", "AppleRegistrationDescription")
+		}
+	}))
+	defer server.Close()
+
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client())
+
+	registrationID, err := hub.CreateRegistrationID(context.Background())
+	if err != nil || registrationID != "7686-reserved" {
+		t.Fatalf(errfmt, "registration id", "7686-reserved", fmt.Sprint(registrationID, err))
+	}
+
+	registration := NewAppleRegistration("token", "news")
+	registration.RegistrationId = registrationID
+	if _, err := hub.UpdateRegistration(context.Background(), registration); err != nil {
+		t.Errorf(errfmt, "create error", nil, err)
+	}
+
+	expected := []string{"POST /hub/registrationIDs ", "PUT /hub/registrations/7686-reserved "}
+	if fmt.Sprint(requests) != fmt.Sprint(expected) {
+		t.Errorf(errfmt, "requests", expected, requests)
+	}
+}
+
+func Test_LocationRegistrationID(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	tests := []struct {
+		location string
+		expected string
+	}{
+		{"https://ns.servicebus.windows.net/hub/registrationIDs/id-1?api-version=2015-01", "id-1"},
+		{"https://ns.servicebus.windows.net/hub/registrations/id-2", "id-2"},
+		{"", ""},
+		{"https://ns.servicebus.windows.net/hub/messages/id-3", ""},
+	}
+
+	for _, test := range tests {
+		registrationID, err := locationRegistrationID(test.location)
+		if registrationID != test.expected || (err == nil) != (test.expected != "") {
+			t.Errorf(errfmt, "registration id of "+test.location, test.expected, fmt.Sprint(registrationID, err))
+		}
+	}
+}
+
+func isResponseStatus(err error, status int) bool {
+	var resErr *ResponseError
+	return errors.As(err, &resErr) && resErr.StatusCode == status
+}
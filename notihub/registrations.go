@@ -0,0 +1,156 @@
+package notihub
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	continuationTokenHeader = "X-MS-ContinuationToken"
+	continuationTokenParam  = "ContinuationToken"
+	registrationsPageSize   = 100
+)
+
+// registrationHandleFields are the registration fields holding device handles of formats
+var registrationHandleFields = map[NotificationFormat]string{
+	AppleFormat:        "DeviceToken",
+	AndroidFormat:      "GcmRegistrationId",
+	FcmV1Format:        "FcmV1RegistrationId",
+	KindleFormat:       "AdmRegistrationId",
+	BaiduFormat:        "BaiduChannelId",
+	WindowsFormat:      "ChannelUri",
+	WindowsPhoneFormat: "ChannelUri",
+}
+
+// ListRegistrationsOptions selects a page of registrations
+type ListRegistrationsOptions struct {
+	// Filter is an OData filter of registrations, e.g. "ChannelUri eq 'https://wns'"
+	Filter string
+	// Top limits the number of registrations in the page, the hub default applies when not set
+	Top int
+	// ContinuationToken is the token returned with the previous page, empty for the first page
+	ContinuationToken string
+}
+
+// ListRegistrations reads a page of registrations selected by opts and returns it
+// with the continuation token of the next page. The token is empty on the last page
+func (h *NotificationHub) ListRegistrations(ctx context.Context, opts ListRegistrationsOptions) ([]RegistrationDescription, string, error) {
+	registrations, next, err := h.listRegistrations(ctx, "", opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("NotificationHub.ListRegistrations: %w", err)
+	}
+
+	return registrations, next, nil
+}
+
+// ListRegistrationsByTag reads a page of registrations with tag, e.g. to audit devices subscribed to it,
+// and returns it with the continuation token of the next page. The hub does not filter tag listings,
+// opts.Filter must be empty
+func (h *NotificationHub) ListRegistrationsByTag(ctx context.Context, tag string, opts ListRegistrationsOptions) ([]RegistrationDescription, string, error) {
+	if tag == "" {
+		return nil, "", errors.New("NotificationHub.ListRegistrationsByTag: tag is required")
+	}
+	if opts.Filter != "" {
+		return nil, "", errors.New("NotificationHub.ListRegistrationsByTag: filter is not supported by tag listings")
+	}
+
+	registrations, next, err := h.listRegistrations(ctx, tag, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("NotificationHub.ListRegistrationsByTag: %w", err)
+	}
+
+	return registrations, next, nil
+}
+
+// ListRegistrationsByChannel reads all registrations of device handle of format, e.g. APNs device token
+// or WNS channel uri, to find duplicate registrations of the same device
+func (h *NotificationHub) ListRegistrationsByChannel(ctx context.Context, format NotificationFormat, pnsHandle string) ([]RegistrationDescription, error) {
+	if pnsHandle == "" {
+		return nil, errors.New("NotificationHub.ListRegistrationsByChannel: device handle is required")
+	}
+	field, ok := registrationHandleFields[format]
+	if !ok {
+		return nil, fmt.Errorf("NotificationHub.ListRegistrationsByChannel: %w: no device handle of format '%s'", ErrInvalidRegistration, format)
+	}
+
+	filter := field + " eq '" + strings.ReplaceAll(pnsHandle, "'", "''") + "'"
+	registrations, err := NewPager(func(ctx context.Context, continuation string) ([]RegistrationDescription, string, error) {
+		return h.listRegistrations(ctx, "", ListRegistrationsOptions{Filter: filter, Top: registrationsPageSize, ContinuationToken: continuation})
+	}).All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.ListRegistrationsByChannel: %w", err)
+	}
+
+	return registrations, nil
+}
+
+// listRegistrations reads a page of registrations selected by opts, all or those with tag when set.
+// The returned continuation token is empty on the last page
+func (h *NotificationHub) listRegistrations(ctx context.Context, tag string, opts ListRegistrationsOptions) ([]RegistrationDescription, string, error) {
+	relPath := "registrations"
+	if tag != "" {
+		relPath = path.Join("tags", url.PathEscape(tag), "registrations")
+	}
+
+	query := h.hubURL.Query()
+	if opts.Filter != "" {
+		query.Set("$filter", opts.Filter)
+	}
+	if opts.Top > 0 {
+		query.Set("$top", strconv.Itoa(opts.Top))
+	}
+	if opts.ContinuationToken != "" {
+		query.Set(continuationTokenParam, opts.ContinuationToken)
+	}
+
+	req, err := h.newRequest(withSensitiveBodies(ctx), "GET", relPath, query, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	o := &sendOptions{}
+	b, err := h.exec(req, o)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var registrations []RegistrationDescription
+	dec := NewRegistrationDecoder(bytes.NewReader(b))
+	for dec.Next() {
+		registrations = append(registrations, dec.Registration())
+	}
+	if err := dec.Err(); err != nil {
+		return nil, "", err
+	}
+
+	return registrations, o.header.Get(continuationTokenHeader), nil
+}
+
+// registrationPager returns pager of registrations, all or those with tag when set
+func (h *NotificationHub) registrationPager(tag string) *Pager[RegistrationDescription] {
+	return NewPager(func(ctx context.Context, continuation string) ([]RegistrationDescription, string, error) {
+		return h.listRegistrations(ctx, tag, ListRegistrationsOptions{Top: registrationsPageSize, ContinuationToken: continuation})
+	})
+}
+
+// countRegistrations pages through registrations, all or those with tag when set, and counts them.
+// Paging stops once limit is reached when it is positive
+func (h *NotificationHub) countRegistrations(ctx context.Context, tag string, limit int) (int, error) {
+	count := 0
+	pager := h.registrationPager(tag)
+	for pager.More() && (limit <= 0 || count < limit) {
+		registrations, err := pager.NextPage(ctx)
+		if err != nil {
+			return count, err
+		}
+		count += len(registrations)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,271 @@
+package notihub
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// registrationFeedServer serves registrations feeds paged with continuation tokens
+// and accepts notification sends
+type registrationFeedServer struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	registrations map[string][]string // tag to registration ids, "" for all
+	pages         int
+	sends         int
+	filters       []string
+}
+
+func newRegistrationFeedServer(registrations map[string][]string) *registrationFeedServer {
+	s := &registrationFeedServer{registrations: registrations}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+func (s *registrationFeedServer) hub(opts ...HubOption) *NotificationHub {
+	return NewNotificationHub("Endpoint="+s.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", s.Client(), opts...)
+}
+
+func (s *registrationFeedServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/hub/messages" {
+		s.sends++
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	tag := ""
+	if strings.HasPrefix(r.URL.Path, "/hub/tags/") {
+		tag = strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/hub/tags/"), "/registrations")
+	} else if r.URL.Path != "/hub/registrations" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	s.pages++
+	s.filters = append(s.filters, r.URL.Query().Get("$filter"))
+
+	ids := s.registrations[tag]
+	top, _ := strconv.Atoi(r.URL.Query().Get("$top"))
+	start, _ := strconv.Atoi(r.URL.Query().Get(continuationTokenParam))
+	end := len(ids)
+	if top > 0 && start+top < end {
+		end = start + top
+		w.Header().Set(continuationTokenHeader, strconv.Itoa(end))
+	}
+
+	fmt.Fprint(w, `<feed xmlns="http://www.w3.org/2005/Atom">`)
+	for _, id := range ids[start:end] {
+		fmt.Fprintf(w, `<entry><content type="application/xml"><GcmRegistrationDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><Tags>%s</Tags><RegistrationId>%s</RegistrationId><GcmRegistrationId>handle-%s</GcmRegistrationId></GcmRegistrationDescription></content></entry>`, tag, id, id)
+	}
+	fmt.Fprint(w, `</feed>`)
+}
+
+func registrationIDs(count int) []string {
+	ids := make([]string, count)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i)
+	}
+
+	return ids
+}
+
+func Test_NotificationHubListRegistrations(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newRegistrationFeedServer(map[string][]string{"news": registrationIDs(3)})
+	defer server.Close()
+	nhub := server.hub()
+
+	registrations, next, err := nhub.listRegistrations(context.Background(), "news", ListRegistrationsOptions{Top: 2})
+	if err != nil {
+		t.Fatalf(errfmt, "list error", nil, err)
+	}
+	if len(registrations) != 2 || next != "2" || registrations[1].RegistrationId != "1" || registrations[1].PnsHandle() != "handle-1" {
+		t.Errorf(errfmt, "first page", "2 registrations and continuation", registrations)
+	}
+
+	registrations, next, err = nhub.listRegistrations(context.Background(), "news", ListRegistrationsOptions{Top: 2, ContinuationToken: next})
+	if err != nil || len(registrations) != 1 || next != "" {
+		t.Errorf(errfmt, "last page", "1 registration without continuation", registrations)
+	}
+}
+
+func Test_NotificationHubListRegistrationsFiltered(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newRegistrationFeedServer(map[string][]string{"": registrationIDs(5)})
+	defer server.Close()
+	nhub := server.hub()
+
+	var (
+		ids  []string
+		opts = ListRegistrationsOptions{Filter: "GcmRegistrationId ne ''", Top: 2}
+	)
+	for {
+		registrations, next, err := nhub.ListRegistrations(context.Background(), opts)
+		if err != nil {
+			t.Fatalf(errfmt, "list error", nil, err)
+		}
+		for _, r := range registrations {
+			ids = append(ids, r.RegistrationId)
+		}
+		if next == "" {
+			break
+		}
+		opts.ContinuationToken = next
+	}
+
+	if strings.Join(ids, ",") != "0,1,2,3,4" {
+		t.Errorf(errfmt, "registrations", "0,1,2,3,4", ids)
+	}
+	if len(server.filters) != 3 || server.filters[2] != "GcmRegistrationId ne ''" {
+		t.Errorf(errfmt, "filters", "filter on every page", server.filters)
+	}
+}
+
+func Test_NotificationHubListRegistrationsByTag(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newRegistrationFeedServer(map[string][]string{"": registrationIDs(5), "news": registrationIDs(3)})
+	defer server.Close()
+	nhub := server.hub()
+
+	registrations, next, err := nhub.ListRegistrationsByTag(context.Background(), "news", ListRegistrationsOptions{Top: 2})
+	if err != nil || len(registrations) != 2 || next != "2" || registrations[0].Tags != "news" {
+		t.Errorf(errfmt, "first page", "2 news registrations and continuation", fmt.Sprint(registrations, err))
+	}
+
+	registrations, next, err = nhub.ListRegistrationsByTag(context.Background(), "news", ListRegistrationsOptions{Top: 2, ContinuationToken: next})
+	if err != nil || len(registrations) != 1 || next != "" {
+		t.Errorf(errfmt, "last page", "1 registration without continuation", fmt.Sprint(registrations, err))
+	}
+
+	for _, invalid := range []struct {
+		tag  string
+		opts ListRegistrationsOptions
+	}{
+		{"", ListRegistrationsOptions{}},
+		{"news", ListRegistrationsOptions{Filter: "Tags eq 'news'"}},
+	} {
+		if _, _, err := nhub.ListRegistrationsByTag(context.Background(), invalid.tag, invalid.opts); err == nil {
+			t.Errorf(errfmt, "invalid listing error", "error", err)
+		}
+	}
+	if server.pages != 2 {
+		t.Errorf(errfmt, "pages read", 2, server.pages)
+	}
+}
+
+func Test_NotificationHubListRegistrationsByChannel(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newRegistrationFeedServer(map[string][]string{"": registrationIDs(150)})
+	defer server.Close()
+	nhub := server.hub()
+
+	registrations, err := nhub.ListRegistrationsByChannel(context.Background(), WindowsFormat, "it's")
+	if err != nil || len(registrations) != 150 {
+		t.Errorf(errfmt, "registrations", 150, fmt.Sprint(len(registrations), err))
+	}
+	if len(server.filters) != 2 || server.filters[1] != "ChannelUri eq 'it''s'" {
+		t.Errorf(errfmt, "filters", "escaped channel filter on every page", server.filters)
+	}
+
+	if _, err := nhub.ListRegistrationsByChannel(context.Background(), WindowsFormat, ""); err == nil {
+		t.Errorf(errfmt, "empty handle error", "error", err)
+	}
+	if _, err := nhub.ListRegistrationsByChannel(context.Background(), Template, "handle"); !errors.Is(err, ErrInvalidRegistration) {
+		t.Errorf(errfmt, "format without handle error", ErrInvalidRegistration, err)
+	}
+}
+
+func Test_NotificationHubListRegistrationsByChannelFilter(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	registrations := []RegistrationDescription{
+		NewAppleRegistration("handle"),
+		NewGcmRegistration("handle"),
+		NewFcmV1Registration("handle"),
+		NewFcmV1Registration("other"),
+		NewWindowsRegistration("handle"),
+	}
+	for i := range registrations {
+		registrations[i].RegistrationId = strconv.Itoa(i)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter := strings.SplitN(r.URL.Query().Get("$filter"), " eq ", 2)
+		if r.URL.Path != "/hub/registrations" || len(filter) != 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		value := strings.ReplaceAll(strings.Trim(filter[1], "'"), "''", "'")
+
+		fmt.Fprint(w, `<feed xmlns="http://www.w3.org/2005/Atom">`)
+		for _, registration := range registrations {
+			b, _ := xml.Marshal(newRegistrationXML(registration))
+			if !strings.Contains(string(b), "<"+filter[0]+">"+value+"</"+filter[0]+">") {
+				continue
+			}
+			fmt.Fprintf(w, `<entry><content type="application/xml">%s</content></entry>`, b)
+		}
+		fmt.Fprint(w, `</feed>`)
+	}))
+	defer server.Close()
+	nhub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client())
+
+	for _, test := range []struct {
+		format   NotificationFormat
+		expected string
+	}{
+		{AppleFormat, "0"},
+		{AndroidFormat, "1"},
+		{FcmV1Format, "2"},
+		{WindowsFormat, "4"},
+		{KindleFormat, ""},
+	} {
+		found, err := nhub.ListRegistrationsByChannel(context.Background(), test.format, "handle")
+		ids := ""
+		for _, registration := range found {
+			ids += registration.RegistrationId
+		}
+		if err != nil || ids != test.expected {
+			t.Errorf(errfmt, string(test.format)+" registrations", test.expected, fmt.Sprint(ids, err))
+		}
+	}
+}
+
+func Test_NotificationHubCountRegistrations(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newRegistrationFeedServer(map[string][]string{"": registrationIDs(250), "news": registrationIDs(20)})
+	defer server.Close()
+	nhub := server.hub()
+
+	if count, err := nhub.countRegistrations(context.Background(), "", 0); err != nil || count != 250 {
+		t.Errorf(errfmt, "all registrations", 250, count)
+	}
+	if server.pages != 3 {
+		t.Errorf(errfmt, "pages read", 3, server.pages)
+	}
+
+	if count, err := nhub.countRegistrations(context.Background(), "news", 0); err != nil || count != 20 {
+		t.Errorf(errfmt, "tag registrations", 20, count)
+	}
+
+	if count, err := nhub.countRegistrations(context.Background(), "", 150); err != nil || count != 200 || server.pages != 6 {
+		t.Errorf(errfmt, "registrations counted until limit", 200, count)
+	}
+}
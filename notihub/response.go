@@ -0,0 +1,114 @@
+package notihub
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	// maxErrorTextLength caps the response text included in ResponseError messages
+	maxErrorTextLength = 1024
+	// maxDecodedBodySize caps decompressed response bodies, guarding against gzip bombs
+	maxDecodedBodySize = 16 << 20
+)
+
+// gzipMagic starts gzip streams
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// decodeResponseBody decompresses gzip encoded response bodies, which intermediaries
+// send even though the client did not ask for compression. Bodies which fail to
+// decompress or exceed maxDecodedBodySize once decompressed are returned as is
+func decodeResponseBody(header http.Header, b []byte) []byte {
+	if !strings.EqualFold(header.Get("Content-Encoding"), "gzip") && !bytes.HasPrefix(b, gzipMagic) {
+		return b
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return b
+	}
+	defer r.Close()
+
+	decoded, err := ioutil.ReadAll(io.LimitReader(r, maxDecodedBodySize+1))
+	if err != nil || len(decoded) > maxDecodedBodySize {
+		return b
+	}
+
+	return decoded
+}
+
+// responseText returns readable text of response body b: decoded by the Content-Type charset
+// or byte order mark, with invalid characters replaced, control characters dropped and long text truncated
+func responseText(header http.Header, b []byte) string {
+	text := decodeCharset(header, b)
+
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(text, "�"))
+
+	if len(text) > maxErrorTextLength {
+		cut := maxErrorTextLength
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut] + "..."
+	}
+
+	return text
+}
+
+// decodeCharset decodes b to UTF-8, supporting UTF-16 and Latin-1 charsets
+func decodeCharset(header http.Header, b []byte) string {
+	charset := ""
+	if header != nil {
+		if _, params, err := mime.ParseMediaType(header.Get("Content-Type")); err == nil {
+			charset = strings.ToLower(params["charset"])
+		}
+	}
+
+	switch {
+	case bytes.HasPrefix(b, []byte{0xef, 0xbb, 0xbf}):
+		return string(b[3:])
+	case bytes.HasPrefix(b, []byte{0xff, 0xfe}):
+		return decodeUTF16(b[2:], false)
+	case bytes.HasPrefix(b, []byte{0xfe, 0xff}):
+		return decodeUTF16(b[2:], true)
+	case charset == "utf-16le" || charset == "utf-16":
+		return decodeUTF16(b, false)
+	case charset == "utf-16be":
+		return decodeUTF16(b, true)
+	case charset == "iso-8859-1" || charset == "latin1" || charset == "windows-1252" || charset == "us-ascii" && !utf8.Valid(b):
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+		return string(runes)
+	}
+
+	return string(b)
+}
+
+// decodeUTF16 decodes UTF-16 text of the given byte order
+func decodeUTF16(b []byte, bigEndian bool) string {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+		} else {
+			units[i] = uint16(b[2*i+1])<<8 | uint16(b[2*i])
+		}
+	}
+
+	return string(utf16.Decode(units))
+}
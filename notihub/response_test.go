@@ -0,0 +1,88 @@
+package notihub
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func gzipBytes(b []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(b)
+	w.Close()
+	return buf.Bytes()
+}
+
+func Test_ResponseText(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	testPatterns := []struct {
+		name        string
+		contentType string
+		body        []byte
+		expected    string
+	}{
+		{"utf-8", "text/plain; charset=utf-8", []byte("Zugriff verweigert: ü"), "Zugriff verweigert: ü"},
+		{"latin-1", "text/html; charset=ISO-8859-1", []byte("Acc\xe8s refus\xe9"), "Accès refusé"},
+		{"utf-16 bom", "text/plain", []byte{0xff, 0xfe, 'o', 0, 'k', 0}, "ok"},
+		{"utf-16be", "text/plain; charset=utf-16be", []byte{0, 'o', 0, 'k'}, "ok"},
+		{"binary", "application/octet-stream", []byte("a\x00\x01\xffb\nc"), "a�b\nc"},
+		{"long", "", bytes.Repeat([]byte("é"), maxErrorTextLength), strings.Repeat("é", maxErrorTextLength/2) + "..."},
+	}
+
+	for _, testData := range testPatterns {
+		header := http.Header{"Content-Type": {testData.contentType}}
+		if text := responseText(header, testData.body); text != testData.expected {
+			t.Errorf(errfmt, testData.name+" text", testData.expected, text)
+		}
+	}
+}
+
+func Test_HandleResponseDecoding(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hub/gzip-error":
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Set("Content-Type", "text/plain; charset=iso-8859-1")
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write(gzipBytes([]byte("Passerelle d\xe9faillante")))
+		case "/hub/gzip-unlabeled":
+			w.Write(gzipBytes([]byte("<ok/>")))
+		}
+	}))
+	defer server.Close()
+
+	nhub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client())
+
+	_, _, err := nhub.Do(context.Background(), "GET", "gzip-error", nil, nil)
+	var resErr *ResponseError
+	if !errors.As(err, &resErr) || string(resErr.Body) != "Passerelle d\xe9faillante" || !strings.HasSuffix(err.Error(), "response: Passerelle défaillante") {
+		t.Errorf(errfmt, "gzip error response", "readable text", err)
+	}
+
+	if b, _, err := nhub.Do(context.Background(), "GET", "gzip-unlabeled", nil, nil); err != nil || string(b) != "<ok/>" {
+		t.Errorf(errfmt, "unlabeled gzip body", "<ok/>", string(b))
+	}
+
+	corrupt := append(gzipMagic[:2:2], "<ok/>"...)
+	if b := decodeResponseBody(http.Header{"Content-Encoding": {"gzip"}}, corrupt); !bytes.Equal(b, corrupt) {
+		t.Errorf(errfmt, "corrupt gzip body", corrupt, b)
+	}
+
+	bomb := gzipBytes(make([]byte, maxDecodedBodySize+1))
+	if b := decodeResponseBody(http.Header{"Content-Encoding": {"gzip"}}, bomb); !bytes.Equal(b, bomb) {
+		t.Errorf(errfmt, "oversized gzip body length", len(bomb), len(b))
+	}
+	limit := gzipBytes(make([]byte, maxDecodedBodySize))
+	if b := decodeResponseBody(http.Header{"Content-Encoding": {"gzip"}}, limit); len(b) != maxDecodedBodySize {
+		t.Errorf(errfmt, "gzip body length at the limit", maxDecodedBodySize, len(b))
+	}
+}
@@ -0,0 +1,172 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrDeadlineWouldExceed is matched by *DeadlineError
+var ErrDeadlineWouldExceed = errors.New("retry would exceed deadline")
+
+type (
+	// RetryPolicy configures retries of failed hub requests.
+	// Transport errors, throttling and server errors are retried, but non-idempotent
+	// requests like notification sends only when the hub certainly did not process them.
+	// Retries stop early when the backoff would outlast the request context deadline
+	RetryPolicy struct {
+		// MaxAttempts is the total number of attempts including the first one
+		MaxAttempts int
+		// Backoff is the delay before the first retry, doubled for every next one
+		Backoff time.Duration
+		// MaxBackoff caps the delay between attempts when positive
+		MaxBackoff time.Duration
+	}
+
+	// DeadlineError is returned instead of waiting for a retry
+	// the request context deadline would not leave time for
+	DeadlineError struct {
+		// Attempts is the number of attempts made
+		Attempts int
+		// Backoff is the delay before the next attempt
+		Backoff time.Duration
+		// Remaining is the time left until the deadline
+		Remaining time.Duration
+		// Err is the error of the last attempt
+		Err error
+	}
+)
+
+// WithRetry sets the hub retry policy. By default requests are not retried
+func WithRetry(policy RetryPolicy) HubOption {
+	return func(h *NotificationHub) {
+		h.retry = policy
+	}
+}
+
+// WithCallRetry overrides the hub retry policy for a single send
+func WithCallRetry(policy RetryPolicy) SendOption {
+	return func(o *sendOptions) {
+		o.retry = &policy
+	}
+}
+
+// retryPolicy returns the policy of the send, the hub policy unless overridden
+func (o *sendOptions) retryPolicy(hubPolicy RetryPolicy) RetryPolicy {
+	if o == nil || o.retry == nil {
+		return hubPolicy
+	}
+
+	return *o.retry
+}
+
+func (p RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+
+	return p.MaxAttempts
+}
+
+// backoff returns the delay after failures failed attempts.
+// Retry-After header of the failed response takes precedence
+func (p RetryPolicy) backoff(failures int, err error) time.Duration {
+	var resErr *ResponseError
+	if errors.As(err, &resErr) {
+		if seconds, perr := strconv.Atoi(resErr.Header.Get("Retry-After")); perr == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+
+	delay := p.Backoff
+	for i := 1; i < failures; i++ {
+		delay *= 2
+		if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+			break
+		}
+	}
+
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+
+	return delay
+}
+
+// deadlineError returns *DeadlineError if waiting delay after attempts failed with err
+// would reach the deadline of ctx, nil otherwise
+func deadlineError(ctx context.Context, delay time.Duration, attempts int, err error) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+
+	if remaining := time.Until(deadline); delay >= remaining {
+		return &DeadlineError{Attempts: attempts, Backoff: delay, Remaining: remaining, Err: err}
+	}
+
+	return nil
+}
+
+// Error returns DeadlineError string representation
+func (e *DeadlineError) Error() string {
+	return fmt.Sprintf("%s: %d attempts made, next in %s with %s left: %s", ErrDeadlineWouldExceed, e.Attempts, e.Backoff, e.Remaining, e.Err)
+}
+
+// Unwrap returns the error of the last attempt
+func (e *DeadlineError) Unwrap() error {
+	return e.Err
+}
+
+// Is identifies ErrDeadlineWouldExceed
+func (e *DeadlineError) Is(target error) bool {
+	return target == ErrDeadlineWouldExceed
+}
+
+// isRetryableError identifies whether failed request may succeed when retried.
+// Timed out attempts are retried unless the request context is done
+func isRetryableError(err error) bool {
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return true
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var resErr *ResponseError
+	if errors.As(err, &resErr) {
+		switch resErr.StatusCode {
+		case http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	return true
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,154 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_NotificationHubSendRetry(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var correlationIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		correlationIDs = append(correlationIDs, r.Header.Get(correlationIdHeader))
+		w.Header().Set(trackingIdHeader, fmt.Sprintf("tracking-%d", len(correlationIDs)))
+		if len(correlationIDs) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Location", "https://testhub-ns.servicebus.windows.net/hub/messages/notification-id?api-version=2015-01")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(),
+		WithRetry(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}))
+
+	var result SendResult
+	if _, err := hub.Send(context.Background(), &Notification{Template, []byte("{}")}, nil, WithCorrelationID("correlation"), WithResult(&result), BroadcastAll()); err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	expectedCorrelationIDs := []string{"correlation", "correlation", "correlation"}
+	if !reflect.DeepEqual(correlationIDs, expectedCorrelationIDs) {
+		t.Errorf(errfmt, "correlation ids", expectedCorrelationIDs, correlationIDs)
+	}
+
+	expectedResult := SendResult{
+		CorrelationID:  "correlation",
+		NotificationID: "notification-id",
+		TrackingIDs:    []string{"tracking-1", "tracking-2", "tracking-3"},
+		Attempts:       3,
+	}
+	if !reflect.DeepEqual(result, expectedResult) {
+		t.Errorf(errfmt, "result", expectedResult, result)
+	}
+}
+
+func Test_NotificationHubSendNoRetryOnClientError(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(),
+		WithRetry(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}))
+
+	var result SendResult
+	_, err := hub.Send(context.Background(), &Notification{Template, []byte("{}")}, nil, WithResult(&result), BroadcastAll())
+
+	var resErr *ResponseError
+	if !errors.As(err, &resErr) || resErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected ResponseError with status %d, got: %v", http.StatusBadRequest, err)
+	}
+
+	if requests != 1 || result.Attempts != 1 {
+		t.Errorf("Expected 1 attempt, got: %d requests, %d attempts", requests, result.Attempts)
+	}
+
+	if result.CorrelationID == "" {
+		t.Error("Expected generated correlation id, got empty")
+	}
+}
+
+func Test_RetryPolicyBackoff(t *testing.T) {
+	throttled := &ResponseError{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"7"}}}
+
+	testCases := []struct {
+		policy   RetryPolicy
+		failures int
+		err      error
+		expected time.Duration
+	}{
+		{
+			policy:   RetryPolicy{Backoff: time.Second},
+			failures: 1,
+			expected: time.Second,
+		},
+		{
+			policy:   RetryPolicy{Backoff: time.Second},
+			failures: 3,
+			expected: 4 * time.Second,
+		},
+		{
+			policy:   RetryPolicy{Backoff: time.Second, MaxBackoff: 3 * time.Second},
+			failures: 5,
+			expected: 3 * time.Second,
+		},
+		{
+			policy:   RetryPolicy{Backoff: time.Second},
+			failures: 1,
+			err:      throttled,
+			expected: 7 * time.Second,
+		},
+	}
+
+	for i, testCase := range testCases {
+		if obtained := testCase.policy.backoff(testCase.failures, testCase.err); obtained != testCase.expected {
+			t.Errorf("backoff test case %d. Expected %v, got %v", i, testCase.expected, obtained)
+		}
+	}
+}
+
+func Test_NotificationHubRetryDeadline(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(),
+		WithRetry(RetryPolicy{MaxAttempts: 10, Backoff: 30 * time.Millisecond}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+
+	_, err := hub.Send(ctx, &Notification{Template, []byte("{}")}, nil, BroadcastAll())
+
+	var deadlineErr *DeadlineError
+	if !errors.As(err, &deadlineErr) || !errors.Is(err, ErrDeadlineWouldExceed) {
+		t.Fatalf(errfmt, "error", ErrDeadlineWouldExceed, err)
+	}
+	if deadlineErr.Attempts != 3 || requests != 3 || deadlineErr.Backoff != 120*time.Millisecond || deadlineErr.Remaining >= deadlineErr.Backoff {
+		t.Errorf(errfmt, "deadline error", "3 attempts, next in 120ms", deadlineErr)
+	}
+
+	var resErr *ResponseError
+	if !errors.As(err, &resErr) || resErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf(errfmt, "last attempt error", http.StatusServiceUnavailable, err)
+	}
+	if ctx.Err() != nil {
+		t.Errorf(errfmt, "context", "deadline not reached", ctx.Err())
+	}
+}
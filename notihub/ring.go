@@ -0,0 +1,140 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vippsas/gozure/notihub/tags"
+)
+
+// ringPollInterval is the delay between notification details polls of VerifyRing
+var ringPollInterval = time.Second
+
+// ErrRingVerificationFailed is returned when notification sent to a test ring
+// is not delivered to every device of the ring
+var ErrRingVerificationFailed = errors.New("ring verification failed")
+
+// WithRing makes Broadcast send every notification to the devices of test ring first,
+// e.g. tags.RingTag("qa") tagged devices of internal testers, and verify their delivery
+// before sending to the rest of the recipients. Ring devices do not receive the notification twice
+func WithRing(ring tags.Tag) SendOption {
+	return func(o *sendOptions) {
+		o.ring = ring.String()
+	}
+}
+
+// SendToRing sends notification to the devices of test ring only.
+// The returned result carries the notification id to be passed to VerifyRing
+func (h *NotificationHub) SendToRing(ctx context.Context, n *Notification, ring tags.Tag, opts ...SendOption) (SendResult, error) {
+	result, err := h.sendToRing(ctx, n, ring.String(), opts)
+	if err != nil {
+		return result, fmt.Errorf("NotificationHub.SendToRing: %w", err)
+	}
+
+	return result, nil
+}
+
+// VerifyRing polls details of notification sent by SendToRing until the hub finishes processing it.
+// ErrRingVerificationFailed is returned unless it was delivered to at least one device and failed for none.
+// Notification details are available on Standard tier hubs only
+func (h *NotificationHub) VerifyRing(ctx context.Context, notificationID string) (*NotificationDetails, error) {
+	details, err := h.verifyRing(ctx, notificationID)
+	if err != nil {
+		return details, fmt.Errorf("NotificationHub.VerifyRing: %w", err)
+	}
+
+	return details, nil
+}
+
+func (h *NotificationHub) sendToRing(ctx context.Context, n *Notification, ring string, opts []SendOption) (SendResult, error) {
+	var result SendResult
+
+	o := newSendOptions(opts)
+	o.result = &result
+	o.ring = ""
+	_, err := h.send(ctx, n, []string{ring}, o)
+
+	return result, err
+}
+
+func (h *NotificationHub) verifyRing(ctx context.Context, notificationID string) (*NotificationDetails, error) {
+	if notificationID == "" {
+		return nil, fmt.Errorf("%w: no notification id, the hub reports it on Standard tier only", ErrRingVerificationFailed)
+	}
+
+	for {
+		details, err := h.getNotificationDetails(ctx, notificationID)
+		if err != nil {
+			return nil, err
+		}
+
+		if details.State.IsFinal() {
+			return details, details.ringError()
+		}
+
+		if err := sleepContext(ctx, ringPollInterval); err != nil {
+			return details, err
+		}
+	}
+}
+
+// getNotificationDetails reads telemetry of sent notification
+func (h *NotificationHub) getNotificationDetails(ctx context.Context, notificationID string) (*NotificationDetails, error) {
+	relPath, err := resourcePath("messages", notificationID)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := h.newRequest(ctx, "GET", relPath, h.hubURL.Query(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := h.exec(req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseNotificationDetails(b)
+}
+
+// ringError returns ErrRingVerificationFailed unless the notification was delivered without failures
+func (d *NotificationDetails) ringError() error {
+	if d.State != NotificationCompleted {
+		return fmt.Errorf("%w: notification '%s' %s", ErrRingVerificationFailed, d.NotificationId, d.State)
+	}
+
+	delivered := d.Total(outcomeSuccess)
+	failed := 0
+	for _, outcomes := range d.OutcomeCounts {
+		for outcome, count := range outcomes {
+			if outcome != outcomeSuccess {
+				failed += count
+			}
+		}
+	}
+
+	if delivered == 0 || failed > 0 {
+		return fmt.Errorf("%w: notification '%s' %d delivered, %d failed", ErrRingVerificationFailed, d.NotificationId, delivered, failed)
+	}
+
+	return nil
+}
+
+// broadcastRing sends notifications to the ring and verifies their delivery before Broadcast
+func (h *NotificationHub) broadcastRing(ctx context.Context, notifications []*Notification, ring string, opts []SendOption) error {
+	for _, n := range notifications {
+		result, err := h.sendToRing(ctx, n, ring, opts)
+		if err != nil {
+			return fmt.Errorf("ring %s: %w", n.Format, err)
+		}
+
+		if _, err := h.verifyRing(ctx, result.NotificationID); err != nil {
+			return fmt.Errorf("ring %s: %w", n.Format, err)
+		}
+	}
+
+	return nil
+}
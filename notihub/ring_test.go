@@ -0,0 +1,130 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vippsas/gozure/notihub/tags"
+)
+
+func newRingTestServer(outcome string) (*httptest.Server, func() []string) {
+	var (
+		mu    sync.Mutex
+		sent  []string
+		polls = map[string]int{}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Method == "POST" {
+			sent = append(sent, r.Header.Get("ServiceBusNotification-Tags"))
+			w.Header().Set("Location", fmt.Sprintf("https://testhub-ns.servicebus.windows.net/hub/messages/notification-%d?api-version=2015-01", len(sent)))
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+
+		id := strings.TrimPrefix(r.URL.Path, "/hub/messages/")
+		polls[id]++
+		state := "Processing"
+		if polls[id] > 1 {
+			state = "Completed"
+		}
+		fmt.Fprintf(w, `<NotificationDetails><NotificationId>%s</NotificationId><State>%s</State>`+
+			`<FcmV1OutcomeCounts><Outcome><Name>%s</Name><Count>2</Count></Outcome></FcmV1OutcomeCounts></NotificationDetails>`, id, state, outcome)
+	}))
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), sent...)
+	}
+}
+
+func Test_NotificationHubSendToRing(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	defer func(interval time.Duration) { ringPollInterval = interval }(ringPollInterval)
+	ringPollInterval = time.Millisecond
+
+	server, sent := newRingTestServer("Success")
+	defer server.Close()
+
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client())
+
+	result, err := hub.SendToRing(context.Background(), &Notification{FcmV1Format, []byte(`{"message":{}}`)}, tags.New(tags.Ring, "qa"))
+	if err != nil || result.NotificationID != "notification-1" {
+		t.Fatalf(errfmt, "ring send", "notification-1", fmt.Sprint(result, err))
+	}
+	if expected := []string{"ring:qa"}; !reflect.DeepEqual(sent(), expected) {
+		t.Errorf(errfmt, "ring tags", expected, sent())
+	}
+
+	details, err := hub.VerifyRing(context.Background(), result.NotificationID)
+	if err != nil || details.State != NotificationCompleted || details.Total("Success") != 2 {
+		t.Errorf(errfmt, "verification", "completed with 2 deliveries", fmt.Sprint(details, err))
+	}
+
+	if _, err := hub.VerifyRing(context.Background(), ""); !errors.Is(err, ErrRingVerificationFailed) {
+		t.Errorf(errfmt, "verification without notification id", ErrRingVerificationFailed, err)
+	}
+}
+
+func Test_NotificationHubBroadcastWithRing(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	defer func(interval time.Duration) { ringPollInterval = interval }(ringPollInterval)
+	ringPollInterval = time.Millisecond
+
+	notifications := []*Notification{{FcmV1Format, []byte(`{"message":{}}`)}}
+
+	testPatterns := []struct {
+		outcome  string
+		expected []string
+		err      error
+	}{
+		{"Success", []string{"ring:qa", "news && !ring:qa"}, nil},
+		{"InvalidToken", []string{"ring:qa"}, ErrRingVerificationFailed},
+	}
+
+	for _, testData := range testPatterns {
+		server, sent := newRingTestServer(testData.outcome)
+		hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client())
+
+		_, err := hub.Broadcast(context.Background(), notifications, []string{"news"}, WithRing(tags.New(tags.Ring, "qa")))
+		if !errors.Is(err, testData.err) {
+			t.Errorf(errfmt, testData.outcome+" error", testData.err, err)
+		}
+		if !reflect.DeepEqual(sent(), testData.expected) {
+			t.Errorf(errfmt, testData.outcome+" sends", testData.expected, sent())
+		}
+
+		server.Close()
+	}
+
+	server, sent := newRingTestServer("Success")
+	defer server.Close()
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client())
+
+	if _, err := hub.Broadcast(context.Background(), notifications, nil, WithRing(tags.New(tags.Ring, "qa"))); !errors.Is(err, ErrBroadcastNotConfirmed) || len(sent()) != 0 {
+		t.Errorf(errfmt, "untargeted ring broadcast error", ErrBroadcastNotConfirmed, err)
+	}
+	if _, err := hub.Broadcast(context.Background(), notifications, nil, WithRing(tags.New(tags.Ring, "qa")), BroadcastAll()); err != nil {
+		t.Errorf(errfmt, "confirmed ring broadcast error", nil, err)
+	}
+	if expected := []string{"ring:qa", "!ring:qa"}; !reflect.DeepEqual(sent(), expected) {
+		t.Errorf(errfmt, "confirmed ring broadcast sends", expected, sent())
+	}
+	if _, err := hub.VerifyRing(context.Background(), "../jobs"); !errors.Is(err, ErrInvalidResourceID) {
+		t.Errorf(errfmt, "traversing notification id error", ErrInvalidResourceID, err)
+	}
+}
@@ -0,0 +1,218 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/vippsas/gozure/notihub/tags"
+)
+
+// ErrResidencyRouting is returned when Router can not tell
+// which residency region a notification belongs to, or has no hub for it
+var ErrResidencyRouting = errors.New("data residency routing failed")
+
+type (
+	// RouterResult is the outcome of a single hub send within Router.BroadcastAll
+	RouterResult struct {
+		Region   string
+		Response []byte
+		Result   SendResult
+		Err      error
+	}
+
+	// RouterError is returned by Router.BroadcastAll when some of the hub sends fail.
+	// Results contain outcomes of all hubs, successful ones included
+	RouterError struct {
+		Results []RouterResult
+	}
+)
+
+// Router sends notifications through the hub of their data residency region,
+// e.g. the hub of EU or US namespace, so that GDPR data routing is enforced in one place.
+// Residency is taken from WithResidency send option, residency tags among context tags
+// and recipients of target tags. All known residencies must agree and at least one must be known,
+// notifications are never routed to a default hub
+type Router struct {
+	// Hubs maps residency regions, e.g. "eu" or "us", to their hubs
+	Hubs map[string]*NotificationHub
+	// Secondary maps residency regions to hubs sends are routed to while the hub of the region is slow,
+	// see WithSlowHubDetection. Secondary hubs must be of the same residency
+	Secondary map[string]*NotificationHub
+	// TagResidency returns residency region of recipients of tag, empty when unknown.
+	// Residency tags, like "residency:eu", are recognized without it
+	TagResidency func(tag string) string
+}
+
+// WithResidency routes the send through the Router hub of residency region
+func WithResidency(region string) SendOption {
+	return func(o *sendOptions) {
+		o.residency = strings.ToLower(region)
+	}
+}
+
+// Route returns the hub of residency region of notification sent to orTags with opts
+func (r *Router) Route(ctx context.Context, orTags []string, opts ...SendOption) (*NotificationHub, error) {
+	h, err := r.route(ctx, orTags, newSendOptions(opts))
+	if err != nil {
+		return nil, fmt.Errorf("Router.Route: %w", err)
+	}
+
+	return h, nil
+}
+
+// Send publishes notification through the hub of its residency region
+func (r *Router) Send(ctx context.Context, n *Notification, orTags []string, opts ...SendOption) ([]byte, error) {
+	o := newSendOptions(opts)
+
+	h, err := r.route(ctx, orTags, o)
+	if err != nil {
+		return nil, fmt.Errorf("Router.Send: %w", err)
+	}
+
+	b, err := h.send(ctx, n, orTags, o)
+	if err != nil {
+		return nil, fmt.Errorf("Router.Send: %w", err)
+	}
+
+	return b, nil
+}
+
+// SendDirect publishes notification to device handle through the hub of its residency region,
+// which must be given by WithResidency or context tags
+func (r *Router) SendDirect(ctx context.Context, n *Notification, deviceHandle string, opts ...SendOption) ([]byte, error) {
+	o := newSendOptions(opts)
+
+	h, err := r.route(ctx, nil, o)
+	if err != nil {
+		return nil, fmt.Errorf("Router.SendDirect: %w", err)
+	}
+
+	b, err := h.sendDirect(ctx, n, deviceHandle, o)
+	if err != nil {
+		return nil, fmt.Errorf("Router.SendDirect: %w", err)
+	}
+
+	return b, nil
+}
+
+// BroadcastAll concurrently sends notification to orTags recipients through every hub of the router,
+// e.g. for platform-wide announcements. Residency routing does not apply. Results are ordered by region,
+// *RouterError is returned when any of the hub sends fails. Notifications without tags
+// must be confirmed by the BroadcastAll send option as with single hub sends
+func (r *Router) BroadcastAll(ctx context.Context, n *Notification, orTags []string, opts ...SendOption) ([]RouterResult, error) {
+	regions := make([]string, 0, len(r.Hubs))
+	for region := range r.Hubs {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	results := make([]RouterResult, len(regions))
+
+	var wg sync.WaitGroup
+	for i, region := range regions {
+		wg.Add(1)
+		i, h := i, r.Hubs[region]
+		results[i].Region = region
+		h.goLabeled(ctx, "router-broadcast", func(ctx context.Context) {
+			defer wg.Done()
+
+			o := newSendOptions(opts)
+			o.result = &results[i].Result
+			results[i].Response, results[i].Err = h.send(ctx, n, orTags, o)
+		})
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if result.Err != nil {
+			return results, &RouterError{Results: results}
+		}
+	}
+
+	return results, nil
+}
+
+// Error returns RouterError string representation
+func (e *RouterError) Error() string {
+	failed := e.Failed()
+
+	msgs := make([]string, 0, len(failed))
+	for _, result := range failed {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", result.Region, result.Err))
+	}
+
+	return fmt.Sprintf("Router.BroadcastAll: %d of %d hub sends failed: %s", len(failed), len(e.Results), strings.Join(msgs, "; "))
+}
+
+// Failed returns results of the failed hub sends
+func (e *RouterError) Failed() []RouterResult {
+	var failed []RouterResult
+	for _, result := range e.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+
+	return failed
+}
+
+// route resolves residency region of the send and returns its hub
+func (r *Router) route(ctx context.Context, orTags []string, o *sendOptions) (*NotificationHub, error) {
+	regions := map[string]bool{}
+	if o.residency != "" {
+		regions[o.residency] = true
+	}
+	for _, tag := range ContextTags(ctx) {
+		if region := tagResidency(tag); region != "" {
+			regions[region] = true
+		}
+	}
+
+	for _, tag := range orTags {
+		region := tagResidency(tag)
+		if region == "" && r.TagResidency != nil {
+			region = strings.ToLower(r.TagResidency(tag))
+		}
+		if region != "" {
+			regions[region] = true
+		}
+	}
+
+	found := make([]string, 0, len(regions))
+	for region := range regions {
+		found = append(found, region)
+	}
+	sort.Strings(found)
+
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("%w: unknown residency", ErrResidencyRouting)
+	case 1:
+	default:
+		return nil, fmt.Errorf("%w: conflicting residencies %s", ErrResidencyRouting, strings.Join(found, ", "))
+	}
+
+	h := r.Hubs[found[0]]
+	if h == nil {
+		return nil, fmt.Errorf("%w: no hub of residency '%s'", ErrResidencyRouting, found[0])
+	}
+	if secondary := r.Secondary[found[0]]; h.IsSlow() && secondary != nil && !secondary.IsSlow() {
+		return secondary, nil
+	}
+
+	return h, nil
+}
+
+// tagResidency returns region of residency tag, empty for other tags
+func tagResidency(tag string) string {
+	t, err := tags.Parse(tag)
+	if err != nil || t.Namespace != tags.Residency {
+		return ""
+	}
+
+	return t.Value
+}
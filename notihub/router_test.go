@@ -0,0 +1,121 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func Test_RouterSend(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var routed []string
+	hub := func(region string) *NotificationHub {
+		return newBulkTestHub(func(req *http.Request) ([]byte, error) {
+			routed = append(routed, region+" "+req.Header.Get("ServiceBusNotification-Tags"))
+			return nil, nil
+		})
+	}
+	router := &Router{
+		Hubs: map[string]*NotificationHub{"eu": hub("eu"), "us": hub("us")},
+		TagResidency: func(tag string) string {
+			if strings.HasPrefix(tag, "user:us-") {
+				return "US"
+			}
+			return ""
+		},
+	}
+
+	euCtx := WithContextTags(context.Background(), "residency:eu")
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		orTags []string
+		opts   []SendOption
+		routed string
+	}{
+		{"option", context.Background(), []string{"topic:news"}, []SendOption{WithResidency("EU")}, "eu topic:news"},
+		{"context tag", euCtx, []string{"user:1"}, nil, "eu user:1 && residency:eu"},
+		{"target tag", context.Background(), []string{"user:us-1", "topic:news"}, nil, "us user:us-1 || topic:news"},
+		{"residency tag", context.Background(), []string{"residency:us"}, nil, "us residency:us"},
+		{"unknown", context.Background(), []string{"user:1"}, nil, ""},
+		{"conflict", euCtx, []string{"user:us-1"}, nil, ""},
+		{"no hub", context.Background(), []string{"user:1"}, []SendOption{WithResidency("apac")}, ""},
+	}
+
+	for _, test := range tests {
+		routed = nil
+
+		_, err := router.Send(test.ctx, &Notification{Template, []byte("{}")}, test.orTags, test.opts...)
+		if test.routed == "" {
+			if !errors.Is(err, ErrResidencyRouting) || len(routed) != 0 {
+				t.Errorf(errfmt, test.name+" error", ErrResidencyRouting, err)
+			}
+			continue
+		}
+
+		if err != nil || len(routed) != 1 || routed[0] != test.routed {
+			t.Errorf(errfmt, test.name+" routed send", test.routed, routed)
+		}
+	}
+
+	routed = nil
+	if _, err := router.SendDirect(context.Background(), &Notification{Template, []byte("{}")}, "handle", WithResidency("us")); err != nil || len(routed) != 1 || !strings.HasPrefix(routed[0], "us") {
+		t.Errorf(errfmt, "direct send", "us", routed)
+	}
+	if _, err := router.SendDirect(context.Background(), &Notification{Template, []byte("{}")}, "handle"); !errors.Is(err, ErrResidencyRouting) {
+		t.Errorf(errfmt, "direct send without residency error", ErrResidencyRouting, err)
+	}
+
+	if h, err := router.Route(euCtx, nil); err != nil || h != router.Hubs["eu"] {
+		t.Errorf(errfmt, "route", "eu hub", err)
+	}
+}
+
+func Test_RouterBroadcastAll(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var (
+		mu   sync.Mutex
+		sent []string
+	)
+	hub := func(region string, fail bool) *NotificationHub {
+		return newBulkTestHub(func(req *http.Request) ([]byte, error) {
+			mu.Lock()
+			sent = append(sent, region)
+			mu.Unlock()
+			if fail {
+				return nil, &ResponseError{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}}
+			}
+			return []byte(region), nil
+		})
+	}
+	router := &Router{Hubs: map[string]*NotificationHub{"us": hub("us", true), "eu": hub("eu", false), "apac": hub("apac", false)}}
+
+	results, err := router.BroadcastAll(context.Background(), &Notification{Template, []byte("{}")}, nil, BroadcastAll())
+
+	var routerErr *RouterError
+	if !errors.As(err, &routerErr) || len(routerErr.Failed()) != 1 || routerErr.Failed()[0].Region != "us" {
+		t.Fatalf(errfmt, "partial failure", "us failed", err)
+	}
+	if len(results) != 3 || results[0].Region != "apac" || string(results[1].Response) != "eu" || results[1].Result.Attempts != 1 || results[2].Err == nil {
+		t.Errorf(errfmt, "results", "apac, eu succeeded, us failed", results)
+	}
+	if len(sent) != 3 {
+		t.Errorf(errfmt, "hub sends", 3, sent)
+	}
+
+	sent = nil
+	results, _ = router.BroadcastAll(context.Background(), &Notification{Template, []byte("{}")}, nil)
+	for _, result := range results {
+		if !errors.Is(result.Err, ErrBroadcastNotConfirmed) {
+			t.Errorf(errfmt, result.Region+" unconfirmed broadcast error", ErrBroadcastNotConfirmed, result.Err)
+		}
+	}
+	if len(sent) != 0 {
+		t.Errorf(errfmt, "unconfirmed broadcast sends", 0, sent)
+	}
+}
@@ -0,0 +1,170 @@
+package notihub
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type (
+	// PayloadSample is a sanitized request and response pair captured by sampling.
+	// Only diagnostic headers are kept, credentials are redacted and bodies truncated
+	PayloadSample struct {
+		Time           time.Time
+		Method         string
+		URL            string
+		RequestHeader  http.Header
+		RequestBody    []byte
+		StatusCode     int
+		ResponseHeader http.Header
+		ResponseBody   []byte
+		Duration       time.Duration
+		Err            string
+	}
+
+	// payloadSampler keeps every nth request and response pair in a ring buffer
+	payloadSampler struct {
+		every int
+
+		mu      sync.Mutex
+		count   int
+		samples []PayloadSample
+		next    int
+		full    bool
+	}
+
+	// samplingTransport captures sampled requests
+	samplingTransport struct {
+		next    http.RoundTripper
+		sampler *payloadSampler
+	}
+)
+
+// WithPayloadSampling makes the hub capture every nth request and response pair,
+// keeping the last capacity samples for inspection through Samples.
+// Sampled pairs are sanitized like debug transport dumps: bodies of management, installation
+// and registration requests, which carry credentials and device handles, are redacted.
+// Notification payloads are kept, so enable it in production only when they carry no personal data
+func WithPayloadSampling(every, capacity int) HubOption {
+	return func(h *NotificationHub) {
+		if every <= 0 || capacity <= 0 {
+			h.sampler = nil
+			return
+		}
+
+		h.sampler = &payloadSampler{every: every, samples: make([]PayloadSample, capacity)}
+	}
+}
+
+// Samples returns captured samples, oldest first.
+// It returns nil unless payload sampling is enabled
+func (h *NotificationHub) Samples() []PayloadSample {
+	if h.sampler == nil {
+		return nil
+	}
+
+	return h.sampler.list()
+}
+
+// RoundTrip executes request with the next transport, capturing every nth pair
+func (t *samplingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.sampler.sample() {
+		return t.next.RoundTrip(req)
+	}
+
+	sample := PayloadSample{
+		Time:          time.Now(),
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		RequestHeader: sanitizedDebugHeaders(req.Header, debugRequestHeaders),
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		sample.RequestBody = truncateDebugBody(debugBody(req, b))
+		req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	sample.Duration = time.Since(sample.Time)
+
+	if err != nil {
+		sample.Err = err.Error()
+		t.sampler.add(sample)
+		return nil, err
+	}
+
+	b, rerr := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+	sample.StatusCode = resp.StatusCode
+	sample.ResponseHeader = sanitizedDebugHeaders(resp.Header, debugResponseHeaders)
+	sample.ResponseBody = truncateDebugBody(debugBody(req, b))
+	if rerr != nil {
+		sample.Err = rerr.Error()
+	}
+	t.sampler.add(sample)
+
+	return resp, nil
+}
+
+// sample counts request and identifies whether it is sampled
+func (s *payloadSampler) sample() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.count++
+	return s.count%s.every == 0
+}
+
+// add stores sample, overwriting the oldest one when the buffer is full
+func (s *payloadSampler) add(sample PayloadSample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.samples[s.next] = sample
+	s.next = (s.next + 1) % len(s.samples)
+	if s.next == 0 {
+		s.full = true
+	}
+}
+
+// list returns copy of stored samples, oldest first
+func (s *payloadSampler) list() []PayloadSample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.full {
+		return append([]PayloadSample(nil), s.samples[:s.next]...)
+	}
+
+	return append(append([]PayloadSample(nil), s.samples[s.next:]...), s.samples[:s.next]...)
+}
+
+// sanitizedDebugHeaders returns sanitized copy of selected headers
+func sanitizedDebugHeaders(header http.Header, selected []string) http.Header {
+	sanitized := http.Header{}
+	for _, name := range selected {
+		if value := header.Get(name); value != "" {
+			sanitized.Set(name, sanitizeDebugHeader(name, value))
+		}
+	}
+
+	return sanitized
+}
+
+// truncateDebugBody returns copy of body truncated to debugBodyLimit bytes
+func truncateDebugBody(body []byte) []byte {
+	if len(body) > debugBodyLimit {
+		body = body[:debugBodyLimit]
+	}
+
+	return append([]byte(nil), body...)
+}
@@ -0,0 +1,84 @@
+package notihub
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_NotificationHubPayloadSampling(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(strings.Repeat("r", 600)))
+	}))
+	defer server.Close()
+
+	nhub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(), WithPayloadSampling(2, 2))
+
+	if samples := nhub.Samples(); len(samples) != 0 {
+		t.Errorf(errfmt, "initial samples", 0, len(samples))
+	}
+
+	for i := 1; i <= 7; i++ {
+		n := &Notification{AndroidFormat, []byte(fmt.Sprintf(`{"data":{"n":%d}}`, i))}
+		b, err := nhub.SendDirect(context.Background(), n, "device-handle-secret")
+		if err != nil {
+			t.Fatalf(errfmt, "send error", nil, err)
+		}
+		if len(b) != 600 {
+			t.Errorf(errfmt, "response body passed through", 600, len(b))
+		}
+	}
+
+	samples := nhub.Samples()
+	if len(samples) != 2 {
+		t.Fatalf(errfmt, "samples", 2, len(samples))
+	}
+
+	for i, expected := range []string{`{"data":{"n":4}}`, `{"data":{"n":6}}`} {
+		sample := samples[i]
+		if string(sample.RequestBody) != expected {
+			t.Errorf(errfmt, "sampled request", expected, string(sample.RequestBody))
+		}
+		if sample.Method != "POST" || sample.StatusCode != http.StatusCreated || len(sample.ResponseBody) != debugBodyLimit {
+			t.Errorf(errfmt, "sampled response", "truncated 201", sample)
+		}
+		if sample.RequestHeader.Get("Authorization") != "[redacted]" ||
+			sample.RequestHeader.Get("ServiceBusNotification-DeviceHandle") != "device...[redacted]" {
+			t.Errorf(errfmt, "sanitized headers", "redacted", sample.RequestHeader)
+		}
+	}
+
+	if samples := (&NotificationHub{}).Samples(); samples != nil {
+		t.Errorf(errfmt, "samples without sampling", nil, samples)
+	}
+}
+
+func Test_NotificationHubPayloadSamplingDeviceBodies(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newInstallationServer()
+	defer server.Close()
+
+	nhub := server.hub(WithPayloadSampling(1, 10))
+	installation := &Installation{InstallationId: "a", Platform: PlatformApns, PushChannel: "device-token-secret"}
+	if err := nhub.CreateOrUpdateInstallation(context.Background(), installation); err != nil {
+		t.Fatalf(errfmt, "create error", nil, err)
+	}
+	if _, err := nhub.GetInstallation(context.Background(), "a"); err != nil {
+		t.Fatalf(errfmt, "read error", nil, err)
+	}
+
+	samples := nhub.Samples()
+	if len(samples) != 2 {
+		t.Fatalf(errfmt, "samples", 2, len(samples))
+	}
+	if string(samples[0].RequestBody) != redactedDebugBody || string(samples[1].ResponseBody) != redactedDebugBody {
+		t.Errorf(errfmt, "installation bodies", redactedDebugBody, samples)
+	}
+}
@@ -0,0 +1,32 @@
+package notihub
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// defaultPorts are ports implied by URL schemes
+var defaultPorts = map[string]string{
+	"https": "443",
+	"http":  "80",
+}
+
+// sasAudience returns the canonical SAS token audience of hub URL: the namespace URI
+// with lowercase scheme and host, without user info, default port, path or query.
+// The service compares audiences exactly, so any other spelling of the same namespace,
+// e.g. an uppercase host or an explicit :443, gets the token rejected with 401
+func sasAudience(u *url.URL) string {
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Host)
+
+	if hostname, port, err := net.SplitHostPort(host); err == nil && defaultPorts[scheme] == port {
+		host = hostname
+		if strings.Contains(hostname, ":") {
+			host = "[" + hostname + "]"
+		}
+	}
+	host = strings.TrimSuffix(host, ".")
+
+	return (&url.URL{Scheme: scheme, Host: host}).String()
+}
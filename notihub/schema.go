@@ -0,0 +1,141 @@
+package notihub
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSON Schema ids of documents returned by InstallationSchema and NotificationEnvelopeSchema
+const (
+	InstallationSchemaID         = "https://github.com/vippsas/gozure/notihub/installation.schema.json"
+	NotificationEnvelopeSchemaID = "https://github.com/vippsas/gozure/notihub/notification-envelope.schema.json"
+)
+
+// schemaEnums lists values of string types with a closed set of values
+var schemaEnums = map[reflect.Type][]string{
+	reflect.TypeOf(Platform("")): {
+		string(PlatformApns),
+		string(PlatformGcm),
+		string(PlatformFcmV1),
+		string(PlatformWns),
+		string(PlatformMpns),
+		string(PlatformAdm),
+		string(PlatformBaidu),
+		string(PlatformBrowser),
+		string(PlatformXiaomi),
+	},
+	reflect.TypeOf(NotificationFormat("")): {
+		string(Template),
+		string(AndroidFormat),
+		string(FcmV1Format),
+		string(AppleFormat),
+		string(BaiduFormat),
+		string(KindleFormat),
+		string(WindowsFormat),
+		string(WindowsPhoneFormat),
+	},
+}
+
+// InstallationSchema returns JSON Schema document of Installation,
+// for producers in other languages writing installations consumed by the client
+func InstallationSchema() []byte {
+	return jsonSchemaDocument(InstallationSchemaID, "Installation", reflect.TypeOf(Installation{}))
+}
+
+// NotificationEnvelopeSchema returns JSON Schema document of the current NotificationEnvelope version,
+// for producers in other languages enqueueing notifications consumed by the client.
+// Payload is set in exactly one of payload, payloadBase64 or ciphertext
+func NotificationEnvelopeSchema() []byte {
+	return jsonSchemaDocument(NotificationEnvelopeSchemaID, "NotificationEnvelope", reflect.TypeOf(notificationEnvelopeJSON{}))
+}
+
+// jsonSchemaDocument returns JSON Schema document of t
+func jsonSchemaDocument(id, title string, t reflect.Type) []byte {
+	schema := jsonSchema(t)
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["$id"] = id
+	schema["title"] = title
+
+	b, _ := json.MarshalIndent(schema, "", "  ")
+	return append(b, '\n')
+}
+
+// jsonSchema returns schema of values of t encoded by encoding/json
+func jsonSchema(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if enum, ok := schemaEnums[t]; ok {
+		return map[string]interface{}{"type": "string", "enum": enum}
+	}
+
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": jsonSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": jsonSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	}
+
+	return map[string]interface{}{}
+}
+
+// structSchema returns schema of struct t, fields without omitempty are required
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name, options := field.Name, ""
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			if j := strings.Index(tag, ","); j >= 0 {
+				name, options = tag[:j], tag[j:]
+			} else {
+				name = tag
+			}
+			if name == "" {
+				name = field.Name
+			}
+		}
+
+		properties[name] = jsonSchema(field.Type)
+		if !strings.Contains(options, ",omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
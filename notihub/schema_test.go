@@ -0,0 +1,48 @@
+package notihub
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func Test_JSONSchemas(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	schemas := map[string][]byte{
+		"installation.schema.json":          InstallationSchema(),
+		"notification-envelope.schema.json": NotificationEnvelopeSchema(),
+	}
+
+	for name, schema := range schemas {
+		golden := filepath.Join("testdata", "schema", name)
+		if *updateFixtures {
+			if err := ioutil.WriteFile(golden, schema, 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		expected, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(schema) != string(expected) {
+			t.Errorf(errfmt, name, string(expected), string(schema))
+		}
+	}
+
+	var installation struct {
+		Required   []string
+		Properties map[string]struct {
+			Enum []string
+		}
+	}
+	if err := json.Unmarshal(schemas["installation.schema.json"], &installation); err != nil {
+		t.Fatalf(errfmt, "schema error", nil, err)
+	}
+	if len(installation.Required) != 3 || len(installation.Properties["platform"].Enum) != 9 {
+		t.Errorf(errfmt, "installation schema", "3 required fields and platform enum", installation)
+	}
+}
@@ -0,0 +1,193 @@
+package notihub
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+)
+
+var (
+	// ErrDeliveryTimeInPast is returned when delivery time set by WithDeliveryTime
+	// is not after the hub clock and WithSendImmediatelyIfPast is not given
+	ErrDeliveryTimeInPast = errors.New("delivery time in the past")
+	// ErrDeliveryTimeNotSupported is returned when WithDeliveryTime is given
+	// to direct or test sends, which the hub can not schedule
+	ErrDeliveryTimeNotSupported = errors.New("delivery time not supported")
+)
+
+type (
+	// SendOption configures a single notification send
+	SendOption func(*sendOptions)
+
+	sendOptions struct {
+		correlationID string
+		result        *SendResult
+		// andTags are required from recipients in addition to hub default tags
+		andTags []string
+		// headers are added to the notification request
+		headers map[string]string
+		// campaign labels the send telemetry
+		campaign string
+		// header is the response header of the last attempt
+		header http.Header
+		// broadcastAll confirms sends without tags
+		broadcastAll bool
+		// deliveryTime schedules the send
+		deliveryTime *time.Time
+		// sendImmediatelyIfPast sends notifications with past delivery time right away
+		sendImmediatelyIfPast bool
+		// retry overrides the hub retry policy
+		retry *RetryPolicy
+		// metrics overrides the hub telemetry receiver
+		metrics Metrics
+		// priority is the SendAsync lane
+		priority Priority
+		// residency selects the Router hub
+		residency string
+		// ring is the test ring Broadcast verifies first
+		ring string
+		// category is checked against user preferences
+		category string
+		// optedOut are users left out of the send by preferences
+		optedOut []string
+		// transformers rewrite the payload after the hub default transformers
+		transformers []PayloadTransformer
+	}
+
+	// SendResult describes requests made by a single notification send
+	SendResult struct {
+		// CorrelationID is sent with every attempt of the send
+		CorrelationID string
+		// NotificationID is assigned by the hub, available on Standard tier hubs
+		NotificationID string
+		// TrackingIDs are reported by the hub for every attempt, in order
+		TrackingIDs []string
+		// Attempts is the number of requests made
+		Attempts int
+		// Err is the error of the send, reported by SendAsync
+		Err error
+		// OptedOut are users left out of the send by Preferences
+		OptedOut []string
+	}
+)
+
+// WithCorrelationID sets the correlation id sent with every attempt of the send.
+// A random id is generated when the option is not provided
+func WithCorrelationID(id string) SendOption {
+	return func(o *sendOptions) {
+		o.correlationID = id
+	}
+}
+
+// WithResult makes the send fill result once it completes, successfully or not
+func WithResult(result *SendResult) SendOption {
+	return func(o *sendOptions) {
+		o.result = result
+	}
+}
+
+// WithDeliveryTime schedules the notification for delivery at t
+// through the scheduled notifications endpoint. Direct and test sends
+// can not be scheduled and fail with ErrDeliveryTimeNotSupported
+func WithDeliveryTime(t time.Time) SendOption {
+	return func(o *sendOptions) {
+		o.deliveryTime = &t
+	}
+}
+
+// WithSendImmediatelyIfPast makes sends with delivery time not after
+// the hub clock go out immediately instead of failing with ErrDeliveryTimeInPast
+func WithSendImmediatelyIfPast() SendOption {
+	return func(o *sendOptions) {
+		o.sendImmediatelyIfPast = true
+	}
+}
+
+// withAndTags requires recipients to have tags in addition to hub default tags
+func withAndTags(tags ...string) SendOption {
+	return func(o *sendOptions) {
+		o.andTags = append(o.andTags, tags...)
+	}
+}
+
+func newSendOptions(opts []SendOption) *sendOptions {
+	o := &sendOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// scheduledTime returns delivery time of scheduled send, nil for immediate sends
+func (o *sendOptions) scheduledTime(now time.Time) (*time.Time, error) {
+	if o == nil || o.deliveryTime == nil {
+		return nil, nil
+	}
+
+	if o.deliveryTime.Unix() > now.Unix() {
+		return o.deliveryTime, nil
+	}
+
+	if o.sendImmediatelyIfPast {
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrDeliveryTimeInPast, o.deliveryTime.Format(time.RFC3339))
+}
+
+// requireImmediate fails sends of kind, which the hub can not schedule, when delivery time is set
+func (o *sendOptions) requireImmediate(kind string) error {
+	if o == nil || o.deliveryTime == nil {
+		return nil
+	}
+
+	return fmt.Errorf("%w by %s sends", ErrDeliveryTimeNotSupported, kind)
+}
+
+// newResult resets and returns result tracking the send
+func (o *sendOptions) newResult() *SendResult {
+	if o == nil {
+		return &SendResult{}
+	}
+
+	if o.result == nil {
+		o.result = &SendResult{}
+	}
+	*o.result = SendResult{CorrelationID: o.correlationID, OptedOut: o.optedOut}
+
+	return o.result
+}
+
+// track records the attempt response headers
+func (r *SendResult) track(header http.Header) {
+	r.Attempts++
+
+	if trackingID := header.Get(trackingIdHeader); trackingID != "" {
+		r.TrackingIDs = append(r.TrackingIDs, trackingID)
+	}
+
+	// Location: https://{namespace}/{hub}/messages/{id}?api-version=...
+	if location := header.Get("Location"); location != "" {
+		if u, err := url.Parse(location); err == nil {
+			r.NotificationID = path.Base(u.Path)
+		}
+	}
+}
+
+// newCorrelationID generates random UUID
+func newCorrelationID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
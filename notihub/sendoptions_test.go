@@ -0,0 +1,70 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"testing"
+	"time"
+)
+
+func Test_NotificationHubSendWithDeliveryTime(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	clock := &mockClock{now: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	var endpoint, scheduleTime string
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		endpoint = path.Base(req.URL.Path)
+		scheduleTime = req.Header.Get("ServiceBusNotification-ScheduleTime")
+		return nil, nil
+	})
+	WithClock(clock)(nhub)
+
+	tests := []struct {
+		name         string
+		opts         []SendOption
+		err          error
+		endpoint     string
+		scheduleTime string
+	}{
+		{"immediate", nil, nil, "messages", ""},
+		{"future", []SendOption{WithDeliveryTime(clock.now.Add(time.Hour))}, nil, "schedulednotifications", "2020-01-01T13:00:00"},
+		{"past", []SendOption{WithDeliveryTime(clock.now)}, ErrDeliveryTimeInPast, "", ""},
+		{"past sent immediately", []SendOption{WithDeliveryTime(clock.now.Add(-time.Hour)), WithSendImmediatelyIfPast()}, nil, "messages", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			endpoint, scheduleTime = "", ""
+
+			_, err := nhub.Send(context.Background(), &Notification{Template, []byte("{}")}, []string{"tag"}, test.opts...)
+			if !errors.Is(err, test.err) {
+				t.Errorf(errfmt, "error", test.err, err)
+			}
+			if endpoint != test.endpoint || scheduleTime != test.scheduleTime {
+				t.Errorf(errfmt, "endpoint and schedule time", test.endpoint+" "+test.scheduleTime, endpoint+" "+scheduleTime)
+			}
+		})
+	}
+}
+
+func Test_NotificationHubUnscheduledSendsWithDeliveryTime(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		t.Errorf(errfmt, "request", nil, req.URL)
+		return nil, nil
+	})
+
+	n := &Notification{Template, []byte("{}")}
+	deliveryTime := WithDeliveryTime(time.Now().Add(time.Hour))
+
+	if _, err := nhub.SendDirect(context.Background(), n, "handle", deliveryTime); !errors.Is(err, ErrDeliveryTimeNotSupported) {
+		t.Errorf(errfmt, "direct send error", ErrDeliveryTimeNotSupported, err)
+	}
+	if _, err := nhub.SendTest(context.Background(), n, []string{"tag"}, deliveryTime); !errors.Is(err, ErrDeliveryTimeNotSupported) {
+		t.Errorf(errfmt, "test send error", ErrDeliveryTimeNotSupported, err)
+	}
+}
@@ -0,0 +1,100 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+)
+
+// maxShadowMirrors limits mirrored sends in flight, further mirrors are dropped
+const maxShadowMirrors = 64
+
+type (
+	// ShadowOutcome compares a send to the primary hub with its mirror sent to the shadow hub
+	ShadowOutcome struct {
+		Notification *Notification
+		// Target is the tag expression of tagged sends or the device handle of direct sends
+		Target     string
+		PrimaryErr error
+		ShadowErr  error
+	}
+
+	// shadowHub mirrors sends to another hub
+	shadowHub struct {
+		hub     *NotificationHub
+		compare func(ShadowOutcome)
+		// mirrors holds a slot of every mirrored send in flight
+		mirrors chan struct{}
+	}
+)
+
+// WithShadowHub mirrors notification sends of the hub to shadow, asynchronously and best effort,
+// for validating migration to a new hub or namespace before cutover. Test sends and sends stopped
+// by a kill switch are not mirrored. Mirrors are dropped while maxShadowMirrors of them are in flight,
+// counted by HubStats.ShadowDropped. compare, when not nil, is called from the mirroring goroutine
+// with outcomes of every mirrored send
+func WithShadowHub(shadow *NotificationHub, compare func(ShadowOutcome)) HubOption {
+	return func(h *NotificationHub) {
+		h.shadow = &shadowHub{hub: shadow, compare: compare, mirrors: make(chan struct{}, maxShadowMirrors)}
+	}
+}
+
+// Matches identifies whether both hubs accepted the send, or both rejected it with the same status code
+func (o ShadowOutcome) Matches() bool {
+	if o.PrimaryErr == nil || o.ShadowErr == nil {
+		return o.PrimaryErr == nil && o.ShadowErr == nil
+	}
+
+	var primaryErr, shadowErr *ResponseError
+	if errors.As(o.PrimaryErr, &primaryErr) != errors.As(o.ShadowErr, &shadowErr) {
+		return false
+	}
+
+	return primaryErr == nil || primaryErr.StatusCode == shadowErr.StatusCode
+}
+
+// mirror sends notification to the shadow hub in a new goroutine, unless the primary send was stopped
+// by a kill switch or too many mirrors are in flight. The mirrored send is detached from cancellation
+// of ctx, keeping its context tags
+func (h *NotificationHub) mirror(ctx context.Context, n *Notification, target string, primaryErr error, send func(ctx context.Context, shadow *NotificationHub) error) {
+	if errors.Is(primaryErr, ErrSendsDisabled) {
+		return
+	}
+
+	shadow := h.shadow
+	select {
+	case shadow.mirrors <- struct{}{}:
+	default:
+		h.stats.observeShadowDropped()
+		return
+	}
+
+	detached := WithContextTags(context.Background(), ContextTags(ctx)...)
+	h.goLabeled(detached, "shadow", func(ctx context.Context) {
+		defer func() { <-shadow.mirrors }()
+
+		err := send(ctx, shadow.hub)
+		if shadow.compare != nil {
+			shadow.compare(ShadowOutcome{Notification: n, Target: target, PrimaryErr: primaryErr, ShadowErr: err})
+		}
+	})
+}
+
+// mirrored returns copy of send options for the mirrored send,
+// without the result and telemetry receiver of the primary send
+func (o *sendOptions) mirrored() *sendOptions {
+	if o == nil {
+		return nil
+	}
+
+	return &sendOptions{
+		correlationID:         o.correlationID,
+		andTags:               o.andTags,
+		headers:               o.headers,
+		campaign:              o.campaign,
+		broadcastAll:          o.broadcastAll,
+		deliveryTime:          o.deliveryTime,
+		sendImmediatelyIfPast: o.sendImmediatelyIfPast,
+		retry:                 o.retry,
+		transformers:          o.transformers,
+	}
+}
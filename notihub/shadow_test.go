@@ -0,0 +1,144 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_NotificationHubShadowSends(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	shadowTags := make(chan string, 1)
+	shadow := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		shadowTags <- req.Header.Get("ServiceBusNotification-Tags") + req.Header.Get("ServiceBusNotification-DeviceHandle")
+		if req.Header.Get("ServiceBusNotification-Format") == string(AppleFormat) {
+			return nil, &ResponseError{StatusCode: http.StatusBadRequest}
+		}
+		return nil, nil
+	})
+
+	outcomes := make(chan ShadowOutcome, 1)
+	primary := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		return nil, nil
+	})
+	WithShadowHub(shadow, func(o ShadowOutcome) { outcomes <- o })(primary)
+
+	ctx, cancel := context.WithCancel(WithContextTags(context.Background(), "tenant:a"))
+	testPatterns := []struct {
+		name    string
+		send    func() error
+		target  string
+		matches bool
+	}{
+		{"tagged send", func() error {
+			_, err := primary.Send(ctx, &Notification{AndroidFormat, []byte(`{"data":{}}`)}, []string{"news"})
+			return err
+		}, "news && tenant:a", true},
+		{"direct send", func() error {
+			_, err := primary.SendDirect(ctx, &Notification{AndroidFormat, []byte(`{"data":{}}`)}, "handle")
+			return err
+		}, "handle", true},
+		{"rejected by shadow", func() error {
+			_, err := primary.Send(ctx, &Notification{AppleFormat, []byte(`{"aps":{}}`)}, []string{"news"})
+			cancel()
+			return err
+		}, "news && tenant:a", false},
+	}
+
+	for _, testData := range testPatterns {
+		if err := testData.send(); err != nil {
+			t.Errorf(errfmt, testData.name+" error", nil, err)
+			continue
+		}
+
+		select {
+		case o := <-outcomes:
+			if o.Target != testData.target || o.Matches() != testData.matches {
+				t.Errorf(errfmt, testData.name+" outcome", testData.target, o)
+			}
+			if target := <-shadowTags; target != testData.target {
+				t.Errorf(errfmt, testData.name+" shadow target", testData.target, target)
+			}
+		case <-time.After(time.Second):
+			t.Errorf(errfmt, testData.name+" outcome", "mirrored send", "timeout")
+		}
+	}
+}
+
+func Test_ShadowOutcomeMatches(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	badRequest, throttled := &ResponseError{StatusCode: http.StatusBadRequest}, &ResponseError{StatusCode: http.StatusTooManyRequests}
+	timeout := errors.New("timeout")
+
+	testPatterns := []struct {
+		outcome  ShadowOutcome
+		expected bool
+	}{
+		{ShadowOutcome{}, true},
+		{ShadowOutcome{PrimaryErr: badRequest}, false},
+		{ShadowOutcome{ShadowErr: timeout}, false},
+		{ShadowOutcome{PrimaryErr: badRequest, ShadowErr: &ResponseError{StatusCode: http.StatusBadRequest}}, true},
+		{ShadowOutcome{PrimaryErr: badRequest, ShadowErr: throttled}, false},
+		{ShadowOutcome{PrimaryErr: timeout, ShadowErr: errors.New("refused")}, true},
+		{ShadowOutcome{PrimaryErr: timeout, ShadowErr: badRequest}, false},
+	}
+
+	for i, testData := range testPatterns {
+		if matches := testData.outcome.Matches(); matches != testData.expected {
+			t.Errorf(errfmt, "outcome "+strconv.Itoa(i)+" matches", testData.expected, matches)
+		}
+	}
+}
+
+func Test_NotificationHubShadowLimits(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	release := make(chan struct{})
+	var mirrored int64
+	shadow := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		atomic.AddInt64(&mirrored, 1)
+		<-release
+		return nil, nil
+	})
+
+	killSwitch := &AtomicKillSwitch{}
+	primary := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		return nil, nil
+	})
+	primary.stats = &hubStats{}
+	WithKillSwitch(killSwitch)(primary)
+	WithShadowHub(shadow, nil)(primary)
+
+	n := &Notification{AndroidFormat, []byte(`{"data":{}}`)}
+
+	killSwitch.Engage()
+	if _, err := primary.Send(context.Background(), n, []string{"news"}); !errors.Is(err, ErrSendsDisabled) {
+		t.Errorf(errfmt, "kill switch error", ErrSendsDisabled, err)
+	}
+	killSwitch.Release()
+
+	for i := 0; i <= maxShadowMirrors; i++ {
+		if _, err := primary.Send(context.Background(), n, []string{"news"}); err != nil {
+			t.Fatalf(errfmt, "send error", nil, err)
+		}
+	}
+	if dropped := primary.Stats().ShadowDropped; dropped != 1 {
+		t.Errorf(errfmt, "dropped mirrors", 1, dropped)
+	}
+
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt64(&mirrored) < maxShadowMirrors && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if count := atomic.LoadInt64(&mirrored); count != maxShadowMirrors {
+		t.Errorf(errfmt, "mirrored sends", maxShadowMirrors, count)
+	}
+}
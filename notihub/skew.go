@@ -0,0 +1,129 @@
+package notihub
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// skewPaddingMin is the smallest expiry padding applied after a skew detection
+	skewPaddingMin = 30 * time.Second
+	// skewPaddingMax caps the expiry padding
+	skewPaddingMax = 30 * time.Minute
+	// skewPaddingHalfLife is the time it takes for the padding to decay by half
+	skewPaddingHalfLife = 15 * time.Minute
+)
+
+type (
+	// SkewStats describes clock skew observed between
+	// the local host and azure notification hub
+	SkewStats struct {
+		// Detections counts token expiry rejections attributed to clock skew
+		Detections int64
+		// LastSkew is the last observed server time minus local time.
+		// Positive value means the local clock runs behind
+		LastSkew time.Duration
+		// LastDetected is the local time of the last detection
+		LastDetected time.Time
+		// Padding is currently added to the token expiry
+		Padding time.Duration
+	}
+
+	// skewTracker keeps token expiry padding which doubles
+	// on every expiry rejection and decays exponentially over time
+	skewTracker struct {
+		mu           sync.Mutex
+		padding      time.Duration // padding at the moment of last detection
+		lastSkew     time.Duration
+		lastDetected time.Time
+		detections   int64
+	}
+)
+
+// SkewStats returns clock skew statistics of the hub
+func (h *NotificationHub) SkewStats() SkewStats {
+	return h.skew.stats(h.now())
+}
+
+// current returns token expiry padding at the moment now
+func (s *skewTracker) current(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.decayed(now)
+}
+
+// observe records token expiry rejection and widens the padding
+func (s *skewTracker) observe(now time.Time, skew time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	padding := 2 * s.decayed(now)
+	if padding < skew+skewPaddingMin {
+		padding = skew + skewPaddingMin
+	}
+	if padding > skewPaddingMax {
+		padding = skewPaddingMax
+	}
+
+	s.padding = padding
+	s.lastSkew = skew
+	s.lastDetected = now
+	s.detections++
+}
+
+func (s *skewTracker) stats(now time.Time) SkewStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return SkewStats{
+		Detections:   s.detections,
+		LastSkew:     s.lastSkew,
+		LastDetected: s.lastDetected,
+		Padding:      s.decayed(now),
+	}
+}
+
+// decayed must be called with mu held
+func (s *skewTracker) decayed(now time.Time) time.Duration {
+	if s.padding == 0 {
+		return 0
+	}
+
+	elapsed := now.Sub(s.lastDetected)
+	if elapsed <= 0 {
+		return s.padding
+	}
+
+	padding := time.Duration(float64(s.padding) * math.Exp2(-float64(elapsed)/float64(skewPaddingHalfLife)))
+	if padding < time.Second {
+		return 0
+	}
+
+	return padding
+}
+
+// isExpiryRejection identifies whether err is
+// an authorization failure caused by token expiry
+func isExpiryRejection(err error) (*ResponseError, bool) {
+	var resErr *ResponseError
+	if !errors.As(err, &resErr) || resErr.StatusCode != http.StatusUnauthorized {
+		return nil, false
+	}
+
+	return resErr, strings.Contains(strings.ToLower(string(resErr.Body)), "expir")
+}
+
+// responseClockSkew estimates clock skew from the response Date header
+func responseClockSkew(resErr *ResponseError, now time.Time) time.Duration {
+	serverTime, err := http.ParseTime(resErr.Header.Get("Date"))
+	if err != nil {
+		return 0
+	}
+
+	return serverTime.Sub(now)
+}
@@ -0,0 +1,136 @@
+package notihub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mockClock struct {
+	now time.Time
+}
+
+func (c *mockClock) Now() time.Time {
+	return c.now
+}
+
+func tokenExpiry(t *testing.T, token string) int64 {
+	params, err := url.ParseQuery(strings.TrimPrefix(token, "SharedAccessSignature "))
+	if err != nil {
+		t.Fatalf("failed to parse token %q: %s", token, err)
+	}
+
+	se, err := strconv.ParseInt(params.Get("se"), 10, 64)
+	if err != nil {
+		t.Fatalf("failed to parse token expiry %q: %s", params.Get("se"), err)
+	}
+
+	return se
+}
+
+func Test_NotificationHubSkewRetry(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+	clock := &mockClock{now: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)}
+	skew := 5 * time.Minute
+
+	var expiries []int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expiries = append(expiries, tokenExpiry(t, r.Header.Get("Authorization")))
+		if len(expiries) == 1 {
+			w.Header().Set("Date", clock.now.Add(skew).Format(http.TimeFormat))
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("ExpiredToken: The token is expired"))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(), WithClock(clock))
+
+	if _, err := hub.Send(context.Background(), &Notification{Template, []byte("{}")}, nil, BroadcastAll()); err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	if len(expiries) != 2 {
+		t.Fatalf(errfmt, "requests", 2, len(expiries))
+	}
+
+	wantPadding := skew + skewPaddingMin
+	if got := time.Duration(expiries[1]-expiries[0]) * time.Second; got != wantPadding {
+		t.Errorf(errfmt, "retried token expiry padding", wantPadding, got)
+	}
+
+	stats := hub.SkewStats()
+	if stats.Detections != 1 {
+		t.Errorf(errfmt, "Detections", 1, stats.Detections)
+	}
+	if stats.LastSkew != skew {
+		t.Errorf(errfmt, "LastSkew", skew, stats.LastSkew)
+	}
+	if stats.Padding != wantPadding {
+		t.Errorf(errfmt, "Padding", wantPadding, stats.Padding)
+	}
+}
+
+func Test_NotificationHubUnauthorizedNoRetry(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("InvalidSignature"))
+	}))
+	defer server.Close()
+
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client())
+
+	if _, err := hub.Send(context.Background(), &Notification{Template, []byte("{}")}, nil, BroadcastAll()); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	if requests != 1 {
+		t.Errorf("Expected 1 request, got: %d", requests)
+	}
+
+	if stats := hub.SkewStats(); stats.Detections != 0 {
+		t.Errorf("Expected no detections, got: %d", stats.Detections)
+	}
+}
+
+func Test_SkewTrackerDecay(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var tracker skewTracker
+	if p := tracker.current(start); p != 0 {
+		t.Errorf(errfmt, "initial padding", 0, p)
+	}
+
+	tracker.observe(start, 0)
+	if p := tracker.current(start); p != skewPaddingMin {
+		t.Errorf(errfmt, "padding after detection", skewPaddingMin, p)
+	}
+
+	tracker.observe(start, 0)
+	if p := tracker.current(start); p != 2*skewPaddingMin {
+		t.Errorf(errfmt, "doubled padding", 2*skewPaddingMin, p)
+	}
+
+	if p := tracker.current(start.Add(skewPaddingHalfLife)); p != skewPaddingMin {
+		t.Errorf(errfmt, "padding after half life", skewPaddingMin, p)
+	}
+
+	if p := tracker.current(start.Add(24 * time.Hour)); p != 0 {
+		t.Errorf(errfmt, "padding after a day", 0, p)
+	}
+
+	tracker.observe(start, time.Hour)
+	if p := tracker.current(start); p != skewPaddingMax {
+		t.Errorf(errfmt, "capped padding", skewPaddingMax, p)
+	}
+}
@@ -0,0 +1,101 @@
+package notihub
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// slowHubRecovery is the fraction of the threshold the latency average must drop below
+// for a slow hub to recover, so that hubs with latency close to the threshold do not flap
+const slowHubRecovery = 0.8
+
+type (
+	// SlowHubEvent reports hub send latency crossing the WithSlowHubDetection threshold
+	SlowHubEvent struct {
+		// Hub is the hub name
+		Hub string
+		// Slow is set when the hub became slow and cleared when it recovered
+		Slow bool
+		// Latency is the moving average of send latencies at the time of the change
+		Latency   time.Duration
+		Threshold time.Duration
+	}
+
+	// latencyTracker keeps exponentially weighted moving average of send latencies
+	latencyTracker struct {
+		hub       string
+		weight    float64
+		threshold time.Duration
+		onChange  func(SlowHubEvent)
+
+		mu      sync.Mutex
+		average time.Duration
+		slow    bool
+	}
+)
+
+// WithSlowHubDetection tracks exponentially weighted moving average of send latencies, weight being
+// the weight of the latest send, e.g. 0.2. The hub becomes slow when the average exceeds threshold
+// and recovers when it drops below 80% of threshold. onChange, when not nil, is called on every change,
+// Router sends fall back to Secondary hubs while their primary hub is slow
+func WithSlowHubDetection(threshold time.Duration, weight float64, onChange func(SlowHubEvent)) HubOption {
+	return func(h *NotificationHub) {
+		if weight <= 0 || weight > 1 {
+			weight = asyncLatencyWeight
+		}
+		h.latency = &latencyTracker{hub: strings.TrimPrefix(h.hubURL.Path, "/"), weight: weight, threshold: threshold, onChange: onChange}
+	}
+}
+
+// Latency returns the moving average of send latencies, zero without WithSlowHubDetection
+func (h *NotificationHub) Latency() time.Duration {
+	if h.latency == nil {
+		return 0
+	}
+
+	h.latency.mu.Lock()
+	defer h.latency.mu.Unlock()
+
+	return h.latency.average
+}
+
+// IsSlow reports whether the moving average of send latencies exceeded the WithSlowHubDetection threshold
+func (h *NotificationHub) IsSlow() bool {
+	if h.latency == nil {
+		return false
+	}
+
+	h.latency.mu.Lock()
+	defer h.latency.mu.Unlock()
+
+	return h.latency.slow
+}
+
+// observe updates the moving average with send latency and reports slow state changes
+func (t *latencyTracker) observe(latency time.Duration) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	if t.average == 0 {
+		t.average = latency
+	} else {
+		t.average = time.Duration(float64(t.average)*(1-t.weight) + float64(latency)*t.weight)
+	}
+
+	changed := false
+	switch {
+	case !t.slow && t.average > t.threshold:
+		t.slow, changed = true, true
+	case t.slow && float64(t.average) < float64(t.threshold)*slowHubRecovery:
+		t.slow, changed = false, true
+	}
+	event := SlowHubEvent{Hub: t.hub, Slow: t.slow, Latency: t.average, Threshold: t.threshold}
+	t.mu.Unlock()
+
+	if changed && t.onChange != nil {
+		t.onChange(event)
+	}
+}
@@ -0,0 +1,82 @@
+package notihub
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_NotificationHubSlowHubDetection(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var events []SlowHubEvent
+	nhub := newBulkTestHub(nil)
+	WithSlowHubDetection(100*time.Millisecond, 0.5, func(event SlowHubEvent) {
+		events = append(events, event)
+	})(nhub)
+
+	tests := []struct {
+		latency  time.Duration
+		expected time.Duration
+		slow     bool
+	}{
+		{50 * time.Millisecond, 50 * time.Millisecond, false},
+		{200 * time.Millisecond, 125 * time.Millisecond, true},
+		{100 * time.Millisecond, 112500 * time.Microsecond, true},
+		{50 * time.Millisecond, 81250 * time.Microsecond, true},
+		{40 * time.Millisecond, 60625 * time.Microsecond, false},
+	}
+
+	for _, test := range tests {
+		nhub.latency.observe(test.latency)
+		if latency, slow := nhub.Latency(), nhub.IsSlow(); latency != test.expected || slow != test.slow {
+			t.Errorf(errfmt, "latency and slowness", []interface{}{test.expected, test.slow}, []interface{}{latency, slow})
+		}
+	}
+
+	expected := []SlowHubEvent{
+		{Hub: "testPath", Slow: true, Latency: 125 * time.Millisecond, Threshold: 100 * time.Millisecond},
+		{Hub: "testPath", Slow: false, Latency: 60625 * time.Microsecond, Threshold: 100 * time.Millisecond},
+	}
+	if len(events) != len(expected) || events[0] != expected[0] || events[1] != expected[1] {
+		t.Errorf(errfmt, "events", expected, events)
+	}
+
+	if untracked := newBulkTestHub(nil); untracked.Latency() != 0 || untracked.IsSlow() {
+		t.Errorf(errfmt, "untracked hub", "not slow", untracked.Latency())
+	}
+}
+
+func Test_RouterSecondaryHub(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	primary, secondary := newBulkTestHub(nil), newBulkTestHub(nil)
+	WithSlowHubDetection(time.Second, 1, nil)(primary)
+	WithSlowHubDetection(time.Second, 1, nil)(secondary)
+	router := &Router{
+		Hubs:      map[string]*NotificationHub{"eu": primary},
+		Secondary: map[string]*NotificationHub{"eu": secondary},
+	}
+
+	route := func() *NotificationHub {
+		h, err := router.Route(context.Background(), []string{"news"}, WithResidency("eu"))
+		if err != nil {
+			t.Fatalf(errfmt, "route error", nil, err)
+		}
+		return h
+	}
+
+	if route() != primary {
+		t.Errorf(errfmt, "hub", "primary", "secondary")
+	}
+
+	primary.latency.observe(2 * time.Second)
+	if route() != secondary {
+		t.Errorf(errfmt, "hub of slow primary", "secondary", "primary")
+	}
+
+	secondary.latency.observe(2 * time.Second)
+	if route() != primary {
+		t.Errorf(errfmt, "hub of slow primary and secondary", "primary", "secondary")
+	}
+}
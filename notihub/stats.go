@@ -0,0 +1,178 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Failure classes of HubStats
+const (
+	FailureThrottled FailureClass = "throttled"
+	FailureClient    FailureClass = "client"
+	FailureServer    FailureClass = "server"
+	FailureTimeout   FailureClass = "timeout"
+	FailureNetwork   FailureClass = "network"
+	FailureCanceled  FailureClass = "canceled"
+	FailureOther     FailureClass = "other"
+)
+
+// failureClasses lists failure classes in the order of hubStats.failures
+var failureClasses = [...]FailureClass{
+	FailureThrottled,
+	FailureClient,
+	FailureServer,
+	FailureTimeout,
+	FailureNetwork,
+	FailureCanceled,
+	FailureOther,
+}
+
+type (
+	// FailureClass groups failed sends by cause
+	FailureClass string
+
+	// HubStats is a snapshot of hub client counters, for health introspection
+	// without metrics integration. Counters start at hub creation
+	HubStats struct {
+		// Sends counts notification sends, successful or not
+		Sends int64
+		// Failures counts failed sends per failure class, classes without failures are omitted
+		Failures map[FailureClass]int64
+		// Retries counts repeated attempts of all hub requests
+		Retries int64
+		// AverageLatency of sends, including retries
+		AverageLatency time.Duration
+		// TokensMinted counts generated SAS tokens
+		TokensMinted int64
+		// Transactional summarizes latencies of recent SendTransactional sends
+		Transactional LatencyPercentiles
+		// Async is the current SendAsync load
+		Async AsyncStats
+		// OptedOut counts users left out of sends by Preferences
+		OptedOut int64
+		// ShadowDropped counts sends not mirrored to the shadow hub, see WithShadowHub
+		ShadowDropped int64
+	}
+
+	// hubStats holds counters updated atomically, int64 fields come first to keep them aligned
+	hubStats struct {
+		sends        int64
+		latency      int64
+		retries      int64
+		tokensMinted int64
+		// asyncInFlight counts running async sends, asyncLatency is moving average of their durations
+		asyncInFlight int64
+		asyncLatency  int64
+		optedOut      int64
+		shadowDropped int64
+		failures      [len(failureClasses)]int64
+
+		transactional latencyWindow
+	}
+)
+
+// Stats returns snapshot of the hub counters, safe for concurrent use.
+// Hubs not created by NewNotificationHub report no counters
+func (h *NotificationHub) Stats() HubStats {
+	stats := HubStats{Failures: map[FailureClass]int64{}, Async: h.asyncStats()}
+	if h.stats == nil {
+		return stats
+	}
+
+	stats.Sends = atomic.LoadInt64(&h.stats.sends)
+	stats.Retries = atomic.LoadInt64(&h.stats.retries)
+	stats.TokensMinted = atomic.LoadInt64(&h.stats.tokensMinted)
+	stats.OptedOut = atomic.LoadInt64(&h.stats.optedOut)
+	stats.ShadowDropped = atomic.LoadInt64(&h.stats.shadowDropped)
+	if stats.Sends > 0 {
+		stats.AverageLatency = time.Duration(atomic.LoadInt64(&h.stats.latency) / stats.Sends)
+	}
+
+	stats.Transactional = h.stats.transactional.percentiles()
+
+	for i, class := range failureClasses {
+		if count := atomic.LoadInt64(&h.stats.failures[i]); count > 0 {
+			stats.Failures[class] = count
+		}
+	}
+
+	return stats
+}
+
+// observeSend counts send completed after latency with err
+func (s *hubStats) observeSend(latency time.Duration, err error) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddInt64(&s.sends, 1)
+	atomic.AddInt64(&s.latency, int64(latency))
+	if err == nil {
+		return
+	}
+
+	class := classifyFailure(err)
+	for i := range failureClasses {
+		if failureClasses[i] == class {
+			atomic.AddInt64(&s.failures[i], 1)
+		}
+	}
+}
+
+// observeRetry counts repeated request attempt
+func (s *hubStats) observeRetry() {
+	if s != nil {
+		atomic.AddInt64(&s.retries, 1)
+	}
+}
+
+// observeToken counts generated SAS token
+func (s *hubStats) observeToken() {
+	if s != nil {
+		atomic.AddInt64(&s.tokensMinted, 1)
+	}
+}
+
+// observeShadowDropped counts send not mirrored to the shadow hub
+func (s *hubStats) observeShadowDropped() {
+	if s != nil {
+		atomic.AddInt64(&s.shadowDropped, 1)
+	}
+}
+
+// classifyFailure returns failure class of send error
+func classifyFailure(err error) FailureClass {
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) || errors.Is(err, context.DeadlineExceeded) {
+		return FailureTimeout
+	}
+	if errors.Is(err, context.Canceled) {
+		return FailureCanceled
+	}
+
+	var resErr *ResponseError
+	if errors.As(err, &resErr) {
+		switch {
+		case resErr.StatusCode == http.StatusTooManyRequests:
+			return FailureThrottled
+		case resErr.StatusCode >= 500:
+			return FailureServer
+		default:
+			return FailureClient
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return FailureTimeout
+		}
+		return FailureNetwork
+	}
+
+	return FailureOther
+}
@@ -0,0 +1,83 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_NotificationHubStats(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var throttled sync.Once
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("X-Case") {
+		case "throttled":
+			retried := true
+			throttled.Do(func() { retried = false })
+			if !retried {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+		case "invalid":
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	nhub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(),
+		WithRetry(RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}))
+
+	n := &Notification{AndroidFormat, []byte(`{"data":{}}`)}
+	var wg sync.WaitGroup
+	for _, c := range []string{"ok", "ok", "throttled", "invalid"} {
+		wg.Add(1)
+		go func(c string) {
+			defer wg.Done()
+			nhub.Send(context.Background(), n, []string{"news"}, WithHeaders(map[string]string{"X-Case": c}))
+		}(c)
+	}
+	wg.Wait()
+
+	stats := nhub.Stats()
+	if stats.Sends != 4 || stats.Failures[FailureClient] != 1 || len(stats.Failures) != 1 {
+		t.Errorf(errfmt, "sends and failures", "4 sends, 1 client failure", stats)
+	}
+	if stats.Retries != 1 || stats.TokensMinted != 5 || stats.AverageLatency <= 0 {
+		t.Errorf(errfmt, "retries and tokens minted", "1 retry, 5 tokens", stats)
+	}
+
+	if empty := (&NotificationHub{}).Stats(); empty.Sends != 0 || empty.Failures == nil {
+		t.Errorf(errfmt, "stats of hub literal", HubStats{Failures: map[FailureClass]int64{}}, empty)
+	}
+}
+
+func Test_ClassifyFailure(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	testPatterns := []struct {
+		err      error
+		expected FailureClass
+	}{
+		{&ResponseError{StatusCode: http.StatusTooManyRequests}, FailureThrottled},
+		{&ResponseError{StatusCode: http.StatusNotFound}, FailureClient},
+		{&ResponseError{StatusCode: http.StatusBadGateway}, FailureServer},
+		{&TimeoutError{Phase: PhaseTotal}, FailureTimeout},
+		{context.Canceled, FailureCanceled},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, FailureNetwork},
+		{errors.New("unknown"), FailureOther},
+	}
+
+	for _, testData := range testPatterns {
+		if class := classifyFailure(testData.err); class != testData.expected {
+			t.Errorf(errfmt, testData.err.Error()+" class", testData.expected, class)
+		}
+	}
+}
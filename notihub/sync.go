@@ -0,0 +1,323 @@
+package notihub
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultSyncBatchSize = 10
+
+// tombstoneTagPrefix marks installations removed in tombstone mode,
+// the tag value is the removal time
+const (
+	tombstoneTagPrefix  = "tombstone:"
+	tombstoneTimeFormat = "20060102T150405Z"
+)
+
+const (
+	SyncCreate    SyncAction = "create"
+	SyncUpdate    SyncAction = "update"
+	SyncDelete    SyncAction = "delete"
+	SyncTombstone SyncAction = "tombstone"
+	SyncUnchanged SyncAction = "unchanged"
+)
+
+type (
+	// SyncAction is a change Sync applies to a hub installation
+	SyncAction string
+
+	// SyncOptions configures Sync
+	SyncOptions struct {
+		// Existing lists ids of installations present in the hub.
+		// Those missing from the desired set are deleted
+		Existing []string
+		// BatchSize limits the number of installations reconciled concurrently, 10 by default
+		BatchSize int
+		// BatchInterval is the minimum time between starts of consecutive batches
+		BatchInterval time.Duration
+		// DryRun computes changes without applying them
+		DryRun bool
+		// GracePeriod enables tombstone mode: installations to delete are first untagged, so they no
+		// longer receive tagged sends, though sends to all devices still reach them. They are deleted
+		// by a Sync run after the period, which requires their ids to stay in Existing, and expire
+		// in the hub at the end of the period otherwise. Tombstoned installations still desired
+		// are restored by the next Sync
+		GracePeriod time.Duration
+	}
+
+	// SyncChange is the outcome of reconciling a single installation.
+	// Err is set when the change could not be determined or applied
+	SyncChange struct {
+		InstallationId string
+		Action         SyncAction
+		Err            error
+	}
+
+	// SyncReport lists changes made by Sync in the order of
+	// desired installations followed by deletions
+	SyncReport struct {
+		DryRun  bool
+		Changes []SyncChange
+	}
+
+	// syncItem is a single installation to reconcile,
+	// desired is nil for installations to delete
+	syncItem struct {
+		installationID string
+		desired        *Installation
+	}
+)
+
+// Sync reconciles hub installations with the desired set: missing installations
+// are created, differing ones are updated and opts.Existing ones not desired are deleted.
+// Report is returned even when some of the changes fail
+func (h *NotificationHub) Sync(ctx context.Context, desired []Installation, opts SyncOptions) (*SyncReport, error) {
+	items, err := syncItems(desired, opts.Existing)
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.Sync: %w", err)
+	}
+
+	report := &SyncReport{DryRun: opts.DryRun}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultSyncBatchSize
+	}
+
+	var lastBatch time.Time
+	for start := 0; start < len(items); start += batchSize {
+		if start > 0 {
+			if err := sleepContext(ctx, opts.BatchInterval-time.Since(lastBatch)); err != nil {
+				return report, fmt.Errorf("NotificationHub.Sync: %w", err)
+			}
+		}
+		lastBatch = time.Now()
+
+		end := start + batchSize
+		if end > len(items) {
+			end = len(items)
+		}
+		report.Changes = append(report.Changes, h.syncBatch(ctx, items[start:end], opts)...)
+	}
+
+	if failed := report.Failed(); len(failed) > 0 {
+		return report, fmt.Errorf("NotificationHub.Sync: %d of %d changes failed, first: %s: %w", len(failed), len(report.Changes), failed[0].InstallationId, failed[0].Err)
+	}
+
+	return report, nil
+}
+
+// Count returns the number of changes with action
+func (r *SyncReport) Count(action SyncAction) int {
+	count := 0
+	for _, change := range r.Changes {
+		if change.Action == action {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Failed returns changes which could not be determined or applied
+func (r *SyncReport) Failed() []SyncChange {
+	var failed []SyncChange
+	for _, change := range r.Changes {
+		if change.Err != nil {
+			failed = append(failed, change)
+		}
+	}
+
+	return failed
+}
+
+// syncItems validates desired installations and lists items to reconcile
+func syncItems(desired []Installation, existing []string) ([]syncItem, error) {
+	items := make([]syncItem, 0, len(desired)+len(existing))
+	desiredIDs := make(map[string]bool, len(desired))
+
+	for i := range desired {
+		id := desired[i].InstallationId
+		if id == "" {
+			return nil, fmt.Errorf("desired installation %d has no id", i)
+		}
+		if desiredIDs[id] {
+			return nil, fmt.Errorf("duplicate desired installation '%s'", id)
+		}
+		desiredIDs[id] = true
+		items = append(items, syncItem{installationID: id, desired: &desired[i]})
+	}
+
+	for _, id := range existing {
+		if !desiredIDs[id] {
+			desiredIDs[id] = true
+			items = append(items, syncItem{installationID: id})
+		}
+	}
+
+	return items, nil
+}
+
+// syncBatch reconciles items concurrently
+func (h *NotificationHub) syncBatch(ctx context.Context, items []syncItem, opts SyncOptions) []SyncChange {
+	changes := make([]SyncChange, len(items))
+
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		i, item := i, item
+		h.goLabeled(ctx, "sync", func(ctx context.Context) {
+			defer wg.Done()
+			changes[i] = h.syncInstallation(ctx, item, opts)
+		})
+	}
+	wg.Wait()
+
+	return changes
+}
+
+// syncInstallation reconciles a single installation
+func (h *NotificationHub) syncInstallation(ctx context.Context, item syncItem, opts SyncOptions) SyncChange {
+	change := SyncChange{InstallationId: item.installationID}
+
+	if item.desired == nil && opts.GracePeriod > 0 {
+		return h.tombstoneInstallation(ctx, item.installationID, opts)
+	}
+
+	if item.desired == nil {
+		change.Action = SyncDelete
+		if !opts.DryRun {
+			if err := h.deleteInstallation(ctx, item.installationID); err != nil && !isNotFoundError(err) {
+				change.Err = err
+			}
+		}
+		return change
+	}
+
+	current, err := h.getInstallation(ctx, item.installationID)
+	switch {
+	case isNotFoundError(err):
+		change.Action = SyncCreate
+	case err != nil:
+		change.Action = SyncUpdate
+		change.Err = err
+		return change
+	case installationsEqual(item.desired, current):
+		change.Action = SyncUnchanged
+		return change
+	default:
+		change.Action = SyncUpdate
+	}
+
+	if !opts.DryRun {
+		change.Err = h.putInstallation(ctx, item.desired)
+	}
+
+	return change
+}
+
+// tombstoneInstallation untags installation to delete and makes it expire at the end of the grace period,
+// deleting it once tombstoned for longer than the period
+func (h *NotificationHub) tombstoneInstallation(ctx context.Context, installationID string, opts SyncOptions) SyncChange {
+	change := SyncChange{InstallationId: installationID}
+
+	current, err := h.getInstallation(ctx, installationID)
+	switch {
+	case isNotFoundError(err):
+		change.Action = SyncUnchanged
+		return change
+	case err != nil:
+		change.Action = SyncTombstone
+		change.Err = err
+		return change
+	}
+
+	now := h.now()
+	if tombstoned, ok := tombstoneTime(current); ok {
+		if now.Sub(tombstoned) < opts.GracePeriod {
+			change.Action = SyncUnchanged
+			return change
+		}
+
+		change.Action = SyncDelete
+		if !opts.DryRun {
+			if err := h.deleteInstallation(ctx, installationID); err != nil && !isNotFoundError(err) {
+				change.Err = err
+			}
+		}
+		return change
+	}
+
+	change.Action = SyncTombstone
+	if !opts.DryRun {
+		current.Tags = []string{tombstoneTagPrefix + now.UTC().Format(tombstoneTimeFormat)}
+		if expires := now.Add(opts.GracePeriod); current.ExpirationTime == nil || current.ExpirationTime.After(expires) {
+			current.ExpirationTime = &expires
+		}
+		for name, template := range current.Templates {
+			template.Tags = nil
+			current.Templates[name] = template
+		}
+		change.Err = h.putInstallation(ctx, current)
+	}
+
+	return change
+}
+
+// tombstoneTime returns the time installation was tombstoned
+func tombstoneTime(installation *Installation) (time.Time, bool) {
+	for _, tag := range installation.Tags {
+		if !strings.HasPrefix(tag, tombstoneTagPrefix) {
+			continue
+		}
+		if t, err := time.Parse(tombstoneTimeFormat, strings.TrimPrefix(tag, tombstoneTagPrefix)); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// installationsEqual compares installation fields managed by the client
+func installationsEqual(a, b *Installation) bool {
+	if a.InstallationId != b.InstallationId ||
+		a.UserId != b.UserId ||
+		a.Platform != b.Platform ||
+		a.PushChannel != b.PushChannel ||
+		!tagSetsEqual(a.Tags, b.Tags) ||
+		len(a.Templates) != len(b.Templates) {
+		return false
+	}
+
+	for name, at := range a.Templates {
+		bt, ok := b.Templates[name]
+		if !ok ||
+			at.Body != bt.Body ||
+			at.Expiry != bt.Expiry ||
+			!tagSetsEqual(at.Tags, bt.Tags) ||
+			!(len(at.Headers) == 0 && len(bt.Headers) == 0 || reflect.DeepEqual(at.Headers, bt.Headers)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// tagSetsEqual compares tags ignoring order
+func tagSetsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	as := append([]string(nil), a...)
+	bs := append([]string(nil), b...)
+	sort.Strings(as)
+	sort.Strings(bs)
+
+	return reflect.DeepEqual(as, bs)
+}
@@ -0,0 +1,219 @@
+package notihub
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// installationServer is an in-memory installations API
+type installationServer struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	installations map[string]Installation
+	writes        int
+}
+
+func newInstallationServer(installations ...Installation) *installationServer {
+	s := &installationServer{installations: map[string]Installation{}}
+	for _, installation := range installations {
+		s.installations[installation.InstallationId] = installation
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+func (s *installationServer) hub(opts ...HubOption) *NotificationHub {
+	return NewNotificationHub("Endpoint="+s.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", s.Client(), opts...)
+}
+
+func (s *installationServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !strings.HasPrefix(r.URL.Path, "/hub/installations/") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id := path.Base(r.URL.Path)
+
+	switch r.Method {
+	case "GET":
+		installation, ok := s.installations[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(installation)
+	case "PUT":
+		var installation Installation
+		b, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &installation); err != nil || installation.InstallationId != id {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.writes++
+		s.installations[id] = installation
+	case "DELETE":
+		s.writes++
+		delete(s.installations, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func Test_NotificationHubSync(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newInstallationServer(
+		Installation{InstallationId: "unchanged", Platform: "apns", PushChannel: "token-1", Tags: []string{"a", "b"}},
+		Installation{InstallationId: "updated", Platform: "apns", PushChannel: "old-token"},
+		Installation{InstallationId: "deleted", Platform: "gcm", PushChannel: "token-3"},
+	)
+	defer server.Close()
+
+	desired := []Installation{
+		{InstallationId: "unchanged", Platform: "apns", PushChannel: "token-1", Tags: []string{"b", "a"}},
+		{InstallationId: "updated", Platform: "apns", PushChannel: "new-token"},
+		{InstallationId: "created", Platform: "wns", PushChannel: "https://channel"},
+	}
+
+	expectedChanges := []SyncChange{
+		{InstallationId: "unchanged", Action: SyncUnchanged},
+		{InstallationId: "updated", Action: SyncUpdate},
+		{InstallationId: "created", Action: SyncCreate},
+		{InstallationId: "deleted", Action: SyncDelete},
+	}
+
+	hub := server.hub()
+	opts := SyncOptions{Existing: []string{"unchanged", "deleted"}, BatchSize: 2, DryRun: true}
+
+	report, err := hub.Sync(context.Background(), desired, opts)
+	if err != nil {
+		t.Fatalf(errfmt, "dry run error", nil, err)
+	}
+
+	if !reflect.DeepEqual(report.Changes, expectedChanges) {
+		t.Errorf(errfmt, "dry run changes", expectedChanges, report.Changes)
+	}
+
+	if server.writes != 0 {
+		t.Errorf(errfmt, "dry run writes", 0, server.writes)
+	}
+
+	opts.DryRun = false
+	report, err = hub.Sync(context.Background(), desired, opts)
+	if err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	if !reflect.DeepEqual(report.Changes, expectedChanges) {
+		t.Errorf(errfmt, "changes", expectedChanges, report.Changes)
+	}
+
+	if report.Count(SyncUnchanged) != 1 || report.Count(SyncDelete) != 1 {
+		t.Errorf(errfmt, "change counts", "1 unchanged, 1 deleted", report.Changes)
+	}
+
+	if _, ok := server.installations["deleted"]; ok {
+		t.Error("Expected installation 'deleted' to be deleted")
+	}
+
+	if got := server.installations["updated"].PushChannel; got != "new-token" {
+		t.Errorf(errfmt, "updated push channel", "new-token", got)
+	}
+
+	if _, ok := server.installations["created"]; !ok {
+		t.Error("Expected installation 'created' to be created")
+	}
+}
+
+func Test_NotificationHubSyncInvalidDesired(t *testing.T) {
+	hub := &NotificationHub{}
+
+	testCases := [][]Installation{
+		{{InstallationId: ""}},
+		{{InstallationId: "a"}, {InstallationId: "a"}},
+	}
+
+	for i, desired := range testCases {
+		if _, err := hub.Sync(context.Background(), desired, SyncOptions{}); err == nil {
+			t.Errorf("test case %d. Expected error, got nil", i)
+		}
+	}
+}
+
+func Test_NotificationHubSyncTombstone(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newInstallationServer(
+		Installation{InstallationId: "kept", Platform: PlatformApns, PushChannel: "token-1", Tags: []string{"a"}},
+		Installation{InstallationId: "removed", Platform: PlatformApns, PushChannel: "token-2", Tags: []string{"a"},
+			Templates: map[string]InstallationTemplate{"t": {Body: "{}", Tags: []string{"b"}}}},
+	)
+	defer server.Close()
+
+	clock := &mockClock{now: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)}
+	hub := server.hub(WithClock(clock))
+
+	desired := []Installation{{InstallationId: "kept", Platform: PlatformApns, PushChannel: "token-1", Tags: []string{"a"}}}
+	opts := SyncOptions{Existing: []string{"kept", "removed", "missing"}, GracePeriod: time.Hour}
+
+	steps := []struct {
+		name    string
+		after   time.Duration
+		desired []Installation
+		action  SyncAction
+		tags    []string
+	}{
+		{"tombstone", 0, desired, SyncTombstone, []string{"tombstone:20200101T120000Z"}},
+		{"within grace period", 30 * time.Minute, desired, SyncUnchanged, []string{"tombstone:20200101T120000Z"}},
+		{"rollback", 0, append(desired, server.installations["removed"]), SyncUpdate, []string{"a"}},
+		{"tombstone again", 0, desired, SyncTombstone, []string{"tombstone:20200101T123000Z"}},
+		{"delete after grace period", time.Hour, desired, SyncDelete, nil},
+	}
+
+	for _, step := range steps {
+		clock.now = clock.now.Add(step.after)
+
+		report, err := hub.Sync(context.Background(), step.desired, opts)
+		if err != nil {
+			t.Fatalf(errfmt, step.name+" error", nil, err)
+		}
+
+		var change SyncChange
+		for _, c := range report.Changes {
+			if c.InstallationId == "removed" {
+				change = c
+			}
+		}
+		if change.Action != step.action {
+			t.Errorf(errfmt, step.name+" action", step.action, change.Action)
+		}
+
+		if got := server.installations["removed"].Tags; !reflect.DeepEqual(got, step.tags) {
+			t.Errorf(errfmt, step.name+" tags", step.tags, got)
+		}
+		if template := server.installations["removed"].Templates["t"]; step.action == SyncTombstone && len(template.Tags) != 0 {
+			t.Errorf(errfmt, step.name+" template tags", "none", template.Tags)
+		}
+		if expires := server.installations["removed"].ExpirationTime; step.action == SyncTombstone && (expires == nil || !expires.Equal(clock.now.Add(time.Hour))) {
+			t.Errorf(errfmt, step.name+" expiration", clock.now.Add(time.Hour), expires)
+		}
+	}
+
+	if _, ok := server.installations["removed"]; ok {
+		t.Error("Expected installation 'removed' to be deleted")
+	}
+}
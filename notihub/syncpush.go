@@ -0,0 +1,180 @@
+package notihub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultSyncPushInterval is the minimum time between silent pushes to a device
+const DefaultSyncPushInterval = 30 * time.Second
+
+type (
+	// SyncPushOptions configures SyncPushCoordinator
+	SyncPushOptions struct {
+		// Interval is the minimum time between silent pushes to a device, DefaultSyncPushInterval by default
+		Interval time.Duration
+		// Notifications are sent to the device, SilentSyncNotifications by default
+		Notifications []*Notification
+		// DeviceTag maps device id to the tag of the device, "$InstallationId:{id}" by default
+		DeviceTag func(deviceID string) string
+		// OnPush is called with the outcome of every push
+		OnPush      func(deviceID string, results []PlatformResult, err error)
+		SendOptions []SendOption
+	}
+
+	// SyncPushCoordinator collapses backend change events into
+	// at most one silent push per device per interval
+	SyncPushCoordinator struct {
+		hub  *NotificationHub
+		ctx  context.Context
+		opts SyncPushOptions
+
+		mu      sync.Mutex
+		devices map[string]*syncPushDevice
+		swept   time.Time
+		closed  bool
+		pushes  sync.WaitGroup
+	}
+
+	// syncPushDevice tracks pushes of a single device,
+	// timer is set while a collapsed push is pending
+	syncPushDevice struct {
+		lastPush time.Time
+		timer    *time.Timer
+	}
+)
+
+// SilentSyncNotifications returns background notifications
+// waking apple and android apps to sync data
+func SilentSyncNotifications() []*Notification {
+	return []*Notification{
+		{Format: AppleFormat, Payload: []byte(`{"aps":{"content-available":1}}`)},
+		{Format: AndroidFormat, Payload: []byte(`{"data":{"sync":"1"}}`)},
+	}
+}
+
+// NewSyncPushCoordinator initializes and returns SyncPushCoordinator pointer.
+// Pushes are sent with ctx, cancelling it stops pending pushes
+func NewSyncPushCoordinator(ctx context.Context, hub *NotificationHub, opts SyncPushOptions) *SyncPushCoordinator {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultSyncPushInterval
+	}
+	if opts.Notifications == nil {
+		opts.Notifications = SilentSyncNotifications()
+	}
+	if opts.DeviceTag == nil {
+		opts.DeviceTag = func(deviceID string) string {
+			return "$InstallationId:{" + deviceID + "}"
+		}
+	}
+
+	return &SyncPushCoordinator{hub: hub, ctx: ctx, opts: opts, devices: map[string]*syncPushDevice{}}
+}
+
+// Notify records data changes of devices. A device not pushed within the interval by the hub clock
+// is pushed right away, otherwise a single push is scheduled for the end of the interval
+func (c *SyncPushCoordinator) Notify(deviceIDs ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	now := c.hub.now()
+	c.sweep(now)
+	for _, id := range deviceIDs {
+		device, ok := c.devices[id]
+		if !ok {
+			device = &syncPushDevice{}
+			c.devices[id] = device
+		}
+
+		if device.timer != nil {
+			continue
+		}
+
+		wait := c.opts.Interval - now.Sub(device.lastPush)
+		if !ok || wait <= 0 {
+			device.lastPush = now
+			c.push(id)
+			continue
+		}
+
+		id := id
+		device.timer = time.AfterFunc(wait, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			if c.closed {
+				return
+			}
+			device.timer = nil
+			device.lastPush = c.hub.now()
+			c.push(id)
+		})
+	}
+}
+
+// Pending returns the number of devices with a scheduled push
+func (c *SyncPushCoordinator) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending := 0
+	for _, device := range c.devices {
+		if device.timer != nil {
+			pending++
+		}
+	}
+
+	return pending
+}
+
+// Close drops scheduled pushes and waits for pushes in flight
+func (c *SyncPushCoordinator) Close() {
+	c.mu.Lock()
+	c.closed = true
+	for _, device := range c.devices {
+		if device.timer != nil {
+			device.timer.Stop()
+			device.timer = nil
+		}
+	}
+	c.mu.Unlock()
+
+	c.pushes.Wait()
+}
+
+// sweep drops devices without pending push not pushed within the interval, which are pushed
+// right away on the next change like unknown devices. Devices are swept at most once per interval, c.mu must be held
+func (c *SyncPushCoordinator) sweep(now time.Time) {
+	if now.Sub(c.swept) < c.opts.Interval {
+		return
+	}
+	c.swept = now
+
+	for id, device := range c.devices {
+		if device.timer == nil && now.Sub(device.lastPush) >= c.opts.Interval {
+			delete(c.devices, id)
+		}
+	}
+}
+
+// push sends notifications to the device in background, c.mu must be held
+func (c *SyncPushCoordinator) push(deviceID string) {
+	c.pushes.Add(1)
+	c.hub.goLabeled(c.ctx, "syncpush", func(ctx context.Context) {
+		defer c.pushes.Done()
+
+		results, err := c.hub.Broadcast(ctx, c.opts.Notifications, []string{c.opts.DeviceTag(deviceID)}, c.opts.SendOptions...)
+		if err != nil {
+			err = fmt.Errorf("SyncPushCoordinator: device '%s': %w", deviceID, err)
+		}
+		if c.opts.OnPush != nil {
+			c.opts.OnPush(deviceID, results, err)
+		}
+	})
+}
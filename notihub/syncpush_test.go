@@ -0,0 +1,137 @@
+package notihub
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_SyncPushCoordinator(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var (
+		mu     sync.Mutex
+		pushes = map[string]int{}
+	)
+	hub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		pushes[req.Header.Get("ServiceBusNotification-Tags")]++
+		return nil, nil
+	})
+
+	done := make(chan string, 10)
+	coordinator := NewSyncPushCoordinator(context.Background(), hub, SyncPushOptions{
+		Interval:      50 * time.Millisecond,
+		Notifications: SilentSyncNotifications()[:1],
+		OnPush: func(deviceID string, results []PlatformResult, err error) {
+			if err != nil {
+				t.Errorf(errfmt, "push error", nil, err)
+			}
+			done <- deviceID
+		},
+	})
+
+	coordinator.Notify("a", "b")
+	coordinator.Notify("a", "a", "a")
+	coordinator.Notify("b")
+
+	if pending := coordinator.Pending(); pending != 2 {
+		t.Errorf(errfmt, "pending devices", 2, pending)
+	}
+
+	for i := 0; i < 4; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf(errfmt, "pushes", 4, i)
+		}
+	}
+
+	mu.Lock()
+	if pushes["$InstallationId:{a}"] != 2 || pushes["$InstallationId:{b}"] != 2 {
+		t.Errorf(errfmt, "pushes per device", 2, pushes)
+	}
+	mu.Unlock()
+
+	coordinator.Notify("a")
+	coordinator.Close()
+	for len(done) > 0 {
+		<-done
+	}
+	coordinator.Notify("b")
+
+	if pending := coordinator.Pending(); pending != 0 {
+		t.Errorf(errfmt, "pending devices after close", 0, pending)
+	}
+	select {
+	case id := <-done:
+		t.Errorf(errfmt, "push after close", "none", id)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func Test_SyncPushCoordinatorSweep(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	hub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		return nil, nil
+	})
+	clock := &mockClock{now: time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)}
+	WithClock(clock)(hub)
+
+	pushed := make(chan string, 10)
+	coordinator := NewSyncPushCoordinator(context.Background(), hub, SyncPushOptions{
+		Interval: time.Minute,
+		OnPush: func(deviceID string, results []PlatformResult, err error) {
+			pushed <- deviceID
+		},
+	})
+	defer coordinator.Close()
+
+	// the clock is advanced once pushes, which read it, completed
+	wait := func(pushes int) {
+		for i := 0; i < pushes; i++ {
+			select {
+			case <-pushed:
+			case <-time.After(time.Second):
+				t.Fatalf(errfmt, "pushes", pushes, i)
+			}
+		}
+	}
+
+	coordinator.Notify("a", "b")
+	wait(2)
+	clock.now = clock.now.Add(30 * time.Second)
+	coordinator.Notify("c")
+	wait(1)
+	if len(coordinator.devices) != 3 {
+		t.Errorf(errfmt, "devices within interval", 3, len(coordinator.devices))
+	}
+
+	clock.now = clock.now.Add(40 * time.Second)
+	coordinator.Notify("d")
+	wait(1)
+	if _, ok := coordinator.devices["a"]; ok || len(coordinator.devices) != 2 {
+		t.Errorf(errfmt, "devices after interval", "c and d", coordinator.devices)
+	}
+	if pending := coordinator.Pending(); pending != 0 {
+		t.Errorf(errfmt, "pending devices", 0, pending)
+	}
+}
+
+func Test_SilentSyncNotifications(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	hub := newBulkTestHub(nil)
+	for _, n := range SilentSyncNotifications() {
+		if n.Format == AppleFormat {
+			if pushType := hub.notificationHeaders(n)["X-Apns-Push-Type"]; pushType != "background" {
+				t.Errorf(errfmt, "apple push type", "background", pushType)
+			}
+		}
+	}
+}
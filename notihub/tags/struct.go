@@ -0,0 +1,152 @@
+package tags
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// structTagKey is the struct field tag key naming the namespace of field tags
+const structTagKey = "nhtag"
+
+// FromStruct returns tags of the fields of struct v annotated with the namespace of their tags:
+//
+//	type User struct {
+//		ID     string   `nhtag:"user"`
+//		Plan   string   `nhtag:"plan,lower"`
+//		Topics []string `nhtag:"topic,lower"`
+//	}
+//
+// gives "user:42", "plan:pro", "topic:news" and "topic:sports" for
+// User{ID: "42", Plan: "Pro", Topics: []string{"News", "Sports"}}.
+// The lower option lower cases values. Fields may be strings, booleans, numbers,
+// fmt.Stringers or pointers and slices of them, every element of slices becomes a tag.
+// Nil pointers, empty strings and empty slices give no tags, while false and zero numbers
+// are values of their own, e.g. "beta:false". Fields of embedded structs are included
+func FromStruct(v interface{}) ([]Tag, error) {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("tags.FromStruct: %T is not a struct", v)
+	}
+
+	tags, err := structTags(value)
+	if err != nil {
+		return nil, fmt.Errorf("tags.FromStruct: %w", err)
+	}
+
+	return tags, nil
+}
+
+// structTags returns tags of annotated fields of struct value
+func structTags(value reflect.Value) ([]Tag, error) {
+	var tags []Tag
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		annotation, annotated := field.Tag.Lookup(structTagKey)
+
+		if !annotated {
+			if field.Anonymous {
+				embedded := value.Field(i)
+				for embedded.Kind() == reflect.Ptr && !embedded.IsNil() {
+					embedded = embedded.Elem()
+				}
+				if embedded.Kind() == reflect.Struct {
+					fieldTags, err := structTags(embedded)
+					if err != nil {
+						return nil, err
+					}
+					tags = append(tags, fieldTags...)
+				}
+			}
+			continue
+		}
+
+		if annotation == "-" || field.PkgPath != "" {
+			continue
+		}
+
+		options := strings.Split(annotation, ",")
+		namespace, lower := Namespace(options[0]), false
+		for _, option := range options[1:] {
+			if option != "lower" {
+				return nil, fmt.Errorf("field %s: unknown option '%s'", field.Name, option)
+			}
+			lower = true
+		}
+
+		values, err := fieldValues(value.Field(i))
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+
+		for _, s := range values {
+			if lower {
+				s = strings.ToLower(s)
+			}
+
+			tag := New(namespace, s)
+			if err := tag.Validate(); err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags, nil
+}
+
+// fieldValues returns tag values of field, none for nil pointers and empty values
+func fieldValues(field reflect.Value) ([]string, error) {
+	for field.Kind() == reflect.Ptr || field.Kind() == reflect.Interface {
+		if field.IsNil() {
+			return nil, nil
+		}
+		if stringer, ok := field.Interface().(fmt.Stringer); ok {
+			return nonEmpty(stringer.String()), nil
+		}
+		field = field.Elem()
+	}
+
+	if field.CanInterface() {
+		if stringer, ok := field.Interface().(fmt.Stringer); ok {
+			return nonEmpty(stringer.String()), nil
+		}
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		return nonEmpty(field.String()), nil
+	case reflect.Bool:
+		return []string{strconv.FormatBool(field.Bool())}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []string{strconv.FormatInt(field.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []string{strconv.FormatUint(field.Uint(), 10)}, nil
+	case reflect.Slice, reflect.Array:
+		var values []string
+		for i := 0; i < field.Len(); i++ {
+			elemValues, err := fieldValues(field.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, elemValues...)
+		}
+		return values, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", field.Type())
+}
+
+// nonEmpty returns s as the only value unless it is empty
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return []string{s}
+}
@@ -0,0 +1,69 @@
+package tags
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testPlan int
+
+func (p testPlan) String() string {
+	return []string{"free", "pro"}[p]
+}
+
+type testAccount struct {
+	Region string `nhtag:"geo,lower"`
+}
+
+type testUser struct {
+	testAccount
+	ID       string   `nhtag:"user"`
+	Plan     testPlan `nhtag:"plan"`
+	Topics   []string `nhtag:"topic,lower"`
+	Beta     bool     `nhtag:"beta"`
+	Team     *string  `nhtag:"team"`
+	Name     string
+	Password string `nhtag:"-"`
+}
+
+func Test_FromStruct(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	team := "backend"
+
+	testPatterns := []struct {
+		name     string
+		v        interface{}
+		expected []string
+	}{
+		{"full", &testUser{testAccount{"NO"}, "42", 1, []string{"News", "Sports"}, true, &team, "Ola", "secret"}, []string{"geo:no", "user:42", "plan:pro", "topic:news", "topic:sports", "beta:true", "team:backend"}},
+		{"empty", testUser{}, []string{"plan:free", "beta:false"}},
+	}
+
+	for _, testData := range testPatterns {
+		tags, err := FromStruct(testData.v)
+		if err != nil {
+			t.Errorf(errfmt, testData.name+" error", nil, err)
+		}
+		if s := Strings(tags...); !reflect.DeepEqual(s, testData.expected) {
+			t.Errorf(errfmt, testData.name+" tags", testData.expected, s)
+		}
+	}
+
+	if _, err := FromStruct(struct {
+		ID string `nhtag:"user"`
+	}{"a b"}); !errors.Is(err, ErrInvalid) {
+		t.Errorf(errfmt, "invalid value error", ErrInvalid, err)
+	}
+
+	if _, err := FromStruct(struct {
+		Meta map[string]string `nhtag:"meta"`
+	}{map[string]string{}}); err == nil {
+		t.Errorf(errfmt, "unsupported type error", "error", err)
+	}
+
+	if _, err := FromStruct("user"); err == nil {
+		t.Errorf(errfmt, "not a struct error", "error", err)
+	}
+}
@@ -0,0 +1,136 @@
+// Package tags builds notification hub tags following the naming convention
+// "namespace:value", e.g. "user:42", "topic:football" or "geo:no"
+package tags
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxLength is the maximum length of a hub tag
+const MaxLength = 120
+
+// Namespaces of tags
+const (
+	User      Namespace = "user"
+	Topic     Namespace = "topic"
+	Geo       Namespace = "geo"
+	Locale    Namespace = "locale"
+	Residency Namespace = "residency"
+	Ring      Namespace = "ring"
+)
+
+const separator = ":"
+
+// ErrInvalid is returned when a tag does not follow the naming convention
+var ErrInvalid = errors.New("invalid tag")
+
+type (
+	// Namespace groups tags of the same kind
+	Namespace string
+
+	// Tag is a namespaced hub tag
+	Tag struct {
+		Namespace Namespace
+		Value     string
+	}
+)
+
+// New returns tag of namespace with value, unlike the namespace constructors
+// it does not validate the tag, see Validate
+func New(namespace Namespace, value string) Tag {
+	return Tag{Namespace: namespace, Value: value}
+}
+
+// UserTag returns tag of user devices
+func UserTag(id string) (Tag, error) {
+	return newValid(User, id)
+}
+
+// TopicTag returns tag of topic subscribers, topic names are case insensitive
+func TopicTag(name string) (Tag, error) {
+	return newValid(Topic, strings.ToLower(name))
+}
+
+// GeoTag returns tag of devices in region, region codes are case insensitive
+func GeoTag(region string) (Tag, error) {
+	return newValid(Geo, strings.ToLower(region))
+}
+
+// LocaleTag returns tag of devices using locale, e.g. "locale:nb-no" for nb_NO
+func LocaleTag(locale string) (Tag, error) {
+	return newValid(Locale, strings.ToLower(strings.Replace(locale, "_", "-", -1)))
+}
+
+// ResidencyTag returns tag of devices whose data must stay in residency region, e.g. "residency:eu"
+func ResidencyTag(region string) (Tag, error) {
+	return newValid(Residency, strings.ToLower(region))
+}
+
+// RingTag returns tag of devices in test ring, e.g. "ring:qa" for devices of internal testers
+func RingTag(name string) (Tag, error) {
+	return newValid(Ring, strings.ToLower(name))
+}
+
+// newValid returns tag of namespace with value, or error if the tag is invalid
+func newValid(namespace Namespace, value string) (Tag, error) {
+	tag := New(namespace, value)
+	if err := tag.Validate(); err != nil {
+		return Tag{}, err
+	}
+
+	return tag, nil
+}
+
+// Parse parses "namespace:value" tag
+func Parse(s string) (Tag, error) {
+	i := strings.Index(s, separator)
+	if i < 0 {
+		return Tag{}, fmt.Errorf("%w '%s': missing namespace", ErrInvalid, s)
+	}
+
+	return newValid(Namespace(s[:i]), s[i+len(separator):])
+}
+
+// String returns the hub tag
+func (t Tag) String() string {
+	return string(t.Namespace) + separator + t.Value
+}
+
+// Validate checks tag against the naming convention and hub tag restrictions
+func (t Tag) Validate() error {
+	s := t.String()
+
+	switch {
+	case t.Namespace == "" || t.Value == "":
+		return fmt.Errorf("%w '%s': empty namespace or value", ErrInvalid, s)
+	case strings.ToLower(string(t.Namespace)) != string(t.Namespace) || strings.Contains(string(t.Namespace), separator):
+		return fmt.Errorf("%w '%s': namespace must be lower case without '%s'", ErrInvalid, s, separator)
+	case len(s) > MaxLength:
+		return fmt.Errorf("%w '%s': longer than %d characters", ErrInvalid, s, MaxLength)
+	}
+
+	for _, c := range s {
+		if !isTagChar(c) {
+			return fmt.Errorf("%w '%s': character '%c' is not allowed", ErrInvalid, s, c)
+		}
+	}
+
+	return nil
+}
+
+// Strings returns hub tags of tags, e.g. for orTags of a send
+func Strings(tags ...Tag) []string {
+	s := make([]string, len(tags))
+	for i, tag := range tags {
+		s[i] = tag.String()
+	}
+
+	return s
+}
+
+// isTagChar reports whether c is allowed in hub tags
+func isTagChar(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("_@#.:-", c)
+}
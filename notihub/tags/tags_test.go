@@ -0,0 +1,78 @@
+package tags
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_Constructors(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	testPatterns := []struct {
+		constructor func(string) (Tag, error)
+		value       string
+		expected    string
+	}{
+		{UserTag, "42", "user:42"},
+		{TopicTag, "Football", "topic:football"},
+		{GeoTag, "NO-03", "geo:no-03"},
+		{LocaleTag, "nb_NO", "locale:nb-no"},
+		{ResidencyTag, "EU", "residency:eu"},
+		{RingTag, "QA", "ring:qa"},
+		{UserTag, "", ""},
+		{TopicTag, "foot ball", ""},
+		{GeoTag, "no/oslo", ""},
+		{LocaleTag, string(make([]byte, MaxLength)), ""},
+	}
+
+	for _, testData := range testPatterns {
+		tag, err := testData.constructor(testData.value)
+		if testData.expected == "" {
+			if !errors.Is(err, ErrInvalid) || tag != (Tag{}) {
+				t.Errorf(errfmt, "'"+testData.value+"' error", ErrInvalid, err)
+			}
+			continue
+		}
+		if err != nil || tag.String() != testData.expected {
+			t.Errorf(errfmt, "tag", testData.expected, tag)
+		}
+	}
+
+	if tag := New("team", "backend"); tag.String() != "team:backend" {
+		t.Errorf(errfmt, "tag", "team:backend", tag)
+	}
+
+	if s := Strings(New(User, "1"), New(Topic, "news")); !reflect.DeepEqual(s, []string{"user:1", "topic:news"}) {
+		t.Errorf(errfmt, "strings", []string{"user:1", "topic:news"}, s)
+	}
+}
+
+func Test_Parse(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	testPatterns := []struct {
+		s        string
+		expected Tag
+		valid    bool
+	}{
+		{"user:42", New(User, "42"), true},
+		{"user:jane@example.com", New(User, "jane@example.com"), true},
+		{"geo:no:oslo", New(Geo, "no:oslo"), true},
+		{"football", Tag{}, false},
+		{"user:", Tag{}, false},
+		{"User:42", Tag{}, false},
+		{"topic:foot ball", Tag{}, false},
+		{"topic:" + string(make([]byte, MaxLength)), Tag{}, false},
+	}
+
+	for _, testData := range testPatterns {
+		tag, err := Parse(testData.s)
+		if testData.valid != (err == nil) || tag != testData.expected {
+			t.Errorf(errfmt, testData.s, testData.expected, tag)
+		}
+		if err != nil && !errors.Is(err, ErrInvalid) {
+			t.Errorf(errfmt, testData.s+" error", ErrInvalid, err)
+		}
+	}
+}
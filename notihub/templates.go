@@ -0,0 +1,240 @@
+package notihub
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	templateVersionSeparator  = ".v"
+	defaultMigrationBatchSize = 10
+)
+
+type (
+	// TemplateMigration rolls installations from one version of a template to another
+	TemplateMigration struct {
+		// Name is the template name without version suffix
+		Name string
+		// From is the version to migrate from, installations without it are skipped
+		From int
+		// To is the version to migrate to
+		To int
+		// Template is stored as version To
+		Template InstallationTemplate
+		// KeepFrom leaves version From in place, so that both versions receive notifications
+		KeepFrom bool
+		// BatchSize limits the number of installations migrated concurrently, 10 by default
+		BatchSize int
+		// BatchInterval is the minimum time between starts of consecutive batches
+		BatchInterval time.Duration
+	}
+
+	// MigrationProgress counts installations processed by a migration so far
+	MigrationProgress struct {
+		Total     int
+		Processed int
+		Migrated  int
+		Skipped   int
+		Failed    int
+	}
+
+	// MigrationResult is the outcome of migrating a single installation.
+	// Skipped is set for installations without version From or already on version To
+	MigrationResult struct {
+		InstallationId string
+		Skipped        bool
+		Err            error
+	}
+
+	// MigrationReport lists results in the order of installation ids
+	MigrationReport struct {
+		Progress MigrationProgress
+		Results  []MigrationResult
+	}
+)
+
+// TemplateVersionName returns the installation template name of version of template name
+func TemplateVersionName(name string, version int) string {
+	return name + templateVersionSeparator + strconv.Itoa(version)
+}
+
+// ParseTemplateVersionName splits installation template name into template name and version.
+// Names without version suffix are reported as version 1 to cover templates created before versioning
+func ParseTemplateVersionName(templateName string) (name string, version int) {
+	i := strings.LastIndex(templateName, templateVersionSeparator)
+	if i <= 0 {
+		return templateName, 1
+	}
+
+	version, err := strconv.Atoi(templateName[i+len(templateVersionSeparator):])
+	if err != nil || version < 1 {
+		return templateName, 1
+	}
+
+	return templateName[:i], version
+}
+
+// TemplateVersions returns sorted versions of template name present in installation
+func (i *Installation) TemplateVersions(name string) []int {
+	var versions []int
+	for templateName := range i.Templates {
+		if n, version := ParseTemplateVersionName(templateName); n == name {
+			versions = append(versions, version)
+		}
+	}
+	sort.Ints(versions)
+
+	return versions
+}
+
+// SetTemplateVersion stores template as version of template name.
+// The versioned template name is added to the template tags,
+// so that notifications can target a single version with it
+func (i *Installation) SetTemplateVersion(name string, version int, template InstallationTemplate) {
+	versionName := TemplateVersionName(name, version)
+
+	tags := make([]string, 0, len(template.Tags)+1)
+	for _, tag := range template.Tags {
+		if tag != versionName {
+			tags = append(tags, tag)
+		}
+	}
+	template.Tags = append(tags, versionName)
+
+	if i.Templates == nil {
+		i.Templates = map[string]InstallationTemplate{}
+	}
+	i.Templates[versionName] = template
+}
+
+// removeTemplateVersion removes version of template name from installation
+func (i *Installation) removeTemplateVersion(name string, version int) {
+	delete(i.Templates, TemplateVersionName(name, version))
+	if version == 1 {
+		delete(i.Templates, name)
+	}
+}
+
+// hasTemplateVersion reports whether installation has version of template name
+func (i *Installation) hasTemplateVersion(name string, version int) bool {
+	for _, v := range i.TemplateVersions(name) {
+		if v == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MigrateTemplate moves installations from template version m.From to m.To.
+// onProgress, when not nil, is called after every batch.
+// Report is returned even when some of the installations fail
+func (h *NotificationHub) MigrateTemplate(ctx context.Context, installationIDs []string, m TemplateMigration, onProgress func(MigrationProgress)) (*MigrationReport, error) {
+	if m.Name == "" || m.From < 1 || m.To < 1 || m.From == m.To {
+		return nil, fmt.Errorf("NotificationHub.MigrateTemplate: invalid migration of '%s' from version %d to %d", m.Name, m.From, m.To)
+	}
+
+	report := &MigrationReport{Progress: MigrationProgress{Total: len(installationIDs)}}
+
+	batchSize := m.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultMigrationBatchSize
+	}
+
+	var lastBatch time.Time
+	for start := 0; start < len(installationIDs); start += batchSize {
+		if start > 0 {
+			if err := sleepContext(ctx, m.BatchInterval-time.Since(lastBatch)); err != nil {
+				return report, fmt.Errorf("NotificationHub.MigrateTemplate: %w", err)
+			}
+		}
+		lastBatch = time.Now()
+
+		end := start + batchSize
+		if end > len(installationIDs) {
+			end = len(installationIDs)
+		}
+
+		for _, result := range h.migrateBatch(ctx, installationIDs[start:end], &m) {
+			report.Results = append(report.Results, result)
+			report.Progress.Processed++
+			switch {
+			case result.Err != nil:
+				report.Progress.Failed++
+			case result.Skipped:
+				report.Progress.Skipped++
+			default:
+				report.Progress.Migrated++
+			}
+		}
+
+		if onProgress != nil {
+			onProgress(report.Progress)
+		}
+	}
+
+	if failed := report.Failed(); len(failed) > 0 {
+		return report, fmt.Errorf("NotificationHub.MigrateTemplate: %d of %d installations failed, first: %s: %w", len(failed), len(report.Results), failed[0].InstallationId, failed[0].Err)
+	}
+
+	return report, nil
+}
+
+// Failed returns results of installations which could not be migrated
+func (r *MigrationReport) Failed() []MigrationResult {
+	var failed []MigrationResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+
+	return failed
+}
+
+// migrateBatch migrates installations concurrently
+func (h *NotificationHub) migrateBatch(ctx context.Context, installationIDs []string, m *TemplateMigration) []MigrationResult {
+	results := make([]MigrationResult, len(installationIDs))
+
+	var wg sync.WaitGroup
+	for i, id := range installationIDs {
+		wg.Add(1)
+		i, id := i, id
+		h.goLabeled(ctx, "migrate", func(ctx context.Context) {
+			defer wg.Done()
+			results[i] = h.migrateInstallation(ctx, id, m)
+		})
+	}
+	wg.Wait()
+
+	return results
+}
+
+// migrateInstallation migrates a single installation
+func (h *NotificationHub) migrateInstallation(ctx context.Context, installationID string, m *TemplateMigration) MigrationResult {
+	result := MigrationResult{InstallationId: installationID}
+
+	installation, err := h.getInstallation(ctx, installationID)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	if !installation.hasTemplateVersion(m.Name, m.From) || installation.hasTemplateVersion(m.Name, m.To) {
+		result.Skipped = true
+		return result
+	}
+
+	installation.SetTemplateVersion(m.Name, m.To, m.Template)
+	if !m.KeepFrom {
+		installation.removeTemplateVersion(m.Name, m.From)
+	}
+
+	result.Err = h.putInstallation(ctx, installation)
+	return result
+}
@@ -0,0 +1,129 @@
+package notihub
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func Test_TemplateVersionName(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	testPatterns := []struct {
+		templateName string
+		name         string
+		version      int
+	}{
+		{"greeting.v2", "greeting", 2},
+		{"greeting.v10", "greeting", 10},
+		{"greeting", "greeting", 1},
+		{"greeting.vx", "greeting.vx", 1},
+		{"greeting.v0", "greeting.v0", 1},
+		{".v2", ".v2", 1},
+	}
+
+	for _, testData := range testPatterns {
+		name, version := ParseTemplateVersionName(testData.templateName)
+		if name != testData.name || version != testData.version {
+			t.Errorf(errfmt, testData.templateName, []interface{}{testData.name, testData.version}, []interface{}{name, version})
+		}
+	}
+
+	if name := TemplateVersionName("greeting", 3); name != "greeting.v3" {
+		t.Errorf(errfmt, "versioned template name", "greeting.v3", name)
+	}
+}
+
+func Test_InstallationTemplateVersions(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	installation := &Installation{Templates: map[string]InstallationTemplate{"greeting": {Body: "v1"}}}
+	installation.SetTemplateVersion("greeting", 3, InstallationTemplate{Body: "v3", Tags: []string{"vip"}})
+	installation.SetTemplateVersion("greeting", 2, InstallationTemplate{Body: "v2"})
+	installation.SetTemplateVersion("other", 5, InstallationTemplate{Body: "other"})
+
+	if versions := installation.TemplateVersions("greeting"); !reflect.DeepEqual(versions, []int{1, 2, 3}) {
+		t.Errorf(errfmt, "template versions", []int{1, 2, 3}, versions)
+	}
+
+	if tags := installation.Templates["greeting.v3"].Tags; !reflect.DeepEqual(tags, []string{"vip", "greeting.v3"}) {
+		t.Errorf(errfmt, "versioned template tags", []string{"vip", "greeting.v3"}, tags)
+	}
+
+	installation.removeTemplateVersion("greeting", 1)
+	if _, ok := installation.Templates["greeting"]; ok {
+		t.Errorf(errfmt, "unversioned template removed", true, ok)
+	}
+}
+
+func Test_NotificationHubMigrateTemplate(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	v1 := InstallationTemplate{Body: `{"aps":{"alert":"$(message)"}}`}
+	server := newInstallationServer(
+		Installation{InstallationId: "legacy", Platform: "apns", PushChannel: "token-1", Templates: map[string]InstallationTemplate{"greeting": v1}},
+		Installation{InstallationId: "versioned", Platform: "apns", PushChannel: "token-2", Templates: map[string]InstallationTemplate{"greeting.v1": v1}},
+		Installation{InstallationId: "migrated", Platform: "apns", PushChannel: "token-3", Templates: map[string]InstallationTemplate{"greeting.v2": v1}},
+		Installation{InstallationId: "unrelated", Platform: "apns", PushChannel: "token-4"},
+	)
+	defer server.Close()
+
+	migration := TemplateMigration{
+		Name:      "greeting",
+		From:      1,
+		To:        2,
+		Template:  InstallationTemplate{Body: `{"aps":{"alert":{"title":"$(title)","body":"$(message)"}}}`},
+		BatchSize: 2,
+	}
+
+	var progress []MigrationProgress
+	report, err := server.hub().MigrateTemplate(context.Background(), []string{"legacy", "versioned", "migrated", "unrelated", "missing"}, migration, func(p MigrationProgress) {
+		progress = append(progress, p)
+	})
+	if err == nil {
+		t.Fatalf(errfmt, "missing installation error", "error", err)
+	}
+
+	expectedProgress := MigrationProgress{Total: 5, Processed: 5, Migrated: 2, Skipped: 2, Failed: 1}
+	if report.Progress != expectedProgress {
+		t.Errorf(errfmt, "migration progress", expectedProgress, report.Progress)
+	}
+	if len(progress) != 3 || progress[0].Processed != 2 || progress[2] != expectedProgress {
+		t.Errorf(errfmt, "progress updates per batch", 3, progress)
+	}
+	if failed := report.Failed(); len(failed) != 1 || failed[0].InstallationId != "missing" {
+		t.Errorf(errfmt, "failed installation", "missing", failed)
+	}
+
+	for _, id := range []string{"legacy", "versioned"} {
+		templates := server.installations[id].Templates
+		if len(templates) != 1 || templates["greeting.v2"].Body != migration.Template.Body {
+			t.Errorf(errfmt, id+" templates", "greeting.v2 only", templates)
+		}
+	}
+	if server.writes != 2 {
+		t.Errorf(errfmt, "installation writes", 2, server.writes)
+	}
+}
+
+func Test_NotificationHubMigrateTemplateKeepFrom(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := newInstallationServer(
+		Installation{InstallationId: "device", Platform: "gcm", PushChannel: "token", Templates: map[string]InstallationTemplate{"greeting.v1": {Body: "v1"}}},
+	)
+	defer server.Close()
+
+	migration := TemplateMigration{Name: "greeting", From: 1, To: 2, Template: InstallationTemplate{Body: "v2"}, KeepFrom: true}
+	if _, err := server.hub().MigrateTemplate(context.Background(), []string{"device"}, migration, nil); err != nil {
+		t.Fatalf(errfmt, "migration error", nil, err)
+	}
+
+	if versions := (&Installation{Templates: server.installations["device"].Templates}).TemplateVersions("greeting"); !reflect.DeepEqual(versions, []int{1, 2}) {
+		t.Errorf(errfmt, "template versions", []int{1, 2}, versions)
+	}
+
+	if _, err := server.hub().MigrateTemplate(context.Background(), nil, TemplateMigration{Name: "greeting", From: 2, To: 2}, nil); err == nil {
+		t.Errorf(errfmt, "invalid migration error", "error", err)
+	}
+}
@@ -0,0 +1,135 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Phases of a hub request reported by TimeoutError
+const (
+	PhaseConnect        = "connect"
+	PhaseTLSHandshake   = "tls handshake"
+	PhaseResponseHeader = "response header"
+	PhaseTotal          = "total"
+)
+
+// DefaultTimeouts suit push workloads: connections are established fast
+// and the whole request is given modest time, as sends are usually retried
+var DefaultTimeouts = Timeouts{
+	Connect:        3 * time.Second,
+	TLSHandshake:   5 * time.Second,
+	ResponseHeader: 10 * time.Second,
+	Total:          15 * time.Second,
+}
+
+type (
+	// Timeouts limit phases of every request attempt
+	Timeouts struct {
+		// Connect limits establishing the TCP connection
+		Connect time.Duration
+		// TLSHandshake limits the TLS handshake
+		TLSHandshake time.Duration
+		// ResponseHeader limits waiting for response headers once the request is written
+		ResponseHeader time.Duration
+		// Total limits the whole attempt including reading the response body
+		Total time.Duration
+	}
+
+	// TimeoutError is returned when a phase of request attempt times out
+	TimeoutError struct {
+		Phase string
+		// Limit is the exceeded timeout
+		Limit time.Duration
+		Err   error
+	}
+)
+
+// WithTimeouts sets per phase timeouts of request attempts,
+// zero fields are taken from DefaultTimeouts
+func WithTimeouts(timeouts Timeouts) HubOption {
+	if timeouts.Connect == 0 {
+		timeouts.Connect = DefaultTimeouts.Connect
+	}
+	if timeouts.TLSHandshake == 0 {
+		timeouts.TLSHandshake = DefaultTimeouts.TLSHandshake
+	}
+	if timeouts.ResponseHeader == 0 {
+		timeouts.ResponseHeader = DefaultTimeouts.ResponseHeader
+	}
+	if timeouts.Total == 0 {
+		timeouts.Total = DefaultTimeouts.Total
+	}
+
+	setTransport := withTransport(func(t *http.Transport) {
+		dial := t.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{KeepAlive: 30 * time.Second}).DialContext
+		}
+		t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeouts.Connect)
+			defer cancel()
+			return dial(ctx, network, addr)
+		}
+		t.TLSHandshakeTimeout = timeouts.TLSHandshake
+		t.ResponseHeaderTimeout = timeouts.ResponseHeader
+	})
+
+	return func(h *NotificationHub) {
+		h.timeouts = &timeouts
+		setTransport(h)
+	}
+}
+
+// Error returns TimeoutError string representation
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s timeout of %s exceeded: %s", e.Phase, e.Limit, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports the error as a timeout, see net.Error
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
+// withAttemptTimeout limits request attempt to the total timeout.
+// The returned cancel must be called once the response is read
+func (t *Timeouts) withAttemptTimeout(req *http.Request) (*http.Request, context.CancelFunc) {
+	if t == nil || t.Total <= 0 {
+		return req, func() {}
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.Total)
+	return req.WithContext(ctx), cancel
+}
+
+// timeoutError identifies the phase of attempt which timed out
+// and wraps err into TimeoutError, other errors are returned as is.
+// parent is the context of the request before the attempt timeout
+func (t *Timeouts) timeoutError(parent context.Context, err error) error {
+	if t == nil || err == nil || parent.Err() != nil {
+		return err
+	}
+
+	var opErr *net.OpError
+	switch {
+	case errors.As(err, &opErr) && opErr.Op == "dial" && (opErr.Timeout() || errors.Is(err, context.DeadlineExceeded)):
+		return &TimeoutError{Phase: PhaseConnect, Limit: t.Connect, Err: err}
+	case strings.Contains(err.Error(), "TLS handshake timeout"):
+		return &TimeoutError{Phase: PhaseTLSHandshake, Limit: t.TLSHandshake, Err: err}
+	case strings.Contains(err.Error(), "timeout awaiting response headers"):
+		return &TimeoutError{Phase: PhaseResponseHeader, Limit: t.ResponseHeader, Err: err}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &TimeoutError{Phase: PhaseTotal, Limit: t.Total, Err: err}
+	}
+
+	return err
+}
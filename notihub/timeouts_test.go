@@ -0,0 +1,82 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_NotificationHubTimeoutPhases(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("phase") {
+		case "header":
+			time.Sleep(200 * time.Millisecond)
+		case "body":
+			w.WriteHeader(http.StatusCreated)
+			w.(http.Flusher).Flush()
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	blockingDial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		<-ctx.Done()
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}
+
+	testPatterns := []struct {
+		phase    string
+		opts     []HubOption
+		expected string
+	}{
+		{"header", []HubOption{WithTimeouts(Timeouts{ResponseHeader: 50 * time.Millisecond})}, PhaseResponseHeader},
+		{"body", []HubOption{WithTimeouts(Timeouts{Total: 50 * time.Millisecond})}, PhaseTotal},
+		{"connect", []HubOption{WithDialContext(blockingDial), WithTimeouts(Timeouts{Connect: 50 * time.Millisecond})}, PhaseConnect},
+	}
+
+	for _, testData := range testPatterns {
+		nhub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(), testData.opts...)
+		nhub.hubURL.RawQuery += "&phase=" + testData.phase
+
+		_, err := nhub.Send(context.Background(), &Notification{AndroidFormat, []byte(`{"data":{}}`)}, nil, BroadcastAll())
+
+		var timeoutErr *TimeoutError
+		if !errors.As(err, &timeoutErr) || timeoutErr.Phase != testData.expected {
+			t.Errorf(errfmt, testData.phase+" timeout phase", testData.expected, err)
+			continue
+		}
+		if timeoutErr.Limit != 50*time.Millisecond || !isRetryableError(err) {
+			t.Errorf(errfmt, testData.phase+" timeout limit", 50*time.Millisecond, timeoutErr.Limit)
+		}
+	}
+}
+
+func Test_NotificationHubTimeoutParentContext(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	nhub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(), WithTimeouts(Timeouts{}))
+	if *nhub.timeouts != DefaultTimeouts {
+		t.Errorf(errfmt, "default timeouts", DefaultTimeouts, *nhub.timeouts)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := nhub.Send(ctx, &Notification{AndroidFormat, []byte(`{"data":{}}`)}, nil, BroadcastAll())
+
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf(errfmt, "caller deadline error", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,144 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// transactionalWindow is the number of recent transactional sends latency percentiles are computed over
+const transactionalWindow = 1024
+
+// ErrSLOExceeded is returned when a transactional send is not accepted by the hub within its SLO
+var ErrSLOExceeded = errors.New("transactional send slo exceeded")
+
+type (
+	// LatencyPercentiles summarizes latencies of recent sends
+	LatencyPercentiles struct {
+		// Count is the number of sends summarized
+		Count int
+		P50   time.Duration
+		P90   time.Duration
+		P99   time.Duration
+	}
+
+	// latencyWindow keeps the latest transactionalWindow latencies
+	latencyWindow struct {
+		mu        sync.Mutex
+		latencies []time.Duration
+		next      int
+	}
+)
+
+// SendTransactional sends time critical notification n, like a login code, to recipients of tag target.
+// The send fails with ErrSLOExceeded unless the hub accepts it within slo:
+// retries are made only while the deadline allows and no attempt outlives it.
+// When the hub does not answer within the hub hedging delay, or half of slo when hedging is not configured,
+// the send is hedged by a duplicate and the first accepted send wins,
+// so recipients may occasionally get the notification twice.
+// Latencies are summarized by HubStats.Transactional
+func (h *NotificationHub) SendTransactional(ctx context.Context, n *Notification, target string, slo time.Duration, opts ...SendOption) ([]byte, error) {
+	b, err := h.sendTransactional(ctx, n, target, slo, newSendOptions(opts))
+	if err != nil {
+		return nil, fmt.Errorf("NotificationHub.SendTransactional: %w", err)
+	}
+
+	return b, nil
+}
+
+// sendTransactional sends n hedged within slo
+func (h *NotificationHub) sendTransactional(ctx context.Context, n *Notification, target string, slo time.Duration, o *sendOptions) ([]byte, error) {
+	if slo <= 0 {
+		return nil, fmt.Errorf("%w: non-positive slo %v", ErrSLOExceeded, slo)
+	}
+
+	started := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, slo)
+	defer cancel()
+
+	type response struct {
+		b      []byte
+		result *SendResult
+		err    error
+	}
+	responses := make(chan response, 2)
+	run := func() {
+		attempt := *o
+		attempt.result = &SendResult{}
+		b, err := h.send(ctx, n, []string{target}, &attempt)
+		responses <- response{b, attempt.result, err}
+	}
+
+	go run()
+
+	delay := h.hedgeDelay
+	if delay <= 0 || delay >= slo {
+		delay = slo / 2
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	inflight := 1
+	for {
+		select {
+		case <-timer.C:
+			inflight++
+			go run()
+		case r := <-responses:
+			inflight--
+			if r.err != nil && inflight > 0 {
+				continue
+			}
+
+			if o.result != nil {
+				*o.result = *r.result
+			}
+			h.stats.observeTransactional(time.Since(started))
+
+			if r.err != nil && (ctx.Err() == context.DeadlineExceeded || errors.Is(r.err, ErrDeadlineWouldExceed)) {
+				return nil, fmt.Errorf("%w: %v: %v", ErrSLOExceeded, slo, r.err)
+			}
+
+			return r.b, r.err
+		}
+	}
+}
+
+// observeTransactional records latency of a transactional send
+func (s *hubStats) observeTransactional(latency time.Duration) {
+	if s == nil {
+		return
+	}
+
+	w := &s.transactional
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.latencies) < transactionalWindow {
+		w.latencies = append(w.latencies, latency)
+		return
+	}
+	w.latencies[w.next] = latency
+	w.next = (w.next + 1) % transactionalWindow
+}
+
+// percentiles summarizes recorded latencies
+func (w *latencyWindow) percentiles() LatencyPercentiles {
+	w.mu.Lock()
+	sorted := append([]time.Duration(nil), w.latencies...)
+	w.mu.Unlock()
+
+	if len(sorted) == 0 {
+		return LatencyPercentiles{}
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	at := func(p int) time.Duration {
+		return sorted[(len(sorted)*p+99)/100-1]
+	}
+
+	return LatencyPercentiles{Count: len(sorted), P50: at(50), P90: at(90), P99: at(99)}
+}
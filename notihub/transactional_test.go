@@ -0,0 +1,104 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_NotificationHubSendTransactional(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	tests := []struct {
+		name     string
+		retry    RetryPolicy
+		respond  func(attempt int64, req *http.Request) error
+		err      error
+		attempts int64
+	}{
+		{
+			"accepted", RetryPolicy{},
+			func(int64, *http.Request) error { return nil },
+			nil, 1,
+		},
+		{
+			"hedged", RetryPolicy{},
+			func(attempt int64, req *http.Request) error {
+				if attempt == 1 {
+					<-req.Context().Done()
+					return req.Context().Err()
+				}
+				return nil
+			},
+			nil, 2,
+		},
+		{
+			"slo exceeded", RetryPolicy{},
+			func(attempt int64, req *http.Request) error {
+				<-req.Context().Done()
+				return req.Context().Err()
+			},
+			ErrSLOExceeded, 2,
+		},
+		{
+			"no retry past slo", RetryPolicy{MaxAttempts: 5, Backoff: time.Hour},
+			func(int64, *http.Request) error {
+				return &ResponseError{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}}
+			},
+			ErrSLOExceeded, 1,
+		},
+	}
+
+	for _, test := range tests {
+		var attempts int64
+		nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+			return nil, test.respond(atomic.AddInt64(&attempts, 1), req)
+		})
+		nhub.stats = &hubStats{}
+		nhub.retry = test.retry
+
+		var result SendResult
+		started := time.Now()
+		_, err := nhub.SendTransactional(context.Background(), &Notification{Template, []byte("{}")}, "user:1", 100*time.Millisecond, WithResult(&result))
+		if elapsed := time.Since(started); elapsed > time.Second {
+			t.Errorf(errfmt, test.name+" duration", "within slo", elapsed)
+		}
+
+		if test.err == nil && err != nil || test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf(errfmt, test.name+" error", test.err, err)
+		}
+		if n := atomic.LoadInt64(&attempts); n != test.attempts {
+			t.Errorf(errfmt, test.name+" attempts", test.attempts, n)
+		}
+		if result.Attempts != 1 {
+			t.Errorf(errfmt, test.name+" result attempts", 1, result.Attempts)
+		}
+		if stats := nhub.Stats().Transactional; stats.Count != 1 || stats.P99 <= 0 {
+			t.Errorf(errfmt, test.name+" latency percentiles", "1 send", stats)
+		}
+	}
+}
+
+func Test_LatencyWindowPercentiles(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	stats := &hubStats{}
+	for i := 1; i <= transactionalWindow+100; i++ {
+		stats.observeTransactional(time.Duration(i) * time.Millisecond)
+	}
+
+	p := stats.transactional.percentiles()
+	expected := LatencyPercentiles{Count: transactionalWindow, P50: 612 * time.Millisecond, P90: 1022 * time.Millisecond, P99: 1114 * time.Millisecond}
+	if p != expected {
+		t.Errorf(errfmt, "percentiles", expected, p)
+	}
+
+	var empty *hubStats
+	empty.observeTransactional(time.Second)
+	if p := (&latencyWindow{}).percentiles(); p != (LatencyPercentiles{}) {
+		t.Errorf(errfmt, "empty percentiles", LatencyPercentiles{}, p)
+	}
+}
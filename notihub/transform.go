@@ -0,0 +1,199 @@
+package notihub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// customDataPaths locates custom data of JSON payloads by format, empty for top-level keys
+var customDataPaths = map[NotificationFormat]string{
+	Template:      "",
+	AppleFormat:   "",
+	AndroidFormat: "data",
+	FcmV1Format:   "message.data",
+	KindleFormat:  "data",
+	BaiduFormat:   "custom_content",
+}
+
+// PayloadTransformer rewrites notification before it is sent, e.g. to inject tracking ids
+// into custom data or strip debug fields. Transformers see notifications in the format they
+// are sent in and must return a new notification rather than modifying n
+type PayloadTransformer func(ctx context.Context, n *Notification) (*Notification, error)
+
+// WithDefaultPayloadTransformers sets transformers applied to every notification, in order
+func WithDefaultPayloadTransformers(transformers ...PayloadTransformer) HubOption {
+	return func(h *NotificationHub) {
+		h.transformers = append(h.transformers, transformers...)
+	}
+}
+
+// WithPayloadTransformers applies transformers to the notification after the hub default transformers
+func WithPayloadTransformers(transformers ...PayloadTransformer) SendOption {
+	return func(o *sendOptions) {
+		o.transformers = append(o.transformers, transformers...)
+	}
+}
+
+// TransformJSON returns transformer of JSON payloads, decoded into payload with numbers as json.Number
+// and marshaled back by the hub encoder, DefaultEncoder outside of hub sends.
+// Notifications of formats without JSON payloads, like WNS toasts, are sent as is
+func TransformJSON(f func(format NotificationFormat, payload map[string]interface{}) error) PayloadTransformer {
+	return func(ctx context.Context, n *Notification) (*Notification, error) {
+		if n.Format.GetContentType() != "application/json" {
+			return n, nil
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(n.Payload))
+		dec.UseNumber()
+
+		var payload map[string]interface{}
+		if err := dec.Decode(&payload); err != nil {
+			return nil, fmt.Errorf("failed to parse %s payload: %w", n.Format, err)
+		}
+
+		if err := f(n.Format, payload); err != nil {
+			return nil, err
+		}
+
+		b, err := contextEncoder(ctx).Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Notification{n.Format, b}, nil
+	}
+}
+
+// InjectCustomData sets key of custom data to value, e.g. a tracking id. Custom data is
+// data of GCM, FCM v1 and ADM payloads, custom_content of Baidu payloads and top-level keys otherwise
+func InjectCustomData(key, value string) PayloadTransformer {
+	return TransformJSON(func(format NotificationFormat, payload map[string]interface{}) error {
+		return setCustomData(format, payload, key, value)
+	})
+}
+
+// setCustomData sets key of custom data of payload of format to value, see InjectCustomData
+func setCustomData(format NotificationFormat, payload map[string]interface{}, key string, value interface{}) error {
+	path, ok := customDataPaths[format]
+	if !ok {
+		return nil
+	}
+	if path != "" {
+		path += "."
+	}
+
+	return setJSONPath(payload, path+key, value)
+}
+
+// StripFields removes fields at dot separated paths, e.g. "data.debug", missing fields are ignored
+func StripFields(paths ...string) PayloadTransformer {
+	return TransformJSON(func(format NotificationFormat, payload map[string]interface{}) error {
+		for _, path := range paths {
+			keys := strings.Split(path, ".")
+			if parent, ok := jsonPathParent(payload, keys, false); ok {
+				delete(parent, keys[len(keys)-1])
+			}
+		}
+
+		return nil
+	})
+}
+
+// AppendLinkParams adds params, e.g. UTM parameters, to the query of the link at dot separated path,
+// e.g. "data.link". Parameters already in the link are kept, payloads without the link are sent as is
+func AppendLinkParams(path string, params url.Values) PayloadTransformer {
+	keys := strings.Split(path, ".")
+	return TransformJSON(func(format NotificationFormat, payload map[string]interface{}) error {
+		parent, ok := jsonPathParent(payload, keys, false)
+		if !ok {
+			return nil
+		}
+		link, ok := parent[keys[len(keys)-1]].(string)
+		if !ok {
+			return nil
+		}
+
+		u, err := url.Parse(link)
+		if err != nil {
+			return fmt.Errorf("link '%s': %w", path, err)
+		}
+
+		query := u.Query()
+		for param, values := range params {
+			if _, ok := query[param]; !ok {
+				query[param] = values
+			}
+		}
+		u.RawQuery = query.Encode()
+		parent[keys[len(keys)-1]] = u.String()
+
+		return nil
+	})
+}
+
+// transform applies hub and send option transformers to n
+func (h *NotificationHub) transform(ctx context.Context, n *Notification, o *sendOptions) (*Notification, error) {
+	transformers := h.transformers
+	if o != nil && len(o.transformers) > 0 {
+		transformers = append(append([]PayloadTransformer(nil), h.transformers...), o.transformers...)
+	}
+
+	ctx = contextWithEncoder(ctx, h.encoder())
+	for _, transformer := range transformers {
+		transformed, err := transformer(ctx, n)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform payload: %w", err)
+		}
+		n = transformed
+	}
+
+	return n, nil
+}
+
+// setJSONPath sets value at dot separated path, creating missing objects on the way
+func setJSONPath(payload map[string]interface{}, path string, value interface{}) error {
+	keys := strings.Split(path, ".")
+	parent, ok := jsonPathParent(payload, keys, true)
+	if !ok {
+		return fmt.Errorf("payload field '%s' is not an object", strings.Join(keys[:len(keys)-1], "."))
+	}
+	parent[keys[len(keys)-1]] = value
+
+	return nil
+}
+
+// hasJSONPath identifies whether payload has a value at dot separated path
+func hasJSONPath(payload map[string]interface{}, path string) bool {
+	keys := strings.Split(path, ".")
+	parent, ok := jsonPathParent(payload, keys, false)
+	if !ok {
+		return false
+	}
+	_, ok = parent[keys[len(keys)-1]]
+
+	return ok
+}
+
+// jsonPathParent returns the object holding the last of keys, objects missing on the way are created when create is set
+func jsonPathParent(payload map[string]interface{}, keys []string, create bool) (map[string]interface{}, bool) {
+	parent := payload
+	for _, key := range keys[:len(keys)-1] {
+		value, ok := parent[key]
+		if !ok && create {
+			value = map[string]interface{}{}
+			parent[key] = value
+		}
+
+		child, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		parent = child
+	}
+
+	return parent, true
+}
@@ -0,0 +1,111 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func Test_PayloadTransformers(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	tests := []struct {
+		name        string
+		transformer PayloadTransformer
+		n           *Notification
+		expected    string
+	}{
+		{
+			name:        "inject apple custom data",
+			transformer: InjectCustomData("trackingId", "t-1"),
+			n:           &Notification{AppleFormat, []byte(`{"aps":{"alert":"hi"}}`)},
+			expected:    `{"aps":{"alert":"hi"},"trackingId":"t-1"}`,
+		},
+		{
+			name:        "inject fcmv1 custom data",
+			transformer: InjectCustomData("trackingId", "t-1"),
+			n:           &Notification{FcmV1Format, []byte(`{"message":{"notification":{"title":"hi"}}}`)},
+			expected:    `{"message":{"data":{"trackingId":"t-1"},"notification":{"title":"hi"}}}`,
+		},
+		{
+			name:        "inject skips xml",
+			transformer: InjectCustomData("trackingId", "t-1"),
+			n:           &Notification{WindowsFormat, []byte(`<toast/>`)},
+			expected:    `<toast/>`,
+		},
+		{
+			name:        "strip fields",
+			transformer: StripFields("data.debug", "missing.field"),
+			n:           &Notification{AndroidFormat, []byte(`{"data":{"debug":{"trace":1},"count":12345678901234567890}}`)},
+			expected:    `{"data":{"count":12345678901234567890}}`,
+		},
+		{
+			name:        "append link params",
+			transformer: AppendLinkParams("data.link", url.Values{"utm_source": {"push"}, "ref": {"campaign"}}),
+			n:           &Notification{AndroidFormat, []byte(`{"data":{"link":"https://example.com/offer?ref=app"}}`)},
+			expected:    `{"data":{"link":"https://example.com/offer?ref=app\u0026utm_source=push"}}`,
+		},
+		{
+			name:        "append link params without link",
+			transformer: AppendLinkParams("data.link", url.Values{"utm_source": {"push"}}),
+			n:           &Notification{AndroidFormat, []byte(`{"data":{}}`)},
+			expected:    `{"data":{}}`,
+		},
+	}
+
+	for _, test := range tests {
+		n, err := test.transformer(context.Background(), test.n)
+		if err != nil {
+			t.Errorf(errfmt, test.name+" error", nil, err)
+			continue
+		}
+		if string(n.Payload) != test.expected {
+			t.Errorf(errfmt, test.name, test.expected, string(n.Payload))
+		}
+	}
+
+	if _, err := InjectCustomData("id", "1")(context.Background(), &Notification{AndroidFormat, []byte(`{"data":"text"}`)}); err == nil {
+		t.Errorf(errfmt, "non-object custom data error", "error", err)
+	}
+}
+
+func Test_NotificationHubPayloadTransformers(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var sent string
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		b, _ := ioutil.ReadAll(req.Body)
+		sent = string(b)
+		return nil, nil
+	})
+	encoder := &countingEncoder{}
+	WithEncoder(encoder)(nhub)
+	WithDefaultPayloadTransformers(StripFields("debug"))(nhub)
+
+	payload := []byte(`{"aps":{"alert":"hi"},"debug":true}`)
+	n := &Notification{AppleFormat, payload}
+	if _, err := nhub.Send(context.Background(), n, []string{"news"}, WithPayloadTransformers(InjectCustomData("trackingId", "t-1"))); err != nil {
+		t.Fatalf(errfmt, "send error", nil, err)
+	}
+
+	if expected := `{"aps":{"alert":"hi"},"trackingId":"t-1"}`; sent != expected {
+		t.Errorf(errfmt, "sent payload", expected, sent)
+	}
+	if string(n.Payload) != string(payload) {
+		t.Errorf(errfmt, "original payload", string(payload), string(n.Payload))
+	}
+	if encoder.marshals != 2 {
+		t.Errorf(errfmt, "hub encoder marshals", 2, encoder.marshals)
+	}
+
+	failing := func(ctx context.Context, n *Notification) (*Notification, error) {
+		return nil, errors.New("failed")
+	}
+	sent = ""
+	if _, err := nhub.Send(context.Background(), n, []string{"news"}, WithPayloadTransformers(failing)); err == nil || sent != "" {
+		t.Errorf(errfmt, "transform error", "error without send", err)
+	}
+}
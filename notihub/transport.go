@@ -0,0 +1,118 @@
+package notihub
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+)
+
+// transportOption configures the hub http transport
+type transportOption func(t *http.Transport)
+
+// WithClientCertificates adds TLS client certificates presented to the hub
+// or to mTLS enforcing proxies. Transport options apply to a copy of the client
+// transport, which must be nil or *http.Transport, and are ignored otherwise
+func WithClientCertificates(certs ...tls.Certificate) HubOption {
+	return withTransport(func(t *http.Transport) {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		t.TLSClientConfig.Certificates = append(t.TLSClientConfig.Certificates, certs...)
+	})
+}
+
+// WithTLSConfig replaces TLS configuration of the hub transport, e.g. to trust a proxy CA
+func WithTLSConfig(config *tls.Config) HubOption {
+	return withTransport(func(t *http.Transport) {
+		t.TLSClientConfig = config.Clone()
+	})
+}
+
+// WithDialer makes the hub transport open connections with dialer,
+// e.g. to set connect timeouts or the local address
+func WithDialer(dialer *net.Dialer) HubOption {
+	return withTransport(func(t *http.Transport) {
+		t.DialContext = dialer.DialContext
+	})
+}
+
+// WithDialContext makes the hub transport open connections with dial,
+// e.g. to route traffic through a local egress sidecar
+func WithDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) HubOption {
+	return withTransport(func(t *http.Transport) {
+		t.DialContext = dial
+	})
+}
+
+// WithRoundTripper sets the transport of the hub http client.
+// Responses are still handled by the hub, only the transport is replaced
+func WithRoundTripper(rt http.RoundTripper) HubOption {
+	return func(h *NotificationHub) {
+		h.roundTripper = rt
+	}
+}
+
+// UnixSocketDialer returns dial function connecting to the unix socket at path
+// regardless of the requested address, for use with WithDialContext
+func UnixSocketDialer(path string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", path)
+	}
+}
+
+func withTransport(opt transportOption) HubOption {
+	return func(h *NotificationHub) {
+		h.transportOpts = append(h.transportOpts, opt)
+	}
+}
+
+// configureClient applies the round tripper, transport options, payload sampling and the debug transport
+// to a copy of the hub http client, leaving the caller's client intact
+func (h *NotificationHub) configureClient() {
+	hc, ok := h.client.(*hubHttpClient)
+	if !ok || len(h.transportOpts) == 0 && h.debugWriter == nil && h.roundTripper == nil && h.sampler == nil {
+		return
+	}
+
+	client := &http.Client{}
+	if hc.httpClient != nil {
+		*client = *hc.httpClient
+	}
+	if h.roundTripper != nil {
+		client.Transport = h.roundTripper
+	}
+
+	if len(h.transportOpts) > 0 {
+		base := client.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		if t, ok := base.(*http.Transport); ok {
+			t = t.Clone()
+			for _, opt := range h.transportOpts {
+				opt(t)
+			}
+			client.Transport = t
+		}
+	}
+
+	if h.sampler != nil {
+		next := client.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		client.Transport = &samplingTransport{next: next, sampler: h.sampler}
+	}
+
+	if h.debugWriter != nil {
+		next := client.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		client.Transport = &debugTransport{next: next, w: h.debugWriter}
+	}
+
+	h.client = &hubHttpClient{httpClient: client}
+}
@@ -0,0 +1,159 @@
+package notihub
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestClientCertificate generates self-signed client certificate
+func newTestClientCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "notihub-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func Test_NotificationHubClientCertificates(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var clientCN string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientCN = r.TLS.PeerCertificates[0].Subject.CommonName
+		w.WriteHeader(http.StatusCreated)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	dialed := 0
+	dialer := &net.Dialer{Timeout: time.Second}
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	nhub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", client,
+		WithTLSConfig(&tls.Config{RootCAs: roots}),
+		WithClientCertificates(newTestClientCertificate(t)),
+		WithDialer(dialer),
+		withTransport(func(t *http.Transport) {
+			dial := t.DialContext
+			t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				dialed++
+				return dial(ctx, network, addr)
+			}
+		}),
+	)
+
+	n := &Notification{AndroidFormat, []byte(`{"data":{}}`)}
+	if _, err := nhub.Send(context.Background(), n, nil, BroadcastAll()); err != nil {
+		t.Fatalf(errfmt, "send error", nil, err)
+	}
+
+	if clientCN != "notihub-client" {
+		t.Errorf(errfmt, "client certificate", "notihub-client", clientCN)
+	}
+	if dialed != 1 {
+		t.Errorf(errfmt, "dials", 1, dialed)
+	}
+	if client.Transport != nil {
+		t.Errorf(errfmt, "caller client transport", nil, client.Transport)
+	}
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func Test_NotificationHubUnixSocketDialer(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	dir, err := ioutil.TempDir("", "notihub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "sidecar.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var host string
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		w.WriteHeader(http.StatusCreated)
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	nhub := NewNotificationHub("Endpoint=sb://testhub-ns.servicebus.windows.net/;SharedAccessKeyName=name;SharedAccessKey=key", "hub", &http.Client{},
+		WithRoundTripper(&http.Transport{}),
+		WithDialContext(UnixSocketDialer(socket)),
+	)
+	nhub.hubURL.Scheme = "http"
+
+	n := &Notification{AndroidFormat, []byte(`{"data":{}}`)}
+	if _, err := nhub.Send(context.Background(), n, nil, BroadcastAll()); err != nil {
+		t.Fatalf(errfmt, "send error", nil, err)
+	}
+	if host != "testhub-ns.servicebus.windows.net" {
+		t.Errorf(errfmt, "request host", "testhub-ns.servicebus.windows.net", host)
+	}
+}
+
+func Test_NotificationHubRoundTripper(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader("unavailable")),
+			Request:    req,
+		}, nil
+	})
+
+	nhub := NewNotificationHub("Endpoint=sb://testhub-ns.servicebus.windows.net/;SharedAccessKeyName=name;SharedAccessKey=key", "hub", nil, WithRoundTripper(rt))
+
+	n := &Notification{AndroidFormat, []byte(`{"data":{}}`)}
+	_, err := nhub.Send(context.Background(), n, nil, BroadcastAll())
+
+	var resErr *ResponseError
+	if !errors.As(err, &resErr) || resErr.StatusCode != http.StatusServiceUnavailable || string(resErr.Body) != "unavailable" {
+		t.Errorf(errfmt, "response error", http.StatusServiceUnavailable, err)
+	}
+}
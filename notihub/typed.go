@@ -0,0 +1,62 @@
+package notihub
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+)
+
+// encoderMarshaler is implemented by payload builders marshaling their JSON with the given encoder
+type encoderMarshaler interface {
+	marshalJSON(encoder Encoder) ([]byte, error)
+}
+
+// MarshalNotification returns notification of format with payload marshaled by encoder,
+// or as XML for formats with XML content type, like WNS toasts. Payloads already
+// encoded, []byte or json.RawMessage, are used as is
+func MarshalNotification[T any](encoder Encoder, format NotificationFormat, payload T) (*Notification, error) {
+	if !format.IsValid() {
+		return nil, fmt.Errorf("unknown format '%s'", format)
+	}
+
+	var (
+		b   []byte
+		err error
+	)
+	switch p := any(payload).(type) {
+	case []byte:
+		b = p
+	case json.RawMessage:
+		b = p
+	case encoderMarshaler:
+		b, err = p.marshalJSON(encoder)
+	default:
+		if format.GetContentType() == "application/json" {
+			b, err = encoder.Marshal(payload)
+		} else {
+			b, err = xml.Marshal(payload)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s payload: %w", format, err)
+	}
+
+	return &Notification{format, b}, nil
+}
+
+// Send marshals payload struct with MarshalNotification using the hub encoder
+// and publishes it to orTags recipients through hub h
+func Send[T any](ctx context.Context, h *NotificationHub, format NotificationFormat, payload T, orTags []string, opts ...SendOption) ([]byte, error) {
+	n, err := MarshalNotification(h.encoder(), format, payload)
+	if err != nil {
+		return nil, fmt.Errorf("Send: %w", err)
+	}
+
+	b, err := h.send(ctx, n, orTags, newSendOptions(opts))
+	if err != nil {
+		return nil, fmt.Errorf("Send: %w", err)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,80 @@
+package notihub
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+type testToast struct {
+	XMLName xml.Name `xml:"toast"`
+	Text    string   `xml:"visual>binding>text"`
+}
+
+func Test_MarshalNotification(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	badge := 3
+	testPatterns := []struct {
+		name     string
+		n        func() (*Notification, error)
+		expected string
+	}{
+		{"template", func() (*Notification, error) {
+			return MarshalNotification(DefaultEncoder, Template, map[string]string{"msg": "hi"})
+		}, `{"msg":"hi"}`},
+		{"apple", func() (*Notification, error) {
+			return MarshalNotification(DefaultEncoder, AppleFormat, ApplePayload{Alert: NewAppleAlert("hi"), Badge: &badge})
+		}, `{"aps":{"alert":"hi","badge":3}}`},
+		{"windows", func() (*Notification, error) {
+			return MarshalNotification(DefaultEncoder, WindowsFormat, testToast{Text: "hi"})
+		}, `<toast><visual><binding><text>hi</text></binding></visual></toast>`},
+		{"raw", func() (*Notification, error) {
+			return MarshalNotification(DefaultEncoder, AndroidFormat, json.RawMessage(`{"data":{}}`))
+		}, `{"data":{}}`},
+		{"unknown format", func() (*Notification, error) {
+			return MarshalNotification(DefaultEncoder, NotificationFormat("sms"), "hi")
+		}, ""},
+		{"unsupported payload", func() (*Notification, error) {
+			return MarshalNotification(DefaultEncoder, Template, func() {})
+		}, ""},
+	}
+
+	for _, testData := range testPatterns {
+		n, err := testData.n()
+		if testData.expected == "" {
+			if err == nil {
+				t.Errorf(errfmt, testData.name+" error", "error", n)
+			}
+			continue
+		}
+		if err != nil || string(n.Payload) != testData.expected {
+			t.Errorf(errfmt, testData.name+" payload", testData.expected, err)
+		}
+	}
+}
+
+func Test_Send(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var format, payload string
+	nhub := newBulkTestHub(func(req *http.Request) ([]byte, error) {
+		format = req.Header.Get("ServiceBusNotification-Format")
+		b, _ := ioutil.ReadAll(req.Body)
+		payload = string(b)
+		return nil, nil
+	})
+
+	type greeting struct {
+		Msg string `json:"msg"`
+	}
+	if _, err := Send(context.Background(), nhub, Template, greeting{"hi"}, []string{"user:1"}); err != nil {
+		t.Fatalf(errfmt, "send error", nil, err)
+	}
+	if format != string(Template) || payload != `{"msg":"hi"}` {
+		t.Errorf(errfmt, "sent notification", `template {"msg":"hi"}`, format+" "+payload)
+	}
+}
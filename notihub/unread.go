@@ -0,0 +1,117 @@
+package notihub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/vippsas/gozure/notihub/tags"
+)
+
+type (
+	// UnreadStore persists unread counters per user
+	UnreadStore interface {
+		// Increment adds delta to the user counter and returns the new value
+		Increment(ctx context.Context, userID string, delta int) (int, error)
+		// Reset sets the user counter to zero
+		Reset(ctx context.Context, userID string) error
+	}
+
+	// MemoryUnreadStore is UnreadStore keeping counters in process memory
+	MemoryUnreadStore struct {
+		mu     sync.Mutex
+		counts map[string]int
+	}
+
+	// UnreadCounter tracks unread counts per user
+	// and pushes them as badge updates to user devices
+	UnreadCounter struct {
+		hub     *NotificationHub
+		store   UnreadStore
+		userTag func(userID string) (string, error)
+	}
+)
+
+// NewMemoryUnreadStore initializes and returns MemoryUnreadStore pointer
+func NewMemoryUnreadStore() *MemoryUnreadStore {
+	return &MemoryUnreadStore{counts: map[string]int{}}
+}
+
+// Increment adds delta to the user counter, never going below zero
+func (s *MemoryUnreadStore) Increment(ctx context.Context, userID string, delta int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := s.counts[userID] + delta
+	if count < 0 {
+		count = 0
+	}
+	s.counts[userID] = count
+
+	return count, nil
+}
+
+// Reset sets the user counter to zero
+func (s *MemoryUnreadStore) Reset(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.counts, userID)
+	return nil
+}
+
+// NewUnreadCounter initializes and returns UnreadCounter pointer.
+// userTag maps user id to the tag of user devices, "user:{id}" is used when nil
+func NewUnreadCounter(hub *NotificationHub, store UnreadStore, userTag func(userID string) string) *UnreadCounter {
+	c := &UnreadCounter{hub: hub, store: store}
+	if userTag == nil {
+		c.userTag = func(userID string) (string, error) {
+			tag, err := tags.UserTag(userID)
+			return tag.String(), err
+		}
+	} else {
+		c.userTag = func(userID string) (string, error) {
+			return userTag(userID), nil
+		}
+	}
+
+	return c
+}
+
+// IncrementAndPush adds delta to the user unread count and pushes the new badge.
+// The new count is returned even if the push fails
+func (c *UnreadCounter) IncrementAndPush(ctx context.Context, userID string, delta int) (int, error) {
+	userTag, err := c.userTag(userID)
+	if err != nil {
+		return 0, fmt.Errorf("UnreadCounter.IncrementAndPush: %w", err)
+	}
+
+	count, err := c.store.Increment(ctx, userID, delta)
+	if err != nil {
+		return 0, fmt.Errorf("UnreadCounter.IncrementAndPush: %w", err)
+	}
+
+	if _, err := c.hub.SendBadgeUpdate(ctx, []string{userTag}, count); err != nil {
+		return count, fmt.Errorf("UnreadCounter.IncrementAndPush: %w", err)
+	}
+
+	return count, nil
+}
+
+// ResetAndPush resets the user unread count and clears the badge
+func (c *UnreadCounter) ResetAndPush(ctx context.Context, userID string) error {
+	userTag, err := c.userTag(userID)
+	if err != nil {
+		return fmt.Errorf("UnreadCounter.ResetAndPush: %w", err)
+	}
+
+	if err := c.store.Reset(ctx, userID); err != nil {
+		return fmt.Errorf("UnreadCounter.ResetAndPush: %w", err)
+	}
+
+	if _, err := c.hub.SendBadgeUpdate(ctx, []string{userTag}, 0); err != nil {
+		return fmt.Errorf("UnreadCounter.ResetAndPush: %w", err)
+	}
+
+	return nil
+}
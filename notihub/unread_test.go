@@ -0,0 +1,74 @@
+package notihub
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func Test_UnreadCounter(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var (
+		mu       sync.Mutex
+		payloads []string
+		tags     []string
+	)
+
+	mockClient := &mockHubHttpClient{execFunc: func(req *http.Request) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if req.Header.Get("ServiceBusNotification-Format") == string(AppleFormat) {
+			b, _ := ioutil.ReadAll(req.Body)
+			payloads = append(payloads, string(b))
+			tags = append(tags, req.Header.Get("ServiceBusNotification-Tags"))
+		}
+
+		return nil, nil
+	}}
+
+	nhub := &NotificationHub{
+		hubURL:         &url.URL{Host: "testHost", Scheme: schemeDefault, Path: "testPath"},
+		client:         mockClient,
+		expiryTimeFunc: TimeFunc(mockExpiryTime),
+	}
+
+	counter := NewUnreadCounter(nhub, NewMemoryUnreadStore(), nil)
+
+	for _, step := range []struct{ delta, expected int }{{1, 1}, {2, 3}} {
+		count, err := counter.IncrementAndPush(context.Background(), "42", step.delta)
+		if err != nil {
+			t.Fatalf(errfmt, "error", nil, err)
+		}
+		if count != step.expected {
+			t.Errorf(errfmt, "count", step.expected, count)
+		}
+	}
+
+	if err := counter.ResetAndPush(context.Background(), "42"); err != nil {
+		t.Fatalf(errfmt, "error", nil, err)
+	}
+
+	count, _ := counter.IncrementAndPush(context.Background(), "42", -5)
+	if count != 0 {
+		t.Errorf(errfmt, "count", 0, count)
+	}
+
+	if count, err := counter.IncrementAndPush(context.Background(), "4 2", 1); err == nil || count != 0 || len(payloads) != 4 {
+		t.Errorf(errfmt, "invalid user tag", "error without push", err)
+	}
+
+	expectedPayloads := []string{`{"aps":{"badge":1}}`, `{"aps":{"badge":3}}`, `{"aps":{"badge":0}}`, `{"aps":{"badge":0}}`}
+	for i, expected := range expectedPayloads {
+		if payloads[i] != expected {
+			t.Errorf(errfmt, "payload", expected, payloads[i])
+		}
+		if tags[i] != "user:42" {
+			t.Errorf(errfmt, "tags", "user:42", tags[i])
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package notihub
+
+import "strings"
+
+// Version is the version of the notihub package, reported in the User-Agent header
+const Version = "0.1.0"
+
+const userAgentHeader = "User-Agent"
+
+// WithAppID identifies the calling application in the User-Agent header of hub requests,
+// helping Azure side diagnostics attribute traffic
+func WithAppID(app string) HubOption {
+	return func(h *NotificationHub) {
+		h.appID = strings.Map(func(r rune) rune {
+			if r < ' ' || r == ';' || r == 0x7f {
+				return -1
+			}
+			return r
+		}, strings.TrimSpace(app))
+	}
+}
+
+// userAgent returns User-Agent header value, "gozure/notihub v{Version}; app={app}"
+func (h *NotificationHub) userAgent() string {
+	if h.appID == "" {
+		return "gozure/notihub v" + Version
+	}
+
+	return "gozure/notihub v" + Version + "; app=" + h.appID
+}
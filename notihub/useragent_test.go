@@ -0,0 +1,42 @@
+package notihub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_NotificationHubUserAgent(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		opts     []HubOption
+		expected string
+	}{
+		{"default", nil, "gozure/notihub v" + Version},
+		{"app id", []HubOption{WithAppID(" orders-api ")}, "gozure/notihub v" + Version + "; app=orders-api"},
+		{"sanitized app id", []HubOption{WithAppID("a;b\r\nc")}, "gozure/notihub v" + Version + "; app=abc"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(), test.opts...)
+			if _, err := hub.Send(context.Background(), &Notification{Template, []byte("{}")}, nil, BroadcastAll()); err != nil {
+				t.Fatalf(errfmt, "send error", nil, err)
+			}
+
+			if userAgent != test.expected {
+				t.Errorf(errfmt, "user agent", test.expected, userAgent)
+			}
+		})
+	}
+}
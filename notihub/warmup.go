@@ -0,0 +1,36 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// warmupNotificationID is the id of the notification read by the warmup probe, no notification has it
+const warmupNotificationID = "warmup-probe"
+
+// Warmup prepares the client for the first send after deployment: reads telemetry of a notification
+// which does not exist, which the hub authenticates and answers with 404, opening a TLS connection
+// kept by the HTTP client for later requests. The probe is a read, so it passes engaged kill switches.
+// Errors point at misconfiguration, like wrong endpoint or credentials, so Warmup can also serve
+// as a readiness check
+func (h *NotificationHub) Warmup(ctx context.Context) error {
+	if err := h.warmup(ctx); err != nil {
+		return fmt.Errorf("NotificationHub.Warmup: %w", err)
+	}
+
+	return nil
+}
+
+func (h *NotificationHub) warmup(ctx context.Context) error {
+	_, err := h.getNotificationDetails(ctx, warmupNotificationID)
+
+	// the hub reports the notification as not found once the request is authorized
+	var resErr *ResponseError
+	if err != nil && !(errors.As(err, &resErr) && resErr.StatusCode == http.StatusNotFound) {
+		return fmt.Errorf("connection: %w", err)
+	}
+
+	return nil
+}
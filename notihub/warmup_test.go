@@ -0,0 +1,68 @@
+package notihub
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_NotificationHubWarmup(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	var (
+		requests    []string
+		connections int
+		status      int
+	)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Authorization") == "" || status != 0 {
+			w.WriteHeader(status)
+			return
+		}
+		if r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/hub/messages/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections++
+		}
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	killSwitch := &AtomicKillSwitch{}
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(), WithKillSwitch(killSwitch))
+	killSwitch.Engage()
+	if err := hub.Warmup(context.Background()); err != nil {
+		t.Fatalf(errfmt, "warmup error with engaged kill switch", nil, err)
+	}
+	killSwitch.Release()
+	if err := hub.Warmup(context.Background()); err != nil {
+		t.Fatalf(errfmt, "warmup error", nil, err)
+	}
+	if _, err := hub.Send(context.Background(), &Notification{Template, []byte("{}")}, []string{"news"}); err != nil {
+		t.Fatalf(errfmt, "send error", nil, err)
+	}
+
+	if len(requests) != 3 || requests[0] != "GET /hub/messages/"+warmupNotificationID {
+		t.Errorf(errfmt, "warmup request", "notification telemetry read", requests)
+	}
+	if connections != 1 {
+		t.Errorf(errfmt, "connections", 1, connections)
+	}
+
+	for _, status = range []int{http.StatusUnauthorized, http.StatusBadRequest} {
+		var resErr *ResponseError
+		if err := hub.Warmup(context.Background()); !errors.As(err, &resErr) || resErr.StatusCode != status {
+			t.Errorf(errfmt, "warmup error", status, err)
+		}
+	}
+}
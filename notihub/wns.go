@@ -0,0 +1,91 @@
+package notihub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const wnsCredentialName = "WnsCredential"
+
+// wnsTokenURL is the WNS OAuth token endpoint, replaced in tests
+var wnsTokenURL = "https://login.live.com/accesstoken.srf"
+
+// UpdateWnsCredentials verifies the package SID and secret against the WNS token endpoint
+// and sets them as the hub WNS credential, so that rotated secrets are known to work
+// before the hub starts using them
+func (m *ManagementClient) UpdateWnsCredentials(ctx context.Context, packageSID, secret string) error {
+	if err := m.verifyWnsCredentials(ctx, packageSID, secret); err != nil {
+		return fmt.Errorf("ManagementClient.UpdateWnsCredentials: %w", err)
+	}
+
+	err := m.updateCredential(ctx, wnsCredentialName, []credentialProperty{
+		{Name: "PackageSid", Value: packageSID},
+		{Name: "SecretKey", Value: secret},
+		{Name: "WindowsLiveEndpoint", Value: wnsTokenURL},
+	})
+	if err != nil {
+		return fmt.Errorf("ManagementClient.UpdateWnsCredentials: %w", err)
+	}
+
+	return nil
+}
+
+// verifyWnsCredentials requests WNS access token with the credentials
+func (m *ManagementClient) verifyWnsCredentials(ctx context.Context, packageSID, secret string) error {
+	if !strings.HasPrefix(packageSID, "ms-app://") || secret == "" {
+		return fmt.Errorf("%w: package SID must start with ms-app:// and secret must not be empty", ErrInvalidCredential)
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {packageSID},
+		"client_secret": {secret},
+		"scope":         {"notify.windows.com"},
+	}
+
+	req, err := http.NewRequest("POST", wnsTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := m.httpClient().Do(req.WithContext(withSensitiveBodies(ctx)))
+	if err != nil {
+		return fmt.Errorf("wns token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("wns token request failed: %w", err)
+	}
+
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(b, &token); err != nil && resp.StatusCode == http.StatusOK {
+		return fmt.Errorf("failed to parse wns token response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK || token.AccessToken == "" {
+		return fmt.Errorf("%w: wns rejected credentials with status %d: %s %s", ErrInvalidCredential, resp.StatusCode, token.Error, token.ErrorDescription)
+	}
+
+	return nil
+}
+
+// httpClient returns http client of the hub, used for requests to other services
+func (m *ManagementClient) httpClient() *http.Client {
+	if hc, ok := m.hub.client.(*hubHttpClient); ok && hc.httpClient != nil {
+		return hc.httpClient
+	}
+
+	return http.DefaultClient
+}
@@ -0,0 +1,119 @@
+package notihub
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_ManagementClientUpdateWnsCredentials(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "notify.windows.com" ||
+			r.FormValue("client_id") != "ms-app://s-1-15-2-1" || r.FormValue("client_secret") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_client","error_description":"Invalid client id"}`))
+			return
+		}
+		w.Write([]byte(`{"token_type":"bearer","access_token":"token","expires_in":86400}`))
+	}))
+	defer tokenServer.Close()
+
+	defer func(original string) { wnsTokenURL = original }(wnsTokenURL)
+	wnsTokenURL = tokenServer.URL
+
+	server := newHubDescriptionServer(testHubDescription)
+	defer server.Close()
+
+	client := server.client()
+	ctx := context.Background()
+
+	for _, invalid := range [][2]string{{"s-1-15-2-1", "secret"}, {"ms-app://s-1-15-2-1", ""}, {"ms-app://s-1-15-2-1", "wrong"}} {
+		if err := client.UpdateWnsCredentials(ctx, invalid[0], invalid[1]); !errors.Is(err, ErrInvalidCredential) {
+			t.Errorf(errfmt, "invalid credentials error", ErrInvalidCredential, err)
+		}
+	}
+	if len(server.updates) != 0 {
+		t.Fatalf(errfmt, "updates with invalid credentials", 0, len(server.updates))
+	}
+
+	if err := client.UpdateWnsCredentials(ctx, "ms-app://s-1-15-2-1", "secret"); err != nil {
+		t.Fatalf(errfmt, "update error", nil, err)
+	}
+
+	description, err := client.getDescription(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wns, ok, err := description.credential(wnsCredentialName)
+	if err != nil || !ok || wns["PackageSid"] != "ms-app://s-1-15-2-1" || wns["SecretKey"] != "secret" || wns["WindowsLiveEndpoint"] != tokenServer.URL {
+		t.Errorf(errfmt, "wns credential", "package sid, secret and endpoint", wns)
+	}
+}
+
+func Test_ManagementClientCredentialsNotCaptured(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"token_type":"bearer","access_token":"wns-access-token","expires_in":86400}`))
+	}))
+	defer tokenServer.Close()
+
+	defer func(original string) { wnsTokenURL = original }(wnsTokenURL)
+	wnsTokenURL = tokenServer.URL
+
+	server := newHubDescriptionServer(testHubDescription)
+	defer server.Close()
+
+	var dump bytes.Buffer
+	hub := NewNotificationHub("Endpoint="+server.URL+";SharedAccessKeyName=name;SharedAccessKey=key", "hub", server.Client(),
+		WithDebugTransport(&dump), WithPayloadSampling(1, 10))
+	client := NewManagementClient(hub)
+	ctx := context.Background()
+
+	if err := client.UpdateWnsCredentials(ctx, "ms-app://s-1-15-2-1", "wns-client-secret"); err != nil {
+		t.Fatalf(errfmt, "wns update error", nil, err)
+	}
+	public, private := newTestVapidKeys(t)
+	if err := client.SetBrowserCredential(ctx, BrowserCredential{"mailto:push@example.com", public, private}); err != nil {
+		t.Fatalf(errfmt, "browser update error", nil, err)
+	}
+
+	captured := dump.String()
+	for _, sample := range hub.Samples() {
+		captured += string(sample.RequestBody) + string(sample.ResponseBody)
+	}
+	if !strings.Contains(captured, redactedDebugBody) {
+		t.Errorf(errfmt, "redacted bodies", redactedDebugBody, captured)
+	}
+	for _, secret := range []string{"wns-client-secret", "wns-access-token", private} {
+		if strings.Contains(captured, secret) {
+			t.Errorf(errfmt, "captured traffic", "no credentials", captured)
+		}
+	}
+}
+
+func Test_ManagementClientUpdateWnsCredentialsMalformedToken(t *testing.T) {
+	errfmt := "Expected %s: %v, got: %v"
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html>maintenance</html>`))
+	}))
+	defer tokenServer.Close()
+
+	defer func(original string) { wnsTokenURL = original }(wnsTokenURL)
+	wnsTokenURL = tokenServer.URL
+
+	server := newHubDescriptionServer(testHubDescription)
+	defer server.Close()
+
+	err := server.client().UpdateWnsCredentials(context.Background(), "ms-app://s-1-15-2-1", "secret")
+	if err == nil || errors.Is(err, ErrInvalidCredential) || len(server.updates) != 0 {
+		t.Errorf(errfmt, "malformed token response error", "parse error without update", err)
+	}
+}